@@ -2,9 +2,13 @@ module github.com/yourusername/gogit
 
 go 1.22
 
-require github.com/spf13/cobra v1.8.0
+require (
+	github.com/spf13/cobra v1.8.0
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/term v0.21.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.21.0 // indirect
 )
@@ -0,0 +1,179 @@
+// Package attributes looks up the gitattributes of paths, read from the
+// .gitattributes files in the working tree and the repository's
+// info/attributes
+package attributes
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/gogit/internal/ignore"
+)
+
+// FileName is the per-directory attributes file
+const FileName = ".gitattributes"
+
+// Values Get returns for an attribute that is set ("attr") or unset
+// ("-attr") rather than given a value
+const (
+	Set   = "true"
+	Unset = "false"
+)
+
+// unspecified is what "!attr" leaves an attribute as
+const unspecified = ""
+
+// macros are the built-in attributes that stand for several others
+var macros = map[string][]string{
+	"binary": {"-diff", "-merge", "-text"},
+}
+
+// assignment is one "attr", "-attr", "!attr" or "attr=value" on a line
+type assignment struct {
+	name  string
+	value string
+}
+
+// rule is one pattern line of an attributes file
+type rule struct {
+	re          *regexp.Regexp
+	assignments []assignment
+}
+
+// Matcher finds the attributes of paths in a working tree. Files in
+// deeper directories take precedence over shallower ones and, within a
+// file, later lines over earlier ones; info/attributes overrides them all.
+type Matcher struct {
+	root string
+	info []rule
+	dirs map[string][]rule // Rules of each directory's file, by slash-separated path; "" for the root
+}
+
+// NewMatcher returns a matcher for the working tree at root. Attributes
+// files are read as paths in their directories are looked up.
+func NewMatcher(root, gitDir string) *Matcher {
+	return &Matcher{
+		root: root,
+		info: readRules(filepath.Join(gitDir, "info", "attributes")),
+		dirs: make(map[string][]rule),
+	}
+}
+
+// Get returns an attribute of a path relative to the root: Set, Unset or
+// the value it was given, and whether it is specified at all
+func (m *Matcher) Get(relPath, name string) (string, bool) {
+	relPath = filepath.ToSlash(relPath)
+
+	// Directories from the root down, each with the path relative to it
+	var dirs []string
+	for dir := path.Dir(relPath); ; dir = path.Dir(dir) {
+		if dir == "." {
+			dirs = append(dirs, "")
+			break
+		}
+		dirs = append(dirs, dir)
+	}
+
+	value := unspecified
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir := dirs[i]
+		rel := relPath
+		if dir != "" {
+			rel = strings.TrimPrefix(relPath, dir+"/")
+		}
+		value = apply(m.dirRules(dir), rel, name, value)
+	}
+	value = apply(m.info, relPath, name, value)
+	return value, value != unspecified
+}
+
+// dirRules returns the rules of a directory's attributes file
+func (m *Matcher) dirRules(dir string) []rule {
+	rules, ok := m.dirs[dir]
+	if !ok {
+		rules = readRules(filepath.Join(m.root, filepath.FromSlash(dir), FileName))
+		m.dirs[dir] = rules
+	}
+	return rules
+}
+
+// apply returns what the rules matching path make of an attribute that
+// was value before them
+func apply(rules []rule, path, name, value string) string {
+	for _, r := range rules {
+		if !r.re.MatchString(path) {
+			continue
+		}
+		for _, a := range r.assignments {
+			if a.name == name {
+				value = a.value
+			}
+		}
+	}
+	return value
+}
+
+// readRules reads an attributes file. A missing or unreadable file has no
+// rules.
+func readRules(file string) []rule {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []rule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if r, ok := parseRule(scanner.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// parseRule parses a "pattern attr..." line, reporting false for blank
+// lines, comments, macro definitions and patterns that can't match a file
+func parseRule(line string) (rule, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+		return rule{}, false
+	}
+	// Negative patterns and directory patterns don't apply to files
+	if strings.HasPrefix(fields[0], "!") || strings.HasSuffix(fields[0], "/") {
+		return rule{}, false
+	}
+
+	re, err := ignore.CompilePattern(fields[0])
+	if err != nil {
+		return rule{}, false
+	}
+	r := rule{re: re}
+	for _, field := range fields[1:] {
+		r.assignments = append(r.assignments, parseAssignment(field)...)
+	}
+	return r, true
+}
+
+// parseAssignment parses one attribute field, expanding macros
+func parseAssignment(field string) []assignment {
+	switch {
+	case strings.HasPrefix(field, "-"):
+		return []assignment{{name: field[1:], value: Unset}}
+	case strings.HasPrefix(field, "!"):
+		return []assignment{{name: field[1:], value: unspecified}}
+	case strings.Contains(field, "="):
+		name, value, _ := strings.Cut(field, "=")
+		return []assignment{{name: name, value: value}}
+	}
+
+	assignments := []assignment{{name: field, value: Set}}
+	for _, expanded := range macros[field] {
+		assignments = append(assignments, parseAssignment(expanded)...)
+	}
+	return assignments
+}
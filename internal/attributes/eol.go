@@ -0,0 +1,91 @@
+package attributes
+
+import (
+	"bytes"
+
+	"github.com/yourusername/gogit/internal/config"
+)
+
+// EOL decides which working tree files have their line endings normalized
+// when compared with what is stored: CRLF becomes LF in files the text or
+// eol attribute marks as text and, with core.autocrlf set to true or input,
+// in files without a text attribute that look like text
+type EOL struct {
+	attrs    *Matcher
+	autocrlf bool
+}
+
+// NewEOL returns the normalization for a working tree given its attributes
+// and the value of core.autocrlf
+func NewEOL(attrs *Matcher, autocrlf string) *EOL {
+	auto := autocrlf == "input"
+	if !auto {
+		auto, _ = config.ParseBool(autocrlf)
+	}
+	return &EOL{attrs: attrs, autocrlf: auto}
+}
+
+// Clean returns the content of the working tree file at relPath as it would
+// be stored. stored returns what is stored for the path now, if anything:
+// as in git, a file that is only detected as text keeps its CRLFs if they
+// are stored too, so that turning on autocrlf doesn't make every file
+// committed with them look changed.
+func (e *EOL) Clean(relPath string, content []byte, stored func() []byte) []byte {
+	if !bytes.Contains(content, []byte("\r\n")) {
+		return content
+	}
+
+	text, _ := e.attrs.Get(relPath, "text")
+	switch text {
+	case Unset:
+		return content
+	case Set:
+		return crlfToLF(content)
+	case "auto":
+	default:
+		eol, _ := e.attrs.Get(relPath, "eol")
+		if eol == "lf" || eol == "crlf" {
+			return crlfToLF(content)
+		}
+		if !e.autocrlf {
+			return content
+		}
+	}
+
+	if looksBinary(content) {
+		return content
+	}
+	if stored != nil && bytes.Contains(stored(), []byte("\r\n")) {
+		return content
+	}
+	return crlfToLF(content)
+}
+
+// crlfToLF drops the CR from each CRLF, leaving lone CRs alone
+func crlfToLF(content []byte) []byte {
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+}
+
+// looksBinary reports whether content would be taken for binary by git's
+// text detection: it has a NUL or a CR not followed by LF, or more than
+// one in 128 of its characters are control characters
+func looksBinary(content []byte) bool {
+	printable, nonPrintable := 0, 0
+	for i, c := range content {
+		switch {
+		case c == 0:
+			return true
+		case c == '\r':
+			if i+1 >= len(content) || content[i+1] != '\n' {
+				return true
+			}
+		case c == '\n', c == '\t', c == '\b', c == '\033', c == '\f':
+			printable++
+		case c < 32, c == 127:
+			nonPrintable++
+		default:
+			printable++
+		}
+	}
+	return printable>>7 < nonPrintable
+}
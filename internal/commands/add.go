@@ -3,23 +3,51 @@ package commands
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/attributes"
+	"github.com/yourusername/gogit/internal/ignore"
 	"github.com/yourusername/gogit/internal/index"
 	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+var (
+	addUpdate bool
+	addAll    bool
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add <file>...",
+	Use:   "add [-u | -A] <file>...",
 	Short: "Add file contents to the index",
-	Long:  `Add file contents to the index (staging area) for the next commit.`,
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  runAdd,
+	Long: `Add file contents to the index (staging area) for the next commit.
+
+With -u, stage the current state of every tracked file instead: changes are
+staged and files deleted from the working tree are removed from the index,
+but new files are left untracked. -A does the same and also adds every new
+file that isn't ignored. Either way, the paths given limit which files are
+considered; without any, the whole working tree is.`,
+	GroupID: groupMain,
+	Example: `  # Stage a file and everything under a directory
+  gogit add README.md src
+
+  # Stage every Go file, in subdirectories too
+  gogit add '*.go'
+
+  # Stage all changes, deletions and new files
+  gogit add -A`,
+	RunE: runAdd,
 }
 
 func init() {
 	rootCmd.AddCommand(addCmd)
+	addCmd.Flags().BoolVarP(&addUpdate, "update", "u", false, "Stage changes and deletions of tracked files, but no new files")
+	addCmd.Flags().BoolVarP(&addAll, "all", "A", false, "Stage changes, deletions and new files across the working tree")
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
@@ -28,28 +56,78 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+	trustExecBit := repo.ConfigBool("core.filemode", true)
+	eol := worktreeEOL(repo)
+
+	if addUpdate && addAll {
+		return fmt.Errorf("-u and -A cannot be used together")
+	}
+	if len(args) == 0 && !addUpdate && !addAll {
+		return fmt.Errorf("nothing specified, nothing added")
+	}
+
 	// Read existing index
 	idx, err := index.ReadIndex(repoRoot)
 	if err != nil {
 		return fmt.Errorf("failed to read index: %w", err)
 	}
 
+	if addUpdate || addAll {
+		var pathspecs []string
+		for _, arg := range args {
+			rel, err := repoRelative(repoRoot, arg)
+			if err != nil {
+				return err
+			}
+			pathspecs = append(pathspecs, filepath.ToSlash(rel))
+		}
+		if err := addTracked(repoRoot, idx, pathspecs, trustExecBit, eol); err != nil {
+			return err
+		}
+		if addAll {
+			if err := addUntracked(repo, idx, pathspecs, trustExecBit, eol); err != nil {
+				return err
+			}
+		}
+		if err := idx.Write(repoRoot); err != nil {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+		return nil
+	}
+
+	// Paths are relative to the current directory; every pathspec must
+	// match something before anything is staged
+	var matches []string
 	for _, arg := range args {
-		// Handle glob patterns and directories
-		matches, err := filepath.Glob(arg)
-		if err != nil {
-			return fmt.Errorf("invalid pattern %s: %w", arg, err)
+		if hasGlob(arg) {
+			found, err := expandGlob(repo, idx, arg)
+			if err != nil {
+				return err
+			}
+			if len(found) == 0 {
+				return fmt.Errorf("pathspec '%s' did not match any files", arg)
+			}
+			matches = append(matches, found...)
+			continue
 		}
 
-		if len(matches) == 0 {
-			// Try as a literal path
-			matches = []string{arg}
+		rel, err := repoRelative(repoRoot, arg)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(repoRoot, rel)); os.IsNotExist(err) && !isTracked(idx, filepath.ToSlash(rel)) {
+			return fmt.Errorf("pathspec '%s' did not match any files", arg)
 		}
+		matches = append(matches, rel)
+	}
 
-		for _, match := range matches {
-			if err := addPath(repoRoot, idx, match); err != nil {
-				return fmt.Errorf("failed to add %s: %w", match, err)
-			}
+	for _, match := range matches {
+		if err := addPath(repoRoot, idx, filepath.Join(repoRoot, match), trustExecBit, eol); err != nil {
+			return fmt.Errorf("failed to add %s: %w", match, err)
 		}
 	}
 
@@ -61,26 +139,218 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func addPath(repoRoot string, idx *index.Index, path string) error {
+// addTracked stages the working tree state of the tracked files within the
+// pathspecs: files that changed are re-added and files that are gone are
+// removed. Conflicted files are staged as resolved.
+func addTracked(repoRoot string, idx *index.Index, pathspecs []string, trustExecBit bool, eol *attributes.EOL) error {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, entry := range idx.Entries {
+		// A submodule is staged by committing in it, not from here
+		if !seen[entry.Path] && entry.Mode != 0160000 && inPathspecs(entry.Path, pathspecs) {
+			seen[entry.Path] = true
+			paths = append(paths, entry.Path)
+		}
+	}
+
+	for _, path := range paths {
+		absPath := filepath.Join(repoRoot, path)
+		info, err := os.Lstat(absPath)
+		if err != nil || info.IsDir() {
+			idx.RemoveEntry(path)
+			continue
+		}
+		if err := addFile(repoRoot, idx, absPath, trustExecBit, eol); err != nil {
+			return fmt.Errorf("failed to add %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// addUntracked adds the files within the pathspecs that are neither tracked
+// nor ignored
+func addUntracked(repo *repository.Repository, idx *index.Index, pathspecs []string, trustExecBit bool, eol *attributes.EOL) error {
+	tracked := make(map[string]bool, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		tracked[entry.Path] = true
+	}
+
+	matcher := ignore.NewMatcher(repo.Path, repo.GitDir)
+	return filepath.Walk(repo.Path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(repo.Path, path)
+		if err != nil {
+			return err
+		}
+		slashPath := filepath.ToSlash(relPath)
+
+		if info.IsDir() {
+			if relPath == "." {
+				return nil
+			}
+			// Skip the metadata directory, submodules and ignored
+			// directories, whose tracked files addTracked has seen to
+			if utils.IsGitDirName(info.Name()) || tracked[slashPath] || matcher.Match(relPath, true) {
+				return filepath.SkipDir
+			}
+			matcher.LoadDir(relPath)
+			return nil
+		}
+
+		if tracked[slashPath] || !inPathspecs(slashPath, pathspecs) || matcher.Match(relPath, false) {
+			return nil
+		}
+		return addFile(repo.Path, idx, path, trustExecBit, eol)
+	})
+}
+
+// hasGlob reports whether a pathspec has wildcards
+func hasGlob(pathspec string) bool {
+	return strings.ContainsAny(pathspec, "*?[")
+}
+
+// expandGlob returns the files a pathspec with wildcards matches, relative
+// to the repository root. The pattern is taken relative to the current
+// directory and, as in git, "*" and "?" match across directories. Tracked
+// files match even if they are gone from the working tree; untracked ones
+// that are ignored don't match.
+func expandGlob(repo *repository.Repository, idx *index.Index, pathspec string) ([]string, error) {
+	prefix, err := repoRelative(repo.Path, ".")
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile("^" + pathspecGlob(path.Join(filepath.ToSlash(prefix), filepath.ToSlash(pathspec))) + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pathspec '%s': %w", pathspec, err)
+	}
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, entry := range idx.Entries {
+		if !seen[entry.Path] && re.MatchString(entry.Path) {
+			matches = append(matches, entry.Path)
+		}
+		seen[entry.Path] = true
+	}
+
+	matcher := ignore.NewMatcher(repo.Path, repo.GitDir)
+	err = filepath.Walk(repo.Path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(repo.Path, p)
+		if err != nil {
+			return err
+		}
+		slashPath := filepath.ToSlash(relPath)
+
+		if info.IsDir() {
+			if relPath == "." {
+				return nil
+			}
+			if utils.IsGitDirName(info.Name()) || seen[slashPath] || matcher.Match(relPath, true) {
+				return filepath.SkipDir
+			}
+			matcher.LoadDir(relPath)
+			return nil
+		}
+		if !seen[slashPath] && re.MatchString(slashPath) && !matcher.Match(relPath, false) {
+			matches = append(matches, slashPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// pathspecGlob translates a pathspec glob into a regexp. Unlike in ignore
+// files, wildcards match slashes too.
+func pathspecGlob(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*':
+			sb.WriteString(".*")
+		case c == '?':
+			sb.WriteString(".")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// isTracked reports whether the index has a file at path or inside it
+func isTracked(idx *index.Index, path string) bool {
+	for _, entry := range idx.Entries {
+		if path == "." || entry.Path == path || strings.HasPrefix(entry.Path, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// inPathspecs reports whether a path is one of the pathspecs or inside one
+// of them. No pathspecs, or ".", match everything.
+func inPathspecs(path string, pathspecs []string) bool {
+	if len(pathspecs) == 0 {
+		return true
+	}
+	for _, spec := range pathspecs {
+		if spec == "." || path == spec || strings.HasPrefix(path, spec+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func addPath(repoRoot string, idx *index.Index, path string, trustExecBit bool, eol *attributes.EOL) error {
 	absPath := path
 	if !filepath.IsAbs(path) {
 		absPath = filepath.Join(repoRoot, path)
 	}
 
 	info, err := os.Stat(absPath)
+	if os.IsNotExist(err) && removeTracked(repoRoot, idx, absPath) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("path not found: %s", path)
 	}
 
 	if info.IsDir() {
+		// As in git, tracked files under it that are gone are staged as
+		// deleted
+		removeMissing(repoRoot, idx, absPath)
+
 		// Recursively add directory contents
 		return filepath.Walk(absPath, func(p string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
 
-			// Skip .gogit directory
-			if info.IsDir() && info.Name() == ".gogit" {
+			// Skip the metadata directory
+			if info.IsDir() && utils.IsGitDirName(info.Name()) {
 				return filepath.SkipDir
 			}
 
@@ -89,31 +359,100 @@ func addPath(repoRoot string, idx *index.Index, path string) error {
 				return nil
 			}
 
-			return addFile(repoRoot, idx, p)
+			return addFile(repoRoot, idx, p, trustExecBit, eol)
 		})
 	}
 
-	return addFile(repoRoot, idx, absPath)
+	return addFile(repoRoot, idx, absPath, trustExecBit, eol)
+}
+
+// removeTracked stages the deletion of a tracked file, or of every tracked
+// file in a directory, that is gone from the working tree. It reports
+// whether anything was tracked there.
+func removeTracked(repoRoot string, idx *index.Index, absPath string) bool {
+	relPath, err := filepath.Rel(repoRoot, absPath)
+	if err != nil {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	var gone []string
+	for _, entry := range idx.Entries {
+		if entry.Path == relPath || strings.HasPrefix(entry.Path, relPath+"/") {
+			gone = append(gone, entry.Path)
+		}
+	}
+	for _, path := range gone {
+		idx.RemoveEntry(path)
+	}
+	return len(gone) > 0
 }
 
-func addFile(repoRoot string, idx *index.Index, absPath string) error {
-	// Read file content
+// removeMissing stages the deletion of the tracked files in a directory
+// that are gone from the working tree
+func removeMissing(repoRoot string, idx *index.Index, absDir string) {
+	relDir, err := filepath.Rel(repoRoot, absDir)
+	if err != nil {
+		return
+	}
+
+	var gone []string
+	for _, entry := range idx.Entries {
+		if !inPathspecs(entry.Path, []string{filepath.ToSlash(relDir)}) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(repoRoot, entry.Path)); os.IsNotExist(err) {
+			gone = append(gone, entry.Path)
+		}
+	}
+	for _, path := range gone {
+		idx.RemoveEntry(path)
+	}
+}
+
+// addFile stages a file. Unless trustExecBit is set, the mode already in the
+// index is kept (new files are staged as non-executable). Line endings are
+// normalized by eol, so that the blob is what diff and status compare the
+// file against.
+func addFile(repoRoot string, idx *index.Index, absPath string, trustExecBit bool, eol *attributes.EOL) error {
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
 	content, err := os.ReadFile(absPath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	relPath, err := filepath.Rel(repoRoot, absPath)
+	if err != nil {
+		return err
+	}
+	relPath = filepath.ToSlash(relPath)
+	existing := idx.GetEntry(relPath)
+	var stored string
+	if existing != nil {
+		stored = existing.HashString()
+	}
+
 	// Create and write blob
-	blob := object.NewBlob(content)
-	_, err = object.WriteObject(repoRoot, blob)
+	hash, err := object.WriteObject(repoRoot, object.NewBlob(cleanContent(repoRoot, eol, relPath, content, stored)))
 	if err != nil {
 		return fmt.Errorf("failed to write blob: %w", err)
 	}
 
-	// Add to index
-	if err := idx.AddFile(repoRoot, absPath); err != nil {
-		return fmt.Errorf("failed to add to index: %w", err)
+	mode := uint32(0100644)
+	switch {
+	case !trustExecBit && existing != nil:
+		mode = existing.Mode
+	case trustExecBit && info.Mode()&0111 != 0:
+		mode = 0100755
 	}
 
+	// Add to index, with the stat data that lets status skip rehashing it
+	if err := idx.AddBlob(relPath, mode, hash); err != nil {
+		return fmt.Errorf("failed to add to index: %w", err)
+	}
+	idx.GetEntry(relPath).SetStat(info)
 	return nil
 }
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAddUpdate(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"kept": "1\n", "modified": "2\n", "deleted": "3\n", "dir/gone": "4\n"})
+	writeFile(t, "modified", "two\n")
+	writeFile(t, "new", "5\n")
+	for _, path := range []string{"deleted", "dir/gone"} {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mustGogit(t, "add", "-u")
+	if got, want := mustGogit(t, "diff", "--cached", "--name-status"), "D\tdeleted\nD\tdir/gone\nM\tmodified\n"; got != want {
+		t.Errorf("staged after add -u:\n%s\nwant:\n%s", got, want)
+	}
+	if got := mustGogit(t, "ls-files"); got != "kept\nmodified\n" {
+		t.Errorf("index after add -u:\n%s\nwant new left untracked", got)
+	}
+
+	mustGogit(t, "add", "-A")
+	if got, want := mustGogit(t, "diff", "--cached", "--name-status"), "D\tdeleted\nD\tdir/gone\nM\tmodified\nA\tnew\n"; got != want {
+		t.Errorf("staged after add -A:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAddAllFromSubdirectory(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"top": "1\n", "sub/f": "2\n"})
+	if err := os.Remove("top"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "new", "3\n")
+	chdir(t, "sub")
+
+	// With no paths, -A covers the whole working tree wherever it is run
+	mustGogit(t, "add", "-A")
+	if got, want := mustGogit(t, "diff", "--cached", "--name-status"), "A\tnew\nD\ttop\n"; got != want {
+		t.Errorf("staged after add -A in sub:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAddStagesDeletion(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "1\n", "dir/g": "2\n", "dir/h": "3\n"})
+	for _, path := range []string{"f", "dir/g"} {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mustGogit(t, "add", "f")
+	if got := mustGogit(t, "ls-files"); got != "dir/g\ndir/h\n" {
+		t.Errorf("index after adding deleted f:\n%s", got)
+	}
+	if got := mustGogit(t, "diff", "--cached", "--name-status"); got != "D\tf\n" {
+		t.Errorf("staged:\n%s\nwant f's deletion", got)
+	}
+
+	// A directory stages the deletions under it
+	mustGogit(t, "add", "dir")
+	if got := mustGogit(t, "ls-files"); got != "dir/h\n" {
+		t.Errorf("index after adding dir:\n%s", got)
+	}
+
+	if _, err := gogit(t, "add", "never-tracked"); err == nil {
+		t.Error("add of a path that neither exists nor is tracked succeeded")
+	}
+}
+
+func TestAddGlobFromSubdirectory(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{".gogitignore": "ignored.go\n"})
+	for _, path := range []string{"top.go", "sub/a.go", "sub/b.txt", "sub/deep/c.go", "sub/ignored.go", "other/d.go"} {
+		writeFile(t, path, path+"\n")
+	}
+	chdir(t, "sub")
+
+	// The pattern is relative to the current directory, and "*" crosses
+	// into subdirectories as in git
+	mustGogit(t, "add", "*.go")
+	if got, want := mustGogit(t, "diff", "--cached", "--name-only"), "sub/a.go\nsub/deep/c.go\n"; got != want {
+		t.Errorf("staged after add '*.go' in sub:\n%s\nwant:\n%s", got, want)
+	}
+
+	_, err := gogit(t, "add", "*.rs")
+	if err == nil || err.Error() != "pathspec '*.rs' did not match any files" {
+		t.Errorf("add of a glob matching nothing: %v", err)
+	}
+
+	// Tracked files that are gone still match, so their deletion is staged
+	mustGogit(t, "commit", "-m", "two")
+	if err := os.Remove("a.go"); err != nil {
+		t.Fatal(err)
+	}
+	mustGogit(t, "add", "?.go")
+	if got, want := mustGogit(t, "diff", "--cached", "--name-status"), "D\tsub/a.go\n"; got != want {
+		t.Errorf("staged after add '?.go':\n%s\nwant:\n%s", got, want)
+	}
+
+	mustGogit(t, "add", "../*.go")
+	if got, want := mustGogit(t, "diff", "--cached", "--name-status"), "A\tother/d.go\nD\tsub/a.go\nA\ttop.go\n"; got != want {
+		t.Errorf("staged after add '../*.go':\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	blameIncremental bool
+	blameCopies      bool
+)
+
+var blameCmd = &cobra.Command{
+	Use:   "blame [--incremental] [-C] [<rev>] [--] <file>",
+	Short: "Show what revision and author last modified each line of a file",
+	Long: `Annotate each line of a file, as of <rev> (default HEAD), with the commit
+that introduced it. Lines are followed back through renames; with -C they
+are also followed into other files the same commit changed, so that code
+moved or copied between files is blamed on the commit that first wrote it.
+A run of lines needs at least 40 letters and digits to count as copied.
+
+With --incremental, nothing is printed per line. Instead each run of lines
+is reported as soon as the commit it came from is found, in the format
+editors read:
+
+  <hash> <source line> <result line> <number of lines>
+
+followed, the first time a commit is reported, by its author, committer and
+summary headers, and then by "previous" (the parent and path the commit was
+compared with, if any) and "filename" lines.`,
+	GroupID: groupMain,
+	Example: `  # Show who last changed each line of a file
+  gogit blame main.go
+
+  # Blame a file as of an older commit, following copied lines
+  gogit blame -C v1.0 -- main.go`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runBlame,
+}
+
+func init() {
+	rootCmd.AddCommand(blameCmd)
+	blameCmd.Flags().BoolVar(&blameIncremental, "incremental", false, "Show results as they are found, in a machine-readable format")
+	blameCmd.Flags().BoolVarP(&blameCopies, "copies", "C", false, "Detect lines moved or copied from other files changed in the same commit")
+}
+
+func runBlame(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	rev, file := "HEAD", args[len(args)-1]
+	if len(args) == 2 {
+		rev = args[0]
+	}
+	path, err := repoRelative(repoRoot, file)
+	if err != nil {
+		return err
+	}
+	path = filepath.ToSlash(path)
+
+	start, err := repo.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+
+	content, ok, err := repo.FileAt(start, path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no such path '%s' in %s", path, rev)
+	}
+
+	commits := make(map[string]*object.Commit)
+	readCommit := func(hash string) (*object.Commit, error) {
+		if c, ok := commits[hash]; ok {
+			return c, nil
+		}
+		c, err := repo.ReadHistoryCommit(hash)
+		if err != nil {
+			return nil, err
+		}
+		commits[hash] = c
+		return c, nil
+	}
+
+	opts := repository.BlameOptions{Copies: blameCopies}
+	if blameIncremental {
+		shown := make(map[string]bool)
+		return repo.Blame(start, path, opts, func(entry repository.BlameEntry) error {
+			commit, err := readCommit(entry.Commit)
+			if err != nil {
+				return err
+			}
+			printIncrementalEntry(entry, commit, !shown[entry.Commit])
+			shown[entry.Commit] = true
+			return nil
+		})
+	}
+
+	// The annotated output is in line order, so it waits for every line
+	var entries []repository.BlameEntry
+	if err := repo.Blame(start, path, opts, func(entry repository.BlameEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	owners := make([]repository.BlameEntry, len(lines))
+	for _, entry := range entries {
+		for i := 0; i < entry.NumLines; i++ {
+			owners[entry.FinalLine-1+i] = entry
+		}
+	}
+
+	showPath := false
+	authorWidth := 0
+	for _, entry := range entries {
+		commit, err := readCommit(entry.Commit)
+		if err != nil {
+			return err
+		}
+		showPath = showPath || entry.Path != path
+		authorWidth = max(authorWidth, utf8.RuneCountInString(commit.Author.Name))
+	}
+	pathWidth := 0
+	for _, entry := range entries {
+		pathWidth = max(pathWidth, utf8.RuneCountInString(entry.Path))
+	}
+	lineWidth := len(fmt.Sprint(len(lines)))
+
+	for i, line := range lines {
+		entry := owners[i]
+		commit := commits[entry.Commit]
+
+		hash := entry.Commit[:8]
+		if len(commit.Parents) == 0 {
+			hash = "^" + entry.Commit[:7]
+		}
+		fmt.Print(hash, " ")
+		if showPath {
+			fmt.Printf("%-*s ", pathWidth, entry.Path)
+		}
+		author := commit.Author.Name + strings.Repeat(" ", authorWidth-utf8.RuneCountInString(commit.Author.Name))
+		fmt.Printf("(%s %s %*d) %s\n", author, commit.Author.When.Format("2006-01-02 15:04:05 -0700"), lineWidth, i+1, line)
+	}
+	return nil
+}
+
+// printIncrementalEntry prints one entry of --incremental output, with the
+// commit's headers if it hasn't been reported yet
+func printIncrementalEntry(entry repository.BlameEntry, commit *object.Commit, withHeaders bool) {
+	fmt.Printf("%s %d %d %d\n", entry.Commit, entry.OrigLine, entry.FinalLine, entry.NumLines)
+	if withHeaders {
+		for _, sig := range []struct {
+			role string
+			sig  object.Signature
+		}{{"author", commit.Author}, {"committer", commit.Committer}} {
+			fmt.Printf("%s %s\n", sig.role, sig.sig.Name)
+			fmt.Printf("%s-mail <%s>\n", sig.role, sig.sig.Email)
+			fmt.Printf("%s-time %d\n", sig.role, sig.sig.When.Unix())
+			fmt.Printf("%s-tz %s\n", sig.role, sig.sig.TZ())
+		}
+		fmt.Printf("summary %s\n", strings.Split(commit.Message, "\n")[0])
+		if len(commit.Parents) == 0 {
+			fmt.Println("boundary")
+		}
+	}
+	if entry.Previous != "" {
+		fmt.Printf("previous %s %s\n", entry.Previous, entry.PreviousPath)
+	}
+	fmt.Printf("filename %s\n", entry.Path)
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// incrementalEntries splits --incremental output into its entries, each
+// starting at a "<hash> <line> <line> <count>" line, in a stable order
+func incrementalEntries(out string) []string {
+	var entries []string
+	var entry strings.Builder
+	for _, line := range strings.SplitAfter(out, "\n") {
+		entry.WriteString(line)
+		if strings.HasPrefix(line, "filename ") {
+			entries = append(entries, entry.String())
+			entry.Reset()
+		}
+	}
+	sort.Strings(entries)
+	return append(entries, entry.String())
+}
+
+func TestBlameIncremental(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "first", map[string]string{"f": "one\ntwo\nthree\n"})
+	commitWorktree(t, "second", map[string]string{"f": "one\n2\nthree\nfour\n"})
+
+	got := mustGogit(t, "blame", "--incremental", "f")
+	want := git(t, root, "--git-dir=.gogit", "--work-tree=.", "blame", "--incremental", "f")
+	if g, w := incrementalEntries(got), incrementalEntries(want); strings.Join(g, "") != strings.Join(w, "") {
+		t.Errorf("blame --incremental:\n%s\nwant what git prints:\n%s", got, want)
+	}
+
+	// Each commit's headers come once, with its first entry
+	second := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	if n := strings.Count(got, "\nsummary second\n"); n != 1 {
+		t.Errorf("second's headers appear %d times:\n%s", n, got)
+	}
+	if !strings.HasPrefix(got, second+" 2 2 1\n") && !strings.Contains(got, "\n"+second+" 2 2 1\n") {
+		t.Errorf("blame --incremental:\n%s\nwant line 2 blamed on %s", got, second)
+	}
+}
+
+func TestBlameCopies(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	moved := "func helper() int {\n\treturn computeSomethingUseful(42)\n}\n"
+	commitWorktree(t, "write helper", map[string]string{"a.go": "package a\n\nvar x = 1\n\n" + moved})
+	commitWorktree(t, "move helper", map[string]string{"a.go": "package a\n\nvar x = 1\n", "b.go": "package b\n\n" + moved})
+	first := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD^"))
+
+	for _, args := range [][]string{{"b.go"}, {"-C", "b.go"}} {
+		got := mustGogit(t, append([]string{"blame", "--incremental"}, args...)...)
+		want := git(t, root, append([]string{"--git-dir=.gogit", "--work-tree=.", "blame", "--incremental"}, args...)...)
+		if g, w := incrementalEntries(got), incrementalEntries(want); strings.Join(g, "") != strings.Join(w, "") {
+			t.Errorf("blame --incremental %v:\n%s\nwant what git prints:\n%s", args, got, want)
+		}
+	}
+
+	// The moved function is blamed on the commit that wrote it, in a.go
+	out := mustGogit(t, "blame", "--incremental", "-C", "b.go")
+	if !strings.Contains(out, first+" 4 2 4\n") || !strings.Contains(out, "filename a.go\n") {
+		t.Errorf("blame -C:\n%s\nwant lines 2-5 traced to a.go in %s", out, first)
+	}
+}
@@ -9,19 +9,34 @@ import (
 
 var (
 	branchDelete bool
+	branchSort   string
 )
 
 var branchCmd = &cobra.Command{
 	Use:   "branch [name]",
 	Short: "List, create, or delete branches",
-	Long:  `Without arguments, list all branches. With a name, create a new branch.`,
-	Args:  cobra.MaximumNArgs(1),
-	RunE:  runBranch,
+	Long: `Without arguments, list all branches. With a name, create a new branch.
+
+--sort orders the listing by refname (the default), creatordate (the date
+of the tip commit) or version:refname, which compares numbers in branch
+names by value. Prefix the key with "-" to reverse the order.`,
+	GroupID: groupMain,
+	Example: `  # List branches, newest first
+  gogit branch --sort=-creatordate
+
+  # Create a branch at HEAD
+  gogit branch feature
+
+  # Delete a branch
+  gogit branch -d feature`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBranch,
 }
 
 func init() {
 	rootCmd.AddCommand(branchCmd)
 	branchCmd.Flags().BoolVarP(&branchDelete, "delete", "d", false, "Delete a branch")
+	branchCmd.Flags().StringVar(&branchSort, "sort", "refname", "Sort branches by refname, creatordate or version:refname; prefix with - to reverse")
 }
 
 func runBranch(cmd *cobra.Command, args []string) error {
@@ -30,7 +45,11 @@ func runBranch(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	refs := repository.NewRefs(repoRoot)
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+	refs := repo.Refs
 
 	// Delete branch
 	if branchDelete {
@@ -60,6 +79,9 @@ func runBranch(cmd *cobra.Command, args []string) error {
 		if err := refs.CreateBranch(branchName, commitHash); err != nil {
 			return err
 		}
+		if err := repo.LogRefUpdate("refs/heads/"+branchName, "", commitHash, "branch: Created from HEAD"); err != nil {
+			return err
+		}
 
 		fmt.Printf("Created branch '%s' at %s\n", branchName, commitHash[:7])
 		return nil
@@ -70,6 +92,9 @@ func runBranch(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to list branches: %w", err)
 	}
+	if err := sortRefNames(repoRoot, "refs/heads/", branches, branchSort); err != nil {
+		return err
+	}
 
 	currentBranch, _ := refs.CurrentBranch()
 
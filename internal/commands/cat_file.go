@@ -1,24 +1,50 @@
 package commands
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
 )
 
 var (
 	catFilePretty bool
 	catFileType   bool
 	catFileSize   bool
+	catFileExists bool
+
+	catFileBatch      bool
+	catFileBatchCheck bool
+	catFileBatchAll   bool
 )
 
 var catFileCmd = &cobra.Command{
-	Use:   "cat-file <object>",
+	Use:   "cat-file (-p|-t|-s|-e) <object> | (--batch|--batch-check) [--batch-all-objects]",
 	Short: "Provide content, type, or size information for repository objects",
-	Long:  `Display information about objects stored in the repository.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runCatFile,
+	Long: `Display information about objects stored in the repository.
+
+With -e, print nothing and exit with status 0 if the object exists and 1 if
+it doesn't.
+
+With --batch-check, read object names from standard input, one per line,
+and print "<hash> <type> <size>" for each, or "<name> missing". --batch also
+prints the object's raw content after that line, followed by a newline.
+With --batch-all-objects, nothing is read: every object in the repository,
+loose or packed, is reported once, in hash order.`,
+	GroupID: groupPlumbing,
+	Example: `  # Show the type and contents of an object
+  gogit cat-file -t HEAD
+  gogit cat-file -p HEAD^{tree}
+
+  # List every object with its type and size
+  gogit cat-file --batch-check --batch-all-objects`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCatFile,
 }
 
 func init() {
@@ -26,16 +52,51 @@ func init() {
 	catFileCmd.Flags().BoolVarP(&catFilePretty, "pretty", "p", false, "Pretty-print the contents of <object>")
 	catFileCmd.Flags().BoolVarP(&catFileType, "type", "t", false, "Show the object type")
 	catFileCmd.Flags().BoolVarP(&catFileSize, "size", "s", false, "Show the object size")
+	catFileCmd.Flags().BoolVarP(&catFileExists, "exists", "e", false, "Exit with zero status if <object> exists, non-zero otherwise")
+	catFileCmd.Flags().BoolVar(&catFileBatch, "batch", false, "Print the type, size and content of each object named on standard input")
+	catFileCmd.Flags().BoolVar(&catFileBatchCheck, "batch-check", false, "Print the type and size of each object named on standard input")
+	catFileCmd.Flags().BoolVar(&catFileBatchAll, "batch-all-objects", false, "With --batch or --batch-check, report every object in the repository instead")
 }
 
 func runCatFile(cmd *cobra.Command, args []string) error {
-	hash := args[0]
-
 	repoRoot, err := FindRepoRoot()
 	if err != nil {
 		return err
 	}
 
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	if catFileBatch || catFileBatchCheck {
+		if len(args) > 0 {
+			return fmt.Errorf("--batch and --batch-check take object names on standard input")
+		}
+		return catFileBatchMode(repo, os.Stdin, os.Stdout)
+	}
+	if catFileBatchAll {
+		return fmt.Errorf("--batch-all-objects requires --batch or --batch-check")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("cat-file needs an object")
+	}
+
+	hash, err := repo.ResolveRevision(args[0])
+	if catFileExists {
+		// A well-formed name of a missing object is an answer, not an error
+		if err != nil && !object.IsHash(args[0]) {
+			return err
+		}
+		if err != nil || !object.Exists(repoRoot, hash) {
+			return &ExitError{Code: exitNo}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
 	// If only type or size is requested, use GetObjectInfo for efficiency
 	if catFileType || catFileSize {
 		objType, size, err := object.GetObjectInfo(repoRoot, hash)
@@ -52,6 +113,23 @@ func runCatFile(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if catFilePretty {
+		// A blob is copied straight through, so that a large one is never
+		// held in memory
+		objType, _, r, err := object.OpenObject(repoRoot, hash)
+		if err != nil {
+			return fmt.Errorf("failed to read object: %w", err)
+		}
+		if objType == object.TypeBlob {
+			defer r.Close()
+			if _, err := io.Copy(os.Stdout, r); err != nil {
+				return fmt.Errorf("failed to read object: %w", err)
+			}
+			return nil
+		}
+		r.Close()
+	}
+
 	// Read and parse the full object
 	obj, err := object.ReadObject(repoRoot, hash)
 	if err != nil {
@@ -76,3 +154,51 @@ func runCatFile(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// catFileBatchMode answers --batch and --batch-check, for the names read
+// from in or, with --batch-all-objects, for every object
+func catFileBatchMode(repo *repository.Repository, in io.Reader, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	if catFileBatchAll {
+		return object.ForEachObject(repo.Path, func(hash string) error {
+			return catFileBatchObject(repo, w, hash, hash)
+		})
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		hash, err := repo.ResolveRevision(name)
+		if err != nil || !object.Exists(repo.Path, hash) {
+			fmt.Fprintf(w, "%s missing\n", name)
+			continue
+		}
+		if err := catFileBatchObject(repo, w, name, hash); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// catFileBatchObject prints one object's batch output
+func catFileBatchObject(repo *repository.Repository, w *bufio.Writer, name, hash string) error {
+	if !catFileBatch {
+		objType, size, err := object.GetObjectInfo(repo.Path, hash)
+		if err != nil {
+			return fmt.Errorf("failed to get info for %s: %w", name, err)
+		}
+		fmt.Fprintf(w, "%s %s %d\n", hash, objType, size)
+		return nil
+	}
+
+	objType, content, err := object.ReadRawObject(repo.Path, hash)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	fmt.Fprintf(w, "%s %s %d\n", hash, objType, len(content))
+	w.Write(content)
+	w.WriteString("\n")
+	return nil
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCatFileBatchAllObjects(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "1\n", "dir/g": "g\n"})
+	mustGogit(t, "gc", "-q")
+	commitWorktree(t, "two", map[string]string{"f": "2\n"})
+	mustGogit(t, "hash-object", "-w", writeTemp(t, "dangling\n"))
+
+	got := mustGogit(t, "cat-file", "--batch-check", "--batch-all-objects")
+	want := git(t, root, "--git-dir=.gogit", "cat-file", "--batch-check", "--batch-all-objects")
+	if got != want {
+		t.Errorf("cat-file --batch-check --batch-all-objects:\n%s\nwant what git prints:\n%s", got, want)
+	}
+
+	// Every object once, packed or loose, in hash order
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 9 {
+		t.Errorf("%d objects listed, want 2 commits, 3 trees and 4 blobs", len(lines))
+	}
+	if !sort.StringsAreSorted(lines) {
+		t.Error("objects aren't in hash order")
+	}
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		hash := strings.Fields(line)[0]
+		if seen[hash] {
+			t.Errorf("%s listed twice", hash)
+		}
+		seen[hash] = true
+	}
+
+	got = mustGogit(t, "cat-file", "--batch", "--batch-all-objects")
+	want = git(t, root, "--git-dir=.gogit", "cat-file", "--batch", "--batch-all-objects")
+	if got != want {
+		t.Errorf("cat-file --batch --batch-all-objects:\n%q\nwant what git prints:\n%q", got, want)
+	}
+
+	if _, err := gogit(t, "cat-file", "--batch-all-objects"); err == nil {
+		t.Error("--batch-all-objects worked without --batch or --batch-check")
+	}
+}
+
+func TestCatFilePrettyLargeBlob(t *testing.T) {
+	newTestRepo(t)
+	var sb strings.Builder
+	for i := 0; sb.Len() < 4<<20; i++ {
+		fmt.Fprintf(&sb, "line %d of a large file\n", i)
+	}
+	content := sb.String()
+	hash := strings.TrimSpace(mustGogit(t, "hash-object", "-w", writeTemp(t, content)))
+
+	if got := mustGogit(t, "cat-file", "-p", hash); got != content {
+		t.Errorf("cat-file -p printed %d bytes, want the %d written", len(got), len(content))
+	}
+	if got := strings.TrimSpace(mustGogit(t, "cat-file", "-s", hash)); got != fmt.Sprint(len(content)) {
+		t.Errorf("cat-file -s = %s, want %d", got, len(content))
+	}
+
+	// Packed, the blob is read whole but prints the same
+	writeFile(t, "big", content)
+	mustGogit(t, "add", "big")
+	mustGogit(t, "commit", "-m", "big")
+	mustGogit(t, "gc", "-q")
+	if got := mustGogit(t, "cat-file", "-p", hash); got != content {
+		t.Errorf("cat-file -p of the packed blob printed %d bytes, want %d", len(got), len(content))
+	}
+}
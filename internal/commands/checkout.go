@@ -3,9 +3,11 @@ package commands
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
 	"github.com/yourusername/gogit/internal/index"
 	"github.com/yourusername/gogit/internal/object"
 	"github.com/yourusername/gogit/internal/repository"
@@ -16,11 +18,20 @@ var (
 )
 
 var checkoutCmd = &cobra.Command{
-	Use:   "checkout <branch|commit>",
-	Short: "Switch branches or restore working tree files",
-	Long:  `Switch to a branch or restore working tree files.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runCheckout,
+	Use:     "checkout <branch|commit>",
+	Short:   "Switch branches or restore working tree files",
+	Long:    `Switch to a branch or restore working tree files.`,
+	GroupID: groupMain,
+	Example: `  # Switch to a branch
+  gogit checkout main
+
+  # Create a branch and switch to it
+  gogit checkout -b feature
+
+  # Go back to the previous branch
+  gogit checkout -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCheckout,
 }
 
 func init() {
@@ -34,42 +45,29 @@ func runCheckout(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
 	target := args[0]
-	refs := repository.NewRefs(repoRoot)
 
 	// Create new branch if -b flag
 	if checkoutCreate {
-		commitHash, err := refs.ResolveHead()
-		if err != nil || commitHash == "" {
-			return fmt.Errorf("cannot create branch: no commits yet")
-		}
+		return createAndSwitchBranch(repo, target)
+	}
 
-		if err := refs.CreateBranch(target, commitHash); err != nil {
+	// "-" is the branch we were on before the last switch
+	if target == "-" {
+		if target, err = repo.Refs.PreviousCheckout(); err != nil {
 			return err
 		}
-
-		if err := refs.SetHead(target, true); err != nil {
-			return fmt.Errorf("failed to update HEAD: %w", err)
-		}
-
-		fmt.Printf("Switched to a new branch '%s'\n", target)
-		return nil
 	}
 
 	// Check if target is a branch
-	branchCommit, err := refs.GetBranchCommit(target)
+	branchCommit, err := repo.Refs.GetBranchCommit(target)
 	if err == nil && branchCommit != "" {
-		// It's a branch
-		if err := checkoutCommit(repoRoot, branchCommit); err != nil {
-			return err
-		}
-
-		if err := refs.SetHead(target, true); err != nil {
-			return fmt.Errorf("failed to update HEAD: %w", err)
-		}
-
-		fmt.Printf("Switched to branch '%s'\n", target)
-		return nil
+		return switchToBranch(repo, target, branchCommit)
 	}
 
 	// Try as a commit hash
@@ -78,17 +76,7 @@ func runCheckout(cmd *cobra.Command, args []string) error {
 		obj, err := object.ReadObject(repoRoot, commitHash)
 		if err == nil {
 			if _, ok := obj.(*object.Commit); ok {
-				if err := checkoutCommit(repoRoot, commitHash); err != nil {
-					return err
-				}
-
-				if err := refs.SetHead(commitHash, false); err != nil {
-					return fmt.Errorf("failed to update HEAD: %w", err)
-				}
-
-				fmt.Printf("Note: switching to '%s'.\n\n", commitHash[:7])
-				fmt.Println("You are in 'detached HEAD' state.")
-				return nil
+				return detachHead(repo, commitHash)
 			}
 		}
 	}
@@ -96,67 +84,171 @@ func runCheckout(cmd *cobra.Command, args []string) error {
 	return fmt.Errorf("pathspec '%s' did not match any branch or commit", target)
 }
 
-func checkoutCommit(repoRoot, commitHash string) error {
-	// Read commit
-	obj, err := object.ReadObject(repoRoot, commitHash)
-	if err != nil {
-		return fmt.Errorf("failed to read commit: %w", err)
+// createAndSwitchBranch creates a branch at HEAD and switches to it
+func createAndSwitchBranch(repo *repository.Repository, name string) error {
+	refs := repo.Refs
+	from, commitHash := headPosition(repo)
+
+	if commitHash == "" {
+		// No commits yet: retarget the unborn HEAD; the first commit creates the branch
+		if existing, _ := refs.GetBranchCommit(name); existing != "" {
+			return fmt.Errorf("a branch named '%s' already exists", name)
+		}
+	} else if err := refs.CreateBranch(name, commitHash); err != nil {
+		return err
+	} else if err := repo.LogRefUpdate("refs/heads/"+name, "", commitHash, "branch: Created from HEAD"); err != nil {
+		return err
 	}
 
-	commit, ok := obj.(*object.Commit)
-	if !ok {
-		return fmt.Errorf("object is not a commit")
+	if err := refs.SetHead(name, true); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	if commitHash != "" {
+		if err := repo.LogCheckout(commitHash, commitHash, from, name); err != nil {
+			return err
+		}
 	}
 
-	// Read tree
-	treeObj, err := object.ReadObject(repoRoot, commit.TreeHash)
-	if err != nil {
-		return fmt.Errorf("failed to read tree: %w", err)
+	fmt.Printf("Switched to a new branch '%s'\n", name)
+	return nil
+}
+
+// switchToBranch checks out a branch's commit and points HEAD at the branch
+func switchToBranch(repo *repository.Repository, name, branchCommit string) error {
+	from, oldHash := headPosition(repo)
+
+	if err := checkoutCommit(repo, branchCommit); err != nil {
+		return err
+	}
+	if from == oldHash {
+		warnLostCommits(repo, oldHash, branchCommit)
 	}
 
-	tree, ok := treeObj.(*object.Tree)
-	if !ok {
-		return fmt.Errorf("object is not a tree")
+	if err := repo.Refs.SetHead(name, true); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	if err := repo.LogCheckout(oldHash, branchCommit, from, name); err != nil {
+		return err
 	}
 
-	// Update working directory and index
-	idx := index.NewIndex()
+	fmt.Printf("Switched to branch '%s'\n", name)
+	return nil
+}
 
-	for _, entry := range tree.Entries {
-		// Read blob
-		blobObj, err := object.ReadObject(repoRoot, entry.Hash)
-		if err != nil {
-			return fmt.Errorf("failed to read blob %s: %w", entry.Name, err)
-		}
+// detachHead checks out a commit and points HEAD directly at it
+func detachHead(repo *repository.Repository, commitHash string) error {
+	from, oldHash := headPosition(repo)
 
-		blob, ok := blobObj.(*object.Blob)
-		if !ok {
-			// Might be a subtree - skip for now (simplified implementation)
-			continue
-		}
+	if err := checkoutCommit(repo, commitHash); err != nil {
+		return err
+	}
+	if from == oldHash {
+		warnLostCommits(repo, oldHash, commitHash)
+	}
+
+	if err := repo.Refs.SetHead(commitHash, false); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	if err := repo.LogCheckout(oldHash, commitHash, from, commitHash); err != nil {
+		return err
+	}
 
-		// Write file
-		filePath := filepath.Join(repoRoot, entry.Name)
+	fmt.Printf("Note: switching to '%s'.\n\n", commitHash[:7])
+	fmt.Println("You are in 'detached HEAD' state.")
+	return nil
+}
 
-		// Ensure directory exists
-		dir := filepath.Dir(filePath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
-		}
+// lostCommitsShown is how many lost commits warnLostCommits lists by name
+const lostCommitsShown = 4
 
-		// Determine file mode
-		mode := os.FileMode(0644)
-		if entry.Mode == "100755" {
-			mode = 0755
+// warnLostCommits warns when moving a detached HEAD from oldHash to newHash
+// leaves commits that no branch or other ref can reach, as they will
+// eventually be garbage collected
+func warnLostCommits(repo *repository.Repository, oldHash, newHash string) {
+	if oldHash == "" || oldHash == newHash {
+		return
+	}
+	lost, err := repo.LostCommits(oldHash, []string{newHash})
+	if err != nil || len(lost) == 0 {
+		return
+	}
+
+	var list strings.Builder
+	for i, hash := range lost {
+		if i == lostCommitsShown && len(lost) > lostCommitsShown+1 {
+			fmt.Fprintf(&list, " ... and %d more.\n", len(lost)-lostCommitsShown)
+			break
 		}
+		subject := ""
+		if commit, err := repo.Objects.ReadCommit(hash); err == nil {
+			subject, _, _ = strings.Cut(commit.Message, "\n")
+		}
+		fmt.Fprintf(&list, "  %s %s\n", hash[:7], subject)
+	}
+
+	count, pronoun := "1 commit", "it"
+	if len(lost) > 1 {
+		count, pronoun = fmt.Sprintf("%d commits", len(lost)), "them"
+	}
+	fmt.Fprintf(os.Stderr, "Warning: you are leaving %s behind, not connected to\n"+
+		"any of your branches:\n\n%s\n"+
+		"If you want to keep %s by creating a new branch, this may be a good time\n"+
+		"to do so with:\n\n gogit branch <new-branch-name> %s\n\n",
+		count, list.String(), pronoun, oldHash[:7])
+}
+
+// headPosition returns what HEAD is on, as the reflog names it (the branch,
+// or the commit when detached), and the commit it resolves to
+func headPosition(repo *repository.Repository) (string, string) {
+	hash, _ := repo.Refs.ResolveHead()
+	if branch, err := repo.Refs.CurrentBranch(); err == nil {
+		return branch, hash
+	}
+	return hash, hash
+}
+
+// checkoutCommit writes every file in a commit's tree, subdirectories
+// included, to the working tree and makes the index match it. Files the
+// index tracks that the commit doesn't have are removed.
+func checkoutCommit(repo *repository.Repository, commitHash string) error {
+	repoRoot := repo.Path
+
+	commit, err := repo.Objects.ReadCommit(commitHash)
+	if err != nil {
+		return fmt.Errorf("failed to read commit: %w", err)
+	}
+	files, err := diff.FlattenTree(repo.Objects, commit.TreeHash)
+	if err != nil {
+		return fmt.Errorf("failed to read tree: %w", err)
+	}
 
-		if err := os.WriteFile(filePath, blob.Content(), mode); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", entry.Name, err)
+	old, err := index.ReadIndex(repoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	for _, entry := range old.Entries {
+		// A submodule's directory is left for its own files
+		if _, ok := files[entry.Path]; !ok && entry.Mode != 0160000 {
+			if err := removeWorktreeFile(repoRoot, entry.Path); err != nil {
+				return err
+			}
 		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
 
-		// Add to index
-		if err := idx.AddFile(repoRoot, filePath); err != nil {
-			return fmt.Errorf("failed to update index: %w", err)
+	// Update working directory and index
+	idx := index.NewIndex()
+	for _, path := range paths {
+		if err := checkoutEntry(repoRoot, path, files[path]); err != nil {
+			return err
+		}
+		if err := stageCheckedOut(repoRoot, idx, path, files[path]); err != nil {
+			return err
 		}
 	}
 
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckoutNewBranchBeforeFirstCommit(t *testing.T) {
+	root := newTestRepo(t)
+	mustGogit(t, "checkout", "-b", "topic")
+
+	head, err := os.ReadFile(filepath.Join(root, ".gogit", "HEAD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(head) != "ref: refs/heads/topic\n" {
+		t.Errorf("HEAD = %q, want it pointing at the unborn topic", head)
+	}
+
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	if got := strings.TrimSpace(mustGogit(t, "rev-parse", "--abbrev-ref", "HEAD")); got != "topic" {
+		t.Errorf("HEAD is on %q, want topic", got)
+	}
+	if _, err := gogit(t, "rev-parse", "--verify", "-q", "main"); err == nil {
+		t.Error("main exists, want the first commit on topic only")
+	}
+}
+
+func TestCheckoutPreviousBranch(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+
+	if _, err := gogit(t, "checkout", "-"); err == nil {
+		t.Error("checkout - succeeded before any switch")
+	}
+
+	mustGogit(t, "checkout", "-b", "feature")
+	commitWorktree(t, "two", map[string]string{"f": "b\n"})
+	for _, c := range []struct {
+		args   []string
+		branch string
+		f      string
+	}{
+		{[]string{"checkout", "-"}, "main", "a\n"},
+		{[]string{"checkout", "-"}, "feature", "b\n"},
+		{[]string{"switch", "-"}, "main", "a\n"},
+	} {
+		mustGogit(t, c.args...)
+		if got := strings.TrimSpace(mustGogit(t, "rev-parse", "--abbrev-ref", "HEAD")); got != c.branch {
+			t.Errorf("%v landed on %q, want %q", c.args, got, c.branch)
+		}
+		if got := readFile(t, "f"); got != c.f {
+			t.Errorf("%v: f = %q, want %q", c.args, got, c.f)
+		}
+	}
+}
+
+func TestCheckoutWarnsAboutLeavingCommitsBehind(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	mustGogit(t, "checkout", strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD")))
+	commitWorktree(t, "lost work", map[string]string{"f": "b\n"})
+	lostHash := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	lost := lostHash[:7]
+
+	stderr, code := execute(t, "checkout", "main")
+	want := "Warning: you are leaving 1 commit behind, not connected to\n" +
+		"any of your branches:\n\n" +
+		"  " + lost + " lost work\n\n" +
+		"If you want to keep it by creating a new branch, this may be a good time\n" +
+		"to do so with:\n\n" +
+		" gogit branch <new-branch-name> " + lost + "\n\n"
+	if code != 0 || plain(stderr) != want {
+		t.Errorf("exit %d, stderr:\n%s\nwant:\n%s", code, stderr, want)
+	}
+
+	// Nothing is lost when a branch still has the commit
+	mustGogit(t, "checkout", lostHash)
+	mustGogit(t, "branch", "kept")
+	if stderr, _ := execute(t, "checkout", "main"); strings.Contains(stderr, "Warning") {
+		t.Errorf("warned about reachable commits:\n%s", stderr)
+	}
+}
+
+func TestCheckoutRestoresNestedTrees(t *testing.T) {
+	newTestRepo(t)
+	files := map[string]string{
+		"top":              "top\n",
+		"src/main.go":      "package main\n",
+		"src/lib/a.go":     "package lib\n",
+		"src/lib/deep/b":   "\x00binary\xff\r\n",
+		"docs/guide/intro": "no newline at the end",
+	}
+	for path, content := range files {
+		writeFile(t, path, content)
+	}
+	writeFile(t, "src/lib/run.sh", "#!/bin/sh\n")
+	if err := os.Chmod("src/lib/run.sh", 0755); err != nil {
+		t.Fatal(err)
+	}
+	files["src/lib/run.sh"] = "#!/bin/sh\n"
+	mustGogit(t, "add", "top", "src", "docs")
+	mustGogit(t, "commit", "-m", "nested")
+	stagedBefore := mustGogit(t, "ls-files", "-s")
+
+	// A branch without the directories, then back again
+	mustGogit(t, "checkout", "-b", "flat")
+	mustGogit(t, "rm", "-q", "-r", "src", "docs")
+	mustGogit(t, "commit", "-m", "flat")
+	for _, dir := range []string{"src", "docs"} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s still exists on the flat branch: %v", dir, err)
+		}
+	}
+	mustGogit(t, "checkout", "main")
+
+	for path, want := range files {
+		if got := readFile(t, path); got != want {
+			t.Errorf("%s restored as %q, want %q", path, got, want)
+		}
+	}
+	if info, err := os.Stat("src/lib/run.sh"); err != nil || info.Mode()&0111 == 0 {
+		t.Errorf("run.sh restored without its executable bit: %v, %v", info, err)
+	}
+	if got := mustGogit(t, "ls-files", "-s"); got != stagedBefore {
+		t.Errorf("index after checking out main again:\n%s\nwant:\n%s", got, stagedBefore)
+	}
+	if got := plain(mustGogit(t, "status")); got != "On branch main\n\nnothing to commit, working tree clean\n" {
+		t.Errorf("status after checking out main again:\n%s", got)
+	}
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var cherryVerbose bool
+
+var cherryCmd = &cobra.Command{
+	Use:   "cherry [-v] <upstream> [<head>]",
+	Short: "Find commits yet to be applied to upstream",
+	Long: `List the commits in <head> (default HEAD) that are not in <upstream>, oldest
+first. Each is marked "+" if upstream has no equivalent change, or "-" if a
+commit making the same change (by patch ID) is already in upstream, for
+example because it was cherry-picked. Merge commits are not listed.`,
+	GroupID: groupAncillary,
+	Example: `  # List the commits on this branch that main doesn't have yet
+  gogit cherry -v main`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runCherry,
+}
+
+func init() {
+	rootCmd.AddCommand(cherryCmd)
+	cherryCmd.Flags().BoolVarP(&cherryVerbose, "verbose", "v", false, "Show the commit subjects")
+}
+
+func runCherry(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	upstream, err := repo.ResolveCommit(args[0])
+	if err != nil {
+		return err
+	}
+	headRev := "HEAD"
+	if len(args) > 1 {
+		headRev = args[1]
+	}
+	head, err := repo.ResolveCommit(headRev)
+	if err != nil {
+		return err
+	}
+
+	// Patch IDs of the changes upstream has that head doesn't
+	upstreamOnly, err := repo.CommitRange(head, upstream)
+	if err != nil {
+		return err
+	}
+	applied := make(map[string]bool)
+	for _, hash := range upstreamOnly {
+		id, err := commitPatchID(repo, hash)
+		if err != nil {
+			return err
+		}
+		if id != "" {
+			applied[id] = true
+		}
+	}
+
+	headOnly, err := repo.CommitRange(upstream, head)
+	if err != nil {
+		return err
+	}
+	for _, hash := range headOnly {
+		commit, err := repo.Objects.ReadCommit(hash)
+		if err != nil {
+			return err
+		}
+		if len(commit.Parents) > 1 {
+			continue
+		}
+
+		id, err := commitPatchID(repo, hash)
+		if err != nil {
+			return err
+		}
+		mark := "+"
+		if id != "" && applied[id] {
+			mark = "-"
+		}
+
+		if cherryVerbose {
+			subject, _, _ := strings.Cut(commit.Message, "\n")
+			fmt.Printf("%s %s %s\n", mark, hash, subject)
+		} else {
+			fmt.Printf("%s %s\n", mark, hash)
+		}
+	}
+
+	return nil
+}
+
+// commitPatchID returns the patch ID of a non-merge commit, or "" for a merge
+func commitPatchID(repo *repository.Repository, hash string) (string, error) {
+	commit, err := repo.Objects.ReadCommit(hash)
+	if err != nil {
+		return "", err
+	}
+	if len(commit.Parents) > 1 {
+		return "", nil
+	}
+	return diff.CommitPatchID(repo.Objects, commit)
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCherry(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "base", map[string]string{"f": "1\n2\n3\n", "g": "1\n"})
+	mustGogit(t, "checkout", "-b", "topic")
+	commitWorktree(t, "change f", map[string]string{"f": "1\ntwo\n3\n"})
+	picked := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	commitWorktree(t, "change g", map[string]string{"g": "one\n"})
+	owed := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+
+	// The same change to f made again upstream, as cherry-pick would
+	mustGogit(t, "checkout", "main")
+	commitWorktree(t, "unrelated", map[string]string{"h": "h\n"})
+	commitWorktree(t, "change f again", map[string]string{"f": "1\ntwo\n3\n"})
+
+	want := "- " + picked + "\n+ " + owed + "\n"
+	if got := plain(mustGogit(t, "cherry", "main", "topic")); got != want {
+		t.Errorf("cherry main topic:\n%s\nwant:\n%s", got, want)
+	}
+	want = "- " + picked + " change f\n+ " + owed + " change g\n"
+	if got := plain(mustGogit(t, "cherry", "-v", "main", "topic")); got != want {
+		t.Errorf("cherry -v main topic:\n%s\nwant:\n%s", got, want)
+	}
+
+	mustGogit(t, "checkout", "topic")
+	if got := plain(mustGogit(t, "cherry", "main")); got != "- "+picked+"\n+ "+owed+"\n" {
+		t.Errorf("cherry main, from topic:\n%s", got)
+	}
+}
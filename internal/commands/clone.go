@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/index"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+	"github.com/yourusername/gogit/internal/transport"
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+var (
+	cloneFilter string
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone [--filter=<spec>] <url> [<directory>]",
+	Short: "Clone a repository into a new directory",
+	Long: `Clone a repository over smart HTTP into a new directory, set it up as the
+remote "origin", and check out the branch the remote's HEAD points to.
+
+With --filter, make a partial clone: the remote leaves out the objects the
+filter excludes and they are fetched on demand when a command needs them.
+Supported filters are blob:none (no file contents), blob:limit=<n>[kmg]
+(no blobs larger than n bytes) and tree:<depth>. Fetching single objects
+later requires the server to allow it (uploadpack.allowReachableSHA1InWant).`,
+	GroupID: groupMain,
+	Example: `  # Clone a repository into a directory named after it
+  gogit clone https://example.com/project.git
+
+  # Clone without any file contents, fetching them when they are needed
+  gogit clone --filter=blob:none https://example.com/project.git work`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runClone,
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+	cloneCmd.Flags().StringVar(&cloneFilter, "filter", "", "Make a partial clone that leaves out the objects the filter excludes")
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	url := args[0]
+	if err := transport.ValidateURL(url); err != nil {
+		return err
+	}
+	if cloneFilter != "" {
+		if err := transport.ValidateFilter(cloneFilter); err != nil {
+			return err
+		}
+	}
+
+	dir := cloneDirName(url)
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	absPath, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	entries, err := os.ReadDir(absPath)
+	switch {
+	case err == nil && len(entries) > 0:
+		return fmt.Errorf("destination path '%s' already exists and is not an empty directory", dir)
+	case err != nil && !os.IsNotExist(err):
+		return fmt.Errorf("destination path '%s' already exists and is not a directory", dir)
+	}
+	created := err != nil
+
+	fmt.Printf("Cloning into '%s'...\n", dir)
+
+	adv, err := transport.GetAdvertisement(transport.NewClient(transport.DefaultClientOptions()), url)
+	if err != nil {
+		return err
+	}
+
+	if err := clone(absPath, url, adv); err != nil {
+		// Don't leave a half-made clone behind
+		if created {
+			os.RemoveAll(absPath)
+		} else {
+			os.RemoveAll(utils.GitDir(absPath))
+		}
+		return err
+	}
+	return nil
+}
+
+// clone creates the repository at absPath and fills it from the remote
+func clone(absPath, url string, adv *transport.Advertisement) error {
+	branch := "main"
+	if target, ok := adv.SymrefTarget("HEAD"); ok && strings.HasPrefix(target, "refs/heads/") {
+		branch = strings.TrimPrefix(target, "refs/heads/")
+	}
+
+	gitDir, err := initRepository(absPath, branch)
+	if err != nil {
+		return err
+	}
+	if err := appendConfig(gitDir, remoteConfig("origin", url, cloneFilter)); err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(absPath)
+	if err != nil {
+		return err
+	}
+	if len(adv.Refs) == 0 {
+		fmt.Println("warning: You appear to have cloned an empty repository.")
+		return nil
+	}
+
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return err
+	}
+	if _, err := repo.Fetch(remote, repository.FetchOptions{}); err != nil {
+		return err
+	}
+
+	tracking := "refs/remotes/origin/" + branch
+	head, err := repo.Refs.ResolveRef(tracking)
+	if err != nil {
+		return err
+	}
+	if head == "" {
+		fmt.Println("warning: remote HEAD refers to nonexistent ref, unable to checkout")
+		return nil
+	}
+	if err := repo.Refs.UpdateRef("refs/remotes/origin/HEAD", "ref: "+tracking); err != nil {
+		return err
+	}
+	if err := repo.UpdateRef("refs/heads/"+branch, head, "clone: from "+url); err != nil {
+		return err
+	}
+
+	return populateWorktree(repo, head)
+}
+
+// cloneDirName returns the directory a clone of url goes into by default:
+// the last path component without a ".git" suffix
+func cloneDirName(url string) string {
+	name := path.Base(strings.TrimRight(url, "/"))
+	name = strings.TrimSuffix(name, ".git")
+	if name == "" || name == "." || name == "/" {
+		return "repo"
+	}
+	return name
+}
+
+// remoteConfig returns the config sections for a remote; with a filter, the
+// remote is also set up as the promisor of a partial clone
+func remoteConfig(name, url, filter string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[remote \"%s\"]\n", name)
+	fmt.Fprintf(&sb, "\turl = %s\n", url)
+	fmt.Fprintf(&sb, "\tfetch = +refs/heads/*:refs/remotes/%s/*\n", name)
+	if filter != "" {
+		sb.WriteString("\tpromisor = true\n")
+		fmt.Fprintf(&sb, "\tpartialclonefilter = %s\n", filter)
+		fmt.Fprintf(&sb, "[extensions]\n\tpartialclone = %s\n", name)
+	}
+	return sb.String()
+}
+
+// appendConfig adds sections to the end of a repository's config file
+func appendConfig(gitDir, sections string) error {
+	f, err := os.OpenFile(filepath.Join(gitDir, "config"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+	if _, err := f.WriteString(sections); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+	return nil
+}
+
+// populateWorktree checks out a commit into an empty working tree and
+// index. In a partial clone the missing blobs are fetched in one request
+// up front rather than one at a time.
+func populateWorktree(repo *repository.Repository, commitHash string) error {
+	commit, err := repo.Objects.ReadCommit(commitHash)
+	if err != nil {
+		return fmt.Errorf("failed to read commit: %w", err)
+	}
+	files, err := diff.FlattenTree(repo.Objects, commit.TreeHash)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(files))
+	var blobs []string
+	for p, entry := range files {
+		paths = append(paths, p)
+		if entry.Mode != object.ModeGitlink {
+			blobs = append(blobs, entry.Hash)
+		}
+	}
+	sort.Strings(paths)
+	if err := object.Prefetch(repo.Path, blobs); err != nil {
+		return err
+	}
+
+	idx := index.NewIndex()
+	for _, p := range paths {
+		if err := checkoutEntry(repo.Path, p, files[p]); err != nil {
+			return err
+		}
+		if err := stageCheckedOut(repo.Path, idx, p, files[p]); err != nil {
+			return err
+		}
+	}
+
+	if err := idx.Write(repo.Path); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
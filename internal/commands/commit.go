@@ -2,6 +2,10 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yourusername/gogit/internal/index"
@@ -10,20 +14,55 @@ import (
 )
 
 var (
-	commitMessage string
+	commitMessage    string
+	commitAuthor     string
+	commitDate       string
+	commitAllowEmpty bool
+	commitTemplate   string
 )
 
 var commitCmd = &cobra.Command{
 	Use:   "commit",
 	Short: "Record changes to the repository",
-	Long:  `Create a new commit containing the current contents of the index.`,
-	RunE:  runCommit,
+	Long: `Create a new commit containing the current contents of the index.
+
+Without -m, the message is written in an editor (GIT_EDITOR, core.editor,
+VISUAL or EDITOR, falling back to vi), which starts out with the contents
+of the --template file or of the file commit.template names, if any. Lines
+starting with "#" are left out of the message, and the commit is aborted
+if the message is empty or the template was saved unchanged.
+
+A commit whose tree would be the same as its parent's is refused, as it
+would record no change, unless --allow-empty is given. Merge commits are
+always allowed.
+
+--author and --date override who the commit is attributed to and when it was
+authored, for example when importing history; the committer is always the
+current user at the current time. Dates may be given as RFC 3339
+(2006-01-02T15:04:05Z07:00), RFC 2822, "2006-01-02 15:04:05 -0700",
+"2006-01-02", a Unix timestamp optionally followed by a zone, or "@<timestamp>".`,
+	GroupID: groupMain,
+	Example: `  # Record the staged changes
+  gogit commit -m "Fix off-by-one in the parser"
+
+  # Import a commit with its original author and date
+  gogit commit -m "Initial import" --author="Ada <ada@example.com>" --date=2020-01-02
+
+  # Write the message in the editor, starting from a template
+  gogit commit --template=.gitmessage
+
+  # Record a commit that changes nothing
+  gogit commit --allow-empty -m "Trigger a rebuild"`,
+	RunE: runCommit,
 }
 
 func init() {
 	rootCmd.AddCommand(commitCmd)
-	commitCmd.Flags().StringVarP(&commitMessage, "message", "m", "", "Commit message")
-	commitCmd.MarkFlagRequired("message")
+	commitCmd.Flags().StringVarP(&commitMessage, "message", "m", "", "Commit message (defaults to the prepared message while merging, or else is written in the editor)")
+	commitCmd.Flags().StringVar(&commitAuthor, "author", "", "Override the commit author, given as \"Name <email>\"")
+	commitCmd.Flags().StringVar(&commitDate, "date", "", "Override the author date")
+	commitCmd.Flags().BoolVar(&commitAllowEmpty, "allow-empty", false, "Allow a commit that changes nothing from its parent")
+	commitCmd.Flags().StringVarP(&commitTemplate, "template", "t", "", "Start the message in the editor from this file (default commit.template)")
 }
 
 func runCommit(cmd *cobra.Command, args []string) error {
@@ -38,55 +77,228 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var author *object.Signature
+	if commitAuthor != "" || commitDate != "" {
+		if author, err = authorOverride(repo, commitAuthor, commitDate); err != nil {
+			return err
+		}
+	}
+
+	return commitIndex(repo, commitMessage, author)
+}
+
+// authorOverride returns the author given by --author and --date, each
+// falling back to the current user and time when not given
+func authorOverride(repo *repository.Repository, ident, date string) (*object.Signature, error) {
+	if ident == "" {
+		var err error
+		if ident, err = repo.GetUserInfo(); err != nil {
+			return nil, err
+		}
+	} else if !strings.HasSuffix(ident, ">") || !strings.Contains(ident, " <") {
+		return nil, fmt.Errorf("--author '%s' is not 'Name <email>'", ident)
+	}
+
+	author := object.ParseSignature(ident)
+	author.When = time.Now()
+	if date != "" {
+		when, err := object.ParseDate(date)
+		if err != nil {
+			return nil, err
+		}
+		author.When = when
+	}
+	return &author, nil
+}
+
+// commitIndex records the index as a new commit on HEAD. While a merge is in
+// progress the commit gets MERGE_HEAD as its second parent, and an empty
+// message falls back to MERGE_MSG, or to SQUASH_MSG after a squash merge. A
+// nil author means the current user, now. Outside a merge, a commit whose
+// tree is its parent's is refused unless --allow-empty was given.
+func commitIndex(repo *repository.Repository, message string, author *object.Signature) error {
+	repoRoot := repo.Path
+
 	// Read index
 	idx, err := index.ReadIndex(repoRoot)
 	if err != nil {
 		return fmt.Errorf("failed to read index: %w", err)
 	}
 
-	if len(idx.Entries) == 0 {
+	// Whatever left conflict stages behind, a tree can't be built from them
+	if conflicts := idx.Conflicts(); len(conflicts) > 0 {
+		return fmt.Errorf("committing is not possible because you have unmerged files:\n\t%s\nFix them up in the working tree, then use \"gogit add <file>\" to mark resolution", strings.Join(conflicts, "\n\t"))
+	}
+
+	mergeHead, err := repo.MergeHead()
+	if err != nil {
+		return err
+	}
+	// An empty index is only nothing to commit on an unborn branch; after
+	// the first commit it records every file being removed
+	parentHash, _ := repo.Refs.ResolveHead()
+	if len(idx.Entries) == 0 && parentHash == "" {
 		return fmt.Errorf("nothing to commit (create/add some files and use \"gogit add\")")
 	}
 
+	// New trees and the commit are written together once all are built, so
+	// a failure can't leave HEAD on a commit with missing trees
+	batch := object.NewBatch(repoRoot)
+
 	// Build tree from index
-	treeHash, err := repo.BuildTreeRecursive(idx)
+	treeHash, err := repo.BuildTreeRecursive(idx, batch)
 	if err != nil {
 		return fmt.Errorf("failed to build tree: %w", err)
 	}
 
-	// Get parent commit (if exists)
-	parentHash, _ := repo.Refs.ResolveHead()
+	if parentHash != "" && mergeHead == "" && !commitAllowEmpty {
+		parent, err := repo.Objects.ReadCommit(parentHash)
+		if err != nil {
+			return fmt.Errorf("failed to read HEAD commit: %w", err)
+		}
+		if parent.TreeHash == treeHash {
+			return fmt.Errorf("nothing to commit: the index matches HEAD (use \"gogit add\" to stage changes, or --allow-empty)")
+		}
+	}
 
-	// Get author info
-	author, err := repo.GetUserInfo()
+	if message == "" {
+		if mergeHead != "" {
+			message, err = repo.MergeMessage()
+		} else {
+			message, err = repo.SquashMessage()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Only once the commit is known to be possible is the user asked for
+	// a message
+	if message == "" {
+		if message, err = editCommitMessage(repo); err != nil {
+			return err
+		}
+	}
+	message = strings.TrimRight(message, "\n") + "\n"
+
+	// Get committer info
+	committer, err := repo.GetUserInfo()
 	if err != nil {
-		author = "Unknown <unknown@unknown>"
+		return err
 	}
 
 	// Create commit object
-	commit := object.NewCommit(treeHash, parentHash, author, commitMessage)
+	var parents []string
+	if parentHash != "" {
+		parents = append(parents, parentHash)
+	}
+	if mergeHead != "" {
+		parents = append(parents, mergeHead)
+	}
+	commit := object.NewCommit(treeHash, parents, committer, message)
+	if author != nil {
+		commit.Author = *author
+	}
 
-	// Write commit
-	commitHash, err := object.WriteObject(repoRoot, commit)
-	if err != nil {
+	commitHash := batch.Add(commit)
+	if err := batch.Flush(); err != nil {
 		return fmt.Errorf("failed to write commit: %w", err)
 	}
 
-	// Update HEAD
-	if err := repo.Refs.UpdateHead(commitHash); err != nil {
+	// Update HEAD only once every object it needs is stored
+	if err := repo.UpdateHead(commitHash, commitReflogMessage(message, parentHash, mergeHead)); err != nil {
 		return fmt.Errorf("failed to update HEAD: %w", err)
 	}
 
+	if err := repo.ClearMergeState(); err != nil {
+		return err
+	}
+
 	// Print result
-	branch, _ := repo.Refs.CurrentBranch()
+	branch, err := repo.Refs.CurrentBranch()
+	if err != nil {
+		branch = "detached HEAD"
+	}
+	subject := strings.Split(message, "\n")[0]
 	if parentHash == "" {
-		fmt.Printf("[%s (root-commit) %s] %s\n", branch, commitHash[:7], commitMessage)
+		fmt.Printf("[%s (root-commit) %s] %s\n", branch, commitHash[:7], subject)
 	} else {
-		fmt.Printf("[%s %s] %s\n", branch, commitHash[:7], commitMessage)
+		fmt.Printf("[%s %s] %s\n", branch, commitHash[:7], subject)
 	}
 
 	// Show summary
 	fmt.Printf(" %d file(s) changed\n", len(idx.Entries))
 
+	autoGC(repo)
 	return nil
 }
+
+// editCommitMessage has the user write the commit message in their editor,
+// starting from the --template file or commit.template if either is set.
+// Comment lines are dropped from the result, and a message left empty or,
+// with a template, left as the template was aborts the commit.
+func editCommitMessage(repo *repository.Repository) (string, error) {
+	template, err := commitTemplateText(repo)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(template)
+	sb.WriteString("\n# Please enter the commit message for your changes. Lines starting\n")
+	sb.WriteString("# with '#' will be ignored, and an empty message aborts the commit.\n")
+	if branch, err := repo.Refs.CurrentBranch(); err == nil {
+		fmt.Fprintf(&sb, "#\n# On branch %s\n", branch)
+	}
+
+	edited, err := editMessage(repo, "COMMIT_EDITMSG", sb.String())
+	if err != nil {
+		return "", err
+	}
+	message := cleanupMessage(edited)
+	if message == "" {
+		return "", fmt.Errorf("aborting commit due to empty commit message")
+	}
+	if template != "" && message == cleanupMessage(template) {
+		return "", fmt.Errorf("aborting commit; you did not edit the message")
+	}
+	return message, nil
+}
+
+// commitTemplateText returns the contents of the message template given by
+// --template or commit.template, or "" if there is none. A leading "~/"
+// stands for the home directory.
+func commitTemplateText(repo *repository.Repository) (string, error) {
+	path := commitTemplate
+	if path == "" {
+		path, _ = repo.GetConfig("commit.template")
+	}
+	if path == "" {
+		return "", nil
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand %s: %w", path, err)
+		}
+		path = filepath.Join(home, rest)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read commit message template '%s': %w", path, err)
+	}
+	return string(data), nil
+}
+
+// commitReflogMessage returns the reflog message for a new commit, which
+// git words after the commit's subject and the kind of commit it is
+func commitReflogMessage(message, parentHash, mergeHead string) string {
+	subject := strings.Split(message, "\n")[0]
+	switch {
+	case parentHash == "":
+		return "commit (initial): " + subject
+	case mergeHead != "":
+		return "commit (merge): " + subject
+	}
+	return "commit: " + subject
+}
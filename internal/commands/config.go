@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/config"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	configList  bool
+	configUnset bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config (<key> [<value>] | --unset <key> | --list)",
+	Short: "Get and set repository options",
+	Long: `Read and write the repository's config file. Keys are written as
+section.name, or section.subsection.name, such as user.email.
+
+With just a key, print its value, exiting with status 1 if it isn't set.
+With a value as well, set it, adding the section if the file lacks it.
+--unset removes a key, exiting with status 5 if it wasn't set, and --list
+prints every key and value as key=value.
+
+Changes rewrite only the lines they touch, so comments, other sections and
+their formatting are kept. Options go before the key, as anything after it
+is taken as the value, even when it starts with a dash.`,
+	GroupID: groupAncillary,
+	Example: `  # Set the name recorded in commits
+  gogit config user.name "Ada Lovelace"
+
+  # Read a value
+  gogit config user.email
+
+  # Show every option
+  gogit config --list`,
+	RunE: runConfig,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.Flags().BoolVarP(&configList, "list", "l", false, "List all keys and values")
+	configCmd.Flags().BoolVar(&configUnset, "unset", false, "Remove a key")
+	// Values like "-trailing-space" for core.whitespace aren't flags
+	configCmd.Flags().SetInterspersed(false)
+}
+
+func runConfig(cmd *cobra.Command, args []string) error {
+	if configList && configUnset {
+		return fmt.Errorf("--list and --unset can't be used together")
+	}
+
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case configList:
+		if len(args) > 0 {
+			return fmt.Errorf("--list takes no arguments")
+		}
+		entries, err := repo.ListConfig()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			fmt.Printf("%s=%s\n", entry.Key, entry.Value)
+		}
+		return nil
+
+	case configUnset:
+		if len(args) != 1 {
+			return fmt.Errorf("--unset takes exactly one key")
+		}
+		err := repo.UnsetConfig(args[0])
+		if errors.Is(err, repository.ErrConfigNotFound) {
+			return &ExitError{Code: exitUnset}
+		}
+		return err
+	}
+
+	if len(args) > 0 {
+		if err := config.CheckKey(args[0]); err != nil {
+			return err
+		}
+	}
+
+	switch len(args) {
+	case 1:
+		value, err := repo.GetConfig(args[0])
+		if errors.Is(err, repository.ErrConfigNotFound) {
+			return &ExitError{Code: exitNo}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	case 2:
+		return repo.SetConfig(args[0], args[1])
+	}
+	return fmt.Errorf("expected a key, optionally followed by a value")
+}
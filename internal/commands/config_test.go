@@ -0,0 +1,16 @@
+package commands
+
+import "testing"
+
+func TestConfigValueStartingWithDash(t *testing.T) {
+	newTestRepo(t)
+	mustGogit(t, "config", "core.whitespace", "-trailing-space,-blank-at-eof")
+	if got := mustGogit(t, "config", "core.whitespace"); got != "-trailing-space,-blank-at-eof\n" {
+		t.Errorf("config core.whitespace: %q", got)
+	}
+
+	mustGogit(t, "config", "--unset", "core.whitespace")
+	if _, code := execute(t, "config", "core.whitespace"); code != exitNo {
+		t.Errorf("config of an unset key exited %d, want %d", code, exitNo)
+	}
+}
@@ -3,30 +3,136 @@ package commands
 import (
 	"fmt"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/attributes"
 	"github.com/yourusername/gogit/internal/diff"
 	"github.com/yourusername/gogit/internal/index"
 	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
 	"github.com/yourusername/gogit/internal/utils"
+	"golang.org/x/term"
 )
 
 var (
-	diffCached bool
+	diffCached     bool
+	diffNameOnly   bool
+	diffNameStatus bool
+	diffRaw        bool
+	diffFilter     string
+	diffExitCode   bool
+	diffRenames    string
+	diffNoRenames  bool
+	diffCopies     bool
+	diffLimit      int
+	diffRelative   string
+	diffStat       bool
+	diffShortStat  bool
+	diffStatWidth  int
+	diffSrcPrefix  string
+	diffDstPrefix  string
+	diffNoPrefix   bool
+	diffText       bool
+	diffTextconv   bool
+	diffCheck      bool
 )
 
 var diffCmd = &cobra.Command{
-	Use:   "diff [file]",
+	Use:   "diff [--cached [<commit>]] [--] [<path>...]",
 	Short: "Show changes between commits, commit and working tree, etc",
-	Long:  `Show changes between the working tree and the index or a tree.`,
-	RunE:  runDiff,
+	Long: `Show changes between the working tree and the index or a tree.
+
+With --cached, show the changes staged in the index relative to <commit>
+(default HEAD). Use -- to separate paths from the commit when they could be
+confused.
+
+With -M, a deleted and an added file with similar content are shown as a
+rename. With -C, added files are also compared against every file on the old
+side and shown as copies of the most similar one; -C implies -M. The config
+setting diff.renames (true, false or copies) sets the default.
+
+-M=<n> only pairs files at least <n> similar: a percentage such as 75%, or a
+fraction written without its leading "0.", so 75 and 9 mean 75% and 90%.
+Comparing every deleted file with every added one gets slow for large
+changes, so with more than -l<num> (diff.renameLimit, default 1000) files
+on either side only identical files are paired.
+
+Paths are shown relative to the top of the repository. With --relative,
+only changes in the current directory are shown, with paths relative to it;
+--relative=<path> does the same for a directory given relative to the top.
+
+Patch headers name the old and new files as a/<path> and b/<path>.
+--src-prefix and --dst-prefix change the prefixes; --no-prefix, or the
+config setting diff.noprefix, leaves them out.
+
+With --stat, show how many lines each file gained and lost instead of the
+patch, with a bar scaled to fit the terminal (or --stat-width columns);
+--shortstat shows only the closing summary line.
+
+A file with a NUL byte in its first 8000 bytes is binary, and its patch
+is just "Binary files ... differ"; -a/--text diffs every file as text.
+The gitattributes "diff" attribute overrides the check: "-diff" (or
+"binary") makes a file binary and "diff" makes it text. "diff=<driver>"
+with a diff.<driver>.textconv command configured diffs the output of that
+command, run with the path of a temporary copy of the file, instead of the
+file itself; --no-textconv turns this off.
+
+With --check, report whitespace errors in the lines the changes add
+instead of showing the patch, as "<path>:<line>: <error>." followed by the
+line, and exit with status 2 if there were any. By default the errors are
+trailing whitespace (blank-at-eol), blank lines added at the end of a file
+(blank-at-eof) and spaces before a tab in an indent (space-before-tab).
+core.whitespace turns checks on, or off with a "-" prefix, from those and
+indent-with-non-tab, tab-in-indent, cr-at-eol and tabwidth=<n>.
+
+With --exit-code, exit with status 1 if there were differences and 0 if
+there were none.`,
+	GroupID: groupMain,
+	Example: `  # Show unstaged changes
+  gogit diff
+
+  # Show what the next commit would record
+  gogit diff --cached
+
+  # Summarize changes per file, detecting renames
+  gogit diff --stat -M
+
+  # Check added lines for whitespace errors
+  gogit diff --check`,
+	RunE: runDiff,
 }
 
 func init() {
 	rootCmd.AddCommand(diffCmd)
 	diffCmd.Flags().BoolVar(&diffCached, "cached", false, "Show changes staged for commit")
 	diffCmd.Flags().BoolVar(&diffCached, "staged", false, "Synonym for --cached")
+	diffCmd.Flags().BoolVar(&diffNameOnly, "name-only", false, "Show only names of changed files")
+	diffCmd.Flags().BoolVar(&diffNameStatus, "name-status", false, "Show only names and status of changed files")
+	diffCmd.Flags().BoolVar(&diffRaw, "raw", false, "Show changes in the raw plumbing format")
+	diffCmd.Flags().StringVar(&diffFilter, "diff-filter", "", "Select only files that are Added (A), Copied (C), Deleted (D), Modified (M), or Renamed (R); lowercase letters exclude")
+	diffCmd.Flags().StringVarP(&diffRenames, "find-renames", "M", "", "Detect renames, optionally only those at least this similar (-M=<n>)")
+	diffCmd.Flags().Lookup("find-renames").NoOptDefVal = strconv.Itoa(diff.DefaultRenameScore) + "%"
+	diffCmd.Flags().BoolVar(&diffNoRenames, "no-renames", false, "Turn off rename detection, even if configured")
+	diffCmd.Flags().BoolVarP(&diffCopies, "find-copies", "C", false, "Detect copies as well as renames")
+	diffCmd.Flags().IntVarP(&diffLimit, "rename-limit", "l", -1, "Skip inexact rename detection with more than this many files on either side")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with status 1 if there were differences")
+	diffCmd.Flags().StringVar(&diffRelative, "relative", "", "Only show changes in a directory, with paths relative to it (default: the current directory)")
+	diffCmd.Flags().Lookup("relative").NoOptDefVal = "."
+	diffCmd.Flags().StringVar(&diffSrcPrefix, "src-prefix", diff.DefaultPrefixes.Src, "Show this prefix instead of \"a/\" before old file names")
+	diffCmd.Flags().StringVar(&diffDstPrefix, "dst-prefix", diff.DefaultPrefixes.Dst, "Show this prefix instead of \"b/\" before new file names")
+	diffCmd.Flags().BoolVar(&diffNoPrefix, "no-prefix", false, "Show file names without a prefix")
+	diffCmd.Flags().BoolVar(&diffStat, "stat", false, "Show a histogram of lines added and removed per file")
+	diffCmd.Flags().BoolVar(&diffShortStat, "shortstat", false, "Show only the summary line of --stat")
+	diffCmd.Flags().IntVar(&diffStatWidth, "stat-width", 0, "Fit --stat output into this many columns (default: the terminal width, or 80)")
+	diffCmd.Flags().BoolVarP(&diffText, "text", "a", false, "Treat all files as text")
+	diffCmd.Flags().BoolVar(&diffTextconv, "textconv", true, "Diff the output of configured textconv commands")
+	diffCmd.Flags().Bool("no-textconv", false, "Don't run textconv commands")
+	diffCmd.Flags().BoolVar(&diffCheck, "check", false, "Warn about whitespace errors in added lines")
 }
 
 func runDiff(cmd *cobra.Command, args []string) error {
@@ -35,117 +141,689 @@ func runDiff(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	filter, err := diff.ParseFilter(diffFilter)
+	if err != nil {
+		return err
+	}
+
+	relDir := ""
+	if cmd.Flags().Changed("relative") {
+		if relDir, err = relativeDir(repoRoot, diffRelative); err != nil {
+			return err
+		}
+	}
+
 	// Read index
 	idx, err := index.ReadIndex(repoRoot)
 	if err != nil {
 		return fmt.Errorf("failed to read index: %w", err)
 	}
 
-	// Build index map
-	indexMap := make(map[string]*index.Entry)
-	for i := range idx.Entries {
-		indexMap[idx.Entries[i].Path] = &idx.Entries[i]
+	var changes []diff.FileChange
+	var baseFiles map[string]object.TreeEntry
+	if diffCached {
+		// Compare index vs HEAD or the given commit
+		var baseTree string
+		baseTree, args, err = cachedDiffBase(repo, args, cmd.ArgsLenAtDash())
+		if err != nil {
+			return err
+		}
+		baseFiles, err = diff.FlattenTree(repo.Objects, baseTree)
+		if err != nil {
+			return err
+		}
+		changes = diff.DiffEntries(baseFiles, indexSnapshot(idx))
+	} else {
+		// Compare working tree vs index
+		baseFiles = indexSnapshot(idx)
+		changes = worktreeChanges(repoRoot, idx, repo.ConfigBool("core.filemode", true), worktreeEOL(repo))
 	}
 
-	// Get files to diff
-	var filesToDiff []string
-	if len(args) > 0 {
-		filesToDiff = args
+	// Files outside the --relative directory don't take part at all, not
+	// even as rename or copy sources
+	if relDir != "" {
+		changes = filterPaths(changes, []string{relDir})
+		for p := range baseFiles {
+			if !matchesPath(p, relDir) {
+				delete(baseFiles, p)
+			}
+		}
+	}
+
+	renames, copies := diffRenameConfig(repo)
+	if cmd.Flags().Changed("find-renames") {
+		renames = true
+	}
+	if diffCopies {
+		renames, copies = true, true
+	}
+	if diffNoRenames {
+		renames, copies = false, false
+	}
+
+	opts, err := renameOptions(repo, diffRenames, diffLimit)
+	if err != nil {
+		return err
+	}
+	var needed int
+	if renames {
+		if changes, needed, err = diff.DetectRenames(repo.Objects, changes, opts); err != nil {
+			return err
+		}
+	}
+	if copies && needed == 0 {
+		if changes, needed, err = diff.DetectCopies(repo.Objects, changes, baseFiles, opts); err != nil {
+			return err
+		}
+	}
+	warnRenameLimit(needed)
+
+	paths := make([]string, len(args))
+	for i, arg := range args {
+		if paths[i], err = repoRelative(repoRoot, arg); err != nil {
+			return err
+		}
+	}
+	changes = filter.Apply(filterPaths(changes, paths))
+
+	prefixes := diffPrefixes(cmd, repo)
+	if noTextconv, _ := cmd.Flags().GetBool("no-textconv"); noTextconv {
+		diffTextconv = false
+	}
+	src := &diffSource{
+		repo:         repo,
+		relDir:       relDir,
+		fromWorktree: !diffCached,
+		attrs:        attributes.NewMatcher(repoRoot, repo.GitDir),
+		eol:          worktreeEOL(repo),
+	}
+
+	wsRule := diff.DefaultWhitespaceRule
+	if diffCheck {
+		if value, err := repo.GetConfig("core.whitespace"); err == nil {
+			if wsRule, err = diff.ParseWhitespaceRule(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	var stats []diff.FileStat
+	wsErrors := false
+	for _, change := range changes {
+		if relDir != "" {
+			change = relativeChange(change, relDir)
+		}
+		switch {
+		case diffCheck:
+			found, err := checkWhitespace(src, change, wsRule)
+			if err != nil {
+				return err
+			}
+			wsErrors = wsErrors || found
+		case diffStat || diffShortStat:
+			stat, changed, err := changeStat(src, change)
+			if err != nil {
+				return err
+			}
+			if changed {
+				stats = append(stats, stat)
+			}
+		case diffNameOnly:
+			fmt.Println(change.Path())
+		case diffNameStatus:
+			fmt.Println(change.NameStatus())
+		case diffRaw:
+			fmt.Println(change.Raw())
+		default:
+			if err := printPatch(src, change, prefixes); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch {
+	case diffStat:
+		fmt.Print(diff.FormatStat(stats, statWidth()))
+	case diffShortStat:
+		fmt.Print(diff.ShortStat(stats))
+	}
+
+	if wsErrors {
+		return &ExitError{Code: exitCheck}
+	}
+	if diffExitCode && len(changes) > 0 {
+		return &ExitError{Code: exitNo}
+	}
+	return nil
+}
+
+// diffRenameConfig returns whether diff.renames asks for rename and copy
+// detection
+func diffRenameConfig(repo *repository.Repository) (renames, copies bool) {
+	value, _ := repo.GetConfig("diff.renames")
+	switch strings.ToLower(value) {
+	case "copies", "copy":
+		return true, true
+	}
+	return repo.ConfigBool("diff.renames", false), false
+}
+
+// renameOptions returns the rename detection options for a -M score and a
+// -l limit, either of which may be unset ("" and -1); diff.renameLimit
+// stands in for a missing limit
+func renameOptions(repo *repository.Repository, score string, limit int) (diff.RenameOptions, error) {
+	opts := diff.DefaultRenameOptions
+	if score != "" {
+		var err error
+		if opts.MinScore, err = parseRenameScore(score); err != nil {
+			return opts, err
+		}
+	}
+
+	if limit < 0 {
+		value, _ := repo.GetConfig("diff.renameLimit")
+		if value == "" {
+			return opts, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return opts, fmt.Errorf("bad diff.renameLimit value '%s'", value)
+		}
+		limit = n
+	}
+	opts.Limit = limit
+	return opts, nil
+}
+
+// parseRenameScore parses a similarity as -M takes it: a percentage such as
+// "75%", or the digits of a fraction after "0.", so "75" and "9" are 75% and
+// 90%
+func parseRenameScore(value string) (int, error) {
+	digits := strings.TrimSuffix(value, "%")
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid similarity '%s'", value)
+	}
+
+	var score float64
+	if strings.HasSuffix(value, "%") {
+		n, _ := strconv.Atoi(digits)
+		score = float64(n)
 	} else {
-		// All tracked files
-		for path := range indexMap {
-			filesToDiff = append(filesToDiff, path)
+		f, _ := strconv.ParseFloat("0."+digits, 64)
+		score = f * 100
+	}
+	if score > 100 {
+		score = 100
+	}
+	return int(score), nil
+}
+
+// warnRenameLimit tells the user that rename detection was cut short, and
+// which diff.renameLimit would have been enough
+func warnRenameLimit(needed int) {
+	if needed == 0 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "warning: exhaustive rename detection was skipped due to too many files.")
+	fmt.Fprintf(os.Stderr, "warning: you may want to set your diff.renameLimit variable to at least %d and retry the command.\n", needed)
+}
+
+// cachedDiffBase returns the tree a cached diff compares the index against,
+// along with the remaining path arguments. The first argument names the tree
+// if it resolves as a revision; arguments after "--" are always paths.
+func cachedDiffBase(repo *repository.Repository, args []string, dash int) (string, []string, error) {
+	if len(args) == 0 || dash == 0 {
+		tree, err := headTreeHash(repo)
+		return tree, args, err
+	}
+
+	rev := args[0]
+	if _, err := repo.ResolveRevision(rev); err != nil {
+		if dash > 0 {
+			return "", nil, err
+		}
+		if _, statErr := os.Stat(rev); statErr == nil {
+			// A path, not a revision
+			tree, err := headTreeHash(repo)
+			return tree, args, err
 		}
+		return "", nil, fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree", rev)
+	}
+
+	tree, err := repo.ResolveTree(rev)
+	if err != nil {
+		return "", nil, err
+	}
+	return tree, args[1:], nil
+}
+
+// diffPrefixes returns the file name prefixes for patch headers, from
+// --src-prefix, --dst-prefix and --no-prefix or diff.noprefix
+func diffPrefixes(cmd *cobra.Command, repo *repository.Repository) diff.Prefixes {
+	if diffNoPrefix {
+		return diff.Prefixes{}
+	}
+	prefixes := diff.DefaultPrefixes
+	if repo.ConfigBool("diff.noprefix", false) {
+		prefixes = diff.Prefixes{}
+	}
+	if cmd.Flags().Changed("src-prefix") {
+		prefixes.Src = diffSrcPrefix
 	}
+	if cmd.Flags().Changed("dst-prefix") {
+		prefixes.Dst = diffDstPrefix
+	}
+	return prefixes
+}
 
-	hasDiff := false
+// diffSource reads the two sides of changes for patches and --stat
+type diffSource struct {
+	repo         *repository.Repository
+	relDir       string // Directory change paths are relative to, from the top
+	fromWorktree bool   // The new side is read from the working tree
+	attrs        *attributes.Matcher
+	eol          *attributes.EOL // Normalizes line endings read from the working tree
+}
 
-	for _, relPath := range filesToDiff {
-		entry, inIndex := indexMap[relPath]
+// printPatch prints the unified diff for a single change
+func printPatch(src *diffSource, change diff.FileChange, prefixes diff.Prefixes) error {
+	oldContent, newContent, binary, err := src.contents(change, diffText)
+	if err != nil {
+		return err
+	}
 
-		absPath := filepath.Join(repoRoot, relPath)
-		workingContent, err := os.ReadFile(absPath)
-		workingExists := err == nil
+	oldName, newName := change.OldPath, change.NewPath
+	if oldName == "" {
+		oldName = "/dev/null"
+	}
+	if newName == "" {
+		newName = "/dev/null"
+	}
 
-		if !inIndex && !workingExists {
-			continue
+	// Compute diff
+	var changes []diff.Change
+	hasChanges := false
+	if binary {
+		hasChanges = oldContent != newContent
+	} else {
+		changes = diff.Diff(oldContent, newContent)
+		for _, c := range changes {
+			if c.Type != diff.ChangeEqual {
+				hasChanges = true
+				break
+			}
 		}
+	}
+	modeChanged := change.OldMode != "" && change.NewMode != "" && change.OldMode != change.NewMode
+	moved := change.Status == diff.StatusRenamed || change.Status == diff.StatusCopied
 
-		var oldContent, newContent string
-		var oldName, newName string
-
-		if diffCached {
-			// Compare index vs HEAD (not implemented here - simplified)
-			// For now, just show index content
-			if inIndex {
-				blobObj, err := object.ReadObject(repoRoot, entry.HashString())
-				if err == nil {
-					if blob, ok := blobObj.(*object.Blob); ok {
-						newContent = string(blob.Content())
-						newName = relPath
-						oldName = relPath
-						oldContent = "" // Would be HEAD content
-					}
+	// Only show if there are actual changes. An empty file added or deleted
+	// has no hunk, but still gets its header.
+	contentChanged := change.OldHash != change.NewHash
+	if !hasChanges && !contentChanged && !modeChanged && !moved {
+		return nil
+	}
+
+	if moved {
+		fmt.Printf("diff --git %s%s %s%s\n", prefixes.Src, change.OldPath, prefixes.Dst, change.NewPath)
+	} else {
+		fmt.Printf("diff --git %s%s %s%s\n", prefixes.Src, change.Path(), prefixes.Dst, change.Path())
+	}
+	switch {
+	case change.Status == diff.StatusAdded:
+		fmt.Printf("new file mode %s\n", change.NewMode)
+	case change.Status == diff.StatusDeleted:
+		fmt.Printf("deleted file mode %s\n", change.OldMode)
+	case modeChanged:
+		fmt.Printf("old mode %s\nnew mode %s\n", change.OldMode, change.NewMode)
+	}
+	if moved {
+		verb := "rename"
+		if change.Status == diff.StatusCopied {
+			verb = "copy"
+		}
+		fmt.Printf("similarity index %d%%\n", change.Score)
+		fmt.Printf("%s from %s\n%s to %s\n", verb, change.OldPath, verb, change.NewPath)
+	}
+	if contentChanged {
+		line, err := indexLine(src.repo, change)
+		if err != nil {
+			return err
+		}
+		fmt.Println(line)
+	}
+	switch {
+	case hasChanges && binary:
+		fmt.Print(diff.FormatBinary(oldName, newName, prefixes))
+	case hasChanges:
+		fmt.Print(diff.Format(oldName, newName, prefixes, changes))
+	}
+
+	return nil
+}
+
+// indexLine returns the "index <old>..<new>" line of a change's patch
+// header, with the file's mode appended if it didn't change
+func indexLine(repo *repository.Repository, change diff.FileChange) (string, error) {
+	hashes := [2]string{change.OldHash, change.NewHash}
+	for i, hash := range hashes {
+		if hash == "" {
+			hash = diff.ZeroHash
+		}
+		abbrev, err := object.Abbreviate(repo.Path, hash, defaultAbbrev)
+		if err != nil {
+			return "", err
+		}
+		hashes[i] = abbrev
+	}
+	line := fmt.Sprintf("index %s..%s", hashes[0], hashes[1])
+	if change.OldMode != "" && change.OldMode == change.NewMode {
+		line += " " + change.OldMode
+	}
+	return line, nil
+}
+
+// contents returns the old and new content of a change as they are to be
+// diffed, converted by the file's textconv command if it has one, and
+// whether they are to be treated as binary, which text overrides
+func (src *diffSource) contents(change diff.FileChange, text bool) (string, string, bool, error) {
+	oldContent, newContent, err := src.rawContents(change)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	driver, _ := src.attrs.Get(path.Join(src.relDir, change.Path()), "diff")
+	switch driver {
+	case attributes.Set:
+		return oldContent, newContent, false, nil
+	case attributes.Unset:
+		return oldContent, newContent, !text, nil
+	}
+
+	if driver != "" && diffTextconv {
+		command, _ := src.repo.GetConfig("diff." + driver + ".textconv")
+		if command != "" {
+			if change.OldPath != "" {
+				if oldContent, err = textconv(src.repo.Path, command, oldContent); err != nil {
+					return "", "", false, err
 				}
 			}
-		} else {
-			// Compare working tree vs index
-			if inIndex {
-				// Get index content
-				blobObj, err := object.ReadObject(repoRoot, entry.HashString())
-				if err != nil {
-					continue
-				}
-				blob, ok := blobObj.(*object.Blob)
-				if !ok {
-					continue
-				}
-				oldContent = string(blob.Content())
-				oldName = relPath
-
-				if workingExists {
-					newContent = string(workingContent)
-					newName = relPath
-
-					// Check if content is the same
-					if utils.HashObject("blob", workingContent) == entry.HashString() {
-						continue
-					}
-				} else {
-					// File deleted
-					newContent = ""
-					newName = "/dev/null"
+			if change.NewPath != "" {
+				if newContent, err = textconv(src.repo.Path, command, newContent); err != nil {
+					return "", "", false, err
 				}
-			} else if workingExists {
-				// New file (not in index)
-				oldContent = ""
-				oldName = "/dev/null"
-				newContent = string(workingContent)
-				newName = relPath
 			}
+			return oldContent, newContent, false, nil
 		}
+	}
 
-		// Compute diff
-		changes := diff.Diff(oldContent, newContent)
+	binary := !text && (diff.IsBinary(oldContent) || diff.IsBinary(newContent))
+	return oldContent, newContent, binary, nil
+}
 
-		// Only show if there are actual changes
-		hasChanges := false
-		for _, change := range changes {
-			if change.Type != diff.ChangeEqual {
-				hasChanges = true
-				break
+// rawContents returns the old and new content of a change
+func (src *diffSource) rawContents(change diff.FileChange) (string, string, error) {
+	repoRoot := src.repo.Path
+	var oldContent, newContent string
+	var err error
+	if change.OldMode == object.ModeGitlink {
+		oldContent = diff.GitlinkContent(change.OldHash)
+	} else if oldContent, err = readBlobContent(repoRoot, change.OldHash); err != nil {
+		return "", "", err
+	}
+
+	if change.NewMode == object.ModeGitlink {
+		newContent = diff.GitlinkContent(change.NewHash)
+	} else if src.fromWorktree && change.NewPath != "" {
+		data, err := os.ReadFile(filepath.Join(repoRoot, src.relDir, change.NewPath))
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read %s: %w", change.NewPath, err)
+		}
+		newContent = string(cleanContent(repoRoot, src.eol, path.Join(src.relDir, change.NewPath), data, change.OldHash))
+	} else if newContent, err = readBlobContent(repoRoot, change.NewHash); err != nil {
+		return "", "", err
+	}
+	return oldContent, newContent, nil
+}
+
+// textconv runs a textconv command on content, which it is given as a
+// temporary file, and returns what the command prints
+func textconv(repoRoot, command, content string) (string, error) {
+	f, err := os.CreateTemp("", "gogit-textconv-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create textconv input: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write textconv input: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write textconv input: %w", err)
+	}
+
+	// As git does, let the shell split the command and append the file
+	cmd := exec.Command("sh", "-c", command+` "$@"`, command, f.Name())
+	cmd.Dir = repoRoot
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("textconv command %q failed: %w", command, err)
+	}
+	return string(output), nil
+}
+
+// checkWhitespace prints the whitespace errors in the lines a change adds
+// and reports whether there were any. Binary files aren't checked.
+func checkWhitespace(src *diffSource, change diff.FileChange, rule diff.WhitespaceRule) (bool, error) {
+	oldContent, newContent, binary, err := src.contents(change, diffText)
+	if err != nil || binary || change.NewPath == "" {
+		return false, err
+	}
+
+	errs := diff.CheckWhitespace(oldContent, newContent, diff.Diff(oldContent, newContent), rule)
+	for _, e := range errs {
+		fmt.Printf("%s:%d: %s.\n", change.NewPath, e.Line, e.Problem)
+		if !e.BlankAtEOF {
+			fmt.Printf("+%s\n", e.Text)
+		}
+	}
+	return len(errs) > 0, nil
+}
+
+// changeStat counts the lines a change adds and deletes. It reports false
+// for a change with nothing to show, as printPatch skips. As in git, -a
+// only affects patches; --stat still shows binary files as sizes.
+func changeStat(src *diffSource, change diff.FileChange) (diff.FileStat, bool, error) {
+	oldContent, newContent, binary, err := src.contents(change, false)
+	if err != nil {
+		return diff.FileStat{}, false, err
+	}
+
+	modeChanged := change.OldMode != "" && change.NewMode != "" && change.OldMode != change.NewMode
+	// These show even with no changed lines, such as an added empty file as "| 0"
+	listed := modeChanged || change.OldMode == "" || change.NewMode == "" ||
+		change.Status == diff.StatusRenamed || change.Status == diff.StatusCopied
+	if binary {
+		stat := diff.NewBinaryFileStat(change, len(oldContent), len(newContent))
+		return stat, oldContent != newContent || listed, nil
+	}
+	stat := diff.NewFileStat(change, diff.Diff(oldContent, newContent))
+	return stat, stat.Added+stat.Deleted > 0 || listed, nil
+}
+
+// statWidth returns the width --stat output is fitted into: --stat-width,
+// or the terminal's width when writing to one
+func statWidth() int {
+	if diffStatWidth > 0 {
+		return diffStatWidth
+	}
+	if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
+		if width, _, err := term.GetSize(fd); err == nil && width > 0 {
+			return width
+		}
+	}
+	return diff.DefaultStatWidth
+}
+
+// readBlobContent returns the content of a blob, or "" for an empty hash
+func readBlobContent(repoRoot, hash string) (string, error) {
+	if hash == "" {
+		return "", nil
+	}
+
+	obj, err := object.ReadObject(repoRoot, hash)
+	if err != nil {
+		return "", err
+	}
+	blob, ok := obj.(*object.Blob)
+	if !ok {
+		return "", fmt.Errorf("object %s is not a blob", hash)
+	}
+	return string(blob.Content()), nil
+}
+
+// headTreeHash returns the tree of the HEAD commit, or "" on an unborn branch
+func headTreeHash(repo *repository.Repository) (string, error) {
+	headHash, err := repo.Refs.ResolveHead()
+	if err != nil || headHash == "" {
+		return "", nil
+	}
+
+	commit, err := repo.Objects.ReadCommit(headHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	return commit.TreeHash, nil
+}
+
+// indexSnapshot returns the resolved index entries keyed by path in tree-entry form.
+// Conflicted paths (stage>0) are left out.
+func indexSnapshot(idx *index.Index) map[string]object.TreeEntry {
+	files := make(map[string]object.TreeEntry, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		if entry.Stage() != 0 {
+			continue
+		}
+		files[entry.Path] = object.TreeEntry{
+			Mode: fmt.Sprintf("%o", entry.Mode),
+			Name: filepath.Base(entry.Path),
+			Hash: entry.HashString(),
+		}
+	}
+	return files
+}
+
+// worktreeChanges compares tracked files in the working tree against the
+// index. Executable bit changes only count when trustExecBit is set, and
+// files are compared after eol normalizes their line endings.
+func worktreeChanges(repoRoot string, idx *index.Index, trustExecBit bool, eol *attributes.EOL) []diff.FileChange {
+	var changes []diff.FileChange
+
+	for i := range idx.Entries {
+		entry := &idx.Entries[i]
+		if entry.Stage() != 0 {
+			continue
+		}
+		mode := fmt.Sprintf("%o", entry.Mode)
+
+		var current object.TreeEntry
+		var exists bool
+		if mode == object.ModeGitlink {
+			current, exists = gitlinkEntry(repoRoot, entry.Path, entry.HashString())
+		} else {
+			current, exists = worktreeEntry(repoRoot, entry.Path)
+			if exists && current.Hash != entry.HashString() {
+				if content, err := os.ReadFile(filepath.Join(repoRoot, entry.Path)); err == nil {
+					current.Hash = utils.HashObject("blob", cleanContent(repoRoot, eol, entry.Path, content, entry.HashString()))
+				}
 			}
 		}
+		if !exists {
+			// File deleted
+			changes = append(changes, diff.FileChange{
+				Status:  diff.StatusDeleted,
+				OldPath: entry.Path,
+				OldMode: mode,
+				OldHash: entry.HashString(),
+			})
+			continue
+		}
+
+		// Without core.filemode the executable bit on disk means nothing
+		if !trustExecBit {
+			current.Mode = mode
+		}
+
+		// Check if content and mode are the same
+		if current.Hash == entry.HashString() && current.Mode == mode {
+			continue
+		}
+
+		changes = append(changes, diff.FileChange{
+			Status:  diff.StatusModified,
+			OldPath: entry.Path,
+			NewPath: entry.Path,
+			OldMode: mode,
+			NewMode: current.Mode,
+			OldHash: entry.HashString(),
+			NewHash: current.Hash,
+		})
+	}
+
+	return changes
+}
 
-		if hasChanges {
-			hasDiff = true
-			fmt.Println(diff.Format(oldName, newName, changes))
+// filterPaths keeps changes at or below one of the given paths
+func filterPaths(changes []diff.FileChange, paths []string) []diff.FileChange {
+	if len(paths) == 0 {
+		return changes
+	}
+
+	var result []diff.FileChange
+	for _, change := range changes {
+		for _, p := range paths {
+			p = filepath.Clean(p)
+			if p == "." || matchesPath(change.OldPath, p) || matchesPath(change.NewPath, p) {
+				result = append(result, change)
+				break
+			}
 		}
 	}
+	return result
+}
 
-	if !hasDiff {
-		// No output means no differences
+// relativeDir returns the directory --relative limits a diff to, relative
+// to the top of the repository: dir itself, or for "." the current
+// directory. An empty result means the whole repository.
+func relativeDir(repoRoot, dir string) (string, error) {
+	if dir != "." {
+		dir = filepath.Clean(dir)
+	} else if cwd, err := repoRelative(repoRoot, "."); err != nil {
+		return "", err
+	} else {
+		dir = cwd
 	}
+	if dir == "." {
+		return "", nil
+	}
+	return dir, nil
+}
 
-	return nil
+// relativeChange rewrites the paths of a change inside dir to be relative
+// to dir
+func relativeChange(change diff.FileChange, dir string) diff.FileChange {
+	prefix := dir + string(filepath.Separator)
+	change.OldPath = strings.TrimPrefix(change.OldPath, prefix)
+	change.NewPath = strings.TrimPrefix(change.NewPath, prefix)
+	return change
+}
+
+func matchesPath(path, prefix string) bool {
+	return path != "" && (path == prefix || strings.HasPrefix(path, prefix+string(filepath.Separator)))
 }
@@ -0,0 +1,516 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+func TestDiffFilter(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "base", map[string]string{"modified": "1\n", "deleted": "2\n"})
+	writeFile(t, "modified", "one\n")
+	writeFile(t, "added", "3\n")
+	mustGogit(t, "add", "modified", "added")
+	mustGogit(t, "rm", "-q", "deleted")
+
+	for _, c := range []struct {
+		filter string
+		want   string
+	}{
+		{"", "A\tadded\nD\tdeleted\nM\tmodified\n"},
+		{"D", "D\tdeleted\n"},
+		{"AM", "A\tadded\nM\tmodified\n"},
+		{"d", "A\tadded\nM\tmodified\n"},
+		{"R", ""},
+	} {
+		if got := plain(mustGogit(t, "diff", "--cached", "--name-status", "--diff-filter="+c.filter)); got != c.want {
+			t.Errorf("--diff-filter=%s:\n%s\nwant:\n%s", c.filter, got, c.want)
+		}
+	}
+
+	if _, err := gogit(t, "diff", "--diff-filter=Z"); err == nil {
+		t.Error("--diff-filter accepted an unknown status letter")
+	}
+}
+
+func TestLogDiffFilter(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "add f", map[string]string{"f": "1\n", "g": "1\n"})
+	commitWorktree(t, "change f", map[string]string{"f": "2\n"})
+	mustGogit(t, "rm", "-q", "f")
+	mustGogit(t, "commit", "-m", "delete f")
+
+	out := plain(mustGogit(t, "log", "--oneline", "--diff-filter=D"))
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 1 || !strings.HasSuffix(lines[0], " delete f") {
+		t.Errorf("log --diff-filter=D:\n%s\nwant only the deleting commit", out)
+	}
+}
+
+func TestDiffRaw(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n", "x": "x\n"})
+	writeFile(t, "f", "b\n")
+	if err := os.Chmod("x", 0755); err != nil {
+		t.Fatal(err)
+	}
+	mustGogit(t, "add", "f", "x")
+
+	// Hashes as git names the blobs
+	want := ":100644 100644 78981922613b2afb6025042ff6bd878ac1994e85 61780798228d17af2d34fce4cfbdf35556832472 M\tf\n" +
+		":100644 100755 587be6b4c3f93f93c489c0111bba5596147a26cb 587be6b4c3f93f93c489c0111bba5596147a26cb M\tx\n"
+	if got := plain(mustGogit(t, "diff", "--cached", "--raw")); got != want {
+		t.Errorf("diff --cached --raw:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffCachedAgainstCommit(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "1\n", "g": "1\n"})
+	commitWorktree(t, "two", map[string]string{"f": "2\n"})
+	writeFile(t, "h", "new\n")
+	mustGogit(t, "add", "h")
+
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--cached"}, "A\th\n"},
+		{[]string{"--cached", "HEAD"}, "A\th\n"},
+		{[]string{"--cached", "HEAD~1"}, "M\tf\nA\th\n"},
+		{[]string{"--cached", "HEAD~1^{tree}"}, "M\tf\nA\th\n"},
+	} {
+		args := append([]string{"diff", "--name-status"}, c.args...)
+		if got := plain(mustGogit(t, args...)); got != c.want {
+			t.Errorf("%v:\n%s\nwant:\n%s", args, got, c.want)
+		}
+	}
+
+	blob := strings.TrimSpace(mustGogit(t, "hash-object", "g"))
+	for _, rev := range []string{"nosuch", blob} {
+		if _, err := gogit(t, "diff", "--cached", rev); err == nil {
+			t.Errorf("diff --cached %s succeeded, want it refused", rev)
+		}
+	}
+}
+
+func TestDiffFindCopies(t *testing.T) {
+	newTestRepo(t)
+	src := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	commitWorktree(t, "one", map[string]string{"src": src, "other": "z\n"})
+	writeFile(t, "copy", src)
+	writeFile(t, "near", strings.Replace(src, "10\n", "ten\n", 1))
+	writeFile(t, "unrelated", "100\n101\n102\n103\n104\n105\n")
+	mustGogit(t, "add", "copy", "near", "unrelated")
+
+	want := "C100\tsrc\tcopy\nC081\tsrc\tnear\nA\tunrelated\n"
+	if got := plain(mustGogit(t, "diff", "--cached", "--find-copies", "--name-status")); got != want {
+		t.Errorf("diff --find-copies:\n%s\nwant:\n%s", got, want)
+	}
+	want = "A\tcopy\nA\tnear\nA\tunrelated\n"
+	if got := plain(mustGogit(t, "diff", "--cached", "--name-status")); got != want {
+		t.Errorf("diff without copy detection:\n%s\nwant:\n%s", got, want)
+	}
+
+	out := plain(mustGogit(t, "diff", "--cached", "-C"))
+	if !strings.Contains(out, "diff --git a/src b/copy\nsimilarity index 100%\ncopy from src\ncopy to copy\n") {
+		t.Errorf("diff -C:\n%s\nwant copy headers for copy", out)
+	}
+}
+
+func TestDiffGitlink(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	head := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	blob := strings.TrimSpace(mustGogit(t, "hash-object", "f"))
+
+	// Commits recording a submodule at sub, as git would write them; the
+	// submodule's commits themselves aren't in this repository
+	sub1 := strings.Repeat("1", 40)
+	sub2 := strings.Repeat("2", 40)
+	withSub := func(parent, sub string) string {
+		tree := object.NewTree()
+		tree.AddEntry(object.ModeFile, "f", blob)
+		tree.AddEntry(object.ModeGitlink, "sub", sub)
+		commit := object.NewCommit(writeObject(t, tree), []string{parent}, "Test <test@example.com>", "submodule\n")
+		return writeObject(t, commit)
+	}
+	first := withSub(head, sub1)
+	second := withSub(first, sub2)
+
+	mustGogit(t, "checkout", second)
+	if info, err := os.Stat("sub"); err != nil || !info.IsDir() {
+		t.Fatalf("sub wasn't checked out as a directory: %v", err)
+	}
+	if out := plain(mustGogit(t, "status")); strings.Contains(out, "sub") {
+		t.Errorf("status:\n%s\nwant the submodule left out", out)
+	}
+
+	want := "diff --git a/sub b/sub\n" +
+		"index 1111111..2222222 160000\n" +
+		"--- a/sub\n" +
+		"+++ b/sub\n" +
+		"@@ -1 +1 @@\n" +
+		"-Subproject commit " + sub1 + "\n" +
+		"+Subproject commit " + sub2 + "\n"
+	if got := plain(mustGogit(t, "diff", "--cached", first)); got != want {
+		t.Errorf("diff --cached:\n%s\nwant:\n%s", got, want)
+	}
+	want = ":160000 160000 " + sub1 + " " + sub2 + " M\tsub\n"
+	if got := plain(mustGogit(t, "diff-tree", first, second)); got != want {
+		t.Errorf("diff-tree:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffRelative(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"top": "a\n", "sub/x": "a\n", "sub/deep/y": "a\n"})
+	for _, path := range []string{"top", "sub/x", "sub/deep/y"} {
+		writeFile(t, path, "b\n")
+	}
+	chdir(t, "sub")
+
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--name-only"}, "sub/deep/y\nsub/x\ntop\n"},
+		{[]string{"--name-only", "--relative"}, "deep/y\nx\n"},
+		{[]string{"--name-only", "--relative=sub/deep"}, "y\n"},
+		{[]string{"--name-status", "--relative"}, "M\tdeep/y\nM\tx\n"},
+	} {
+		args := append([]string{"diff"}, c.args...)
+		if got := plain(mustGogit(t, args...)); got != c.want {
+			t.Errorf("%v from sub:\n%s\nwant:\n%s", args, got, c.want)
+		}
+	}
+
+	out := plain(mustGogit(t, "diff", "--relative"))
+	if !strings.HasPrefix(out, "diff --git a/deep/y b/deep/y\n") || strings.Contains(out, "top") {
+		t.Errorf("diff --relative from sub:\n%s\nwant only sub's files, named from sub", out)
+	}
+	if out := plain(mustGogit(t, "status")); !strings.Contains(out, "modified:   sub/x") || !strings.Contains(out, "modified:   top") {
+		t.Errorf("status from sub:\n%s\nwant every change, named from the top", out)
+	}
+}
+
+func TestDiffStatWidth(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"small": "1\n2\n3\n", "gone": "x\ny\n"})
+
+	writeFile(t, "small", "1\ntwo\n3\n")
+	writeFile(t, "big", strings.Repeat("line\n", 150))
+	writeFile(t, "a/rather/long/directory/name/for/a/file.txt", "a\nb\nc\n")
+	mustGogit(t, "add", ".")
+	mustGogit(t, "rm", "-q", "gone")
+
+	for _, width := range []int{30, 40, 60, 80, 120, 200} {
+		got := mustGogit(t, "diff", "--cached", "--stat", fmt.Sprintf("--stat-width=%d", width))
+		want := git(t, root, "--git-dir=.gogit", "--work-tree=.", "diff", "--cached", fmt.Sprintf("--stat=%d", width))
+		if got != want {
+			t.Errorf("--stat-width=%d:\n%s\nwant what git prints:\n%s", width, got, want)
+		}
+	}
+
+	// The bar of the largest change is what shrinks
+	narrow := mustGogit(t, "diff", "--cached", "--stat", "--stat-width=60")
+	wide := mustGogit(t, "diff", "--cached", "--stat", "--stat-width=200")
+	if strings.Count(narrow, "+") >= strings.Count(wide, "+") {
+		t.Errorf("the bars didn't scale with the width:\n%s\n%s", narrow, wide)
+	}
+
+	want := " 4 files changed, 154 insertions(+), 3 deletions(-)\n"
+	if got := mustGogit(t, "diff", "--cached", "--shortstat"); got != want {
+		t.Errorf("--shortstat = %q, want %q", got, want)
+	}
+	if !strings.HasSuffix(wide, want) {
+		t.Errorf("--stat:\n%s\ndoesn't end with the --shortstat line", wide)
+	}
+}
+
+func TestDiffRenameThreshold(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{
+		"old":   "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		"draft": "p\nq\nr\ns\nt\n",
+		"moved": "a\nb\nc\n",
+	})
+	mustGogit(t, "rm", "-q", "old", "draft", "moved")
+	writeFile(t, "new", "1\n2\n3\n4\nfive\nsix\nseven\neight\nnine\nten\n")
+	writeFile(t, "final", "p\nq\nr\ns\nT\n")
+	writeFile(t, "renamed", "a\nb\nc\n")
+	mustGogit(t, "add", "new", "final", "renamed")
+
+	// As git lists them
+	const (
+		loose = "R080\tdraft\tfinal\nR021\told\tnew\nR100\tmoved\trenamed\n"
+		fifty = "R080\tdraft\tfinal\nA\tnew\nD\told\nR100\tmoved\trenamed\n"
+		exact = "D\tdraft\nA\tfinal\nA\tnew\nD\told\nR100\tmoved\trenamed\n"
+	)
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-M"}, fifty},
+		{[]string{"-M=20%"}, loose},
+		{[]string{"-M=2"}, loose},
+		{[]string{"-M=22%"}, fifty},
+		// Past the limit only identical files are paired
+		{[]string{"-M=20%", "-l1"}, exact},
+		{[]string{"-M=20%", "-l2"}, loose},
+	} {
+		args := append([]string{"diff", "--cached", "--name-status"}, c.args...)
+		if got := plain(mustGogit(t, args...)); got != c.want {
+			t.Errorf("%v:\n%s\nwant:\n%s", c.args, got, c.want)
+		}
+	}
+
+	stderr, _ := execute(t, "diff", "--cached", "--name-status", "-M=20%", "-l1")
+	want := "warning: exhaustive rename detection was skipped due to too many files.\n" +
+		"warning: you may want to set your diff.renameLimit variable to at least 2 and retry the command.\n"
+	if stderr != want {
+		t.Errorf("stderr past the limit:\n%s\nwant:\n%s", stderr, want)
+	}
+
+	mustGogit(t, "config", "diff.renameLimit", "1")
+	if got := plain(mustGogit(t, "diff", "--cached", "--name-status", "-M=20%")); got != exact {
+		t.Errorf("with diff.renameLimit=1:\n%s\nwant:\n%s", got, exact)
+	}
+	if got := plain(mustGogit(t, "diff", "--cached", "--name-status", "-M=20%", "-l2")); got != loose {
+		t.Errorf("-l2 over diff.renameLimit=1:\n%s\nwant:\n%s", got, loose)
+	}
+
+	for _, score := range []string{"-M=x", "-M=-1"} {
+		if _, err := gogit(t, "diff", "--cached", score); err == nil {
+			t.Errorf("diff %s succeeded", score)
+		}
+	}
+}
+
+func TestDiffPrefixes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"modified": "1\n", "deleted": "2\n", "old": "moved\ncontent\n"})
+	writeFile(t, "modified", "one\n")
+	writeFile(t, "added", "3\n")
+	writeFile(t, "new", "moved\ncontent\n")
+	mustGogit(t, "add", "modified", "added", "new")
+	mustGogit(t, "rm", "-q", "deleted", "old")
+
+	for _, args := range [][]string{
+		nil,
+		{"--src-prefix=old/", "--dst-prefix=new/"},
+		{"--src-prefix=", "--dst-prefix=b/"},
+		{"--no-prefix"},
+		{"--no-prefix", "-M"},
+	} {
+		got := plain(mustGogit(t, append([]string{"diff", "--cached"}, args...)...))
+		want := git(t, root, append([]string{"--git-dir=.gogit", "--work-tree=.", "diff", "--cached", "--no-renames"}, args...)...)
+		if got != want {
+			t.Errorf("%v:\n%s\nwant what git prints:\n%s", args, got, want)
+		}
+	}
+
+	mustGogit(t, "config", "diff.noprefix", "true")
+	for _, args := range [][]string{nil, {"--src-prefix=x/"}} {
+		got := plain(mustGogit(t, append([]string{"diff", "--cached"}, args...)...))
+		want := git(t, root, append([]string{"--git-dir=.gogit", "--work-tree=.", "diff", "--cached", "--no-renames"}, args...)...)
+		if got != want {
+			t.Errorf("diff.noprefix %v:\n%s\nwant what git prints:\n%s", args, got, want)
+		}
+	}
+	if out := plain(mustGogit(t, "diff", "--cached")); !strings.Contains(out, "diff --git modified modified\n") || !strings.Contains(out, "--- modified\n+++ modified\n") {
+		t.Errorf("with diff.noprefix:\n%s\nwant bare names", out)
+	}
+}
+
+func TestDiffBinaryAsText(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"data.bin": "header\x00\nline one\nline two\n", "plain": "text\n"})
+	writeFile(t, "data.bin", "header\x00\nline one\nline 2\n")
+	writeFile(t, "plain", "text\nmore\n")
+
+	compare := func(args ...string) {
+		t.Helper()
+		got := plain(mustGogit(t, append([]string{"diff"}, args...)...))
+		want := git(t, root, append([]string{"--git-dir=.gogit", "--work-tree=.", "diff"}, args...)...)
+		if got != want {
+			t.Errorf("diff %v:\n%q\nwant what git prints:\n%q", args, got, want)
+		}
+	}
+
+	compare()
+	if out := mustGogit(t, "diff", "data.bin"); !strings.Contains(out, "Binary files a/data.bin and b/data.bin differ\n") {
+		t.Errorf("diff of a file with a NUL byte:\n%s", out)
+	}
+	compare("-a")
+	compare("--text", "data.bin")
+	if out := plain(mustGogit(t, "diff", "-a", "data.bin")); !strings.Contains(out, "-line two\n+line 2\n") {
+		t.Errorf("diff -a:\n%s\nwant a line diff", out)
+	}
+	compare("--stat")
+	compare("--stat", "-a")
+
+	// Attributes decide instead of the NUL check; -a still wins over -diff
+	writeFile(t, ".gitattributes", "*.bin diff\nplain -diff\n")
+	compare()
+	compare("-a")
+
+	writeFile(t, ".gitattributes", "*.bin diff=visible\n")
+	mustGogit(t, "config", "diff.visible.textconv", "cat -v")
+	compare()
+	compare("--no-textconv")
+	if out := plain(mustGogit(t, "diff", "data.bin")); !strings.Contains(out, " header^@\n") {
+		t.Errorf("diff through textconv:\n%s\nwant the converted text", out)
+	}
+}
+
+func TestDiffEmptyFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"removed": "", "emptied": "content\n", "filled": ""})
+	writeFile(t, "added", "")
+	writeFile(t, "emptied", "")
+	writeFile(t, "filled", "content\n")
+	mustGogit(t, "add", "added", "emptied", "filled")
+	mustGogit(t, "rm", "-q", "removed")
+
+	for _, args := range [][]string{
+		{"--cached"},
+		{"--cached", "--stat"},
+		{"--cached", "--name-status"},
+		{"--cached", "added"},
+		{"--cached", "--", "removed"},
+	} {
+		got := plain(mustGogit(t, append([]string{"diff"}, args...)...))
+		want := git(t, root, append([]string{"--git-dir=.gogit", "--work-tree=.", "diff", "--no-renames"}, args...)...)
+		if got != want {
+			t.Errorf("diff %v:\n%s\nwant what git prints:\n%s", args, got, want)
+		}
+	}
+
+	want := "diff --git a/added b/added\nnew file mode 100644\nindex 0000000..e69de29\n"
+	if got := plain(mustGogit(t, "diff", "--cached", "added")); got != want {
+		t.Errorf("diff of an added empty file:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A tracked empty file deleted from the working tree
+	mustGogit(t, "commit", "-m", "two")
+	if err := os.Remove("added"); err != nil {
+		t.Fatal(err)
+	}
+	want = "diff --git a/added b/added\ndeleted file mode 100644\nindex e69de29..0000000\n"
+	if got := plain(mustGogit(t, "diff")); got != want {
+		t.Errorf("diff of a deleted empty file:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffCheck(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"a": "kept  \nremoved  \nend\n", "b": "b\n"})
+
+	check := func(desc string, wantErrors bool) {
+		t.Helper()
+		got, _ := gogit(t, "diff", "--check")
+		// git exits non-zero on errors too, which git() doesn't allow
+		cmd := exec.Command("git", "--git-dir=.gogit", "--work-tree=.", "diff", "--check")
+		cmd.Dir = root
+		wantOut, _ := cmd.Output()
+		want := string(wantOut)
+		if got != want {
+			t.Errorf("%s: diff --check:\n%s\nwant what git prints:\n%s", desc, got, want)
+		}
+		if wantErrors != (got != "") {
+			t.Errorf("%s: diff --check printed %q", desc, got)
+		}
+		wantCode := 0
+		if wantErrors {
+			wantCode = exitCheck
+		}
+		if _, code := execute(t, "diff", "--check"); code != wantCode {
+			t.Errorf("%s: diff --check exited %d, want %d", desc, code, wantCode)
+		}
+	}
+
+	// Whitespace already there, or only on removed lines, is no error
+	writeFile(t, "a", "kept  \nend\nclean\n")
+	check("clean change", false)
+
+	writeFile(t, "a", "kept  \nend\ntrailing \n \tspace before tab\n")
+	writeFile(t, "b", "b\n\n\n")
+	check("whitespace errors", true)
+
+	mustGogit(t, "config", "core.whitespace", "-trailing-space,-blank-at-eof")
+	check("core.whitespace", true)
+	mustGogit(t, "config", "core.whitespace", "-trailing-space,-blank-at-eof,-space-before-tab")
+	check("every check turned off", false)
+}
+
+func TestDiffAutocrlf(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"a": "a\nb\n", "crlf": "kept\r\n"})
+	mustGogit(t, "config", "core.autocrlf", "true")
+	clean := "On branch main\n\nnothing to commit, working tree clean\n"
+
+	// A CRLF copy of an LF blob is unchanged, and so is a file committed
+	// with CRLFs before autocrlf was turned on
+	writeFile(t, "a", "a\r\nb\r\n")
+	for _, args := range [][]string{{"diff"}, {"diff", "--stat"}, {"diff", "--name-only"}} {
+		if got := mustGogit(t, args...); got != "" {
+			t.Errorf("%v of a CRLF copy of the blob:\n%s\nwant nothing", args, got)
+		}
+	}
+	if got := plain(mustGogit(t, "status")); got != clean {
+		t.Errorf("status with a CRLF copy of the blob:\n%s\nwant:\n%s", got, clean)
+	}
+
+	// Adding it stores the LF blob again, and new files are stored with LFs
+	writeFile(t, "new", "new\r\n")
+	mustGogit(t, "add", "a", "crlf", "new")
+	var want string
+	for _, f := range []struct{ path, content string }{{"a", "a\nb\n"}, {"crlf", "kept\r\n"}, {"new", "new\n"}} {
+		want += "100644 " + strings.TrimSpace(mustGogit(t, "hash-object", writeTemp(t, f.content))) + " 0\t" + f.path + "\n"
+	}
+	if got := mustGogit(t, "ls-files", "-s"); got != want {
+		t.Errorf("ls-files -s after adding CRLF files:\n%s\nwant:\n%s", got, want)
+	}
+	if got := mustGogit(t, "diff", "--cached", "--name-only"); got != "new\n" {
+		t.Errorf("staged after adding CRLF files: %q, want only new", got)
+	}
+	mustGogit(t, "commit", "-m", "two")
+
+	// A real change is shown without the CRs
+	writeFile(t, "a", "a\r\nc\r\n")
+	got := plain(mustGogit(t, "diff"))
+	want = git(t, root, "--git-dir=.gogit", "--work-tree=.", "diff")
+	if got != want || !strings.Contains(got, "\n-b\n+c\n") {
+		t.Errorf("diff of a changed CRLF file:\n%q\nwant what git prints:\n%q", got, want)
+	}
+
+	// Without autocrlf the CRs are a change of their own
+	writeFile(t, "a", "a\r\nb\r\n")
+	mustGogit(t, "config", "core.autocrlf", "false")
+	if got := mustGogit(t, "diff", "--name-only"); got != "a\nnew\n" {
+		t.Errorf("diff --name-only without autocrlf: %q, want a and new", got)
+	}
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	diffTreeRecursive bool
+	diffTreeRoot      bool
+)
+
+var diffTreeCmd = &cobra.Command{
+	Use:   "diff-tree [-r] [--root] (<tree-ish> <tree-ish> | <commit>)",
+	Short: "Compare the content and mode of blobs found via two tree objects",
+	Long: `Show the differences between two trees in the raw format:
+
+  :<old mode> <new mode> <old object> <new object> <status>	<path>
+
+where the status is A (added), D (deleted) or M (modified). Only the
+entries directly in the trees are compared, so a changed subdirectory is a
+single entry; -r descends into subtrees and lists the files instead.
+
+Given a single commit, compare it to its parent, printing the commit's
+name first if there are differences. Merge commits show nothing, and a
+root commit shows nothing unless --root is given, in which case its whole
+tree is listed as added.`,
+	GroupID: groupPlumbing,
+	Example: `  # List the files a commit changed
+  gogit diff-tree -r HEAD
+
+  # Compare two trees entry by entry
+  gogit diff-tree v1.0 v1.1`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDiffTree,
+}
+
+func init() {
+	rootCmd.AddCommand(diffTreeCmd)
+	diffTreeCmd.Flags().BoolVarP(&diffTreeRecursive, "recursive", "r", false, "Recurse into subtrees")
+	diffTreeCmd.Flags().BoolVar(&diffTreeRoot, "root", false, "Show a root commit as adding its whole tree")
+}
+
+func runDiffTree(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	var oldTree, newTree, header string
+	if len(args) == 2 {
+		if oldTree, err = repo.ResolveTree(args[0]); err != nil {
+			return err
+		}
+		if newTree, err = repo.ResolveTree(args[1]); err != nil {
+			return err
+		}
+	} else {
+		hash, err := repo.ResolveCommit(args[0])
+		if err != nil {
+			return err
+		}
+		commit, err := repo.ReadHistoryCommit(hash)
+		if err != nil {
+			return err
+		}
+		parents := commit.Parents
+		switch {
+		case len(parents) > 1, len(parents) == 0 && !diffTreeRoot:
+			return nil
+		case len(parents) == 1:
+			parent, err := repo.Objects.ReadCommit(parents[0])
+			if err != nil {
+				return err
+			}
+			oldTree = parent.TreeHash
+		}
+		newTree, header = commit.TreeHash, hash
+	}
+
+	var changes []diff.FileChange
+	if diffTreeRecursive {
+		changes, err = diff.DiffTrees(repo.Objects, oldTree, newTree)
+	} else {
+		changes, err = diff.DiffTreeEntries(repo.Objects, oldTree, newTree)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(changes) > 0 && header != "" {
+		fmt.Println(header)
+	}
+	for _, change := range changes {
+		fmt.Println(change.Raw())
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestDiffTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{
+		"a": "a\n", "gone": "gone\n", "run.sh": "echo\n", "dir/b": "b\n", "dir/c": "c\n",
+	})
+	writeFile(t, "a", "a2\n")
+	writeFile(t, "dir/b", "b2\n")
+	writeFile(t, "dir/new", "new\n")
+	if err := os.Chmod("run.sh", 0755); err != nil {
+		t.Fatal(err)
+	}
+	mustGogit(t, "add", "a", "dir", "run.sh")
+	mustGogit(t, "rm", "-q", "gone")
+	mustGogit(t, "commit", "-m", "two")
+
+	for _, args := range [][]string{
+		{"HEAD"},
+		{"-r", "HEAD"},
+		{"HEAD~1", "HEAD"},
+		{"-r", "HEAD", "HEAD~1"},
+		{"HEAD~1^{tree}", "HEAD^{tree}"},
+		{"HEAD~1"},
+		{"--root", "HEAD~1"},
+		{"-r", "--root", "HEAD~1"},
+		{"HEAD", "HEAD"},
+	} {
+		got := mustGogit(t, append([]string{"diff-tree"}, args...)...)
+		want := git(t, root, append([]string{"--git-dir=.gogit", "--work-tree=.", "diff-tree"}, args...)...)
+		if got != want {
+			t.Errorf("diff-tree %v:\n%s\nwant what git prints:\n%s", args, got, want)
+		}
+	}
+
+	// Added, modified and deleted entries, and a subtree as one entry
+	var statuses []string
+	for _, line := range strings.Split(strings.TrimSpace(mustGogit(t, "diff-tree", "HEAD~1", "HEAD")), "\n") {
+		fields := strings.Fields(line)
+		statuses = append(statuses, fields[4]+" "+fields[5])
+	}
+	if got, want := strings.Join(statuses, ","), "M a,M dir,D gone,M run.sh"; got != want {
+		t.Errorf("diff-tree statuses: %s, want %s", got, want)
+	}
+
+	if _, err := gogit(t, "diff-tree", "HEAD", "no-such-rev"); err == nil {
+		t.Error("diff-tree accepted an unknown revision")
+	}
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+// defaultEditor is used when none is configured, as in git
+const defaultEditor = "vi"
+
+// editorCommand returns the editor to run, chosen as git chooses it
+func editorCommand(repo *repository.Repository) string {
+	if editor := os.Getenv("GIT_EDITOR"); editor != "" {
+		return editor
+	}
+	if editor, err := repo.GetConfig("core.editor"); err == nil && editor != "" {
+		return editor
+	}
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(env); editor != "" {
+			return editor
+		}
+	}
+	return defaultEditor
+}
+
+// editMessage writes text to a file in the metadata directory, lets the
+// user edit it and returns what they saved
+func editMessage(repo *repository.Repository, name, text string) (string, error) {
+	path := filepath.Join(repo.GitDir, name)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	editor := editorCommand(repo)
+	// As git does, let the shell split the command and append the file
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("there was a problem with the editor '%s': %w", editor, err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return string(edited), nil
+}
+
+// cleanupMessage tidies an edited message as git's default cleanup does:
+// comment lines starting with "#" and trailing whitespace are dropped, runs
+// of blank lines become one, and blank lines at either end go
+func cleanupMessage(message string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadCommandsOnEmptyRepository(t *testing.T) {
+	newTestRepo(t)
+
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"log"}, "No commits yet\n"},
+		{[]string{"log", "--oneline"}, "No commits yet\n"},
+		{[]string{"status"}, "On branch main\n\nNo commits yet\n\nnothing to commit (create/copy files and use \"gogit add\" to track)\n"},
+		{[]string{"diff"}, ""},
+		{[]string{"diff", "--cached"}, ""},
+		{[]string{"diff", "--cached", "--stat"}, ""},
+		{[]string{"branch"}, "No branches yet (make a commit first)\n"},
+		{[]string{"tag"}, ""},
+		{[]string{"ls-files"}, ""},
+		{[]string{"for-each-ref"}, ""},
+		{[]string{"reflog"}, ""},
+		{[]string{"stash", "list"}, ""},
+		{[]string{"show-branch"}, ""}, // "No revs to be shown." goes to stderr
+	} {
+		out, err := gogit(t, c.args...)
+		if err != nil {
+			t.Errorf("%v: %v", c.args, err)
+		} else if got := plain(out); got != c.want {
+			t.Errorf("%v:\n%s\nwant:\n%s", c.args, got, c.want)
+		}
+	}
+
+	// Commands that need a commit name the problem instead of failing oddly
+	for _, args := range [][]string{{"rev-parse", "HEAD"}, {"blame", "f"}} {
+		stderr, code := execute(t, args...)
+		if code != exitFatal || !strings.Contains(stderr, "no commits yet") {
+			t.Errorf("%v exited %d:\n%s", args, code, stderr)
+		}
+	}
+}
+
+func TestReadCommandsOnEmptyRepositoryWithStagedFiles(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "f", "hello\n")
+	writeFile(t, "dir/g", "g\n")
+	mustGogit(t, "add", ".")
+
+	// Against the empty tree every staged file is new
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"diff", "--cached", "--name-status"}, "A\tdir/g\nA\tf\n"},
+		{[]string{"diff", "--cached", "--stat"}, " dir/g | 1 +\n f     | 1 +\n 2 files changed, 2 insertions(+)\n"},
+		{[]string{"diff"}, ""},
+		{[]string{"log"}, "No commits yet\n"},
+		{[]string{"branch"}, "No branches yet (make a commit first)\n"},
+	} {
+		if got := plain(mustGogit(t, c.args...)); got != c.want {
+			t.Errorf("%v:\n%s\nwant:\n%s", c.args, got, c.want)
+		}
+	}
+
+	patch := plain(mustGogit(t, "diff", "--cached"))
+	if !strings.Contains(patch, "new file mode 100644\n") || !strings.Contains(patch, "--- /dev/null\n+++ b/f\n") {
+		t.Errorf("diff --cached:\n%s\nwant f added", patch)
+	}
+
+	status := plain(mustGogit(t, "status"))
+	for _, want := range []string{"No commits yet", "new file:   dir/g", "new file:   f"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("status:\n%s\nwant %q", status, want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes, following git
+const (
+	exitNo    = 1   // A query answered no: missing object, differences found, not an ancestor
+	exitCheck = 2   // diff --check found whitespace errors
+	exitUnset = 5   // config --unset of a key that isn't set
+	exitFatal = 128 // Any other failure
+)
+
+// ExitError makes a command exit with a specific code. Err is printed if set;
+// a nil Err exits silently, for answers scripts read from the code alone.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return exitFatal
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExitCodes(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	first := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	commitWorktree(t, "two", map[string]string{"f": "b\n"})
+	blob := strings.TrimSpace(mustGogit(t, "hash-object", "f"))
+	writeFile(t, "f", "c \n")
+
+	for _, c := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"cat-file", "-e", blob}, 0},
+		{[]string{"cat-file", "-e", strings.Repeat("0", 40)}, exitNo},
+		{[]string{"diff", "--exit-code", "--cached"}, 0},
+		{[]string{"diff", "--exit-code"}, exitNo},
+		{[]string{"diff", "--check"}, exitCheck},
+		{[]string{"merge-base", "--is-ancestor", first, "HEAD"}, 0},
+		{[]string{"merge-base", "--is-ancestor", "HEAD", first}, exitNo},
+		{[]string{"config", "--unset", "no.such"}, exitUnset},
+		{[]string{"cat-file", "-p", "nosuch"}, exitFatal},
+		{[]string{"nosuch"}, exitFatal},
+	} {
+		if _, code := execute(t, c.args...); code != c.want {
+			t.Errorf("%v: exit %d, want %d", c.args, code, c.want)
+		}
+	}
+}
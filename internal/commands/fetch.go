@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/repository"
+	"github.com/yourusername/gogit/internal/transport"
+)
+
+var (
+	fetchFilter string
+	fetchPrune  bool
+)
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch [--filter=<spec>] [--prune] [<remote>]",
+	Short: "Download objects and refs from another repository",
+	Long: `Fetch the branches and tags of a remote (default "origin") over smart HTTP.
+Branches are stored as remote-tracking refs, refs/remotes/<remote>/<branch>;
+new tags are created but existing ones are never moved. With --prune,
+remote-tracking refs for branches deleted on the remote are removed.
+
+In a partial clone, fetches from the promisor remote use the filter the
+clone was made with; --filter overrides it for one fetch. --filter can only
+be used with the remote configured in extensions.partialclone.`,
+	GroupID: groupMain,
+	Example: `  # Download new commits from origin, dropping branches deleted there
+  gogit fetch --prune origin`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runFetch,
+}
+
+func init() {
+	rootCmd.AddCommand(fetchCmd)
+	fetchCmd.Flags().StringVar(&fetchFilter, "filter", "", "Leave out the objects the filter excludes (partial clones only)")
+	fetchCmd.Flags().BoolVarP(&fetchPrune, "prune", "p", false, "Remove remote-tracking refs that no longer exist on the remote")
+}
+
+func runFetch(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	name := "origin"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	if fetchFilter != "" {
+		if err := transport.ValidateFilter(fetchFilter); err != nil {
+			return err
+		}
+	}
+
+	remote, err := repo.Remote(name)
+	if err != nil {
+		return err
+	}
+	updates, err := repo.Fetch(remote, repository.FetchOptions{Filter: fetchFilter, Prune: fetchPrune})
+	if err != nil {
+		return err
+	}
+
+	if len(updates) > 0 {
+		fmt.Printf("From %s\n", remote.URL)
+	}
+	for _, update := range updates {
+		fmt.Println(formatRefUpdate(repo, update))
+	}
+	return nil
+}
+
+// formatRefUpdate describes a fetched ref like git fetch does
+func formatRefUpdate(repo *repository.Repository, update repository.RefUpdate) string {
+	source := strings.TrimPrefix(strings.TrimPrefix(update.Source, "refs/heads/"), "refs/tags/")
+	local := strings.TrimPrefix(strings.TrimPrefix(update.Ref, "refs/remotes/"), "refs/tags/")
+
+	if update.NewHash == "" {
+		return fmt.Sprintf(" - %-17s %-10s -> %s", "[deleted]", "(none)", local)
+	}
+
+	if update.OldHash == "" {
+		kind := "[new branch]"
+		if strings.HasPrefix(update.Ref, "refs/tags/") {
+			kind = "[new tag]"
+		}
+		return fmt.Sprintf(" * %-17s %-10s -> %s", kind, source, local)
+	}
+
+	oldShort, newShort := shortHash(update.OldHash), shortHash(update.NewHash)
+	if ok, err := repo.IsAncestor(update.OldHash, update.NewHash); err == nil && ok {
+		return fmt.Sprintf("   %-17s %-10s -> %s", oldShort+".."+newShort, source, local)
+	}
+	return fmt.Sprintf(" + %-17s %-10s -> %s  (forced update)", oldShort+"..."+newShort, source, local)
+}
+
+// shortHash abbreviates an object name to seven characters
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
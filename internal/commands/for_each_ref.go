@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	forEachRefFormat string
+	forEachRefSort   string
+	forEachRefCount  int
+)
+
+var forEachRefCmd = &cobra.Command{
+	Use:   "for-each-ref [--format=<format>] [--sort=<key>] [--count=<n>] [<pattern>...]",
+	Short: "Output information on each ref",
+	Long: `List refs, optionally only those matching a pattern, and print each with a
+format string. A pattern matches a ref exactly, as a leading path such as
+"refs/tags", or as a shell glob.
+
+The format may use these placeholders:
+  %(refname)          Full ref name; %(refname:short) drops the refs/... prefix
+  %(objectname)       Object the ref points to; %(objectname:short) abbreviates it
+  %(objecttype)       Type of that object
+  %(subject)          First line of a commit or tag message; %(body) the rest
+  %(authorname), %(authoremail), %(authordate)
+  %(committername), %(committeremail), %(committerdate)
+  %(taggername), %(taggeremail), %(taggerdate)
+  %(creatordate)      Tagger date of a tag, committer date of a commit
+
+%% prints a literal percent sign and %xx the byte with hex code xx.`,
+	GroupID: groupPlumbing,
+	Example: `  # Print the branches, newest first
+  gogit for-each-ref --sort=-creatordate --format='%(refname:short) %(subject)' refs/heads`,
+	RunE: runForEachRef,
+}
+
+func init() {
+	rootCmd.AddCommand(forEachRefCmd)
+	forEachRefCmd.Flags().StringVar(&forEachRefFormat, "format", "%(objectname) %(objecttype)\t%(refname)", "Format to print each ref with")
+	forEachRefCmd.Flags().StringVar(&forEachRefSort, "sort", "refname", "Sort refs by refname, creatordate or version:refname; prefix with - to reverse")
+	forEachRefCmd.Flags().IntVar(&forEachRefCount, "count", 0, "Stop after printing this many refs")
+}
+
+func runForEachRef(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	format, err := parseRefFormat(forEachRefFormat)
+	if err != nil {
+		return err
+	}
+
+	names, err := repo.Refs.ListRefs()
+	if err != nil {
+		return fmt.Errorf("failed to list refs: %w", err)
+	}
+	var refs []string
+	for _, name := range names {
+		if matchRefPatterns(name, args) {
+			refs = append(refs, name)
+		}
+	}
+	if err := repo.SortRefs(refs, forEachRefSort); err != nil {
+		return err
+	}
+	if forEachRefCount > 0 && len(refs) > forEachRefCount {
+		refs = refs[:forEachRefCount]
+	}
+
+	for _, ref := range refs {
+		hash, err := repo.Refs.ResolveRef(ref)
+		if err != nil {
+			return err
+		}
+		if hash == "" {
+			continue
+		}
+		line, err := format.expand(repo, ref, hash)
+		if err != nil {
+			return err
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// matchRefPatterns reports whether a ref matches any of the patterns, or
+// whether there are no patterns
+func matchRefPatterns(ref string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		prefix := strings.TrimSuffix(pattern, "/")
+		if ref == pattern || strings.HasPrefix(ref, prefix+"/") {
+			return true
+		}
+		if ok, _ := path.Match(pattern, ref); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// refFormat is a parsed --format string: literal text alternating with
+// the names of the %(...) placeholders between them
+type refFormat struct {
+	literals []string // One more than fields
+	fields   []string
+}
+
+// refFields are the placeholders a format may use
+var refFields = map[string]bool{
+	"refname": true, "refname:short": true,
+	"objectname": true, "objectname:short": true, "objecttype": true,
+	"subject": true, "body": true,
+	"authorname": true, "authoremail": true, "authordate": true,
+	"committername": true, "committeremail": true, "committerdate": true,
+	"taggername": true, "taggeremail": true, "taggerdate": true,
+	"creatordate": true,
+}
+
+// parseRefFormat splits a format string into literals and placeholders,
+// decoding %% and %xx escapes in the literal text
+func parseRefFormat(format string) (*refFormat, error) {
+	f := &refFormat{}
+	var literal strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+		rest := format[i+1:]
+		switch {
+		case strings.HasPrefix(rest, "%"):
+			literal.WriteByte('%')
+			i++
+		case strings.HasPrefix(rest, "("):
+			end := strings.IndexByte(rest, ')')
+			if end < 0 {
+				return nil, fmt.Errorf("malformed format string %s", format)
+			}
+			field := rest[1:end]
+			if !refFields[field] {
+				return nil, fmt.Errorf("unknown field name: %s", field)
+			}
+			f.literals = append(f.literals, literal.String())
+			f.fields = append(f.fields, field)
+			literal.Reset()
+			i += end + 1
+		case len(rest) >= 2 && isHexDigit(rest[0]) && isHexDigit(rest[1]):
+			var b byte
+			fmt.Sscanf(rest[:2], "%02x", &b)
+			literal.WriteByte(b)
+			i += 2
+		default:
+			literal.WriteByte('%')
+		}
+	}
+	f.literals = append(f.literals, literal.String())
+	return f, nil
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// expand fills in the format for one ref. The object is only read when a
+// placeholder needs more than the ref's name and hash.
+func (f *refFormat) expand(repo *repository.Repository, ref, hash string) (string, error) {
+	var obj object.Object
+	var sb strings.Builder
+	for i, field := range f.fields {
+		sb.WriteString(f.literals[i])
+
+		switch field {
+		case "refname":
+			sb.WriteString(ref)
+			continue
+		case "refname:short":
+			sb.WriteString(shortRefName(ref))
+			continue
+		case "objectname":
+			sb.WriteString(hash)
+			continue
+		case "objectname:short":
+			sb.WriteString(hash[:7])
+			continue
+		}
+
+		if obj == nil {
+			var err error
+			if obj, err = repo.Objects.Read(hash); err != nil {
+				return "", fmt.Errorf("failed to read %s: %w", ref, err)
+			}
+		}
+		sb.WriteString(objectField(obj, field))
+	}
+	sb.WriteString(f.literals[len(f.literals)-1])
+	return sb.String(), nil
+}
+
+// objectField returns a placeholder's value for an object, or "" when it
+// doesn't apply to that kind of object, such as %(taggername) of a commit
+func objectField(obj object.Object, field string) string {
+	if field == "objecttype" {
+		return string(obj.Type())
+	}
+
+	var message string
+	var author, committer, tagger *object.Signature
+	switch o := obj.(type) {
+	case *object.Commit:
+		message = o.Message
+		author, committer = &o.Author, &o.Committer
+	case *object.Tag:
+		message = o.Message
+		tagger = &o.Tagger
+	}
+
+	switch field {
+	case "subject", "body":
+		subject, body, _ := strings.Cut(message, "\n\n")
+		if field == "subject" {
+			return strings.ReplaceAll(strings.TrimSpace(subject), "\n", " ")
+		}
+		return body
+	case "creatordate":
+		if tagger != nil {
+			return formatRefDate(tagger)
+		}
+		return formatRefDate(committer)
+	}
+
+	var role, part string
+	for _, suffix := range []string{"name", "email", "date"} {
+		if strings.HasSuffix(field, suffix) {
+			role, part = strings.TrimSuffix(field, suffix), suffix
+		}
+	}
+
+	sig := map[string]*object.Signature{"author": author, "committer": committer, "tagger": tagger}[role]
+	if sig == nil {
+		return ""
+	}
+	switch part {
+	case "name":
+		return sig.Name
+	case "email":
+		return "<" + sig.Email + ">"
+	}
+	return formatRefDate(sig)
+}
+
+// formatRefDate formats a date the way log does
+func formatRefDate(sig *object.Signature) string {
+	if sig == nil || sig.When.Equal(time.Time{}) {
+		return ""
+	}
+	return sig.When.Format("Mon Jan 2 15:04:05 2006 -0700")
+}
+
+// shortRefName returns the shortest unambiguous form of a ref name that
+// gogit commands accept, such as "main" for "refs/heads/main"
+func shortRefName(ref string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/", "refs/"} {
+		if strings.HasPrefix(ref, prefix) {
+			return strings.TrimPrefix(ref, prefix)
+		}
+	}
+	return ref
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+func TestForEachRefFormat(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "first commit", map[string]string{"f": "a\n"})
+	mustGogit(t, "tag", "light")
+	mustGogit(t, "branch", "topic")
+	commitWorktree(t, "second commit\n\nwith a body", map[string]string{"f": "b\n"})
+
+	tag := &object.Tag{
+		Object:  strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD")),
+		ObjType: object.TypeCommit,
+		Name:    "annotated",
+		Tagger:  object.ParseSignature("Tagger <tagger@example.com> 1700000000 +0530"),
+		Message: "release notes\n",
+	}
+	mustGogit(t, "tag", "annotated", writeObject(t, tag))
+
+	gitDir := "--git-dir=" + filepath.Join(root, ".gogit")
+	if got, want := plain(mustGogit(t, "for-each-ref")), git(t, root, gitDir, "for-each-ref"); got != want {
+		t.Errorf("for-each-ref:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Each format as git itself prints it for the same refs
+	for _, format := range []string{
+		"%(refname) %(objectname) %(objecttype)",
+		"%(refname:short) %(objectname:short): %(subject)",
+		"%(authorname) %(authoremail) %(authordate)|%(taggername) %(taggeremail) %(taggerdate)",
+		"[%(creatordate)] %(body)",
+	} {
+		for _, pattern := range []string{"refs/heads", "refs/tags"} {
+			want := git(t, root, gitDir, "for-each-ref", "--format="+format, pattern)
+			if got := plain(mustGogit(t, "for-each-ref", "--format="+format, pattern)); got != want {
+				t.Errorf("for-each-ref --format=%q %s:\n%s\nwant:\n%s", format, pattern, got, want)
+			}
+		}
+	}
+}
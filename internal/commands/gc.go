@@ -0,0 +1,400 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/pack"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+// defaultGCAuto is the loose object count above which "gc --auto" packs
+const defaultGCAuto = 6700
+
+// Default expiry cutoffs for unreachable loose objects, reflog entries, and
+// reflog entries whose commits have left the ref's history
+const (
+	defaultPruneExpire             = "2 weeks ago"
+	defaultReflogExpire            = "90 days ago"
+	defaultReflogExpireUnreachable = "30 days ago"
+)
+
+var (
+	gcAggressive bool
+	gcAuto       bool
+	gcQuiet      bool
+	gcPrune      string
+	gcNoPrune    bool
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc [--aggressive] [--auto] [--prune=<date> | --no-prune]",
+	Short: "Cleanup unnecessary files and optimize the local repository",
+	Long: `Clean up the repository in one go:
+
+ 1. Expire reflog entries older than gc.reflogExpire (default 90 days), and
+    those older than gc.reflogExpireUnreachable (default 30 days) whose
+    commit is no longer in the ref's history.
+ 2. Pack every object reachable from the refs, the index and the remaining
+    reflog entries into a single pack, replacing any existing packs and
+    removing loose objects that are now packed. Unreachable objects in the
+    old packs are kept as loose objects. A pack with a ".keep" file next to
+    it is left as it is, and the objects in it aren't packed again.
+ 3. Delete unreachable loose objects last written before the --prune date
+    (default gc.pruneExpire, or "2 weeks ago"). The grace period protects
+    objects a command running at the same time has just written.
+
+Dates may be given as "<n> <unit>s ago", "now", "never" or an absolute
+date.
+
+  --aggressive  Search much harder for delta bases. Slower, but usually
+                produces a smaller pack.
+  --auto        Only run if there are more loose objects than gc.auto
+                (default 6700); gc.auto=0 disables automatic packing.`,
+	GroupID: groupAncillary,
+	Example: `  # Pack objects and prune unreachable ones older than two weeks
+  gogit gc
+
+  # Prune every unreachable object right away
+  gogit gc --prune=now`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().BoolVar(&gcAggressive, "aggressive", false, "Spend more time optimizing deltas for a smaller pack")
+	gcCmd.Flags().BoolVar(&gcAuto, "auto", false, "Only pack if there are too many loose objects")
+	gcCmd.Flags().BoolVarP(&gcQuiet, "quiet", "q", false, "Suppress the before/after report")
+	gcCmd.Flags().StringVar(&gcPrune, "prune", "", "Delete unreachable loose objects older than this date (default \"2 weeks ago\")")
+	gcCmd.Flags().BoolVar(&gcNoPrune, "no-prune", false, "Don't delete any unreachable loose objects")
+}
+
+// objectStats summarizes how a repository's objects are stored
+type objectStats struct {
+	loose     int
+	packed    int
+	packs     int
+	packBytes int64
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	if gcAuto {
+		if !needsAutoGC(repo) {
+			return nil
+		}
+		if !gcQuiet {
+			fmt.Println("Auto packing the repository for optimum performance.")
+		}
+	}
+
+	opts := pack.DefaultWriteOptions
+	if gcAggressive {
+		opts = pack.AggressiveWriteOptions
+	}
+	expiry, err := gcExpiry(repo)
+	if err != nil {
+		return err
+	}
+	if gcNoPrune {
+		expiry.prune = time.Time{}
+	} else if gcPrune != "" {
+		if expiry.prune, err = object.ParseExpiry(gcPrune, time.Now()); err != nil {
+			return err
+		}
+	}
+	return gc(repo, opts, expiry, gcQuiet)
+}
+
+// gcCutoffs are the dates at or before which gc drops things
+type gcCutoffs struct {
+	prune             time.Time // Unreachable loose objects
+	reflog            time.Time // Reflog entries
+	reflogUnreachable time.Time // Reflog entries for commits no longer in the ref's history
+}
+
+// gcExpiry reads the expiry cutoffs from the configuration, falling back
+// to git's defaults
+func gcExpiry(repo *repository.Repository) (gcCutoffs, error) {
+	now := time.Now()
+	var cutoffs gcCutoffs
+	for _, c := range []struct {
+		key      string
+		fallback string
+		dest     *time.Time
+	}{
+		{"gc.pruneExpire", defaultPruneExpire, &cutoffs.prune},
+		{"gc.reflogExpire", defaultReflogExpire, &cutoffs.reflog},
+		{"gc.reflogExpireUnreachable", defaultReflogExpireUnreachable, &cutoffs.reflogUnreachable},
+	} {
+		value, err := repo.GetConfig(c.key)
+		if err != nil || value == "" {
+			value = c.fallback
+		}
+		when, err := object.ParseExpiry(value, now)
+		if err != nil {
+			return cutoffs, fmt.Errorf("bad %s: %w", c.key, err)
+		}
+		*c.dest = when
+	}
+	return cutoffs, nil
+}
+
+// gc expires old reflog entries, repacks every reachable object not in a
+// kept pack into one new pack and prunes old unreachable loose objects.
+// Objects are read as stored, so that what a replaced object reaches is
+// kept too; the repository is opened afresh for that, leaving the caller's
+// as it was.
+func gc(repo *repository.Repository, opts pack.WriteOptions, expiry gcCutoffs, quiet bool) error {
+	repo, err := repository.Open(repo.Path)
+	if err != nil {
+		return err
+	}
+	repo.ReadAsStored()
+
+	before, err := collectObjectStats(repo.Path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := repo.ExpireReflogs(expiry.reflog, expiry.reflogUnreachable); err != nil {
+		return err
+	}
+
+	// One walk decides both what gets packed and what may be pruned
+	reachable, err := repo.ReachableObjects()
+	if err != nil {
+		return fmt.Errorf("failed to walk reachable objects: %w", err)
+	}
+	isReachable := make(map[string]bool, len(reachable))
+	for _, hash := range reachable {
+		isReachable[hash] = true
+	}
+
+	packs, err := object.Packs(repo.Path)
+	if err != nil {
+		return err
+	}
+	var oldPacks, keptPacks []*pack.Pack
+	for _, p := range packs {
+		if isKeptPack(p.Path()) {
+			keptPacks = append(keptPacks, p)
+		} else {
+			oldPacks = append(oldPacks, p)
+		}
+	}
+	inKeptPack := func(hash string) bool {
+		for _, p := range keptPacks {
+			if p.Contains(hash) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Unreachable objects in the packs about to be deleted are kept as loose
+	// objects, so repacking never loses anything
+	for _, p := range oldPacks {
+		for i := 0; i < p.Len(); i++ {
+			hash := p.Hash(i)
+			if isReachable[hash] {
+				continue
+			}
+			objType, content, err := p.Read(hash)
+			if err != nil {
+				return err
+			}
+			if _, err := object.WriteRawObject(repo.Path, object.Type(objType), content); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Objects borrowed from alternates stay there, as with git repack -l,
+	// and ones in kept packs stay in those
+	objects := make([]pack.Object, 0, len(reachable))
+	for _, hash := range reachable {
+		if !object.IsLocal(repo.Path, hash) || inKeptPack(hash) {
+			continue
+		}
+		objType, content, err := repo.Objects.ReadRaw(hash)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, pack.Object{Hash: hash, Type: string(objType), Data: content})
+	}
+
+	var oldPaths []string
+	for _, p := range oldPacks {
+		oldPaths = append(oldPaths, p.Path())
+	}
+
+	promisor, err := repo.PromisorRemote()
+	if err != nil {
+		return err
+	}
+
+	name := ""
+	if len(objects) > 0 {
+		if name, err = pack.Write(object.PackDir(repo.Path), objects, opts); err != nil {
+			return fmt.Errorf("failed to write pack: %w", err)
+		}
+		// The new pack still refers to objects the promisor remote holds
+		if promisor != nil {
+			marker := filepath.Join(object.PackDir(repo.Path), name+".promisor")
+			if err := os.WriteFile(marker, nil, 0644); err != nil {
+				return fmt.Errorf("failed to write pack: %w", err)
+			}
+		}
+	}
+
+	// Drop the packs that were just rewritten...
+	for _, packPath := range oldPaths {
+		if name != "" && filepath.Base(packPath) == name+".pack" {
+			continue
+		}
+		// The index goes first so no reader finds an index without its pack
+		base := strings.TrimSuffix(packPath, ".pack")
+		for _, ext := range []string{".idx", ".pack", ".promisor"} {
+			if err := os.Remove(base + ext); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove old pack: %w", err)
+			}
+		}
+	}
+
+	object.ClosePacks(repo.Path)
+
+	// ...and the loose copies of everything now packed
+	for _, hash := range reachable {
+		if err := object.RemoveLoose(repo.Path, hash); err != nil {
+			return err
+		}
+	}
+
+	if err := pruneLoose(repo, isReachable, expiry.prune); err != nil {
+		return err
+	}
+
+	if quiet {
+		return nil
+	}
+
+	after, err := collectObjectStats(repo.Path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Before: %s\n", before)
+	fmt.Printf("After:  %s\n", after)
+	return nil
+}
+
+// isKeptPack reports whether a pack is marked, by a ".keep" file beside
+// it, as one gc must not repack
+func isKeptPack(packPath string) bool {
+	_, err := os.Stat(strings.TrimSuffix(packPath, ".pack") + ".keep")
+	return err == nil
+}
+
+// needsAutoGC estimates the loose object count like git does, by sampling
+// one fan-out directory, and compares it with gc.auto
+func needsAutoGC(repo *repository.Repository) bool {
+	limit := defaultGCAuto
+	if value, err := repo.GetConfig("gc.auto"); err == nil && value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			limit = n
+		}
+	}
+	if limit <= 0 {
+		return false
+	}
+
+	entries, err := os.ReadDir(filepath.Join(repo.GitDir, "objects", "17"))
+	if err != nil {
+		return false
+	}
+	// Objects are spread evenly over 256 directories
+	perDir := (limit + 255) / 256
+	return len(entries) > perDir
+}
+
+// autoGC runs "gc --auto" after a command that may have written many
+// objects. Failures are reported but never fail the calling command.
+func autoGC(repo *repository.Repository) {
+	if !needsAutoGC(repo) {
+		return
+	}
+	fmt.Println("Auto packing the repository for optimum performance.")
+	expiry, err := gcExpiry(repo)
+	if err == nil {
+		err = gc(repo, pack.DefaultWriteOptions, expiry, true)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: auto gc failed: %v\n", err)
+	}
+}
+
+// pruneLoose deletes the unreachable loose objects last written at or
+// before cutoff
+func pruneLoose(repo *repository.Repository, isReachable map[string]bool, cutoff time.Time) error {
+	loose, err := object.LooseObjects(repo.Path)
+	if err != nil {
+		return err
+	}
+	for _, hash := range loose {
+		if isReachable[hash] {
+			continue
+		}
+		modTime, err := object.LooseModTime(repo.Path, hash)
+		if err != nil || modTime.After(cutoff) {
+			continue
+		}
+		if err := object.RemoveLoose(repo.Path, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectObjectStats(repoRoot string) (objectStats, error) {
+	var stats objectStats
+
+	loose, err := object.LooseObjects(repoRoot)
+	if err != nil {
+		return stats, err
+	}
+	stats.loose = len(loose)
+
+	packs, err := object.Packs(repoRoot)
+	if err != nil {
+		return stats, err
+	}
+	for _, p := range packs {
+		stats.packs++
+		stats.packed += p.Len()
+		if info, err := os.Stat(p.Path()); err == nil {
+			stats.packBytes += info.Size()
+		}
+	}
+
+	return stats, nil
+}
+
+func (s objectStats) String() string {
+	return fmt.Sprintf("%d loose objects, %d packed objects in %d pack(s), %d bytes", s.loose, s.packed, s.packs, s.packBytes)
+}
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/pack"
+)
+
+// packSize returns the total size of the repository's pack files
+func packSize(t *testing.T, root string) int64 {
+	t.Helper()
+	packs, err := filepath.Glob(filepath.Join(root, ".gogit", "objects", "pack", "*.pack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size int64
+	for _, pack := range packs {
+		info, err := os.Stat(pack)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += info.Size()
+	}
+	return size
+}
+
+func TestGCAggressiveFindsDistantDeltas(t *testing.T) {
+	root := newTestRepo(t)
+
+	// Every version of a file is the same random text with more appended.
+	// Packing puts objects of a size together, so the versions of a file
+	// are 20 objects apart, past the default delta window.
+	rng := rand.New(rand.NewSource(1))
+	files := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		var sb strings.Builder
+		for sb.Len() < 4000 {
+			fmt.Fprintf(&sb, "%016x\n", rng.Uint64())
+		}
+		files[fmt.Sprintf("f%02d", i)] = sb.String()
+	}
+	for version := 0; version < 3; version++ {
+		for name := range files {
+			files[name] += fmt.Sprintf("version %d\n", version)
+		}
+		commitWorktree(t, fmt.Sprintf("version %d", version), files)
+	}
+
+	mustGogit(t, "gc", "-q")
+	normal := packSize(t, root)
+	mustGogit(t, "gc", "-q", "--aggressive")
+	aggressive := packSize(t, root)
+
+	if aggressive >= normal*2/3 {
+		t.Errorf("aggressive pack is %d bytes, plain is %d: want it much smaller", aggressive, normal)
+	}
+	// The deltified objects still read back whole
+	blob := strings.TrimSpace(mustGogit(t, "hash-object", "f00"))
+	if got := mustGogit(t, "cat-file", "-p", blob); got != files["f00"] {
+		t.Errorf("f00 read back from the pack as:\n%s", got)
+	}
+}
+
+func TestGCPrune(t *testing.T) {
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "1\n"})
+	first := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	commitWorktree(t, "two", map[string]string{"f": "2\n"})
+	second := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	mustGogit(t, "reset", "--hard", first)
+
+	fresh := strings.TrimSpace(mustGogit(t, "hash-object", "-w", writeTemp(t, "fresh\n")))
+	old := strings.TrimSpace(mustGogit(t, "hash-object", "-w", writeTemp(t, "old\n")))
+	weeksAgo := time.Now().Add(-21 * 24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(root, ".gogit", "objects", old[:2], old[2:]), weeksAgo, weeksAgo); err != nil {
+		t.Fatal(err)
+	}
+	exists := func(hash string) bool {
+		_, err := gogit(t, "cat-file", "-e", hash)
+		return err == nil
+	}
+
+	mustGogit(t, "gc", "-q", "--no-prune")
+	if !exists(fresh) || !exists(old) {
+		t.Fatal("gc --no-prune deleted a dangling object")
+	}
+
+	// By default only objects past the two week grace period go
+	mustGogit(t, "gc", "-q")
+	if !exists(fresh) {
+		t.Error("gc deleted a dangling object written just now")
+	}
+	if exists(old) {
+		t.Error("gc kept a dangling object three weeks old")
+	}
+
+	mustGogit(t, "gc", "-q", "--prune=now")
+	if exists(fresh) {
+		t.Error("gc --prune=now kept a dangling object")
+	}
+	// The commit reset away from is still in HEAD's reflog
+	for _, hash := range []string{first, second} {
+		if !exists(hash) {
+			t.Errorf("gc --prune=now deleted %s, which is still reachable", hash)
+		}
+	}
+
+	if _, err := gogit(t, "gc", "--prune=whenever"); err == nil {
+		t.Error("gc accepted a bad --prune date")
+	}
+}
+
+func TestGCKeepsKeptPacks(t *testing.T) {
+	root := newTestRepo(t)
+	packDir := filepath.Join(root, ".gogit", "objects", "pack")
+	packs := func() []string {
+		t.Helper()
+		names, err := filepath.Glob(filepath.Join(packDir, "*.pack"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return names
+	}
+	keep := func(packPath string, kept bool) {
+		t.Helper()
+		keepPath := strings.TrimSuffix(packPath, ".pack") + ".keep"
+		if kept {
+			writeFile(t, keepPath, "")
+		} else if err := os.Remove(keepPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newPack := func(before []string) string {
+		t.Helper()
+		for _, name := range packs() {
+			if !slices.Contains(before, name) {
+				return name
+			}
+		}
+		t.Fatal("gc wrote no new pack")
+		return ""
+	}
+
+	// A kept pack, two packs that aren't and a loose commit
+	commitWorktree(t, "one", map[string]string{"f": "1\n"})
+	one := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	mustGogit(t, "gc", "-q")
+	kept := newPack(nil)
+	keep(kept, true)
+	keptContent := readFile(t, kept)
+
+	commitWorktree(t, "two", map[string]string{"f": "2\n"})
+	mustGogit(t, "gc", "-q")
+	second := newPack([]string{kept})
+	keep(second, true)
+	commitWorktree(t, "three", map[string]string{"f": "3\n"})
+	mustGogit(t, "gc", "-q")
+	if got := len(packs()); got != 3 {
+		t.Fatalf("%d packs before the last gc, want 3", got)
+	}
+	keep(second, false)
+	commitWorktree(t, "four", map[string]string{"f": "4\n"})
+	four := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+
+	before := packs()
+	mustGogit(t, "gc", "-q")
+	after := packs()
+	if len(after) != 2 || !slices.Contains(after, kept) {
+		t.Fatalf("packs after gc: %v, want the kept one and one new one", after)
+	}
+	if readFile(t, kept) != keptContent {
+		t.Error("gc rewrote the kept pack")
+	}
+	merged := newPack(before)
+	p, err := pack.Open(strings.TrimSuffix(merged, ".pack") + ".idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if p.Contains(one) || !p.Contains(four) {
+		t.Errorf("new pack has the kept pack's commit: %v, the loose commit: %v; want only the loose one", p.Contains(one), p.Contains(four))
+	}
+
+	loose, err := object.LooseObjects(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loose) != 0 {
+		t.Errorf("loose objects left after gc: %v", loose)
+	}
+	if got := plain(mustGogit(t, "log", "--oneline")); strings.Count(got, "\n") != 4 {
+		t.Errorf("log after gc:\n%s\nwant all four commits", got)
+	}
+}
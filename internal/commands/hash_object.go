@@ -1,25 +1,42 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/config"
 	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+	"github.com/yourusername/gogit/internal/utils"
 )
 
 var (
 	hashObjectWrite bool
 	hashObjectType  string
 	hashObjectStdin bool
+	hashObjectRaw   bool
 )
 
 var hashObjectCmd = &cobra.Command{
 	Use:   "hash-object [file]",
-	Short: "Compute object ID and optionally create a blob from a file",
-	Long:  `Compute the SHA-1 hash of a file and optionally write it to the object database.`,
-	Args:  cobra.MaximumNArgs(1),
-	RunE:  runHashObject,
+	Short: "Compute object ID and optionally create an object from a file",
+	Long: `Compute the SHA-1 hash of a file and optionally write it to the object database.
+
+The content must be a valid object of the given type, and with -w it may not
+be larger than core.maxObjectSize (unlimited if unset; k, m and g suffixes
+are allowed). --literally skips both checks and accepts any type name, which
+is only useful for crafting broken objects to test with.`,
+	GroupID: groupPlumbing,
+	Example: `  # Print the object name a file would have
+  gogit hash-object main.go
+
+  # Store standard input as a blob
+  echo hello | gogit hash-object -w --stdin`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHashObject,
 }
 
 func init() {
@@ -27,6 +44,7 @@ func init() {
 	hashObjectCmd.Flags().BoolVarP(&hashObjectWrite, "write", "w", false, "Actually write the object into the object database")
 	hashObjectCmd.Flags().StringVarP(&hashObjectType, "type", "t", "blob", "Specify the type of object to be created")
 	hashObjectCmd.Flags().BoolVar(&hashObjectStdin, "stdin", false, "Read the object from standard input")
+	hashObjectCmd.Flags().BoolVar(&hashObjectRaw, "literally", false, "Don't check that the content is a valid object of its type")
 }
 
 func runHashObject(cmd *cobra.Command, args []string) error {
@@ -47,8 +65,18 @@ func runHashObject(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("must specify a file or use --stdin")
 	}
 
-	blob := object.NewBlob(data)
-	hash := blob.Hash()
+	objType := object.Type(hashObjectType)
+
+	if hashObjectRaw {
+		if objType == "" || strings.ContainsAny(string(objType), " \x00") {
+			return fmt.Errorf("invalid object type \"%s\"", objType)
+		}
+	} else if _, err := object.ParseContent(objType, data); err != nil {
+		// Validate that the content really is an object of the requested type
+		return fmt.Errorf("content is not a valid %s object: %w", objType, err)
+	}
+
+	hash := utils.HashObject(string(objType), data)
 
 	if hashObjectWrite {
 		repoRoot, err := FindRepoRoot()
@@ -56,7 +84,14 @@ func runHashObject(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		_, err = object.WriteObject(repoRoot, blob)
+		if !hashObjectRaw {
+			if err := checkObjectSize(repoRoot, len(data)); err != nil {
+				return err
+			}
+		}
+
+		// Store the input bytes as-is so the written object matches the computed hash
+		_, err = object.WriteRawObject(repoRoot, objType, data)
 		if err != nil {
 			return fmt.Errorf("failed to write object: %w", err)
 		}
@@ -65,3 +100,26 @@ func runHashObject(cmd *cobra.Command, args []string) error {
 	fmt.Println(hash)
 	return nil
 }
+
+// checkObjectSize refuses content larger than core.maxObjectSize
+func checkObjectSize(repoRoot string, size int) error {
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+	value, err := repo.GetConfig("core.maxObjectSize")
+	if errors.Is(err, repository.ErrConfigNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	limit, err := config.ParseInt(value)
+	if err != nil {
+		return fmt.Errorf("bad core.maxObjectSize: %w", err)
+	}
+	if limit > 0 && int64(size) > limit {
+		return fmt.Errorf("object of %d bytes exceeds core.maxObjectSize (%d bytes)", size, limit)
+	}
+	return nil
+}
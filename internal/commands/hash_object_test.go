@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+func TestHashObjectTree(t *testing.T) {
+	newTestRepo(t)
+	blob := strings.TrimSpace(mustGogit(t, "hash-object", "-w", writeTemp(t, "f\n")))
+	if blob != "6a69f92020f5df77af6e8813ff1232493383b708" {
+		t.Fatalf("blob hash %s", blob)
+	}
+
+	// A tree entry is "<mode> <name>\0" and the raw 20-byte hash
+	hash, err := utils.HexToBytes(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := "100644 f\x00" + string(hash)
+	tree := strings.TrimSpace(mustGogit(t, "hash-object", "-t", "tree", "-w", writeTemp(t, raw)))
+
+	// As git hashes the same tree
+	if want := "8fecaa0af926d864d8e55f05104cabb500c3c239"; tree != want {
+		t.Errorf("tree hash %s, want %s", tree, want)
+	}
+	if got := strings.TrimSpace(mustGogit(t, "cat-file", "-t", tree)); got != "tree" {
+		t.Errorf("cat-file -t: %s, want tree", got)
+	}
+	if got := plain(mustGogit(t, "cat-file", "-p", tree)); got != "100644 blob "+blob+"\tf\n" {
+		t.Errorf("cat-file -p:\n%s", got)
+	}
+
+	for _, bad := range []string{"100644 f", "100644 f\x00short", "hello\n"} {
+		if _, err := gogit(t, "hash-object", "-t", "tree", writeTemp(t, bad)); err == nil {
+			t.Errorf("hash-object -t tree accepted %q", bad)
+		}
+	}
+}
+
+func TestHashObjectLiterally(t *testing.T) {
+	root := newTestRepo(t)
+	bad := writeTemp(t, "not a commit\n")
+
+	if _, err := gogit(t, "hash-object", "-t", "commit", "-w", bad); err == nil {
+		t.Fatal("hash-object -t commit accepted a malformed commit")
+	}
+
+	hash := strings.TrimSpace(mustGogit(t, "hash-object", "-t", "commit", "-w", "--literally", bad))
+	if want := utils.HashObject("commit", []byte("not a commit\n")); hash != want {
+		t.Errorf("hash %s, want %s", hash, want)
+	}
+	if !object.Exists(root, hash) {
+		t.Fatal("--literally -w didn't store the object")
+	}
+	if _, err := gogit(t, "cat-file", "-p", hash); err == nil {
+		t.Error("the malformed commit reads back as a valid one")
+	}
+
+	// Any type name will do, as long as it can be written in a header
+	if _, err := gogit(t, "hash-object", "-t", "bogus", "--literally", bad); err != nil {
+		t.Errorf("--literally refused a made-up type: %v", err)
+	}
+	for _, typ := range []string{"", "two words"} {
+		if _, err := gogit(t, "hash-object", "-t", typ, "--literally", bad); err == nil {
+			t.Errorf("--literally accepted type %q", typ)
+		}
+	}
+}
+
+func TestHashObjectMaxSize(t *testing.T) {
+	newTestRepo(t)
+	mustGogit(t, "config", "core.maxObjectSize", "1k")
+	small := writeTemp(t, strings.Repeat("x", 1024))
+	large := writeTemp(t, strings.Repeat("x", 1025))
+
+	mustGogit(t, "hash-object", "-w", small)
+	if _, err := gogit(t, "hash-object", "-w", large); err == nil {
+		t.Error("hash-object -w stored an object over core.maxObjectSize")
+	}
+	// The limit is on what is stored
+	mustGogit(t, "hash-object", large)
+	mustGogit(t, "hash-object", "-w", "--literally", large)
+
+	mustGogit(t, "config", "core.maxObjectSize", "lots")
+	if _, err := gogit(t, "hash-object", "-w", small); err == nil {
+		t.Error("hash-object -w ignored a bad core.maxObjectSize")
+	}
+}
@@ -0,0 +1,40 @@
+package commands
+
+import "github.com/spf13/cobra"
+
+// Groups the root help lists commands under, as git's own help does
+const (
+	groupMain      = "main"
+	groupAncillary = "ancillary"
+	groupPlumbing  = "plumbing"
+)
+
+// revisionsTopic is a help topic rather than a command: it only has text,
+// shown by "gogit help revisions"
+var revisionsTopic = &cobra.Command{
+	Use:   "revisions",
+	Short: "How to name commits and other objects",
+	Long: `Commands that take a commit, tree or other object accept a revision:
+
+  <sha1>        A full object name, or a unique prefix of at least 4 characters
+  HEAD, @       The current commit
+  <refname>     A ref, looked up as given, then under refs/, refs/tags/,
+                refs/heads/ and refs/remotes/, so "main" is refs/heads/main
+  <rev>~<n>     The n-th generation ancestor, following first parents
+  <rev>^<n>     The n-th parent of a merge; ^ alone is the first parent, and
+                ^0 is the commit itself
+  <rev>^{tree}  The object peeled to a tree; likewise ^{commit}, ^{blob},
+                ^{tag}, ^{object}, and ^{} to peel tags to whatever they name
+
+Suffixes can be chained, as in main~2^{tree}.`,
+}
+
+func init() {
+	rootCmd.AddGroup(
+		&cobra.Group{ID: groupMain, Title: "Main commands:"},
+		&cobra.Group{ID: groupAncillary, Title: "Ancillary commands:"},
+		&cobra.Group{ID: groupPlumbing, Title: "Low-level commands (plumbing):"},
+	)
+	rootCmd.SetHelpCommandGroupID(groupAncillary)
+	rootCmd.AddCommand(revisionsTopic)
+}
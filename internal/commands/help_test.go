@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpShowsExamples(t *testing.T) {
+	for _, args := range [][]string{{"help", "commit"}, {"commit", "--help"}} {
+		out := mustGogit(t, args...)
+		for _, want := range []string{commitCmd.Long, "Examples:\n" + commitCmd.Example, `gogit commit --allow-empty -m "Trigger a rebuild"`} {
+			if !strings.Contains(out, want) {
+				t.Errorf("gogit %v:\n%s\nwant it to contain:\n%s", args, out, want)
+			}
+		}
+	}
+}
+
+func TestHelpGroupsCommands(t *testing.T) {
+	out := mustGogit(t, "help")
+	// Each command is listed under its group, and the groups come in order
+	var pos int
+	for _, want := range []string{"Main commands:", "  commit ", "Ancillary commands:", "  config ", "  help ", "Low-level commands (plumbing):", "  cat-file ", "  diff-tree "} {
+		i := strings.Index(out[pos:], want)
+		if i < 0 {
+			t.Fatalf("help:\n%s\nwant %q after position %d", out, want, pos)
+		}
+		pos += i
+	}
+
+	if out := mustGogit(t, "help", "revisions"); !strings.Contains(out, revisionsTopic.Long) {
+		t.Errorf("help revisions:\n%s\nwant the topic's text", out)
+	}
+}
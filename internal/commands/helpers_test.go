@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+// newTestRepo makes an empty repository in a temporary directory and moves
+// into it for the rest of the test, with an identity to commit as and no
+// user-wide configuration
+func newTestRepo(t testing.TB) string {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", t.TempDir())
+	chdir(t, root)
+
+	if _, err := initRepository(root, "main"); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := repository.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{"user.name": "Test", "user.email": "test@example.com"} {
+		if err := repo.SetConfig(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// chdir moves into dir until the test ends
+func chdir(t testing.TB, dir string) {
+	t.Helper()
+	old, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(old) })
+}
+
+// gogit runs a command line in the current directory, as the binary would,
+// and returns what it printed on stdout. Flags are put back to their
+// defaults first, since they live in package variables.
+func gogit(t testing.TB, args ...string) (string, error) {
+	t.Helper()
+	resetFlags(rootCmd)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		out <- buf.Bytes()
+	}()
+
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = stdout
+	return string(<-out), err
+}
+
+// execute runs a command line through Execute, as main does, and returns
+// what it printed on stderr and the exit code
+func execute(t testing.TB, args ...string) (string, int) {
+	t.Helper()
+	resetFlags(rootCmd)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	out := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		out <- buf.Bytes()
+	}()
+
+	rootCmd.SetArgs(args)
+	err = Execute()
+
+	w.Close()
+	os.Stderr = stderr
+	return string(<-out), ExitCode(err)
+}
+
+// ansiEscape matches the colour codes gogit writes
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// plain strips colour codes from command output
+func plain(out string) string {
+	return ansiEscape.ReplaceAllString(out, "")
+}
+
+// mustGogit runs a command line that is expected to succeed
+func mustGogit(t testing.TB, args ...string) string {
+	t.Helper()
+	out, err := gogit(t, args...)
+	if err != nil {
+		t.Fatalf("gogit %v: %v", args, err)
+	}
+	return out
+}
+
+// resetFlags puts every flag of cmd and its subcommands back to its default
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// commitWorktree writes files into the working tree and commits them
+func commitWorktree(t testing.TB, message string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		writeFile(t, path, content)
+		mustGogit(t, "add", path)
+	}
+	mustGogit(t, "commit", "-m", message)
+}
+
+// writeFile writes a file in the working tree, making its directories
+func writeFile(t testing.TB, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFile returns a working tree file's content
+func readFile(t testing.TB, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// writeTemp writes content to a file outside the working tree and returns
+// its path
+func writeTemp(t testing.TB, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input")
+	writeFile(t, path, content)
+	return path
+}
+
+// writeObject stores obj in the test repository and returns its hash
+func writeObject(t testing.TB, obj object.Object) string {
+	t.Helper()
+	hash, err := object.WriteObject(".", obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
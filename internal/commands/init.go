@@ -6,18 +6,30 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+var (
+	initBranch string
 )
 
 var initCmd = &cobra.Command{
-	Use:   "init [directory]",
-	Short: "Create an empty GoGit repository",
-	Long:  `Initialize a new GoGit repository in the specified directory, or the current directory if not specified.`,
-	Args:  cobra.MaximumNArgs(1),
-	RunE:  runInit,
+	Use:     "init [directory]",
+	Short:   "Create an empty GoGit repository",
+	Long:    `Initialize a new GoGit repository in the specified directory, or the current directory if not specified.`,
+	GroupID: groupMain,
+	Example: `  # Make the current directory a repository
+  gogit init
+
+  # Create a repository in a new directory, with trunk as its first branch
+  gogit init -b trunk project`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInit,
 }
 
 func init() {
 	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().StringVarP(&initBranch, "initial-branch", "b", "main", "Name of the branch HEAD points to in the new repository")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
@@ -31,11 +43,27 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	gogitDir := filepath.Join(absPath, ".gogit")
+	if initBranch == "" {
+		return fmt.Errorf("initial branch name cannot be empty")
+	}
+
+	gogitDir, err := initRepository(absPath, initBranch)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Initialized empty GoGit repository in %s\n", gogitDir)
+	return nil
+}
+
+// initRepository creates the metadata directory of a new repository at
+// absPath, with HEAD on the unborn branch, and returns its path
+func initRepository(absPath, branch string) (string, error) {
+	gogitDir := utils.GitDir(absPath)
 
 	// Check if already initialized
 	if _, err := os.Stat(gogitDir); err == nil {
-		return fmt.Errorf("already a gogit repository: %s", gogitDir)
+		return "", fmt.Errorf("already a gogit repository: %s", gogitDir)
 	}
 
 	// Create directory structure
@@ -48,14 +76,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+			return "", fmt.Errorf("failed to create directory %s: %w", dir, err)
 		}
 	}
 
-	// Create HEAD file pointing to main branch
-	headContent := "ref: refs/heads/main\n"
+	// Create HEAD file pointing to the initial (unborn) branch
+	headContent := fmt.Sprintf("ref: refs/heads/%s\n", branch)
 	if err := os.WriteFile(filepath.Join(gogitDir, "HEAD"), []byte(headContent), 0644); err != nil {
-		return fmt.Errorf("failed to create HEAD: %w", err)
+		return "", fmt.Errorf("failed to create HEAD: %w", err)
 	}
 
 	// Create config file
@@ -65,15 +93,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 	bare = false
 `
 	if err := os.WriteFile(filepath.Join(gogitDir, "config"), []byte(configContent), 0644); err != nil {
-		return fmt.Errorf("failed to create config: %w", err)
+		return "", fmt.Errorf("failed to create config: %w", err)
 	}
 
 	// Create description file
 	descContent := "Unnamed repository; edit this file to name the repository.\n"
 	if err := os.WriteFile(filepath.Join(gogitDir, "description"), []byte(descContent), 0644); err != nil {
-		return fmt.Errorf("failed to create description: %w", err)
+		return "", fmt.Errorf("failed to create description: %w", err)
 	}
 
-	fmt.Printf("Initialized empty GoGit repository in %s\n", gogitDir)
-	return nil
+	return gogitDir, nil
 }
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+func TestFirstCommitOnCustomBranch(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", t.TempDir())
+	chdir(t, root)
+	mustGogit(t, "init", "-b", "trunk")
+	mustGogit(t, "config", "user.name", "Test")
+	mustGogit(t, "config", "user.email", "test@example.com")
+
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+
+	head := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	if got := strings.TrimSpace(mustGogit(t, "rev-parse", "trunk")); got != head {
+		t.Errorf("trunk = %q, want the new commit %s", got, head)
+	}
+	if got := strings.TrimSpace(mustGogit(t, "rev-parse", "--abbrev-ref", "HEAD")); got != "trunk" {
+		t.Errorf("HEAD is on %q, want trunk", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".gogit", "refs", "heads", "main")); !os.IsNotExist(err) {
+		t.Error("main was created, want only trunk")
+	}
+}
+
+func TestRepositoryInDotGit(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", t.TempDir())
+	chdir(t, root)
+	if _, err := initRepository(root, "main"); err != nil {
+		t.Fatal(err)
+	}
+	// A repository whose metadata directory was renamed, or made by git
+	if err := os.Rename(filepath.Join(root, ".gogit"), filepath.Join(root, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	mustGogit(t, "config", "user.name", "Test")
+	mustGogit(t, "config", "user.email", "test@example.com")
+
+	commitWorktree(t, "one", map[string]string{"f": "a\n", "dir/g": "b\n"})
+	chdir(t, "dir")
+	commitWorktree(t, "two", map[string]string{"g": "c\n"})
+
+	if got := strings.TrimSpace(mustGogit(t, "rev-parse", "--git-dir")); got != filepath.Join(root, ".git") {
+		t.Errorf("rev-parse --git-dir = %q, want the .git directory", got)
+	}
+	if out := plain(mustGogit(t, "log", "--oneline")); strings.Count(out, "\n") != 2 {
+		t.Errorf("log:\n%s\nwant both commits", out)
+	}
+	if out := plain(mustGogit(t, "status")); !strings.Contains(out, "nothing to commit") {
+		t.Errorf("status:\n%s\nwant a clean tree, without .git listed", out)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".gogit")); !os.IsNotExist(err) {
+		t.Error("a .gogit directory was created next to .git")
+	}
+}
+
+func TestStrictChecksRefs(t *testing.T) {
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "1\n"})
+	head := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+
+	mustGogit(t, "--strict", "status")
+	if err := object.RemoveLoose(root, head); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gogit(t, "--strict", "status"); err == nil || !strings.Contains(err.Error(), "repository check failed") {
+		t.Errorf("--strict status with HEAD's commit gone: %v", err)
+	}
+}
+
+func TestCommandsRefuseBrokenRepository(t *testing.T) {
+	root := newTestRepo(t)
+	if err := os.Remove(filepath.Join(root, ".gogit", "HEAD")); err != nil {
+		t.Fatal(err)
+	}
+	stderr, code := execute(t, "status")
+	if code != exitFatal || !strings.Contains(stderr, "HEAD is missing") {
+		t.Errorf("status without HEAD exited %d:\n%s", code, stderr)
+	}
+}
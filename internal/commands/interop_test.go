@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The interop fixture was written by real git: a merge of a side branch,
+// packed by gc along with its refs and a commit-graph, then one more
+// loose commit, with the index converted to version 4. The metadata
+// directory is stored as gitdir, since git won't track one named .git.
+const interopFixture = "testdata/interop"
+
+// openInteropFixture copies the fixture into a temporary directory, with
+// its metadata directory named .gogit, and moves into it
+func openInteropFixture(t *testing.T) {
+	t.Helper()
+	src, err := filepath.Abs(interopFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := newTestRepo(t)
+	if err := os.RemoveAll(filepath.Join(root, ".gogit")); err != nil {
+		t.Fatal(err)
+	}
+	copyTree(t, filepath.Join(src, "gitdir"), filepath.Join(root, ".gogit"))
+	copyTree(t, filepath.Join(src, "worktree"), root)
+}
+
+func copyTree(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, info.Mode().Perm())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInteropWithRealGit(t *testing.T) {
+	openInteropFixture(t)
+
+	// Each expected output is what git itself prints for the fixture
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{
+			// A loose commit
+			[]string{"cat-file", "-p", "HEAD"},
+			"tree c49f5ac82c90244edd14c9ab5a7a212d102ae07a\n" +
+				"parent 5a3e2c4fe9977923d9973987609a6d19d7e94ed4\n" +
+				"author A U Thor <author@example.com> 1700000240 +0200\n" +
+				"committer C O Mitter <committer@example.com> 1700000240 +0200\n" +
+				"\n" +
+				"Add a loose commit\n",
+		},
+		{
+			// A packed merge commit
+			[]string{"cat-file", "-p", "HEAD^"},
+			"tree 99b9dcb5dd180110889f877b080c4baf41bdfc93\n" +
+				"parent cca04a03933871fdecc98344b6e2449d0883f993\n" +
+				"parent b08d14c99dfd6c3ae560bce2c4ae39b611ac1d4e\n" +
+				"author A U Thor <author@example.com> 1700000180 +0200\n" +
+				"committer C O Mitter <committer@example.com> 1700000180 +0200\n" +
+				"\n" +
+				"Merge branch 'side'\n",
+		},
+		{
+			// A tag only named in packed-refs
+			[]string{"cat-file", "-p", "v1"},
+			"object 5a3e2c4fe9977923d9973987609a6d19d7e94ed4\n" +
+				"type commit\n" +
+				"tag v1\n" +
+				"tagger C O Mitter <committer@example.com> 1700000180 +0200\n" +
+				"\n" +
+				"Version 1\n",
+		},
+		{
+			// "foo" sorts after "foo.bar" as a tree, before it as a file
+			[]string{"cat-file", "-p", "HEAD^{tree}"},
+			"100644 blob 2227cddb7f6318ea735a1c4adb52f5cd36c5783c\ta.txt\n" +
+				"040000 tree 2f54e0fb70087a7c99db2e85d6f9d307a52af90b\tdir\n" +
+				"100644 blob 587be6b4c3f93f93c489c0111bba5596147a26cb\tfoo.bar\n" +
+				"040000 tree a0101d9122906945c17a0b1af164003a0748fdb2\tfoo\n" +
+				"100644 blob b6586661e7ec0a4c9389276355d01e145861eb0c\tloose.txt\n" +
+				"100755 blob 85ba14df52f8c72688537de6e7555fb402217b1e\trun.sh\n",
+		},
+		{
+			[]string{"log", "--oneline"},
+			"087a72c Add a loose commit\n" +
+				"5a3e2c4 Merge branch 'side'\n" +
+				"cca04a0 Change a on main\n" +
+				"b08d14c Change b on side\n" +
+				"6a4c5c6 Initial commit\n",
+		},
+		{
+			[]string{"log", "--oneline", "--first-parent"},
+			"087a72c Add a loose commit\n" +
+				"5a3e2c4 Merge branch 'side'\n" +
+				"cca04a0 Change a on main\n" +
+				"6a4c5c6 Initial commit\n",
+		},
+		{
+			// The index is version 4, with prefix-compressed paths
+			[]string{"ls-files", "-s"},
+			"100644 2227cddb7f6318ea735a1c4adb52f5cd36c5783c 0\ta.txt\n" +
+				"100644 d029c25b32ab51afd0373e680bfba2e6c72e7aff 0\tdir/b.txt\n" +
+				"100644 587be6b4c3f93f93c489c0111bba5596147a26cb 0\tfoo.bar\n" +
+				"100644 975fbec8256d3e8a3797e7a3611380f27c49f4ac 0\tfoo/inner\n" +
+				"100644 b6586661e7ec0a4c9389276355d01e145861eb0c 0\tloose.txt\n" +
+				"100755 85ba14df52f8c72688537de6e7555fb402217b1e 0\trun.sh\n",
+		},
+		{
+			[]string{"merge-base", "main", "side"},
+			"b08d14c99dfd6c3ae560bce2c4ae39b611ac1d4e\n",
+		},
+		{
+			[]string{"status"},
+			"On branch main\n\nnothing to commit, working tree clean\n",
+		},
+	} {
+		out := plain(mustGogit(t, c.args...))
+		if out != c.want {
+			t.Errorf("gogit %v:\n%s\nwant:\n%s", c.args, out, c.want)
+		}
+	}
+}
@@ -2,29 +2,65 @@ package commands
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
 	"github.com/yourusername/gogit/internal/object"
 	"github.com/yourusername/gogit/internal/repository"
 )
 
 var (
-	logOneline bool
-	logCount   int
+	logOneline     bool
+	logCount       int
+	logNameStatus  bool
+	logDiffFilter  string
+	logAll         bool
+	logFollow      bool
+	logFirstParent bool
 )
 
 var logCmd = &cobra.Command{
-	Use:   "log",
+	Use:   "log [--first-parent] [--follow] [--] [<path>...]",
 	Short: "Show commit logs",
-	Long:  `Show the commit history starting from HEAD.`,
-	RunE:  runLog,
+	Long: `Show the commit history starting from HEAD, or from every ref with --all,
+newest first.
+
+Given paths, only the commits that changed them are shown, and a merge that
+left them as one of its parents had them only has that parent's history
+walked. --follow continues the history of a single file past renames: when
+a commit turns out to have created the file by renaming another, the
+older commits are searched for the old name.
+
+--first-parent follows only the first parent of each merge, showing the
+mainline of history: the commits made on the branch itself and the merges
+into it, but not the commits the merges brought in.
+
+Commits are printed as the history is walked, so output starts at once
+however long the history is, and -n stops the walk as soon as enough
+commits have been shown.`,
+	GroupID: groupMain,
+	Example: `  # Show the last five commits, one line each
+  gogit log --oneline -n 5
+
+  # Show the history of a file across renames
+  gogit log --follow -- docs/guide.md
+
+  # Show only the mainline of a branch with merges
+  gogit log --first-parent`,
+	RunE: runLog,
 }
 
 func init() {
 	rootCmd.AddCommand(logCmd)
 	logCmd.Flags().BoolVar(&logOneline, "oneline", false, "Show each commit on a single line")
 	logCmd.Flags().IntVarP(&logCount, "number", "n", 0, "Limit the number of commits to show")
+	logCmd.Flags().BoolVar(&logNameStatus, "name-status", false, "Show the names and status of changed files")
+	logCmd.Flags().StringVar(&logDiffFilter, "diff-filter", "", "Show only commits with changes of the selected types (ACDMR); lowercase letters exclude")
+	logCmd.Flags().BoolVar(&logAll, "all", false, "Show the history of every ref, not just HEAD")
+	logCmd.Flags().BoolVar(&logFollow, "follow", false, "Continue listing the history of a file beyond renames")
+	logCmd.Flags().BoolVar(&logFirstParent, "first-parent", false, "Follow only the first parent of merge commits")
 }
 
 func runLog(cmd *cobra.Command, args []string) error {
@@ -33,33 +69,69 @@ func runLog(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	refs := repository.NewRefs(repoRoot)
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
 
-	// Get HEAD commit
-	commitHash, err := refs.ResolveHead()
+	filter, err := diff.ParseFilter(logDiffFilter)
 	if err != nil {
-		return fmt.Errorf("failed to resolve HEAD: %w", err)
+		return err
+	}
+
+	opts := repository.LogOptions{FirstParent: logFirstParent, Follow: logFollow}
+	for _, arg := range args {
+		path, err := repoRelative(repoRoot, arg)
+		if err != nil {
+			return err
+		}
+		opts.Paths = append(opts.Paths, filepath.ToSlash(path))
+	}
+
+	var starts []string
+	if logAll {
+		if starts, err = repo.RefTips(); err != nil {
+			return err
+		}
+	} else {
+		head, err := repo.Refs.ResolveHead()
+		if err != nil {
+			return fmt.Errorf("failed to resolve HEAD: %w", err)
+		}
+		if head != "" {
+			starts = append(starts, head)
+		}
 	}
 
-	if commitHash == "" {
+	if len(starts) == 0 {
 		fmt.Println("No commits yet")
 		return nil
 	}
 
+	// -n counts the commits shown, which a filter may hide some of, so it
+	// stops the walk here rather than through opts.MaxCount
 	count := 0
-	for commitHash != "" {
-		if logCount > 0 && count >= logCount {
-			break
-		}
+	return repo.Log(starts, opts, func(entry repository.LogEntry) error {
+		commitHash, commit := entry.Hash, entry.Commit
 
-		obj, err := object.ReadObject(repoRoot, commitHash)
-		if err != nil {
-			return fmt.Errorf("failed to read commit %s: %w", commitHash, err)
-		}
+		var changes []diff.FileChange
+		if logNameStatus || filter != nil {
+			var err error
+			changes, err = commitChanges(repo, commit)
+			if err != nil {
+				return err
+			}
+			if len(opts.Paths) > 0 {
+				changes = limitChanges(changes, entry.Paths, entry.Rename)
+			}
 
-		commit, ok := obj.(*object.Commit)
-		if !ok {
-			return fmt.Errorf("object %s is not a commit", commitHash)
+			// A filter hides commits with no matching changes
+			if filter != nil {
+				changes = filter.Apply(changes)
+				if len(changes) == 0 {
+					return nil
+				}
+			}
 		}
 
 		if logOneline {
@@ -70,14 +142,55 @@ func runLog(cmd *cobra.Command, args []string) error {
 			// Full format
 			fmt.Printf("\033[33mcommit %s\033[0m\n", commitHash)
 			fmt.Printf("Author: %s\n", commit.Author)
-			fmt.Printf("Date:   %s\n", commit.AuthorTime.Format("Mon Jan 2 15:04:05 2006 -0700"))
-			fmt.Printf("\n    %s\n\n", strings.ReplaceAll(commit.Message, "\n", "\n    "))
+			fmt.Printf("Date:   %s\n", commit.Author.When.Format("Mon Jan 2 15:04:05 2006 -0700"))
+			fmt.Printf("\n    %s\n\n", strings.ReplaceAll(strings.TrimRight(commit.Message, "\n"), "\n", "\n    "))
+		}
+
+		if logNameStatus {
+			for _, change := range changes {
+				fmt.Println(change.NameStatus())
+			}
+			if !logOneline && len(changes) > 0 {
+				fmt.Println()
+			}
 		}
 
-		// Move to parent
-		commitHash = commit.ParentHash
 		count++
+		if logCount > 0 && count >= logCount {
+			return repository.ErrStopLog
+		}
+		return nil
+	})
+}
+
+// commitChanges returns the files a commit changed relative to its first parent
+func commitChanges(repo *repository.Repository, commit *object.Commit) ([]diff.FileChange, error) {
+	parentTree := ""
+	if parentHash := commit.FirstParent(); parentHash != "" {
+		parent, err := repo.Objects.ReadCommit(parentHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", parentHash, err)
+		}
+		parentTree = parent.TreeHash
 	}
 
-	return nil
+	return diff.DiffTrees(repo.Objects, parentTree, commit.TreeHash)
+}
+
+// limitChanges keeps the changes to the given paths, with a followed
+// rename shown as one change rather than a deletion and an addition
+func limitChanges(changes []diff.FileChange, paths []string, renamed *diff.FileChange) []diff.FileChange {
+	var limited []diff.FileChange
+	for _, change := range changes {
+		if renamed != nil && (change.Path() == renamed.OldPath || change.Path() == renamed.NewPath) {
+			continue
+		}
+		if inPathspecs(change.Path(), paths) {
+			limited = append(limited, change)
+		}
+	}
+	if renamed != nil {
+		limited = append(limited, *renamed)
+	}
+	return limited
 }
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+func TestLogNameStatus(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"a": "a\n"})
+	commitWorktree(t, "two", map[string]string{"a": "a2\n", "b": "b\n"})
+	mustGogit(t, "rm", "-q", "a")
+	commitWorktree(t, "three", map[string]string{"c/d": "d\n"})
+
+	want := "three\nD\ta\nA\tc/d\ntwo\nM\ta\nA\tb\none\nA\ta\n"
+	var got strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(plain(mustGogit(t, "log", "--oneline", "--name-status"))), "\n") {
+		if !strings.Contains(line, "\t") {
+			_, line, _ = strings.Cut(line, " ")
+		}
+		got.WriteString(line + "\n")
+	}
+	if got.String() != want {
+		t.Errorf("log --name-status:\n%s\nwant:\n%s", got.String(), want)
+	}
+}
+
+func TestLogAllStopsReadingAtCount(t *testing.T) {
+	root := newTestRepo(t)
+	for i := 0; i < 10; i++ {
+		commitWorktree(t, fmt.Sprintf("main %d", i), map[string]string{"f": fmt.Sprintf("%d\n", i)})
+	}
+	mustGogit(t, "checkout", strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD~5")))
+	mustGogit(t, "checkout", "-b", "topic")
+	for i := 0; i < 10; i++ {
+		commitWorktree(t, fmt.Sprintf("topic %d", i), map[string]string{"g": fmt.Sprintf("%d\n", i)})
+	}
+
+	repo, err := repository.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tips := map[string]bool{}
+	for _, branch := range []string{"main", "topic"} {
+		tips[strings.TrimSpace(mustGogit(t, "rev-parse", branch))] = true
+	}
+
+	// Take away every commit but the tips: a walk that reads any further
+	// fails
+	var starts []string
+	for tip := range tips {
+		starts = append(starts, tip)
+	}
+	err = repo.Log(starts, repository.LogOptions{}, func(entry repository.LogEntry) error {
+		if !tips[entry.Hash] {
+			return object.RemoveLoose(root, entry.Hash)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := plain(mustGogit(t, "log", "-n", "1", "--all", "--oneline"))
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 1 {
+		t.Errorf("log -n 1 --all:\n%s\nwant one commit", out)
+	}
+	if _, err := gogit(t, "log", "--all", "--oneline"); err == nil {
+		t.Error("log --all succeeded without the older commits, so the test proves nothing")
+	}
+}
+
+func TestLogFollow(t *testing.T) {
+	newTestRepo(t)
+	content := "a fair amount\nof text that\nstays the same\nacross the rename\n"
+	commitWorktree(t, "create", map[string]string{"old.txt": content, "other": "x\n"})
+	commitWorktree(t, "edit before", map[string]string{"old.txt": content + "more\n"})
+	commitWorktree(t, "unrelated", map[string]string{"other": "y\n"})
+	mustGogit(t, "mv", "old.txt", "new.txt")
+	writeFile(t, "new.txt", content+"more\nand a little\n")
+	mustGogit(t, "add", "new.txt")
+	mustGogit(t, "commit", "-m", "rename")
+	commitWorktree(t, "edit after", map[string]string{"new.txt": content + "changed\n"})
+
+	subjects := func(args ...string) []string {
+		var result []string
+		for _, line := range strings.Split(strings.TrimSpace(plain(mustGogit(t, append([]string{"log", "--oneline"}, args...)...))), "\n") {
+			_, subject, _ := strings.Cut(line, " ")
+			result = append(result, subject)
+		}
+		return result
+	}
+
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"new.txt"}, "edit after,rename"},
+		{[]string{"--follow", "new.txt"}, "edit after,rename,edit before,create"},
+		{[]string{"--follow", "--", "new.txt"}, "edit after,rename,edit before,create"},
+	} {
+		if got := strings.Join(subjects(c.args...), ","); got != c.want {
+			t.Errorf("log %v: %s, want %s", c.args, got, c.want)
+		}
+	}
+
+	if _, err := gogit(t, "log", "--follow", "new.txt", "other"); err == nil {
+		t.Error("log --follow accepted two paths")
+	}
+}
+
+func TestLogFirstParent(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "base", map[string]string{"a": "a\n", "b": "b\n"})
+	mustGogit(t, "checkout", "-b", "side")
+	commitWorktree(t, "side one", map[string]string{"b": "b1\n"})
+	commitWorktree(t, "side two", map[string]string{"b": "b2\n"})
+	mustGogit(t, "checkout", "main")
+	commitWorktree(t, "main one", map[string]string{"a": "a1\n"})
+	mustGogit(t, "merge", "side")
+	commitWorktree(t, "after", map[string]string{"a": "a2\n"})
+
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--first-parent"}, "after,Merge branch 'side',main one,base"},
+		{[]string{"--first-parent", "-n", "2"}, "after,Merge branch 'side'"},
+		// Against its first parent the merge changes b, so it stands in for
+		// the side branch's commits
+		{[]string{"--first-parent", "b"}, "Merge branch 'side',base"},
+		{[]string{"b"}, "side two,side one,base"},
+		{[]string{"--first-parent", "--", "a"}, "after,main one,base"},
+	} {
+		var subjects []string
+		for _, line := range strings.Split(strings.TrimSpace(plain(mustGogit(t, append([]string{"log", "--oneline"}, c.args...)...))), "\n") {
+			_, subject, _ := strings.Cut(line, " ")
+			subjects = append(subjects, subject)
+		}
+		if got := strings.Join(subjects, ","); got != c.want {
+			t.Errorf("log %v: %s, want %s", c.args, got, c.want)
+		}
+	}
+}
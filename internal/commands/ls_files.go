@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/index"
+)
+
+var (
+	lsFilesStage    bool
+	lsFilesUnmerged bool
+)
+
+var lsFilesCmd = &cobra.Command{
+	Use:     "ls-files [-s] [-u]",
+	Short:   "Show information about files in the index",
+	Long:    `List the paths recorded in the index, streaming entries straight from the index file.`,
+	GroupID: groupPlumbing,
+	Example: `  # Show the index with modes, object names and stages
+  gogit ls-files -s
+
+  # Show only conflicted entries
+  gogit ls-files -u`,
+	Args: cobra.NoArgs,
+	RunE: runLsFiles,
+}
+
+func init() {
+	rootCmd.AddCommand(lsFilesCmd)
+	lsFilesCmd.Flags().BoolVarP(&lsFilesStage, "stage", "s", false, "Show mode, object name and stage number of each entry")
+	lsFilesCmd.Flags().BoolVarP(&lsFilesUnmerged, "unmerged", "u", false, "Show only unmerged (conflicted) entries; implies --stage")
+}
+
+func runLsFiles(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	return index.Scan(repoRoot, func(entry index.Entry) error {
+		if lsFilesUnmerged && entry.Stage() == 0 {
+			return nil
+		}
+		if lsFilesStage || lsFilesUnmerged {
+			_, err := fmt.Fprintf(out, "%06o %s %d\t%s\n", entry.Mode, entry.HashString(), entry.Stage(), entry.Path)
+			return err
+		}
+		_, err := fmt.Fprintln(out, entry.Path)
+		return err
+	})
+}
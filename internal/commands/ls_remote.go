@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/transport"
+)
+
+var (
+	lsRemoteHeads bool
+	lsRemoteTags  bool
+)
+
+var lsRemoteCmd = &cobra.Command{
+	Use:   "ls-remote [--heads] [--tags] <url>",
+	Short: "List references in a remote repository",
+	Long: `Fetch the ref advertisement of a remote repository over smart HTTP and
+print one "<hash>\t<refname>" line per ref. No objects are downloaded.
+
+  --heads  Only show branches (refs/heads/)
+  --tags   Only show tags (refs/tags/), including peeled "^{}" entries
+
+Both options together show branches and tags.`,
+	GroupID: groupPlumbing,
+	Example: `  # List the branches of a remote repository
+  gogit ls-remote --heads https://example.com/project.git`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLsRemote,
+}
+
+func init() {
+	rootCmd.AddCommand(lsRemoteCmd)
+	lsRemoteCmd.Flags().BoolVar(&lsRemoteHeads, "heads", false, "Limit to refs/heads")
+	lsRemoteCmd.Flags().BoolVarP(&lsRemoteTags, "tags", "t", false, "Limit to refs/tags")
+}
+
+func runLsRemote(cmd *cobra.Command, args []string) error {
+	adv, err := transport.GetAdvertisement(transport.NewClient(transport.DefaultClientOptions()), args[0])
+	if err != nil {
+		return err
+	}
+
+	var prefixes []string
+	if lsRemoteHeads {
+		prefixes = append(prefixes, "refs/heads/")
+	}
+	if lsRemoteTags {
+		prefixes = append(prefixes, "refs/tags/")
+	}
+
+	for _, ref := range adv.Refs {
+		if len(prefixes) > 0 && !hasAnyPrefix(ref.Name, prefixes) {
+			continue
+		}
+		fmt.Printf("%s\t%s\n", ref.Hash, ref.Name)
+	}
+	return nil
+}
+
+// hasAnyPrefix reports whether s starts with one of prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
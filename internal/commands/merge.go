@@ -0,0 +1,636 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/index"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	mergeAbort    bool
+	mergeContinue bool
+	mergeSquash   bool
+	mergeFFOnly   bool
+	mergeNoFF     bool
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [--ff-only | --no-ff] [--squash] <branch> | --abort | --continue",
+	Short: "Join two development histories together",
+	Long: `Merge the named branch or commit into the current branch.
+
+If the current branch is an ancestor of the other one, the branch is
+fast-forwarded. Otherwise the two sides are merged against their merge base:
+file by file, and then line by line for files changed on both sides. Where
+both sides changed the same lines differently, the file is left with both
+versions between conflict markers and the merge stops, with exit status 1,
+so they can be resolved; otherwise the result is committed with both
+commits as parents.
+
+  --ff-only   Refuse to merge unless the branch can be fast-forwarded.
+  --no-ff     Create a merge commit even when a fast-forward is possible.
+  --squash    Merge the changes into the index and working tree, but don't
+              commit or record a merge: the next commit has HEAD as its only
+              parent, with a message listing the squashed commits.
+  --abort     Give up on a conflicted merge and return to the pre-merge state.
+  --continue  Conclude a merge once all conflicts have been resolved and added.`,
+	GroupID: groupMain,
+	Example: `  # Merge a branch into the current one
+  gogit merge feature
+
+  # Always record a merge commit
+  gogit merge --no-ff feature
+
+  # Give up on a merge with conflicts
+  gogit merge --abort`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMerge,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+	mergeCmd.Flags().BoolVar(&mergeAbort, "abort", false, "Abort the current conflict resolution and restore the pre-merge state")
+	mergeCmd.Flags().BoolVar(&mergeContinue, "continue", false, "Conclude the merge after conflicts have been resolved")
+	mergeCmd.Flags().BoolVar(&mergeFFOnly, "ff-only", false, "Refuse to merge unless a fast-forward is possible")
+	mergeCmd.Flags().BoolVar(&mergeNoFF, "no-ff", false, "Create a merge commit even when a fast-forward is possible")
+	mergeCmd.Flags().BoolVar(&mergeSquash, "squash", false, "Stage the merged changes for a single-parent commit instead of merging")
+}
+
+// mergeEntry is the outcome of merging one path
+type mergeEntry struct {
+	path     string
+	result   object.TreeEntry
+	exists   bool // The path exists in the merge result
+	conflict bool
+	base     object.TreeEntry
+	inBase   bool
+	ours     object.TreeEntry
+	inOurs   bool
+	theirs   object.TreeEntry
+	inTheirs bool
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	if mergeAbort && mergeContinue {
+		return fmt.Errorf("--abort and --continue are mutually exclusive")
+	}
+	if (mergeSquash || mergeFFOnly || mergeNoFF) && (mergeAbort || mergeContinue) {
+		return fmt.Errorf("--abort and --continue take no other options")
+	}
+	if mergeFFOnly && mergeNoFF {
+		return fmt.Errorf("you cannot combine --no-ff with --ff-only")
+	}
+	if mergeSquash && mergeNoFF {
+		return fmt.Errorf("you cannot combine --squash with --no-ff")
+	}
+
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case mergeAbort:
+		return abortMerge(repo)
+	case mergeContinue:
+		return continueMerge(repo)
+	case len(args) != 1:
+		return fmt.Errorf("specify a branch or commit to merge")
+	}
+
+	inProgress, err := repo.MergeHead()
+	if err != nil {
+		return err
+	}
+	if inProgress != "" {
+		return fmt.Errorf("you have not concluded your merge (MERGE_HEAD exists); use \"gogit merge --continue\" or \"gogit merge --abort\"")
+	}
+
+	headHash, err := repo.Refs.ResolveHead()
+	if err != nil {
+		return err
+	}
+	if headHash == "" {
+		return fmt.Errorf("cannot merge: no commits yet")
+	}
+
+	theirsHash, err := repo.ResolveCommit(args[0])
+	if err != nil {
+		return fmt.Errorf("%s - not something we can merge: %w", args[0], err)
+	}
+
+	upToDate, err := repo.IsAncestor(theirsHash, headHash)
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		fmt.Println("Already up to date.")
+		return nil
+	}
+
+	fastForward, err := repo.IsAncestor(headHash, theirsHash)
+	if err != nil {
+		return err
+	}
+	if fastForward && !mergeNoFF {
+		return fastForwardMerge(repo, args[0], headHash, theirsHash, mergeSquash)
+	}
+	if !fastForward && mergeFFOnly {
+		return fmt.Errorf("not possible to fast-forward, aborting")
+	}
+
+	return threeWayMerge(repo, args[0], headHash, theirsHash, mergeSquash)
+}
+
+// fastForwardMerge moves HEAD to a descendant commit, keeping unrelated local
+// changes. A squash updates the index and working tree but leaves HEAD.
+func fastForwardMerge(repo *repository.Repository, name, headHash, theirsHash string, squash bool) error {
+	headFiles, idx, err := headAndIndex(repo)
+	if err != nil {
+		return err
+	}
+	theirsFiles, err := commitFiles(repo, theirsHash)
+	if err != nil {
+		return err
+	}
+
+	actions, err := planReset(repo.Path, resetModeKeep, headFiles, indexSnapshot(idx), theirsFiles, nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Updating %s..%s\n", headHash[:7], theirsHash[:7])
+	if err := applyReset(repo.Path, idx, actions); err != nil {
+		return err
+	}
+	if err := repo.Refs.UpdateRef("ORIG_HEAD", headHash); err != nil {
+		return fmt.Errorf("failed to write ORIG_HEAD: %w", err)
+	}
+	fmt.Println("Fast-forward")
+
+	if squash {
+		fmt.Println("Squash commit -- not updating HEAD")
+		return writeSquashMessage(repo, headHash, theirsHash)
+	}
+	if err := repo.UpdateHead(theirsHash, "merge "+name+": Fast-forward"); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	return nil
+}
+
+// threeWayMerge merges theirsHash into HEAD against their merge base, then
+// commits the result or stops with the conflicts recorded in the index. A
+// squash always stops, and records no merge for the commit to conclude.
+func threeWayMerge(repo *repository.Repository, name, headHash, theirsHash string, squash bool) error {
+	repoRoot := repo.Path
+
+	headFiles, idx, err := headAndIndex(repo)
+	if err != nil {
+		return err
+	}
+	if len(idx.Conflicts()) > 0 || len(diff.DiffEntries(headFiles, indexSnapshot(idx))) > 0 {
+		return fmt.Errorf("your index contains uncommitted changes; commit or reset them before you merge")
+	}
+
+	baseHash, err := repo.MergeBase(headHash, theirsHash)
+	if err != nil {
+		return err
+	}
+	baseFiles, err := commitFiles(repo, baseHash)
+	if err != nil {
+		return err
+	}
+	theirsFiles, err := commitFiles(repo, theirsHash)
+	if err != nil {
+		return err
+	}
+
+	entries := mergeFiles(baseFiles, headFiles, theirsFiles)
+	if err := mergeContents(repo, entries); err != nil {
+		return err
+	}
+
+	// Refuse before touching anything if a file the merge writes has local changes
+	var dirty []string
+	for _, e := range entries {
+		if !e.conflict && sameEntry(e.result, e.exists, e.ours, e.inOurs) {
+			continue
+		}
+		w, inWorktree := worktreeEntry(repoRoot, e.path)
+		if !sameEntry(w, inWorktree, e.ours, e.inOurs) {
+			dirty = append(dirty, e.path)
+		}
+	}
+	if len(dirty) > 0 {
+		return fmt.Errorf("your local changes to the following files would be overwritten by merge:\n\t%s\nPlease commit your changes before you merge", strings.Join(dirty, "\n\t"))
+	}
+
+	var conflicts []string
+	for _, e := range entries {
+		if e.conflict {
+			if err := recordConflict(repo, idx, e, name); err != nil {
+				return err
+			}
+			conflicts = append(conflicts, conflictMessage(e, name))
+			continue
+		}
+		if sameEntry(e.result, e.exists, e.ours, e.inOurs) {
+			continue
+		}
+		if err := takeMergeResult(repoRoot, idx, e); err != nil {
+			return err
+		}
+	}
+
+	if err := idx.Write(repoRoot); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	if err := repo.Refs.UpdateRef("ORIG_HEAD", headHash); err != nil {
+		return fmt.Errorf("failed to write ORIG_HEAD: %w", err)
+	}
+	if squash {
+		err = writeSquashMessage(repo, headHash, theirsHash)
+	} else {
+		err = repo.WriteMergeState(theirsHash, mergeMessage(repo, name))
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		for _, message := range conflicts {
+			fmt.Println(message)
+		}
+		if squash {
+			fmt.Println("Squash commit -- not updating HEAD")
+		}
+		fmt.Println("Automatic merge failed; fix conflicts and then commit the result.")
+		return &ExitError{Code: exitNo}
+	}
+	if squash {
+		fmt.Println("Automatic merge went well; stopped before committing as requested")
+		fmt.Println("Squash commit -- not updating HEAD")
+		return nil
+	}
+
+	return commitIndex(repo, "", nil)
+}
+
+// mergeFiles decides the merged entry for every path. A side that didn't
+// change the path relative to base yields to the other; if both changed it
+// differently the path conflicts.
+func mergeFiles(baseFiles, oursFiles, theirsFiles map[string]object.TreeEntry) []mergeEntry {
+	paths := make(map[string]bool)
+	for _, files := range []map[string]object.TreeEntry{baseFiles, oursFiles, theirsFiles} {
+		for path := range files {
+			paths[path] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	entries := make([]mergeEntry, 0, len(sorted))
+	for _, path := range sorted {
+		e := mergeEntry{path: path}
+		e.base, e.inBase = baseFiles[path]
+		e.ours, e.inOurs = oursFiles[path]
+		e.theirs, e.inTheirs = theirsFiles[path]
+
+		switch {
+		case sameEntry(e.ours, e.inOurs, e.theirs, e.inTheirs):
+			e.result, e.exists = e.ours, e.inOurs
+		case sameEntry(e.base, e.inBase, e.ours, e.inOurs):
+			e.result, e.exists = e.theirs, e.inTheirs
+		case sameEntry(e.base, e.inBase, e.theirs, e.inTheirs):
+			e.result, e.exists = e.ours, e.inOurs
+		default:
+			e.conflict = true
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// mergeContents merges the files both sides changed line by line, so that
+// only those where the changes overlap are left in conflict. A file merged
+// cleanly is stored as the path's result.
+func mergeContents(repo *repository.Repository, entries []mergeEntry) error {
+	for i := range entries {
+		e := &entries[i]
+		if !e.conflict || !e.inOurs || !e.inTheirs {
+			continue
+		}
+		mode, ok := mergedMode(*e)
+		if !ok {
+			continue
+		}
+		base, ours, theirs, err := conflictContents(repo, *e)
+		if err != nil {
+			return err
+		}
+		if diff.IsBinary(base) || diff.IsBinary(ours) || diff.IsBinary(theirs) {
+			continue
+		}
+
+		fmt.Printf("Auto-merging %s\n", e.path)
+		merged, conflicts := diff.Merge3(base, ours, theirs, "", "")
+		if conflicts > 0 {
+			continue
+		}
+		hash, err := object.WriteRawObject(repo.Path, object.TypeBlob, []byte(merged))
+		if err != nil {
+			return err
+		}
+		e.result = object.TreeEntry{Mode: mode, Name: filepath.Base(e.path), Hash: hash}
+		e.exists, e.conflict = true, false
+	}
+	return nil
+}
+
+// mergedMode returns the mode of a file both sides changed, taking a mode
+// change made on one side only. Files that aren't regular on both sides,
+// or whose mode both sides changed differently, can't be merged.
+func mergedMode(e mergeEntry) (string, bool) {
+	if !isRegularMode(e.ours.Mode) || !isRegularMode(e.theirs.Mode) {
+		return "", false
+	}
+	switch {
+	case e.ours.Mode == e.theirs.Mode:
+		return e.ours.Mode, true
+	case !e.inBase:
+		return "", false
+	case e.base.Mode == e.ours.Mode:
+		return e.theirs.Mode, true
+	case e.base.Mode == e.theirs.Mode:
+		return e.ours.Mode, true
+	}
+	return "", false
+}
+
+// isRegularMode reports whether a mode is that of a regular file
+func isRegularMode(mode string) bool {
+	return mode == object.ModeFile || mode == object.ModeExecutable
+}
+
+// conflictContents reads the base, ours and theirs versions of a path both
+// sides have, with a path the base lacks read as empty
+func conflictContents(repo *repository.Repository, e mergeEntry) (string, string, string, error) {
+	base := ""
+	if e.inBase && isRegularMode(e.base.Mode) {
+		var err error
+		if base, err = readBlobContent(repo.Path, e.base.Hash); err != nil {
+			return "", "", "", err
+		}
+	}
+	ours, err := readBlobContent(repo.Path, e.ours.Hash)
+	if err != nil {
+		return "", "", "", err
+	}
+	theirs, err := readBlobContent(repo.Path, e.theirs.Hash)
+	if err != nil {
+		return "", "", "", err
+	}
+	return base, ours, theirs, nil
+}
+
+// takeMergeResult brings a cleanly merged path into the index and working tree
+func takeMergeResult(repoRoot string, idx *index.Index, e mergeEntry) error {
+	if !e.exists {
+		idx.RemoveEntry(e.path)
+		return removeWorktreeFile(repoRoot, e.path)
+	}
+
+	if err := checkoutEntry(repoRoot, e.path, e.result); err != nil {
+		return err
+	}
+	return stageCheckedOut(repoRoot, idx, e.path, e.result)
+}
+
+// recordConflict stores the three versions of a conflicted path as index
+// stages and leaves a working tree file for the user to resolve
+func recordConflict(repo *repository.Repository, idx *index.Index, e mergeEntry, name string) error {
+	if err := stageConflict(idx, e); err != nil {
+		return err
+	}
+
+	// Modify/delete: leave the surviving version in place
+	if !e.inOurs || !e.inTheirs {
+		if e.inTheirs {
+			return checkoutEntry(repo.Path, e.path, e.theirs)
+		}
+		return nil
+	}
+
+	// Only text files can hold conflict markers; otherwise ours stays
+	if !isRegularMode(e.ours.Mode) || !isRegularMode(e.theirs.Mode) {
+		return nil
+	}
+	base, ours, theirs, err := conflictContents(repo, e)
+	if err != nil {
+		return err
+	}
+	if diff.IsBinary(base) || diff.IsBinary(ours) || diff.IsBinary(theirs) {
+		fmt.Printf("warning: Cannot merge binary files: %s (HEAD vs. %s)\n", e.path, name)
+		return nil
+	}
+	merged, _ := diff.Merge3(base, ours, theirs, "HEAD", name)
+
+	perm := os.FileMode(0644)
+	if e.ours.Mode == "100755" {
+		perm = 0755
+	}
+	if err := os.WriteFile(filepath.Join(repo.Path, e.path), []byte(merged), perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", e.path, err)
+	}
+	return nil
+}
+
+// conflictMessage describes a conflicted path as git does
+func conflictMessage(e mergeEntry, name string) string {
+	switch {
+	case !e.inOurs:
+		return fmt.Sprintf("CONFLICT (modify/delete): %s deleted in HEAD and modified in %s.  Version %s of %s left in tree.", e.path, name, name, e.path)
+	case !e.inTheirs:
+		return fmt.Sprintf("CONFLICT (modify/delete): %s deleted in %s and modified in HEAD.  Version HEAD of %s left in tree.", e.path, name, e.path)
+	case !e.inBase:
+		return fmt.Sprintf("CONFLICT (add/add): Merge conflict in %s", e.path)
+	}
+	return fmt.Sprintf("CONFLICT (content): Merge conflict in %s", e.path)
+}
+
+// stageConflict replaces a path's index entries with the base, ours and
+// theirs versions as stages 1, 2 and 3, leaving out the sides without it
+func stageConflict(idx *index.Index, e mergeEntry) error {
+	idx.RemoveEntry(e.path)
+
+	stages := []struct {
+		entry  object.TreeEntry
+		exists bool
+	}{{e.base, e.inBase}, {e.ours, e.inOurs}, {e.theirs, e.inTheirs}}
+	for i, stage := range stages {
+		if !stage.exists {
+			continue
+		}
+		mode, err := strconv.ParseUint(stage.entry.Mode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid mode %s for %s", stage.entry.Mode, e.path)
+		}
+		if err := idx.AddStage(e.path, i+1, uint32(mode), stage.entry.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// abortMerge restores the index and working tree to the pre-merge HEAD,
+// keeping local changes that predate the merge
+func abortMerge(repo *repository.Repository) error {
+	mergeHead, err := repo.MergeHead()
+	if err != nil {
+		return err
+	}
+	if mergeHead == "" {
+		return fmt.Errorf("there is no merge to abort (MERGE_HEAD missing)")
+	}
+
+	headFiles, idx, err := headAndIndex(repo)
+	if err != nil {
+		return err
+	}
+
+	// Conflicted paths count as unchanged in the working tree so they are
+	// always reset, whatever the user has done to them since
+	indexFiles := indexSnapshot(idx)
+	discard, err := mergeChanges(repo, headFiles, idx)
+	if err != nil {
+		return err
+	}
+	for _, path := range idx.Conflicts() {
+		if w, ok := worktreeEntry(repo.Path, path); ok {
+			indexFiles[path] = w
+		}
+	}
+
+	actions, err := planReset(repo.Path, resetModeMerge, headFiles, indexFiles, headFiles, discard)
+	if err != nil {
+		return err
+	}
+	if err := applyReset(repo.Path, idx, actions); err != nil {
+		return err
+	}
+
+	return repo.ClearMergeState()
+}
+
+// mergeChanges returns the paths a merge in progress has changed: those
+// with conflicts and, since a merge only starts with nothing staged,
+// everything staged
+func mergeChanges(repo *repository.Repository, headFiles map[string]object.TreeEntry, idx *index.Index) (map[string]bool, error) {
+	changed := make(map[string]bool)
+	for _, path := range idx.Conflicts() {
+		changed[path] = true
+	}
+	mergeHead, err := repo.MergeHead()
+	if err != nil || mergeHead == "" {
+		return changed, err
+	}
+	for _, change := range diff.DiffEntries(headFiles, indexSnapshot(idx)) {
+		changed[change.Path()] = true
+	}
+	return changed, nil
+}
+
+// continueMerge commits a merge whose conflicts have all been resolved
+func continueMerge(repo *repository.Repository) error {
+	mergeHead, err := repo.MergeHead()
+	if err != nil {
+		return err
+	}
+	if mergeHead == "" {
+		return fmt.Errorf("there is no merge in progress (MERGE_HEAD missing)")
+	}
+
+	return commitIndex(repo, "", nil)
+}
+
+// headAndIndex returns the flattened HEAD tree and the current index
+func headAndIndex(repo *repository.Repository) (map[string]object.TreeEntry, *index.Index, error) {
+	headTree, err := headTreeHash(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	headFiles, err := diff.FlattenTree(repo.Objects, headTree)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx, err := index.ReadIndex(repo.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	return headFiles, idx, nil
+}
+
+// commitFiles returns the flattened tree of a commit, or no files for ""
+func commitFiles(repo *repository.Repository, commitHash string) (map[string]object.TreeEntry, error) {
+	if commitHash == "" {
+		return diff.FlattenTree(repo.Objects, "")
+	}
+	commit, err := repo.Objects.ReadCommit(commitHash)
+	if err != nil {
+		return nil, err
+	}
+	return diff.FlattenTree(repo.Objects, commit.TreeHash)
+}
+
+// writeSquashMessage prepares the message for committing a squash merge of
+// theirsHash, listing the commits it brings in newest first as log does
+func writeSquashMessage(repo *repository.Repository, headHash, theirsHash string) error {
+	commits, err := repo.CommitRange(headHash, theirsHash)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Squashed commit of the following:\n")
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit, err := repo.Objects.ReadCommit(commits[i])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&sb, "\ncommit %s\n", commits[i])
+		fmt.Fprintf(&sb, "Author: %s\n", commit.Author)
+		fmt.Fprintf(&sb, "Date:   %s\n", commit.Author.When.Format("Mon Jan 2 15:04:05 2006 -0700"))
+		fmt.Fprintf(&sb, "\n    %s\n", strings.ReplaceAll(strings.TrimRight(commit.Message, "\n"), "\n", "\n    "))
+	}
+	return repo.WriteSquashMessage(sb.String())
+}
+
+// mergeMessage returns the default message for merging name
+func mergeMessage(repo *repository.Repository, name string) string {
+	if existing, _ := repo.Refs.GetBranchCommit(name); existing != "" {
+		return fmt.Sprintf("Merge branch '%s'\n", name)
+	}
+	return fmt.Sprintf("Merge commit '%s'\n", name)
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var mergeBaseIsAncestor bool
+
+var mergeBaseCmd = &cobra.Command{
+	Use:   "merge-base (<commit> <commit> | --is-ancestor <commit> <commit>)",
+	Short: "Find a common ancestor for a merge",
+	Long: `Print the best common ancestor of two commits. Exits with status 1 if
+they have none.
+
+With --is-ancestor, print nothing and exit with status 0 if the first commit
+is an ancestor of the second, and 1 if it isn't.`,
+	GroupID: groupPlumbing,
+	Example: `  # Find where two branches diverged
+  gogit merge-base main topic
+
+  # Check whether a commit is already in main
+  gogit merge-base --is-ancestor 1a2b3c4 main`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMergeBase,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeBaseCmd)
+	mergeBaseCmd.Flags().BoolVar(&mergeBaseIsAncestor, "is-ancestor", false, "Check if the first commit is an ancestor of the second")
+}
+
+func runMergeBase(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	var commits [2]string
+	for i, arg := range args {
+		if commits[i], err = repo.ResolveCommit(arg); err != nil {
+			return err
+		}
+	}
+
+	if mergeBaseIsAncestor {
+		isAncestor, err := repo.IsAncestor(commits[0], commits[1])
+		if err != nil {
+			return err
+		}
+		if !isAncestor {
+			return &ExitError{Code: exitNo}
+		}
+		return nil
+	}
+
+	base, err := repo.MergeBase(commits[0], commits[1])
+	if err != nil {
+		return err
+	}
+	if base == "" {
+		return &ExitError{Code: exitNo}
+	}
+	fmt.Println(base)
+	return nil
+}
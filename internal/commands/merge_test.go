@@ -0,0 +1,342 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// divergedBranches commits base on main, then theirs on a side branch and
+// ours on main. A nil content deletes the file.
+func divergedBranches(t *testing.T, base, ours, theirs map[string]*string) {
+	t.Helper()
+	apply := func(message string, files map[string]*string) {
+		for path, content := range files {
+			if content == nil {
+				mustGogit(t, "rm", "-q", path)
+				continue
+			}
+			writeFile(t, path, *content)
+			mustGogit(t, "add", path)
+		}
+		mustGogit(t, "commit", "-m", message)
+	}
+	apply("base", base)
+	mustGogit(t, "checkout", "-b", "side")
+	apply("theirs", theirs)
+	mustGogit(t, "checkout", "main")
+	apply("ours", ours)
+}
+
+func text(s string) *string { return &s }
+
+// stages returns the index stages recorded for path, as "<stage>:<hash>"
+func stages(t *testing.T, path string) []string {
+	t.Helper()
+	var result []string
+	for _, line := range strings.Split(mustGogit(t, "ls-files", "-s"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 4 && fields[3] == path {
+			result = append(result, fields[2])
+		}
+	}
+	return result
+}
+
+func TestMerge(t *testing.T) {
+	for _, c := range []struct {
+		name               string
+		base, ours, theirs map[string]*string
+		wantConflict       bool
+		wantOutput         string
+		wantFile           *string // Content of f after the merge; nil if f is gone
+		wantStages         []string
+	}{
+		{
+			name:       "changes to different lines merge cleanly",
+			base:       map[string]*string{"f": text("1\n2\n3\n4\n5\n")},
+			ours:       map[string]*string{"f": text("one\n2\n3\n4\n5\n")},
+			theirs:     map[string]*string{"f": text("1\n2\n3\n4\nfive\n")},
+			wantOutput: "Auto-merging f\n",
+			wantFile:   text("one\n2\n3\n4\nfive\n"),
+			wantStages: []string{"0"},
+		},
+		{
+			name:         "changes to the same line conflict",
+			base:         map[string]*string{"f": text("a\nb\nc\n")},
+			ours:         map[string]*string{"f": text("a\nours\nc\n")},
+			theirs:       map[string]*string{"f": text("a\ntheirs\nc\n")},
+			wantConflict: true,
+			wantOutput:   "Auto-merging f\nCONFLICT (content): Merge conflict in f\n",
+			wantFile:     text("a\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> side\nc\n"),
+			wantStages:   []string{"1", "2", "3"},
+		},
+		{
+			name:         "add/add",
+			base:         map[string]*string{"other": text("x\n")},
+			ours:         map[string]*string{"f": text("ours\n")},
+			theirs:       map[string]*string{"f": text("theirs\n")},
+			wantConflict: true,
+			wantOutput:   "Auto-merging f\nCONFLICT (add/add): Merge conflict in f\n",
+			wantFile:     text("<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> side\n"),
+			wantStages:   []string{"2", "3"},
+		},
+		{
+			name:         "deleted here, modified there",
+			base:         map[string]*string{"f": text("a\n"), "other": text("x\n")},
+			ours:         map[string]*string{"f": nil},
+			theirs:       map[string]*string{"f": text("theirs\n")},
+			wantConflict: true,
+			wantOutput:   "CONFLICT (modify/delete): f deleted in HEAD and modified in side.  Version side of f left in tree.\n",
+			wantFile:     text("theirs\n"),
+			wantStages:   []string{"1", "3"},
+		},
+		{
+			name:         "modified here, deleted there",
+			base:         map[string]*string{"f": text("a\n"), "other": text("x\n")},
+			ours:         map[string]*string{"f": text("ours\n")},
+			theirs:       map[string]*string{"f": nil},
+			wantConflict: true,
+			wantOutput:   "CONFLICT (modify/delete): f deleted in side and modified in HEAD.  Version HEAD of f left in tree.\n",
+			wantFile:     text("ours\n"),
+			wantStages:   []string{"1", "2"},
+		},
+		{
+			name:       "deleted on one side only",
+			base:       map[string]*string{"f": text("a\n"), "other": text("x\n")},
+			ours:       map[string]*string{"other": text("y\n")},
+			theirs:     map[string]*string{"f": nil},
+			wantStages: nil,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			newTestRepo(t)
+			divergedBranches(t, c.base, c.ours, c.theirs)
+
+			out, err := gogit(t, "merge", "side")
+			var exitErr *ExitError
+			switch {
+			case c.wantConflict && !(errors.As(err, &exitErr) && exitErr.Code == exitNo && exitErr.Err == nil):
+				t.Fatalf("merge returned %v, want a silent exit %d", err, exitNo)
+			case !c.wantConflict && err != nil:
+				t.Fatalf("merge failed: %v", err)
+			}
+
+			if c.wantConflict {
+				want := c.wantOutput + "Automatic merge failed; fix conflicts and then commit the result.\n"
+				if out != want {
+					t.Errorf("output:\n%s\nwant:\n%s", out, want)
+				}
+			} else {
+				if !strings.HasPrefix(out, c.wantOutput) {
+					t.Errorf("output:\n%s\nwant it to start with:\n%s", out, c.wantOutput)
+				}
+				if mustGogit(t, "rev-parse", "HEAD^2") != mustGogit(t, "rev-parse", "side") {
+					t.Error("no merge commit with side as its second parent")
+				}
+			}
+
+			content, err := os.ReadFile("f")
+			switch {
+			case c.wantFile == nil && err == nil:
+				t.Errorf("f = %q, want it deleted", content)
+			case c.wantFile != nil && string(content) != *c.wantFile:
+				t.Errorf("f = %q, want %q", content, *c.wantFile)
+			}
+			if got := stages(t, "f"); strings.Join(got, " ") != strings.Join(c.wantStages, " ") {
+				t.Errorf("f has stages %v, want %v", got, c.wantStages)
+			}
+		})
+	}
+}
+
+// conflictedMerge leaves a merge of side into main stopped on a conflict
+// in f, with a clean change to g merged in
+func conflictedMerge(t *testing.T) (main, side string) {
+	t.Helper()
+	divergedBranches(t,
+		map[string]*string{"f": text("base\n"), "g": text("base\n")},
+		map[string]*string{"f": text("ours\n")},
+		map[string]*string{"f": text("theirs\n"), "g": text("theirs\n")})
+	main = strings.TrimSpace(mustGogit(t, "rev-parse", "main"))
+	side = strings.TrimSpace(mustGogit(t, "rev-parse", "side"))
+	if _, err := gogit(t, "merge", "side"); err == nil {
+		t.Fatal("merge succeeded, want a conflict in f")
+	}
+	return main, side
+}
+
+func TestMergeAbort(t *testing.T) {
+	root := newTestRepo(t)
+	main, _ := conflictedMerge(t)
+
+	mustGogit(t, "merge", "--abort")
+
+	if got := readFile(t, "f"); got != "ours\n" {
+		t.Errorf("f = %q, want the pre-merge content", got)
+	}
+	if got := readFile(t, "g"); got != "base\n" {
+		t.Errorf("g = %q, want the merged change undone", got)
+	}
+	if got := stages(t, "f"); len(got) != 1 || got[0] != "0" {
+		t.Errorf("f has stages %v, want the conflict gone from the index", got)
+	}
+	if out := mustGogit(t, "diff", "--cached", "--name-only"); out != "" {
+		t.Errorf("staged paths = %q, want none", out)
+	}
+	if got := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD")); got != main {
+		t.Errorf("HEAD = %s, want it left at %s", got, main)
+	}
+	for _, name := range []string{"MERGE_HEAD", "MERGE_MSG"} {
+		if _, err := os.Stat(filepath.Join(root, ".gogit", name)); !os.IsNotExist(err) {
+			t.Errorf("%s is still there", name)
+		}
+	}
+	if _, err := gogit(t, "merge", "--abort"); err == nil {
+		t.Error("merge --abort succeeded with no merge in progress")
+	}
+}
+
+func TestMergeContinue(t *testing.T) {
+	for _, finish := range [][]string{{"merge", "--continue"}, {"commit"}} {
+		t.Run(strings.Join(finish, " "), func(t *testing.T) {
+			root := newTestRepo(t)
+			t.Setenv("GIT_EDITOR", "true") // Take the prepared message as it is
+			main, side := conflictedMerge(t)
+
+			if _, err := gogit(t, finish...); err == nil {
+				t.Fatalf("%v succeeded with f still conflicted", finish)
+			}
+
+			writeFile(t, "f", "resolved\n")
+			mustGogit(t, "add", "f")
+			mustGogit(t, finish...)
+
+			for rev, want := range map[string]string{"HEAD^1": main, "HEAD^2": side} {
+				if got := strings.TrimSpace(mustGogit(t, "rev-parse", rev)); got != want {
+					t.Errorf("%s = %s, want %s", rev, got, want)
+				}
+			}
+			if got := readFile(t, "g"); got != "theirs\n" {
+				t.Errorf("g = %q, want side's change", got)
+			}
+			for _, args := range [][]string{{"diff", "--name-only"}, {"diff", "--cached", "--name-only"}} {
+				if out := mustGogit(t, args...); out != "" {
+					t.Errorf("%v = %q, want the resolution committed", args, out)
+				}
+			}
+			if out := plain(mustGogit(t, "log", "--oneline", "-n", "1")); !strings.Contains(out, "Merge branch 'side'") {
+				t.Errorf("merge commit subject: %q, want the prepared merge message", out)
+			}
+			if _, err := os.Stat(filepath.Join(root, ".gogit", "MERGE_HEAD")); !os.IsNotExist(err) {
+				t.Error("MERGE_HEAD is still there")
+			}
+		})
+	}
+}
+
+func TestMergeSquash(t *testing.T) {
+	for _, diverged := range []bool{true, false} {
+		name := "fast-forward"
+		if diverged {
+			name = "diverged"
+		}
+		t.Run(name, func(t *testing.T) {
+			root := newTestRepo(t)
+			t.Setenv("GIT_EDITOR", "true") // Take the prepared message as it is
+			commitWorktree(t, "base", map[string]string{"f": "1\n2\n3\n", "g": "g\n"})
+			mustGogit(t, "checkout", "-b", "side")
+			commitWorktree(t, "side one", map[string]string{"f": "1\n2\nthree\n"})
+			commitWorktree(t, "side two", map[string]string{"new": "n\n"})
+			mustGogit(t, "checkout", "main")
+			if diverged {
+				commitWorktree(t, "main", map[string]string{"f": "one\n2\n3\n"})
+			}
+			head := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+
+			out := mustGogit(t, "merge", "--squash", "side")
+			if !strings.Contains(out, "Squash commit -- not updating HEAD\n") {
+				t.Errorf("merge --squash printed:\n%s", out)
+			}
+			if got := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD")); got != head {
+				t.Errorf("merge --squash moved HEAD to %s", got)
+			}
+			if _, err := os.Stat(filepath.Join(root, ".gogit", "MERGE_HEAD")); !os.IsNotExist(err) {
+				t.Error("merge --squash recorded a merge")
+			}
+			if got, want := mustGogit(t, "diff", "--cached", "--name-status"), "M\tf\nA\tnew\n"; got != want {
+				t.Errorf("staged after merge --squash:\n%s\nwant:\n%s", got, want)
+			}
+
+			mustGogit(t, "commit")
+			if got := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD^1")); got != head {
+				t.Errorf("HEAD^1 = %s, want %s", got, head)
+			}
+			if _, err := gogit(t, "rev-parse", "--verify", "-q", "HEAD^2"); err == nil {
+				t.Error("the squash commit has a second parent")
+			}
+			wantF := "1\n2\nthree\n"
+			if diverged {
+				wantF = "one\n2\nthree\n"
+			}
+			if got := readFile(t, "f"); got != wantF {
+				t.Errorf("f = %q, want %q", got, wantF)
+			}
+			message := plain(mustGogit(t, "log", "-n", "1"))
+			for _, want := range []string{"Squashed commit of the following:", "    side two\n", "    side one\n"} {
+				if !strings.Contains(message, want) {
+					t.Errorf("squash commit:\n%s\nwant %q in its message", message, want)
+				}
+			}
+			if strings.Index(message, "side two") > strings.Index(message, "side one") {
+				t.Errorf("squash commit:\n%s\nwant the squashed commits newest first", message)
+			}
+		})
+	}
+}
+
+func TestMergeFastForwardPolicy(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "base", map[string]string{"f": "1\n"})
+	base := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	mustGogit(t, "checkout", "-b", "ahead")
+	commitWorktree(t, "ahead", map[string]string{"g": "2\n"})
+	ahead := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	mustGogit(t, "checkout", "main")
+	mustGogit(t, "checkout", "-b", "copy")
+	mustGogit(t, "checkout", "main")
+
+	// --no-ff records a merge where a fast-forward would do
+	mustGogit(t, "merge", "--no-ff", "ahead")
+	for rev, want := range map[string]string{"HEAD^1": base, "HEAD^2": ahead} {
+		if got := strings.TrimSpace(mustGogit(t, "rev-parse", rev)); got != want {
+			t.Errorf("after --no-ff %s = %s, want %s", rev, got, want)
+		}
+	}
+	merge := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+
+	// --ff-only takes a fast-forward
+	mustGogit(t, "checkout", "copy")
+	mustGogit(t, "merge", "--ff-only", "ahead")
+	if got := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD")); got != ahead {
+		t.Errorf("after --ff-only HEAD = %s, want %s", got, ahead)
+	}
+
+	// and refuses anything else, leaving HEAD alone
+	commitWorktree(t, "diverge", map[string]string{"h": "3\n"})
+	diverged := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+	if _, err := gogit(t, "merge", "--ff-only", "main"); err == nil {
+		t.Errorf("merge --ff-only of diverged %s succeeded", merge)
+	}
+	if got := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD")); got != diverged {
+		t.Errorf("a refused --ff-only merge moved HEAD to %s", got)
+	}
+
+	for _, args := range [][]string{{"--ff-only", "--no-ff"}, {"--squash", "--no-ff"}} {
+		if _, err := gogit(t, append(append([]string{"merge"}, args...), "main")...); err == nil {
+			t.Errorf("merge %v succeeded", args)
+		}
+	}
+}
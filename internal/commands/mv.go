@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/index"
+)
+
+var (
+	mvForce bool
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv [-f] <source>... <destination>",
+	Short: "Move or rename a file or a directory",
+	Long: `Move or rename tracked files and directories, in the working tree and the
+index at once.
+
+With one source, the source is renamed to <destination> unless
+<destination> is an existing directory, in which case the source is moved
+into it. With several sources, <destination> must be an existing directory.
+Moving a directory moves every tracked file under it.
+
+A file that already exists at the destination is only overwritten with -f.`,
+	GroupID: groupMain,
+	Example: `  # Rename a file
+  gogit mv old.txt new.txt
+
+  # Move files into a directory
+  gogit mv a.go b.go pkg/`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runMv,
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+	mvCmd.Flags().BoolVarP(&mvForce, "force", "f", false, "Overwrite a file that exists at the destination")
+}
+
+// mvPlan is one source being moved: the path on disk and the index
+// entries that go with it
+type mvPlan struct {
+	source, target string
+	entries        map[string]string // Old index path -> new index path
+}
+
+func runMv(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	idx, err := index.ReadIndex(repoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+
+	plans, err := planMoves(repoRoot, idx, args[:len(args)-1], args[len(args)-1])
+	if err != nil {
+		return err
+	}
+
+	// Tracked files overwritten with -f give up their entries first
+	moving := make(map[string]bool)
+	for _, plan := range plans {
+		for oldPath := range plan.entries {
+			moving[oldPath] = true
+		}
+	}
+	for _, plan := range plans {
+		for _, newPath := range plan.entries {
+			if !moving[newPath] {
+				idx.RemoveEntry(newPath)
+			}
+		}
+	}
+
+	for _, plan := range plans {
+		if err := os.Rename(filepath.Join(repoRoot, plan.source), filepath.Join(repoRoot, plan.target)); err != nil {
+			return fmt.Errorf("renaming '%s' failed: %w", plan.source, err)
+		}
+		for oldPath, newPath := range plan.entries {
+			idx.RenameEntry(oldPath, newPath)
+		}
+	}
+
+	if err := idx.Write(repoRoot); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// planMoves checks every source before anything is moved, so a bad
+// argument leaves the working tree and index untouched
+func planMoves(repoRoot string, idx *index.Index, sources []string, destination string) ([]mvPlan, error) {
+	dst, err := repoRelative(repoRoot, destination)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filepath.Join(repoRoot, dst))
+	intoDir := err == nil && info.IsDir()
+	if len(sources) > 1 && !intoDir {
+		return nil, fmt.Errorf("destination '%s' is not a directory", destination)
+	}
+
+	tracked := make(map[string]bool, len(idx.Entries))
+	var paths []string
+	for _, entry := range idx.Entries {
+		if !tracked[entry.Path] {
+			tracked[entry.Path] = true
+			paths = append(paths, entry.Path)
+		}
+	}
+	sort.Strings(paths)
+
+	var plans []mvPlan
+	moved := make(map[string]bool)   // Index paths being moved away
+	targets := make(map[string]bool) // Index paths being moved to
+	for _, source := range sources {
+		src, err := repoRelative(repoRoot, source)
+		if err != nil {
+			return nil, err
+		}
+		target := dst
+		if intoDir {
+			target = filepath.Join(dst, filepath.Base(src))
+		}
+		bad := func(reason string) error {
+			return fmt.Errorf("%s, source=%s, destination=%s", reason, src, target)
+		}
+
+		srcInfo, err := os.Lstat(filepath.Join(repoRoot, src))
+		switch {
+		case src == ".":
+			return nil, bad("can not move the top of the working tree")
+		case err != nil:
+			return nil, bad("bad source")
+		case target == src:
+			return nil, bad("can not move directory into itself")
+		case srcInfo.IsDir() && strings.HasPrefix(target, src+string(filepath.Separator)):
+			return nil, bad("can not move directory into itself")
+		}
+		if info, err := os.Lstat(filepath.Join(repoRoot, target)); err == nil {
+			if !mvForce || info.IsDir() || srcInfo.IsDir() {
+				return nil, bad("destination exists")
+			}
+		}
+		if info, err := os.Stat(filepath.Join(repoRoot, filepath.Dir(target))); err != nil || !info.IsDir() {
+			return nil, bad("destination directory does not exist")
+		}
+
+		plan := mvPlan{source: src, target: target, entries: make(map[string]string)}
+		if srcInfo.IsDir() && !tracked[src] {
+			prefix := src + string(filepath.Separator)
+			for _, path := range paths {
+				if strings.HasPrefix(path, prefix) {
+					plan.entries[path] = filepath.Join(target, strings.TrimPrefix(path, prefix))
+				}
+			}
+			if len(plan.entries) == 0 {
+				return nil, bad("source directory is empty")
+			}
+		} else {
+			if !tracked[src] {
+				return nil, bad("not under version control")
+			}
+			plan.entries[src] = target
+		}
+
+		for oldPath, newPath := range plan.entries {
+			if targets[newPath] {
+				return nil, bad("multiple sources for the same target")
+			}
+			targets[newPath] = true
+			moved[oldPath] = true
+		}
+		plans = append(plans, plan)
+	}
+
+	// A destination may only be taken by a file that is itself moving away,
+	// unless forced
+	for newPath := range targets {
+		if tracked[newPath] && !moved[newPath] && !mvForce {
+			return nil, fmt.Errorf("destination exists in the index, destination=%s", newPath)
+		}
+	}
+
+	return plans, nil
+}
+
+// repoRelative turns a path given on the command line, relative to the
+// current directory, into a path relative to the top of the working tree
+func repoRelative(repoRoot, path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(repoRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("'%s' is outside repository at '%s'", path, repoRoot)
+	}
+	return rel, nil
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMvDirectory(t *testing.T) {
+	files := map[string]string{"src/a": "a\n", "src/deep/b": "b\n", "src/deep/er/c": "c\n", "top": "top\n"}
+
+	t.Run("rename", func(t *testing.T) {
+		newTestRepo(t)
+		commitWorktree(t, "one", files)
+		mustGogit(t, "mv", "src", "dst")
+
+		if got, want := mustGogit(t, "ls-files"), "dst/a\ndst/deep/b\ndst/deep/er/c\ntop\n"; got != want {
+			t.Errorf("index:\n%s\nwant:\n%s", got, want)
+		}
+		if got := readFile(t, "dst/deep/er/c"); got != "c\n" {
+			t.Errorf("dst/deep/er/c = %q", got)
+		}
+		if _, err := os.Stat("src"); !os.IsNotExist(err) {
+			t.Error("src is still there")
+		}
+	})
+
+	t.Run("into an existing directory", func(t *testing.T) {
+		newTestRepo(t)
+		commitWorktree(t, "one", files)
+		if err := os.Mkdir("dst", 0755); err != nil {
+			t.Fatal(err)
+		}
+		mustGogit(t, "mv", "src", "dst")
+
+		if got, want := mustGogit(t, "ls-files"), "dst/src/a\ndst/src/deep/b\ndst/src/deep/er/c\ntop\n"; got != want {
+			t.Errorf("index:\n%s\nwant:\n%s", got, want)
+		}
+		if got := readFile(t, "dst/src/deep/b"); got != "b\n" {
+			t.Errorf("dst/src/deep/b = %q", got)
+		}
+	})
+
+	t.Run("onto tracked files", func(t *testing.T) {
+		newTestRepo(t)
+		commitWorktree(t, "one", map[string]string{"src/a": "a\n", "dst/src/a": "taken\n"})
+		if _, err := gogit(t, "mv", "src", "dst"); err == nil {
+			t.Fatal("mv succeeded over a tracked file")
+		}
+		if got, want := mustGogit(t, "ls-files"), "dst/src/a\nsrc/a\n"; got != want {
+			t.Errorf("index after the refused move:\n%s\nwant:\n%s", got, want)
+		}
+		if got := readFile(t, "dst/src/a"); got != "taken\n" {
+			t.Errorf("dst/src/a = %q, want it untouched", got)
+		}
+		if got := readFile(t, "src/a"); got != "a\n" {
+			t.Errorf("src/a = %q, want it left in place", got)
+		}
+	})
+}
+
+func TestMvFile(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"run.sh": "#!/bin/sh\n", "taken": "taken\n", "dir/x": "x\n"})
+	if err := os.Chmod("run.sh", 0755); err != nil {
+		t.Fatal(err)
+	}
+	mustGogit(t, "add", "run.sh")
+	mustGogit(t, "commit", "-m", "executable")
+
+	// The mode moves with the file, and a directory destination takes it in
+	mustGogit(t, "mv", "run.sh", "dir")
+	staged := mustGogit(t, "ls-files", "-s")
+	executable := false
+	for _, line := range strings.Split(staged, "\n") {
+		executable = executable || strings.HasPrefix(line, "100755 ") && strings.HasSuffix(line, "\tdir/run.sh")
+	}
+	if !executable {
+		t.Errorf("ls-files -s:\n%s\nwant dir/run.sh still executable", staged)
+	}
+	if info, err := os.Stat("dir/run.sh"); err != nil || info.Mode()&0111 == 0 {
+		t.Errorf("dir/run.sh lost its executable bit: %v, %v", info, err)
+	}
+
+	if _, err := gogit(t, "mv", "dir/run.sh", "taken"); err == nil {
+		t.Fatal("mv overwrote a tracked file without -f")
+	}
+	if got := readFile(t, "taken"); got != "taken\n" {
+		t.Errorf("taken = %q after the refused move", got)
+	}
+
+	mustGogit(t, "mv", "-f", "dir/run.sh", "taken")
+	if got := readFile(t, "taken"); got != "#!/bin/sh\n" {
+		t.Errorf("taken = %q after mv -f", got)
+	}
+	if got, want := mustGogit(t, "ls-files"), "dir/x\ntaken\n"; got != want {
+		t.Errorf("index after mv -f:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := gogit(t, "mv", "untracked", "elsewhere"); err == nil {
+		t.Error("mv moved a file that doesn't exist")
+	}
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+)
+
+var patchIDCmd = &cobra.Command{
+	Use:   "patch-id",
+	Short: "Compute unique IDs for patches",
+	Long: `Read patches from standard input, such as the output of "gogit diff" or
+"git log -p", and print "<patch-id> <commit-id>" for each one.
+
+The patch ID is a hash of the changes with whitespace and line numbers
+ignored, summed per file so the order of files doesn't matter (like
+"git patch-id --stable"). Two patches making the same change have the same
+ID. The commit ID comes from the "commit <hash>" line before the patch, and
+is all zeros when there is none.`,
+	GroupID: groupPlumbing,
+	Example: `  # Compute the patch ID of the staged changes
+  gogit diff --cached | gogit patch-id`,
+	Args: cobra.NoArgs,
+	RunE: runPatchID,
+}
+
+func init() {
+	rootCmd.AddCommand(patchIDCmd)
+}
+
+func runPatchID(cmd *cobra.Command, args []string) error {
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	return diff.ReadPatchIDs(os.Stdin, func(patchID, commit string) error {
+		_, err := fmt.Fprintf(out, "%s %s\n", patchID, commit)
+		return err
+	})
+}
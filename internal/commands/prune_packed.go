@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	prunePackedDryRun bool
+	prunePackedQuiet  bool
+)
+
+var prunePackedCmd = &cobra.Command{
+	Use:   "prune-packed [-n] [-q]",
+	Short: "Remove extra objects that are already in pack files",
+	Long: `Remove loose objects that a pack also holds. Each candidate is read from
+both places first, and a loose object whose packed copy differs is kept and
+reported. Unlike gc, no reachability walk is done: loose objects that are not
+in any pack are left alone whether reachable or not.
+
+  -n  Only list the loose objects that would be removed
+  -q  Don't report how many objects were removed`,
+	GroupID: groupPlumbing,
+	Example: `  # Show which loose objects a pack already holds
+  gogit prune-packed -n`,
+	Args: cobra.NoArgs,
+	RunE: runPrunePacked,
+}
+
+func init() {
+	rootCmd.AddCommand(prunePackedCmd)
+	prunePackedCmd.Flags().BoolVarP(&prunePackedDryRun, "dry-run", "n", false, "Show what would be removed without removing it")
+	prunePackedCmd.Flags().BoolVarP(&prunePackedQuiet, "quiet", "q", false, "Suppress the report")
+}
+
+func runPrunePacked(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	loose, err := object.LooseObjects(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, hash := range loose {
+		packed, err := object.PackedDuplicate(repoRoot, hash)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			continue
+		}
+		if !packed {
+			continue
+		}
+
+		if prunePackedDryRun {
+			path := filepath.Join(repo.GitDir, "objects", hash[:2], hash[2:])
+			if rel, err := filepath.Rel(repoRoot, path); err == nil {
+				path = rel
+			}
+			fmt.Printf("rm -f %s\n", path)
+			continue
+		}
+		if err := object.RemoveLoose(repoRoot, hash); err != nil {
+			return err
+		}
+		removed++
+	}
+
+	if !prunePackedDryRun && !prunePackedQuiet {
+		fmt.Printf("Removed %d loose objects already in packs\n", removed)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// loosePaths returns the files of the repository's loose objects, by hash
+func loosePaths(t *testing.T, root string) map[string]string {
+	t.Helper()
+	hashes, err := object.LooseObjects(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make(map[string]string)
+	for _, hash := range hashes {
+		paths[hash] = filepath.Join(root, ".gogit", "objects", hash[:2], hash[2:])
+	}
+	return paths
+}
+
+func TestPrunePacked(t *testing.T) {
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"a": "a\n", "dir/b": "b\n"})
+	commitWorktree(t, "two", map[string]string{"a": "a2\n"})
+
+	// Pack everything, then put the loose copies back as gc leaves them
+	// when it is interrupted
+	saved := make(map[string][]byte)
+	for hash, path := range loosePaths(t, root) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved[hash] = content
+	}
+	mustGogit(t, "gc", "-q")
+	for hash, content := range saved {
+		path := filepath.Join(root, ".gogit", "objects", hash[:2], hash[2:])
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0444); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unpacked := strings.TrimSpace(mustGogit(t, "hash-object", "-w", writeTemp(t, "not packed\n")))
+
+	// A dry run lists the duplicates and removes nothing
+	var want []string
+	for hash := range saved {
+		want = append(want, filepath.Join(".gogit", "objects", hash[:2], hash[2:]))
+	}
+	sort.Strings(want)
+	var listed []string
+	for _, line := range strings.Split(strings.TrimSpace(mustGogit(t, "prune-packed", "-n")), "\n") {
+		listed = append(listed, strings.TrimPrefix(line, "rm -f "))
+	}
+	sort.Strings(listed)
+	if !reflect.DeepEqual(listed, want) {
+		t.Errorf("prune-packed -n listed %v, want %v", listed, want)
+	}
+	if got := len(loosePaths(t, root)); got != len(saved)+1 {
+		t.Fatalf("prune-packed -n left %d loose objects, want %d", got, len(saved)+1)
+	}
+
+	out := mustGogit(t, "prune-packed")
+	if want := fmt.Sprintf("Removed %d loose objects already in packs\n", len(saved)); out != want {
+		t.Errorf("prune-packed printed %q, want %q", out, want)
+	}
+	if left := loosePaths(t, root); len(left) != 1 || left[unpacked] == "" {
+		t.Errorf("loose objects left: %v, want only %s", left, unpacked)
+	}
+	for hash := range saved {
+		if _, err := gogit(t, "cat-file", "-e", hash); err != nil {
+			t.Errorf("%s can't be read after prune-packed: %v", hash, err)
+		}
+	}
+
+	if out := mustGogit(t, "prune-packed", "-q"); out != "" {
+		t.Errorf("prune-packed -q printed %q", out)
+	}
+}
+
+func TestPrunePackedKeepsDifferingCopy(t *testing.T) {
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"a": "a\n"})
+	blob := strings.TrimSpace(mustGogit(t, "hash-object", "a"))
+	mustGogit(t, "gc", "-q")
+
+	// Store another object's data under the packed blob's name
+	other := strings.TrimSpace(mustGogit(t, "hash-object", "-w", writeTemp(t, "other\n")))
+	otherPath := filepath.Join(root, ".gogit", "objects", other[:2], other[2:])
+	content, err := os.ReadFile(otherPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(otherPath); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, ".gogit", "objects", blob[:2], blob[2:])
+	writeFile(t, path, string(content))
+
+	mustGogit(t, "prune-packed", "-q")
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("the loose copy that differs from the pack was removed: %v", err)
+	}
+}
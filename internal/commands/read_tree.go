@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/index"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var readTreeMerge bool
+
+var readTreeCmd = &cobra.Command{
+	Use:   "read-tree [-m] <tree-ish> | -m <base> <ours> <theirs>",
+	Short: "Read tree information into the index",
+	Long: `Replace the index with the contents of a tree. The working tree is not
+touched.
+
+With -m and three trees, merge them into the index instead: a path that is
+the same in ours and theirs, or that only one side changed relative to base,
+gets a single resolved entry with the result (or none if the result is a
+deletion). A path both sides changed differently is recorded as conflict
+stages 1 (base), 2 (ours) and 3 (theirs), as merge leaves it.
+
+With -m, entries that end up unchanged keep their cached stat data, so the
+files that match them need not be re-read.`,
+	GroupID: groupPlumbing,
+	Example: `  # Load HEAD's tree into the index
+  gogit read-tree HEAD
+
+  # Merge two branches into the index over their merge base
+  gogit read-tree -m $(gogit merge-base main topic) main topic`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: runReadTree,
+}
+
+func init() {
+	rootCmd.AddCommand(readTreeCmd)
+	readTreeCmd.Flags().BoolVarP(&readTreeMerge, "merge", "m", false, "Merge the trees into the index")
+}
+
+func runReadTree(cmd *cobra.Command, args []string) error {
+	if len(args) == 2 || (len(args) == 3 && !readTreeMerge) {
+		return fmt.Errorf("read-tree takes one tree, or three trees with -m")
+	}
+
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	old, err := index.ReadIndex(repoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	if readTreeMerge && len(old.Conflicts()) > 0 {
+		return fmt.Errorf("you need to resolve your current index first")
+	}
+
+	trees := make([]map[string]object.TreeEntry, len(args))
+	for i, arg := range args {
+		tree, err := repo.ResolveTree(arg)
+		if err != nil {
+			return fmt.Errorf("failed to unpack tree object %s: %w", arg, err)
+		}
+		if trees[i], err = diff.FlattenTree(repo.Objects, tree); err != nil {
+			return err
+		}
+	}
+
+	var idx *index.Index
+	if len(trees) == 3 {
+		idx, err = mergeTreesIntoIndex(trees[0], trees[1], trees[2])
+	} else {
+		idx, err = treeIndex(trees[0])
+	}
+	if err != nil {
+		return err
+	}
+	if readTreeMerge {
+		keepStatData(idx, old)
+	}
+
+	if err := idx.Write(repoRoot); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// treeIndex returns an index holding exactly the files of a tree
+func treeIndex(files map[string]object.TreeEntry) (*index.Index, error) {
+	idx := index.NewIndex()
+	for path, entry := range files {
+		mode, err := strconv.ParseUint(entry.Mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mode %s for %s", entry.Mode, path)
+		}
+		if err := idx.AddBlob(path, uint32(mode), entry.Hash); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// mergeTreesIntoIndex returns the index a three-way merge of the trees
+// produces: resolved entries for the paths that merge cleanly and conflict
+// stages for the rest
+func mergeTreesIntoIndex(baseFiles, oursFiles, theirsFiles map[string]object.TreeEntry) (*index.Index, error) {
+	idx := index.NewIndex()
+	for _, e := range mergeFiles(baseFiles, oursFiles, theirsFiles) {
+		if e.conflict {
+			if err := stageConflict(idx, e); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !e.exists {
+			continue
+		}
+		mode, err := strconv.ParseUint(e.result.Mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mode %s for %s", e.result.Mode, e.path)
+		}
+		if err := idx.AddBlob(e.path, uint32(mode), e.result.Hash); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// keepStatData copies the cached stat data of old's resolved entries onto
+// the resolved entries of idx that have the same blob and mode
+func keepStatData(idx, old *index.Index) {
+	cached := make(map[string]index.Entry, len(old.Entries))
+	for _, entry := range old.Entries {
+		if entry.Stage() == 0 {
+			cached[entry.Path] = entry
+		}
+	}
+	for i := range idx.Entries {
+		entry := &idx.Entries[i]
+		prev, ok := cached[entry.Path]
+		if ok && entry.Stage() == 0 && prev.Hash == entry.Hash && prev.Mode == entry.Mode {
+			*entry = prev
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadTreeThreeWayMerge(t *testing.T) {
+	newTestRepo(t)
+	divergedBranches(t,
+		map[string]*string{
+			"same": text("s\n"), "ours": text("o\n"), "theirs": text("t\n"),
+			"both": text("b\n"), "gone": text("g\n"), "conflict": text("c\n"),
+		},
+		map[string]*string{"ours": text("o2\n"), "both": text("b2\n"), "conflict": text("ours\n")},
+		map[string]*string{"theirs": text("t2\n"), "both": text("b2\n"), "gone": nil, "conflict": text("theirs\n")},
+	)
+	base := strings.TrimSpace(mustGogit(t, "merge-base", "main", "side"))
+	worktree := readFile(t, "conflict")
+
+	mustGogit(t, "read-tree", "-m", base, "main", "side")
+
+	blob := func(content string) string {
+		return strings.TrimSpace(mustGogit(t, "hash-object", writeTemp(t, content)))
+	}
+	want := "100644 " + blob("b2\n") + " 0\tboth\n" +
+		"100644 " + blob("c\n") + " 1\tconflict\n" +
+		"100644 " + blob("ours\n") + " 2\tconflict\n" +
+		"100644 " + blob("theirs\n") + " 3\tconflict\n" +
+		"100644 " + blob("o2\n") + " 0\tours\n" +
+		"100644 " + blob("s\n") + " 0\tsame\n" +
+		"100644 " + blob("t2\n") + " 0\ttheirs\n"
+	if got := mustGogit(t, "ls-files", "-s"); got != want {
+		t.Errorf("index after read-tree -m:\n%s\nwant:\n%s", got, want)
+	}
+	if got := readFile(t, "conflict"); got != worktree {
+		t.Errorf("read-tree -m changed the working tree: conflict = %q", got)
+	}
+}
+
+func TestReadTreeThreeWayMergeClean(t *testing.T) {
+	newTestRepo(t)
+	divergedBranches(t,
+		map[string]*string{"a": text("a\n"), "b": text("b\n")},
+		map[string]*string{"a": text("a2\n")},
+		map[string]*string{"b": text("b2\n"), "c": text("c\n")},
+	)
+	base := strings.TrimSpace(mustGogit(t, "merge-base", "main", "side"))
+
+	mustGogit(t, "read-tree", "-m", base, "main", "side")
+	if out := mustGogit(t, "ls-files", "-u"); out != "" {
+		t.Errorf("a clean merge left unmerged entries:\n%s", out)
+	}
+
+	// The merged index is what the working tree needs to become
+	writeFile(t, "b", "b2\n")
+	writeFile(t, "c", "c\n")
+	if out := mustGogit(t, "diff"); out != "" {
+		t.Errorf("diff after writing the merged files:\n%s", out)
+	}
+	if out := mustGogit(t, "diff", "--cached", "--name-status"); out != "M\tb\nA\tc\n" {
+		t.Errorf("diff --cached --name-status:\n%s\nwant theirs' changes staged", out)
+	}
+}
+
+func TestReadTreeArgs(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	for _, args := range [][]string{
+		{"read-tree", "HEAD", "HEAD"},
+		{"read-tree", "HEAD", "HEAD", "HEAD"},
+	} {
+		if _, err := gogit(t, args...); err == nil {
+			t.Errorf("%v succeeded", args)
+		}
+	}
+}
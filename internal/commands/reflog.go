@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var reflogCmd = &cobra.Command{
+	Use:   "reflog [show] [<ref>]",
+	Short: "Show where HEAD and branches have been",
+	Long: `Print a ref's reflog, the record of every update of it, newest first:
+
+  <commit> <ref>@{<n>}: <message>
+
+where <commit> is what the ref pointed to after the update and the message
+says what made it, such as a commit, checkout, merge or reset. Without a
+ref, show HEAD's reflog; a branch name is looked up under refs/heads/ and
+refs/remotes/ as well.
+
+The reflog keeps commits that no branch leads to any more within reach, so
+work lost to a reset or to committing on a detached HEAD can be found there
+and recovered with "gogit branch" or "gogit reset". Any command taking a
+revision accepts <ref>@{<n>} for where the ref was n updates ago, and
+@{<n>} for the current branch.`,
+	GroupID: groupAncillary,
+	Example: `  # See where HEAD has been
+  gogit reflog
+
+  # See how a branch moved
+  gogit reflog show main
+
+  # Undo a reset, going back to where HEAD was before it
+  gogit reset --hard HEAD@{1}`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runReflog,
+}
+
+func init() {
+	rootCmd.AddCommand(reflogCmd)
+}
+
+func runReflog(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 && args[0] == "show" {
+		args = args[1:]
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+	name := "HEAD"
+	if len(args) == 1 {
+		name = args[0]
+	}
+
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	ref, err := repo.ReflogRef(name)
+	if err != nil {
+		return err
+	}
+	entries, err := repo.Refs.ReadReflog(ref)
+	if err != nil {
+		return err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		fmt.Printf("%s %s@{%d}: %s\n", entries[i].NewHash[:7], name, len(entries)-1-i, entries[i].Message)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestReflog(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	one := mustGogit(t, "rev-parse", "HEAD")[:7]
+	commitWorktree(t, "two\n\nbody", map[string]string{"f": "b\n"})
+	two := mustGogit(t, "rev-parse", "HEAD")[:7]
+	mustGogit(t, "checkout", "-b", "side")
+	mustGogit(t, "checkout", "main")
+	mustGogit(t, "reset", "--hard", "HEAD~1")
+
+	head := fmt.Sprintf(`%[1]s HEAD@{0}: reset: moving to HEAD~1
+%[2]s HEAD@{1}: checkout: moving from side to main
+%[2]s HEAD@{2}: checkout: moving from main to side
+%[2]s HEAD@{3}: commit: two
+%[1]s HEAD@{4}: commit (initial): one
+`, one, two)
+	if got := mustGogit(t, "reflog"); got != head {
+		t.Errorf("reflog:\n%s\nwant:\n%s", got, head)
+	}
+
+	main := fmt.Sprintf(`%[1]s main@{0}: reset: moving to HEAD~1
+%[2]s main@{1}: commit: two
+%[1]s main@{2}: commit (initial): one
+`, one, two)
+	if got := mustGogit(t, "reflog", "show", "main"); got != main {
+		t.Errorf("reflog show main:\n%s\nwant:\n%s", got, main)
+	}
+	if got, want := mustGogit(t, "reflog", "side"), two+" side@{0}: branch: Created from HEAD\n"; got != want {
+		t.Errorf("reflog side = %q, want %q", got, want)
+	}
+
+	if _, err := gogit(t, "reflog", "nope"); err == nil {
+		t.Error("reflog of a missing branch succeeded")
+	}
+}
+
+func TestResetToReflogEntry(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	one := mustGogit(t, "rev-parse", "HEAD")
+	commitWorktree(t, "two", map[string]string{"f": "b\n"})
+	two := mustGogit(t, "rev-parse", "HEAD")
+
+	mustGogit(t, "reset", "--hard", "HEAD~1")
+	mustGogit(t, "reset", "--hard", "HEAD@{1}")
+	if got := mustGogit(t, "rev-parse", "HEAD"); got != two {
+		t.Errorf("HEAD after reset to HEAD@{1} = %s, want the commit reset away from, %s", got, two)
+	}
+	if got := readFile(t, "f"); got != "b\n" {
+		t.Errorf("f = %q, want it restored", got)
+	}
+
+	for rev, want := range map[string]string{
+		"HEAD@{0}":    two,
+		"HEAD@{2}":    two,
+		"main@{1}":    one,
+		"@{1}":        one,
+		"HEAD@{2}~1":  one,
+		"main@{3}^{}": one,
+	} {
+		if got := mustGogit(t, "rev-parse", rev); got != want {
+			t.Errorf("rev-parse %s = %s, want %s", rev, got, want)
+		}
+	}
+	for _, rev := range []string{"HEAD@{4}", "main@{yesterday}", "nope@{0}"} {
+		if _, err := gogit(t, "rev-parse", rev); err == nil {
+			t.Errorf("rev-parse %s succeeded", rev)
+		}
+	}
+}
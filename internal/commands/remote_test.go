@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"io"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// git runs real git in dir and returns its output
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_NOSYSTEM=1")
+	out, err := cmd.Output()
+	if err != nil {
+		msg := err.Error()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			msg = string(exitErr.Stderr)
+		}
+		t.Fatalf("git %v: %s", args, msg)
+	}
+	return string(out)
+}
+
+// newRemote makes a bare repository with real git, served over smart HTTP
+// by git http-backend, and returns its path and URL. Its history is a
+// commit on main with a.txt, a second changing a.txt and adding b.txt, a
+// topic branch off the first, and an annotated tag v1 on main.
+func newRemote(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	bare := filepath.Join(dir, "remote.git")
+
+	git(t, dir, "init", "-q", "-b", "main", work)
+	writeFile(t, filepath.Join(work, "a.txt"), "a\n")
+	git(t, work, "add", ".")
+	git(t, work, "commit", "-q", "-m", "one")
+	git(t, work, "branch", "topic")
+	writeFile(t, filepath.Join(work, "a.txt"), "a2\n")
+	writeFile(t, filepath.Join(work, "b.txt"), "b\n")
+	git(t, work, "add", ".")
+	git(t, work, "commit", "-q", "-m", "two")
+	git(t, work, "tag", "-a", "-m", "release", "v1")
+	git(t, dir, "clone", "-q", "--bare", work, bare)
+	git(t, bare, "config", "uploadpack.allowFilter", "true")
+	git(t, bare, "config", "uploadpack.allowReachableSHA1InWant", "true")
+
+	backend := strings.TrimSpace(git(t, dir, "--exec-path"))
+	server := httptest.NewServer(&cgi.Handler{
+		Path:   filepath.Join(backend, "git-http-backend"),
+		Env:    []string{"GIT_PROJECT_ROOT=" + dir, "GIT_HTTP_EXPORT_ALL=1"},
+		Stderr: io.Discard,
+	})
+	t.Cleanup(server.Close)
+	return bare, server.URL + "/remote.git"
+}
+
+func TestLsRemote(t *testing.T) {
+	bare, url := newRemote(t)
+	chdir(t, t.TempDir())
+
+	all := git(t, bare, "ls-remote", ".")
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{nil, all},
+		{[]string{"--heads"}, git(t, bare, "ls-remote", "--heads", ".")},
+		{[]string{"--tags"}, git(t, bare, "ls-remote", "--tags", ".")},
+		{[]string{"--heads", "--tags"}, git(t, bare, "ls-remote", "--heads", "--tags", ".")},
+	} {
+		args := append(append([]string{"ls-remote"}, c.args...), url)
+		if got := mustGogit(t, args...); got != c.want {
+			t.Errorf("%v:\n%s\nwant what git lists:\n%s", c.args, got, c.want)
+		}
+	}
+
+	if _, err := gogit(t, "ls-remote", strings.Replace(url, "remote.git", "nosuch.git", 1)); err == nil {
+		t.Error("ls-remote of a missing repository succeeded")
+	}
+}
+
+func TestBloblessClone(t *testing.T) {
+	_, url := newRemote(t)
+	t.Setenv("HOME", t.TempDir())
+	chdir(t, t.TempDir())
+	mustGogit(t, "clone", "--filter=blob:none", url, "work")
+	chdir(t, "work")
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The checkout fetched what it needed
+	if got := readFile(t, "a.txt"); got != "a2\n" {
+		t.Errorf("a.txt = %q", got)
+	}
+	if out := plain(mustGogit(t, "status")); !strings.Contains(out, "nothing to commit") {
+		t.Errorf("status:\n%s", out)
+	}
+
+	// a.txt's first version is only in history, so it wasn't downloaded
+	old := strings.TrimSpace(mustGogit(t, "hash-object", writeTemp(t, "a\n")))
+	if object.Exists(root, old) {
+		t.Fatal("the clone has a blob the filter should have left out")
+	}
+	if got := mustGogit(t, "cat-file", "-p", old); got != "a\n" {
+		t.Errorf("cat-file -p of the missing blob = %q, want it fetched", got)
+	}
+	if !object.Exists(root, old) {
+		t.Error("the fetched blob wasn't kept")
+	}
+
+	if value := strings.TrimSpace(mustGogit(t, "config", "remote.origin.promisor")); value != "true" {
+		t.Errorf("remote.origin.promisor = %q, want the remote recorded as a promisor", value)
+	}
+}
+
+func TestFetchPrune(t *testing.T) {
+	bare, url := newRemote(t)
+	t.Setenv("HOME", t.TempDir())
+	chdir(t, t.TempDir())
+	mustGogit(t, "clone", url, "work")
+	chdir(t, "work")
+
+	remoteRefs := func() string {
+		return mustGogit(t, "for-each-ref", "--format=%(refname)", "refs/remotes")
+	}
+	before := remoteRefs()
+	if !strings.Contains(before, "refs/remotes/origin/topic\n") {
+		t.Fatalf("the clone has no origin/topic:\n%s", before)
+	}
+
+	git(t, bare, "branch", "-D", "topic")
+
+	// A plain fetch leaves the stale ref alone
+	mustGogit(t, "fetch")
+	if got := remoteRefs(); got != before {
+		t.Errorf("fetch without --prune changed the remote-tracking refs:\n%s\nwas:\n%s", got, before)
+	}
+
+	out := mustGogit(t, "fetch", "--prune")
+	if !strings.Contains(out, "[deleted]") || !strings.Contains(out, "origin/topic") {
+		t.Errorf("fetch --prune didn't report the deletion:\n%s", out)
+	}
+	want := strings.Replace(before, "refs/remotes/origin/topic\n", "", 1)
+	if got := remoteRefs(); got != want {
+		t.Errorf("after fetch --prune the remote-tracking refs are:\n%s\nwant:\n%s", got, want)
+	}
+	if !strings.Contains(want, "refs/remotes/origin/main\n") {
+		t.Errorf("fetch --prune removed origin/main:\n%s", want)
+	}
+
+	// Nothing is left to prune
+	if out := mustGogit(t, "fetch", "--prune"); out != "" {
+		t.Errorf("a second fetch --prune printed:\n%s", out)
+	}
+}
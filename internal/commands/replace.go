@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	replaceDelete bool
+	replaceForce  bool
+	replaceList   bool
+)
+
+var replaceCmd = &cobra.Command{
+	Use:   "replace [-f] <object> <replacement> | -d <object>... | [-l]",
+	Short: "Create, list, or delete refs to replace objects",
+	Long: `Record that <replacement> stands in for <object>, with a ref named
+refs/replace/<object>. Reading <object> then returns <replacement>, so that,
+for instance, a commit can be given different parents without rewriting
+the history built on it. Both must be of the same type.
+
+Replacements are followed by every command except gc and commit-graph,
+which work with objects as stored; --no-replace-objects, or setting
+GIT_NO_REPLACE_OBJECTS, turns them off for any command.
+
+-d deletes replace refs. Without arguments, or with -l, the replaced
+objects are listed.`,
+	GroupID: groupAncillary,
+	Example: `  # Make reads of one commit return another
+  gogit replace 1a2b3c4 5d6e7f8
+
+  # List and remove replacements
+  gogit replace -l
+  gogit replace -d 1a2b3c4`,
+	RunE: runReplace,
+}
+
+func init() {
+	rootCmd.AddCommand(replaceCmd)
+	replaceCmd.Flags().BoolVarP(&replaceDelete, "delete", "d", false, "Delete replace refs")
+	replaceCmd.Flags().BoolVarP(&replaceForce, "force", "f", false, "Overwrite an existing replace ref")
+	replaceCmd.Flags().BoolVarP(&replaceList, "list", "l", false, "List replaced objects")
+}
+
+func runReplace(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+	// Objects are named and checked as stored
+	repo.ReadAsStored()
+
+	switch {
+	case replaceDelete:
+		if len(args) == 0 {
+			return fmt.Errorf("-d needs at least one argument")
+		}
+		for _, arg := range args {
+			hash, err := repo.ResolveRevision(arg)
+			if err != nil {
+				return fmt.Errorf("failed to resolve '%s' as a valid ref", arg)
+			}
+			ref := "refs/replace/" + hash
+			if existing, _ := repo.Refs.ResolveRef(ref); existing == "" {
+				return fmt.Errorf("replace ref '%s' not found", hash)
+			}
+			if err := repo.Refs.DeleteRef(ref); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted replace ref '%s'\n", hash)
+		}
+		return nil
+
+	case replaceList || len(args) == 0:
+		if len(args) > 0 {
+			return fmt.Errorf("-l takes no arguments")
+		}
+		var replaced []string
+		for hash := range repository.ReplaceRefs(repoRoot) {
+			replaced = append(replaced, hash)
+		}
+		sort.Strings(replaced)
+		for _, hash := range replaced {
+			fmt.Println(hash)
+		}
+		return nil
+
+	case len(args) != 2:
+		return fmt.Errorf("bad number of arguments")
+	}
+
+	hash, err := repo.ResolveRevision(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to resolve '%s' as a valid ref", args[0])
+	}
+	replacement, err := repo.ResolveRevision(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to resolve '%s' as a valid ref", args[1])
+	}
+	if hash == replacement {
+		return fmt.Errorf("new object is the same as the old one: '%s'", hash)
+	}
+
+	objType, _, err := repo.Objects.Info(hash)
+	if err != nil {
+		return err
+	}
+	replacementType, _, err := repo.Objects.Info(replacement)
+	if err != nil {
+		return err
+	}
+	if objType != replacementType {
+		return fmt.Errorf("objects must be of the same type.\n'%s' points to a replaced object of type '%s'\nwhile '%s' points to a replacement object of type '%s'", args[0], objType, args[1], replacementType)
+	}
+
+	ref := "refs/replace/" + hash
+	if existing, _ := repo.Refs.ResolveRef(ref); existing != "" && !replaceForce {
+		return fmt.Errorf("replace ref '%s' already exists", ref)
+	}
+	return repo.Refs.UpdateRef(ref, replacement)
+}
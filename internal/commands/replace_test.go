@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+func TestReplace(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "1\n"})
+	commitWorktree(t, "two", map[string]string{"f": "2\n"})
+	commitWorktree(t, "three", map[string]string{"f": "3\n"})
+	two := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD~1"))
+	tree := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD~1^{tree}"))
+
+	// A root commit standing in for "two" cuts "one" out of history
+	replacement := writeObject(t, object.NewCommit(tree, nil, "Test <test@example.com>", "two, rewritten\n"))
+	mustGogit(t, "replace", two, replacement)
+
+	subjects := func(args ...string) string {
+		t.Helper()
+		var result []string
+		for _, line := range strings.Split(strings.TrimSpace(plain(mustGogit(t, append(args, "log", "--oneline")...))), "\n") {
+			_, subject, _ := strings.Cut(line, " ")
+			result = append(result, subject)
+		}
+		return strings.Join(result, ",")
+	}
+	if got, want := subjects(), "three,two, rewritten"; got != want {
+		t.Errorf("log: %s, want %s", got, want)
+	}
+	// Commands that work with objects as stored leave replacement on for
+	// whatever runs after them
+	mustGogit(t, "gc")
+	mustGogit(t, "commit-graph", "write")
+	if got, want := subjects(), "three,two, rewritten"; got != want {
+		t.Errorf("log after gc and commit-graph write: %s, want %s", got, want)
+	}
+	if got, want := subjects("--no-replace-objects"), "three,two,one"; got != want {
+		t.Errorf("log --no-replace-objects: %s, want %s", got, want)
+	}
+	t.Setenv("GIT_NO_REPLACE_OBJECTS", "1")
+	if got, want := subjects(), "three,two,one"; got != want {
+		t.Errorf("log with GIT_NO_REPLACE_OBJECTS: %s, want %s", got, want)
+	}
+	t.Setenv("GIT_NO_REPLACE_OBJECTS", "")
+
+	if out := mustGogit(t, "cat-file", "-p", two); !strings.HasSuffix(out, "\ntwo, rewritten\n") {
+		t.Errorf("cat-file -p of the replaced commit:\n%s\nwant the replacement", out)
+	}
+	if got := mustGogit(t, "replace", "-l"); got != two+"\n" {
+		t.Errorf("replace -l: %q, want %q", got, two+"\n")
+	}
+
+	if _, err := gogit(t, "replace", two, "HEAD"); err == nil {
+		t.Error("replace overwrote an existing replacement without -f")
+	}
+	if _, err := gogit(t, "replace", "HEAD", tree); err == nil {
+		t.Error("replace accepted a tree for a commit")
+	}
+
+	mustGogit(t, "replace", "-d", two)
+	if got, want := subjects(), "three,two,one"; got != want {
+		t.Errorf("log after replace -d: %s, want %s", got, want)
+	}
+	if got := mustGogit(t, "replace"); got != "" {
+		t.Errorf("replace after -d: %q, want nothing", got)
+	}
+}
+
+func TestReplacedObjectInfo(t *testing.T) {
+	newTestRepo(t)
+	original := writeObject(t, object.NewBlob([]byte("short\n")))
+	replacement := writeObject(t, object.NewBlob([]byte("a longer replacement\n")))
+	mustGogit(t, "replace", original, replacement)
+
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"cat-file", "-t", original}, "blob\n"},
+		{[]string{"cat-file", "-s", original}, "21\n"},
+		{[]string{"cat-file", "-p", original}, "a longer replacement\n"},
+		{[]string{"--no-replace-objects", "cat-file", "-s", original}, "6\n"},
+		{[]string{"--no-replace-objects", "cat-file", "-p", original}, "short\n"},
+	} {
+		if got := mustGogit(t, c.args...); got != c.want {
+			t.Errorf("%v = %q, want %q", c.args, got, c.want)
+		}
+	}
+	if _, err := gogit(t, "cat-file", "-e", original); err != nil {
+		t.Errorf("cat-file -e of a replaced object: %v", err)
+	}
+}
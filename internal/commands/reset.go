@@ -0,0 +1,431 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/index"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	resetSoft  bool
+	resetMixed bool
+	resetHard  bool
+	resetMerge bool
+	resetKeep  bool
+	resetForce bool
+)
+
+var resetCmd = &cobra.Command{
+	Use:   "reset [--soft | --mixed | --hard [-f] | --merge | --keep] [<commit>]",
+	Short: "Reset current HEAD to the specified state",
+	Long: `Move the current branch, or HEAD itself if detached, to <commit> (default
+HEAD), and update the index and working tree according to the mode:
+
+  --soft   Leave the index and working tree alone, so that the changes
+           between <commit> and the old HEAD end up staged.
+  --mixed  Reset the index to <commit> but leave the working tree alone, so
+           that the changes end up unstaged. This is the default.
+  --hard   Reset the index and working tree to <commit>. Refuses if there
+           are changes that aren't committed, which would be lost, unless
+           -f is given.
+  --merge  Reset the index and the files that differ between <commit> and HEAD,
+           keeping unstaged changes, and staged changes to other files.
+           Aborts if a file that differs between <commit> and the index has
+           unstaged changes, or one that differs between <commit> and HEAD
+           has staged changes. Changes made by a merge in progress are
+           always reset.
+  --keep   Reset the index and the files that differ between <commit> and HEAD.
+           Aborts if any of those files has local changes.
+
+The old HEAD is saved in ORIG_HEAD, and a merge in progress is forgotten.`,
+	GroupID: groupMain,
+	Example: `  # Unstage everything, keeping the changes in the working tree
+  gogit reset
+
+  # Undo the last commit but keep its changes staged
+  gogit reset --soft HEAD~1
+
+  # Throw away the last commit and any local changes
+  gogit reset --hard -f HEAD~1
+
+  # Undo the last commit, keeping unrelated local changes
+  gogit reset --keep HEAD~1`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runReset,
+}
+
+func init() {
+	rootCmd.AddCommand(resetCmd)
+	resetCmd.Flags().BoolVar(&resetSoft, "soft", false, "Move HEAD only, leaving index and working tree")
+	resetCmd.Flags().BoolVar(&resetMixed, "mixed", false, "Reset the index but not the working tree (default)")
+	resetCmd.Flags().BoolVar(&resetHard, "hard", false, "Reset index and working tree")
+	resetCmd.Flags().BoolVar(&resetMerge, "merge", false, "Reset index and working tree, keeping unstaged changes")
+	resetCmd.Flags().BoolVar(&resetKeep, "keep", false, "Reset index and working tree, aborting if local changes would be lost")
+	resetCmd.Flags().BoolVarP(&resetForce, "force", "f", false, "With --hard, discard uncommitted changes")
+}
+
+// Reset modes
+const (
+	resetModeSoft  = "soft"
+	resetModeMixed = "mixed"
+	resetModeHard  = "hard"
+	resetModeMerge = "merge"
+	resetModeKeep  = "keep"
+)
+
+// resetAction describes how reset updates a single path
+type resetAction struct {
+	path        string
+	target      object.TreeEntry
+	inTarget    bool
+	useTarget   bool // Working tree ends up matching the target
+	setWorktree bool // Working tree file must be rewritten to get there
+}
+
+// resetMode returns the mode chosen by the flags, mixed if none is
+func resetMode() (string, error) {
+	mode := resetModeMixed
+	chosen := 0
+	for _, flag := range []struct {
+		set  bool
+		mode string
+	}{
+		{resetSoft, resetModeSoft},
+		{resetMixed, resetModeMixed},
+		{resetHard, resetModeHard},
+		{resetMerge, resetModeMerge},
+		{resetKeep, resetModeKeep},
+	} {
+		if flag.set {
+			mode = flag.mode
+			chosen++
+		}
+	}
+	if chosen > 1 {
+		return "", fmt.Errorf("--soft, --mixed, --hard, --merge and --keep are mutually exclusive")
+	}
+	if resetForce && mode != resetModeHard {
+		return "", fmt.Errorf("-f only makes sense with --hard")
+	}
+	return mode, nil
+}
+
+func runReset(cmd *cobra.Command, args []string) error {
+	mode, err := resetMode()
+	if err != nil {
+		return err
+	}
+
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	headHash, err := repo.Refs.ResolveHead()
+	if err != nil {
+		return err
+	}
+	if headHash == "" {
+		return fmt.Errorf("cannot reset: no commits yet")
+	}
+
+	rev := "HEAD"
+	if len(args) > 0 {
+		rev = args[0]
+	}
+
+	targetHash, err := repo.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+	targetCommit, err := repo.Objects.ReadCommit(targetHash)
+	if err != nil {
+		return err
+	}
+
+	headFiles, idx, err := headAndIndex(repo)
+	if err != nil {
+		return err
+	}
+	targetFiles, err := diff.FlattenTree(repo.Objects, targetCommit.TreeHash)
+	if err != nil {
+		return err
+	}
+
+	// Conflicted paths have no entry of their own, and are given the one in
+	// the working tree so that every mode that touches the index resolves
+	// them
+	indexFiles := indexSnapshot(idx)
+	conflicts := idx.Conflicts()
+	for _, path := range conflicts {
+		if w, ok := worktreeEntry(repoRoot, path); ok {
+			indexFiles[path] = w
+		} else {
+			indexFiles[path] = object.TreeEntry{}
+		}
+	}
+
+	if len(conflicts) > 0 && (mode == resetModeSoft || mode == resetModeKeep) {
+		return fmt.Errorf("cannot do a %s reset in the middle of a merge", mode)
+	}
+
+	var actions []resetAction
+	switch mode {
+	case resetModeMixed:
+		actions = planMixedReset(indexFiles, targetFiles)
+	case resetModeHard:
+		if !resetForce {
+			if err := checkHardReset(repo, idx, headFiles); err != nil {
+				return err
+			}
+		}
+		actions = planHardReset(repoRoot, indexFiles, targetFiles)
+	case resetModeMerge, resetModeKeep:
+		discard, err := mergeChanges(repo, headFiles, idx)
+		if err != nil {
+			return err
+		}
+		if actions, err = planReset(repoRoot, mode, headFiles, indexFiles, targetFiles, discard); err != nil {
+			return fmt.Errorf("%w\ncould not reset index file to revision '%s'", err, rev)
+		}
+	}
+
+	if mode != resetModeSoft {
+		if err := applyReset(repoRoot, idx, actions); err != nil {
+			return err
+		}
+	}
+
+	if err := repo.Refs.UpdateRef("ORIG_HEAD", headHash); err != nil {
+		return fmt.Errorf("failed to write ORIG_HEAD: %w", err)
+	}
+	if err := repo.UpdateHead(targetHash, "reset: moving to "+rev); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	if err := repo.ClearMergeState(); err != nil {
+		return err
+	}
+
+	switch mode {
+	case resetModeSoft:
+	case resetModeMixed:
+		changes := worktreeChanges(repoRoot, idx, repo.ConfigBool("core.filemode", true), worktreeEOL(repo))
+		if len(changes) > 0 {
+			fmt.Println("Unstaged changes after reset:")
+			for _, change := range changes {
+				fmt.Println(change.NameStatus())
+			}
+		}
+	default:
+		subject := strings.Split(targetCommit.Message, "\n")[0]
+		fmt.Printf("HEAD is now at %s %s\n", targetHash[:7], subject)
+	}
+	return nil
+}
+
+// checkHardReset refuses a hard reset that would lose changes not yet
+// committed, staged or not
+func checkHardReset(repo *repository.Repository, idx *index.Index, headFiles map[string]object.TreeEntry) error {
+	changed := make(map[string]bool)
+	for _, path := range idx.Conflicts() {
+		changed[path] = true
+	}
+	for _, change := range diff.DiffEntries(headFiles, indexSnapshot(idx)) {
+		changed[change.Path()] = true
+	}
+	for _, change := range worktreeChanges(repo.Path, idx, repo.ConfigBool("core.filemode", true), worktreeEOL(repo)) {
+		changed[change.Path()] = true
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(changed))
+	for path := range changed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return fmt.Errorf("your local changes to the following files would be lost by a hard reset:\n\t%s\nCommit or stash them, or use -f to discard them", strings.Join(paths, "\n\t"))
+}
+
+// planReset compares HEAD, index, working tree, and target for every path and
+// decides what to update, failing before anything is touched if local changes
+// would be lost. With --merge, staged changes are kept where HEAD and the
+// target agree and refused elsewhere, except in the discard paths, those a
+// merge in progress changed, which are reset to the target whatever their
+// state.
+func planReset(repoRoot, mode string, headFiles, indexFiles, targetFiles map[string]object.TreeEntry, discard map[string]bool) ([]resetAction, error) {
+	paths := make(map[string]bool)
+	for _, files := range []map[string]object.TreeEntry{headFiles, indexFiles, targetFiles} {
+		for path := range files {
+			paths[path] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var actions []resetAction
+	for _, path := range sorted {
+		h, inHead := headFiles[path]
+		i, inIndex := indexFiles[path]
+		t, inTarget := targetFiles[path]
+		w, inWorktree := worktreeEntry(repoRoot, path)
+
+		action := resetAction{path: path, target: t, inTarget: inTarget}
+
+		if mode == resetModeMerge {
+			switch {
+			case discard[path]:
+				action.useTarget = true
+			case sameEntry(h, inHead, t, inTarget):
+				// The target doesn't touch the file: keep staged and
+				// unstaged changes alike
+				continue
+			case sameEntry(i, inIndex, t, inTarget):
+				// Already staged as the target: keep unstaged changes
+			case !sameEntry(i, inIndex, h, inHead):
+				return nil, fmt.Errorf("entry '%s' has staged changes. Cannot merge", path)
+			case sameEntry(w, inWorktree, i, inIndex):
+				// No local changes: take the target in both places
+				action.useTarget = true
+			default:
+				return nil, fmt.Errorf("entry '%s' not uptodate. Cannot merge", path)
+			}
+		} else {
+			if !sameEntry(h, inHead, t, inTarget) {
+				if !sameEntry(i, inIndex, h, inHead) || !sameEntry(w, inWorktree, i, inIndex) {
+					return nil, fmt.Errorf("entry '%s' would be overwritten by merge. Cannot merge", path)
+				}
+				action.useTarget = true
+			}
+		}
+
+		action.setWorktree = action.useTarget && !sameEntry(w, inWorktree, t, inTarget)
+
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// planHardReset plans making the index and working tree match the target
+// for every path in either, discarding any local changes to them
+func planHardReset(repoRoot string, indexFiles, targetFiles map[string]object.TreeEntry) []resetAction {
+	paths := make(map[string]bool)
+	for _, files := range []map[string]object.TreeEntry{indexFiles, targetFiles} {
+		for path := range files {
+			paths[path] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	actions := make([]resetAction, 0, len(sorted))
+	for _, path := range sorted {
+		t, inTarget := targetFiles[path]
+		w, inWorktree := worktreeEntry(repoRoot, path)
+		actions = append(actions, resetAction{
+			path:        path,
+			target:      t,
+			inTarget:    inTarget,
+			useTarget:   true,
+			setWorktree: !sameEntry(w, inWorktree, t, inTarget),
+		})
+	}
+	return actions
+}
+
+// planMixedReset plans making the index match the target for every path
+// in either, leaving the working tree as it is
+func planMixedReset(indexFiles, targetFiles map[string]object.TreeEntry) []resetAction {
+	paths := make(map[string]bool)
+	for _, files := range []map[string]object.TreeEntry{indexFiles, targetFiles} {
+		for path := range files {
+			paths[path] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var actions []resetAction
+	for _, path := range sorted {
+		i, inIndex := indexFiles[path]
+		t, inTarget := targetFiles[path]
+		if sameEntry(i, inIndex, t, inTarget) {
+			continue
+		}
+		actions = append(actions, resetAction{path: path, target: t, inTarget: inTarget})
+	}
+	return actions
+}
+
+// applyReset updates the working tree and index according to the plan
+func applyReset(repoRoot string, idx *index.Index, actions []resetAction) error {
+	for _, action := range actions {
+		if action.setWorktree {
+			var err error
+			if action.inTarget {
+				err = checkoutEntry(repoRoot, action.path, action.target)
+			} else {
+				err = removeWorktreeFile(repoRoot, action.path)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case !action.inTarget:
+			idx.RemoveEntry(action.path)
+		case action.useTarget:
+			if err := stageCheckedOut(repoRoot, idx, action.path, action.target); err != nil {
+				return err
+			}
+		default:
+			mode, err := strconv.ParseUint(action.target.Mode, 8, 32)
+			if err != nil {
+				return fmt.Errorf("invalid mode %s for %s", action.target.Mode, action.path)
+			}
+			if err := idx.AddBlob(action.path, uint32(mode), action.target.Hash); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := idx.Write(repoRoot); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// sameEntry reports whether two optional entries have the same content and mode
+func sameEntry(a object.TreeEntry, aOK bool, b object.TreeEntry, bOK bool) bool {
+	if aOK != bOK {
+		return false
+	}
+	return !aOK || (a.Hash == b.Hash && a.Mode == b.Mode)
+}
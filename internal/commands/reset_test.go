@@ -0,0 +1,242 @@
+package commands
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestResetMergeKeepsStagedChangesTargetDoesNotTouch(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n", "g": "a\n"})
+	commitWorktree(t, "two", map[string]string{"g": "b\n"})
+
+	writeFile(t, "f", "staged\n")
+	mustGogit(t, "add", "f")
+	mustGogit(t, "reset", "--merge", "HEAD~1")
+
+	if got := readFile(t, "f"); got != "staged\n" {
+		t.Errorf("f = %q, want the staged change kept", got)
+	}
+	if got := readFile(t, "g"); got != "a\n" {
+		t.Errorf("g = %q, want it reset to the target", got)
+	}
+	out := mustGogit(t, "diff", "--cached", "--name-only")
+	if strings.TrimSpace(out) != "f" {
+		t.Errorf("staged paths = %q, want just f", out)
+	}
+}
+
+func TestResetMergeRefusesStagedChangesTargetTouches(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	commitWorktree(t, "two", map[string]string{"f": "b\n"})
+	head := mustGogit(t, "rev-parse", "HEAD")
+
+	writeFile(t, "f", "staged\n")
+	mustGogit(t, "add", "f")
+	if _, err := gogit(t, "reset", "--merge", "HEAD~1"); err == nil {
+		t.Fatal("reset --merge succeeded, want it to refuse to drop the staged change")
+	}
+
+	if got := readFile(t, "f"); got != "staged\n" {
+		t.Errorf("f = %q, want it untouched", got)
+	}
+	if got := mustGogit(t, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD moved to %s, want it left at %s", got, head)
+	}
+}
+
+func TestResetMergeTakesStagedTarget(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	commitWorktree(t, "two", map[string]string{"f": "b\n"})
+
+	writeFile(t, "f", "a\n")
+	mustGogit(t, "add", "f")
+	mustGogit(t, "reset", "--merge", "HEAD~1")
+
+	for _, args := range [][]string{{"diff", "--name-only"}, {"diff", "--cached", "--name-only"}} {
+		if out := mustGogit(t, args...); out != "" {
+			t.Errorf("%v = %q, want no changes", args, out)
+		}
+	}
+}
+
+func TestResetMergeUndoesMergeInProgress(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n", "g": "a\n"})
+	mustGogit(t, "checkout", "-b", "side")
+	commitWorktree(t, "side", map[string]string{"f": "side\n", "g": "side\n"})
+	mustGogit(t, "checkout", "main")
+	commitWorktree(t, "main", map[string]string{"f": "main\n"})
+
+	if _, err := gogit(t, "merge", "side"); err == nil {
+		t.Fatal("merge succeeded, want a conflict in f")
+	}
+	mustGogit(t, "reset", "--merge")
+
+	if got := readFile(t, "f"); got != "main\n" {
+		t.Errorf("f = %q, want the conflict undone", got)
+	}
+	if got := readFile(t, "g"); got != "a\n" {
+		t.Errorf("g = %q, want the merged change undone", got)
+	}
+	if out := mustGogit(t, "diff", "--cached", "--name-only"); out != "" {
+		t.Errorf("staged paths = %q, want none", out)
+	}
+}
+
+func TestResetKeepCarriesLocalChanges(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n", "g": "a\n"})
+	commitWorktree(t, "two", map[string]string{"g": "b\n", "h": "new\n"})
+
+	writeFile(t, "f", "local\n")
+	mustGogit(t, "reset", "--keep", "HEAD~1")
+
+	if got := readFile(t, "f"); got != "local\n" {
+		t.Errorf("f = %q, want the local change kept", got)
+	}
+	if got := readFile(t, "g"); got != "a\n" {
+		t.Errorf("g = %q, want it reset to the target", got)
+	}
+	if _, err := os.Stat("h"); !os.IsNotExist(err) {
+		t.Errorf("h still exists, want it removed with the commit that added it")
+	}
+	if out := mustGogit(t, "diff", "--name-only"); strings.TrimSpace(out) != "f" {
+		t.Errorf("changed paths = %q, want just f", out)
+	}
+}
+
+func TestResetKeepRefusesToLoseLocalChanges(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	commitWorktree(t, "two", map[string]string{"f": "b\n"})
+	head := mustGogit(t, "rev-parse", "HEAD")
+
+	writeFile(t, "f", "local\n")
+	if _, err := gogit(t, "reset", "--keep", "HEAD~1"); err == nil {
+		t.Fatal("reset --keep succeeded, want it to refuse to overwrite the local change")
+	}
+
+	if got := readFile(t, "f"); got != "local\n" {
+		t.Errorf("f = %q, want it untouched", got)
+	}
+	if got := mustGogit(t, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD moved to %s, want it left at %s", got, head)
+	}
+}
+
+func TestResetSoftKeepsChangesStaged(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	one := mustGogit(t, "rev-parse", "HEAD")
+	commitWorktree(t, "two", map[string]string{"f": "b\n", "g": "new\n"})
+	two := mustGogit(t, "rev-parse", "HEAD")
+
+	mustGogit(t, "reset", "--soft", "HEAD~1")
+	if got := mustGogit(t, "rev-parse", "HEAD"); got != one {
+		t.Errorf("HEAD = %s, want %s", got, one)
+	}
+	if got := mustGogit(t, "rev-parse", "ORIG_HEAD"); got != two {
+		t.Errorf("ORIG_HEAD = %s, want the old HEAD %s", got, two)
+	}
+	if got := mustGogit(t, "diff", "--cached", "--name-only"); got != "f\ng\n" {
+		t.Errorf("staged paths = %q, want the undone commit's", got)
+	}
+	if got := mustGogit(t, "diff", "--name-only"); got != "" {
+		t.Errorf("unstaged paths = %q, want none", got)
+	}
+	if got := readFile(t, "f"); got != "b\n" {
+		t.Errorf("f = %q, want the working tree left alone", got)
+	}
+}
+
+func TestResetMixedUnstagesChanges(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	one := mustGogit(t, "rev-parse", "HEAD")
+	commitWorktree(t, "two", map[string]string{"f": "b\n"})
+	mustGogit(t, "branch", "old")
+
+	// A bare reset is mixed, to HEAD
+	writeFile(t, "f", "staged\n")
+	mustGogit(t, "add", "f")
+	mustGogit(t, "reset")
+	if got := mustGogit(t, "diff", "--cached", "--name-only"); got != "" {
+		t.Errorf("staged paths after reset = %q, want none", got)
+	}
+	if got := readFile(t, "f"); got != "staged\n" {
+		t.Errorf("f = %q, want the change kept in the working tree", got)
+	}
+
+	mustGogit(t, "reset", "--mixed", one[:7])
+	if got := mustGogit(t, "rev-parse", "HEAD"); got != one {
+		t.Errorf("HEAD = %s, want %s", got, one)
+	}
+	if got := mustGogit(t, "diff", "--cached", "--name-only"); got != "" {
+		t.Errorf("staged paths = %q, want none", got)
+	}
+	if got := mustGogit(t, "diff", "--name-only"); got != "f\n" {
+		t.Errorf("unstaged paths = %q, want f", got)
+	}
+
+	// Back to a branch by name
+	mustGogit(t, "reset", "old")
+	if got, want := mustGogit(t, "rev-parse", "HEAD"), mustGogit(t, "rev-parse", "old"); got != want {
+		t.Errorf("HEAD = %s, want old at %s", got, want)
+	}
+}
+
+func TestResetHard(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	one := mustGogit(t, "rev-parse", "HEAD")
+	commitWorktree(t, "two", map[string]string{"f": "b\n", "dir/g": "new\n"})
+
+	mustGogit(t, "reset", "--hard", "HEAD~1")
+	if got := mustGogit(t, "rev-parse", "HEAD"); got != one {
+		t.Errorf("HEAD = %s, want %s", got, one)
+	}
+	if got := readFile(t, "f"); got != "a\n" {
+		t.Errorf("f = %q, want it reset", got)
+	}
+	if _, err := os.Stat("dir"); !os.IsNotExist(err) {
+		t.Errorf("dir left behind after resetting past the commit adding it: %v", err)
+	}
+
+	writeFile(t, "f", "local\n")
+	if _, err := gogit(t, "reset", "--hard", "ORIG_HEAD"); err == nil {
+		t.Fatal("reset --hard discarded a local change without -f")
+	}
+	if got := readFile(t, "f"); got != "local\n" {
+		t.Errorf("f = %q, want the refused reset to leave it", got)
+	}
+	if got := mustGogit(t, "rev-parse", "HEAD"); got != one {
+		t.Errorf("refused reset moved HEAD to %s", got)
+	}
+
+	mustGogit(t, "reset", "--hard", "-f", "ORIG_HEAD")
+	if got := readFile(t, "dir/g"); got != "new\n" {
+		t.Errorf("dir/g = %q, want it restored", got)
+	}
+	if got := mustGogit(t, "status"); got != "On branch main\n\nnothing to commit, working tree clean\n" {
+		t.Errorf("status after reset --hard -f:\n%s", got)
+	}
+}
+
+func TestResetRejectsConflictingModes(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+
+	for _, args := range [][]string{
+		{"reset", "--soft", "--hard"},
+		{"reset", "--soft", "-f"},
+		{"reset", "-f"},
+	} {
+		if _, err := gogit(t, args...); err == nil {
+			t.Errorf("%v succeeded", args)
+		}
+	}
+}
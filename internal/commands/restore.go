@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/index"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	restoreSource   string
+	restoreStaged   bool
+	restoreWorktree bool
+	restoreOverlay  bool
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [--source=<tree>] [--staged] [--worktree] [--overlay] <path>...",
+	Short: "Restore working tree files",
+	Long: `Restore paths in the working tree, the index, or both, from a source.
+
+  --worktree  Restore the working tree (the default without --staged)
+  --staged    Restore the index; with --worktree as well, restore both
+
+The source defaults to the index when only the working tree is restored and
+to HEAD otherwise; --source names a commit or tree to use instead.
+
+Files in the restored paths that the source doesn't have are deleted, so the
+paths end up exactly as in the source. With --overlay they are left alone.`,
+	GroupID: groupMain,
+	Example: `  # Discard unstaged changes to a file
+  gogit restore main.go
+
+  # Unstage a file, keeping its changes in the working tree
+  gogit restore --staged main.go
+
+  # Bring back a file as it was two commits ago
+  gogit restore --source=HEAD~2 main.go`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().StringVarP(&restoreSource, "source", "s", "", "Restore from this commit or tree")
+	restoreCmd.Flags().BoolVarP(&restoreStaged, "staged", "S", false, "Restore the index")
+	restoreCmd.Flags().BoolVarP(&restoreWorktree, "worktree", "W", false, "Restore the working tree")
+	restoreCmd.Flags().BoolVar(&restoreOverlay, "overlay", false, "Never delete files that are missing from the source")
+	restoreCmd.Flags().Bool("no-overlay", false, "Delete files that are missing from the source (the default)")
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	idx, err := index.ReadIndex(repoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+
+	worktree := restoreWorktree || !restoreStaged
+	overlay := restoreOverlay
+	if noOverlay, _ := cmd.Flags().GetBool("no-overlay"); noOverlay {
+		overlay = false
+	}
+
+	paths := make([]string, len(args))
+	for i, arg := range args {
+		if paths[i], err = repoRelative(repoRoot, arg); err != nil {
+			return err
+		}
+	}
+
+	fromIndex := restoreSource == "" && !restoreStaged
+	var source map[string]object.TreeEntry
+	if fromIndex {
+		for _, path := range idx.Conflicts() {
+			if matchesAnyPath(path, paths) {
+				return fmt.Errorf("path '%s' is unmerged", path)
+			}
+		}
+		source = indexSnapshot(idx)
+	} else {
+		tree, err := restoreTree(repo)
+		if err != nil {
+			return err
+		}
+		if source, err = diff.FlattenTree(repo.Objects, tree); err != nil {
+			return err
+		}
+	}
+
+	// Everything the index knows about, conflicted paths included
+	tracked := make(map[string]bool, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		tracked[entry.Path] = true
+	}
+
+	for _, path := range paths {
+		matched := false
+		for known := range tracked {
+			matched = matched || matchesAnyPath(known, []string{path})
+		}
+		for known := range source {
+			matched = matched || matchesAnyPath(known, []string{path})
+		}
+		if !matched {
+			return fmt.Errorf("pathspec '%s' did not match any file(s) known to git", path)
+		}
+	}
+
+	var restored []string
+	for path := range source {
+		if matchesAnyPath(path, paths) {
+			restored = append(restored, path)
+		}
+	}
+	sort.Strings(restored)
+
+	for _, path := range restored {
+		entry := source[path]
+		if worktree {
+			if err := checkoutEntry(repoRoot, path, entry); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case worktree && (restoreStaged || fromIndex):
+			// The index and the file now agree; record the file's stat data
+			if err := stageCheckedOut(repoRoot, idx, path, entry); err != nil {
+				return err
+			}
+		case restoreStaged:
+			mode, err := strconv.ParseUint(entry.Mode, 8, 32)
+			if err != nil {
+				return fmt.Errorf("invalid mode %s for %s", entry.Mode, path)
+			}
+			if err := idx.AddBlob(path, uint32(mode), entry.Hash); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !overlay {
+		for path := range tracked {
+			if _, ok := source[path]; ok || !matchesAnyPath(path, paths) {
+				continue
+			}
+			if worktree {
+				if err := removeWorktreeFile(repoRoot, path); err != nil {
+					return err
+				}
+			}
+			if restoreStaged {
+				idx.RemoveEntry(path)
+			}
+		}
+	}
+
+	if err := idx.Write(repoRoot); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// restoreTree returns the tree named by --source, or HEAD's tree. An unborn
+// branch restores from an empty tree.
+func restoreTree(repo *repository.Repository) (string, error) {
+	if restoreSource == "" {
+		return headTreeHash(repo)
+	}
+	return repo.ResolveTree(restoreSource)
+}
+
+// matchesAnyPath reports whether path is at or below one of the paths
+func matchesAnyPath(path string, paths []string) bool {
+	for _, p := range paths {
+		if p == "." || matchesPath(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRestoreStagedAndWorktree(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "1\n"})
+	commitWorktree(t, "two", map[string]string{"f": "2\n"})
+
+	writeFile(t, "f", "staged\n")
+	mustGogit(t, "add", "f")
+	writeFile(t, "f", "unstaged\n")
+
+	// The working tree alone comes from the index
+	mustGogit(t, "restore", "f")
+	if got := readFile(t, "f"); got != "staged\n" {
+		t.Errorf("after restore f, f = %q, want the staged content", got)
+	}
+
+	// Both come from HEAD
+	writeFile(t, "f", "unstaged\n")
+	mustGogit(t, "restore", "--staged", "--worktree", "f")
+	if got := readFile(t, "f"); got != "2\n" {
+		t.Errorf("after restore --staged --worktree, f = %q, want HEAD's", got)
+	}
+	for _, args := range [][]string{{"diff", "--name-only"}, {"diff", "--cached", "--name-only"}} {
+		if out := mustGogit(t, args...); out != "" {
+			t.Errorf("%v = %q, want no changes", args, out)
+		}
+	}
+
+	// Or both from another commit
+	mustGogit(t, "restore", "-S", "-W", "--source=HEAD~1", "f")
+	if got := readFile(t, "f"); got != "1\n" {
+		t.Errorf("after restore --source=HEAD~1, f = %q", got)
+	}
+	if out := mustGogit(t, "diff", "--name-only"); out != "" {
+		t.Errorf("diff --name-only = %q, want the index restored too", out)
+	}
+	if out := mustGogit(t, "diff", "--cached", "--name-only"); out != "f\n" {
+		t.Errorf("diff --cached --name-only = %q, want f staged", out)
+	}
+}
+
+func TestRestoreOverlay(t *testing.T) {
+	for _, c := range []struct {
+		flag string
+		kept bool
+	}{
+		{"", false},
+		{"--no-overlay", false},
+		{"--overlay", true},
+	} {
+		name := c.flag
+		if name == "" {
+			name = "default"
+		}
+		t.Run(name, func(t *testing.T) {
+			newTestRepo(t)
+			commitWorktree(t, "one", map[string]string{"f": "1\n"})
+			commitWorktree(t, "two", map[string]string{"f": "2\n", "new": "new\n"})
+
+			args := []string{"restore", "--staged", "--worktree", "--source=HEAD~1"}
+			if c.flag != "" {
+				args = append(args, c.flag)
+			}
+			mustGogit(t, append(args, ".")...)
+
+			if got := readFile(t, "f"); got != "1\n" {
+				t.Errorf("f = %q, want it restored", got)
+			}
+			_, err := os.Stat("new")
+			if kept := err == nil; kept != c.kept {
+				t.Errorf("new kept in the working tree: %v, want %v", kept, c.kept)
+			}
+			want := "f\nnew\n"
+			if !c.kept {
+				want = "f\n"
+			}
+			if got := mustGogit(t, "ls-files"); got != want {
+				t.Errorf("index:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
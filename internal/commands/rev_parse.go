@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+var (
+	revParseShowToplevel   bool
+	revParseGitDir         bool
+	revParseInsideWorkTree bool
+	revParseAbbrevRef      bool
+	revParseVerify         bool
+	revParseQuiet          bool
+	revParseShort          int
+)
+
+// defaultAbbrev is the length object names are abbreviated to when
+// core.abbrev doesn't say
+const defaultAbbrev = 7
+
+var revParseCmd = &cobra.Command{
+	Use:     "rev-parse [options] [<rev>...]",
+	Short:   "Pick out and massage parameters",
+	Long:    `Resolve revisions to object names and answer questions about the repository layout.`,
+	GroupID: groupPlumbing,
+	Example: `  # Print the full name of a commit
+  gogit rev-parse HEAD~2
+
+  # Print the current branch's short name
+  gogit rev-parse --abbrev-ref HEAD
+
+  # Print the top of the working tree
+  gogit rev-parse --show-toplevel`,
+	RunE: runRevParse,
+}
+
+func init() {
+	rootCmd.AddCommand(revParseCmd)
+	revParseCmd.Flags().BoolVar(&revParseShowToplevel, "show-toplevel", false, "Show the absolute path of the top-level directory of the working tree")
+	revParseCmd.Flags().BoolVar(&revParseGitDir, "git-dir", false, "Show the path to the metadata directory")
+	revParseCmd.Flags().BoolVar(&revParseInsideWorkTree, "is-inside-work-tree", false, "Print true if the current directory is inside the working tree")
+	revParseCmd.Flags().BoolVar(&revParseAbbrevRef, "abbrev-ref", false, "Print a short, non-ambiguous name of each ref")
+	revParseCmd.Flags().BoolVar(&revParseVerify, "verify", false, "Verify that exactly one parameter is given and that it resolves to an object")
+	revParseCmd.Flags().BoolVarP(&revParseQuiet, "quiet", "q", false, "With --verify, exit with status 1 instead of printing an error")
+	revParseCmd.Flags().IntVar(&revParseShort, "short", 0, "Like --verify, but print the shortest unique abbreviation of at least this many characters (default core.abbrev, or 7)")
+	revParseCmd.Flags().Lookup("short").NoOptDefVal = "0"
+}
+
+func runRevParse(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	gogitDir := utils.GitDir(repoRoot)
+
+	if revParseShowToplevel {
+		fmt.Println(repoRoot)
+	}
+
+	if revParseGitDir {
+		if cwd == repoRoot {
+			fmt.Println(utils.GitDirName(repoRoot))
+		} else {
+			fmt.Println(gogitDir)
+		}
+	}
+
+	if revParseInsideWorkTree {
+		inside := cwd != gogitDir && !strings.HasPrefix(cwd, gogitDir+string(filepath.Separator))
+		fmt.Println(inside)
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	short := cmd.Flags().Changed("short")
+	if revParseVerify || short {
+		// Quietly, a revision that doesn't resolve is an answer, not an error
+		if len(args) != 1 {
+			if revParseQuiet {
+				return &ExitError{Code: exitNo}
+			}
+			return fmt.Errorf("needed a single revision")
+		}
+		hash, err := repo.ResolveRevision(args[0])
+		if err != nil {
+			if revParseQuiet {
+				return &ExitError{Code: exitNo}
+			}
+			return fmt.Errorf("needed a single revision: %w", err)
+		}
+		if short {
+			if hash, err = object.Abbreviate(repoRoot, hash, abbrevLength(repo, revParseShort)); err != nil {
+				return err
+			}
+		}
+		fmt.Println(hash)
+		return nil
+	}
+
+	for _, rev := range args {
+		if revParseAbbrevRef {
+			name, err := abbrevRef(repo, rev)
+			if err != nil {
+				return err
+			}
+			fmt.Println(name)
+			continue
+		}
+
+		hash, err := repo.ResolveRevision(rev)
+		if err != nil {
+			return err
+		}
+		fmt.Println(hash)
+	}
+
+	return nil
+}
+
+// abbrevLength returns the minimum abbreviation length: n if given, else
+// core.abbrev, else defaultAbbrev
+func abbrevLength(repo *repository.Repository, n int) int {
+	if n > 0 {
+		return n
+	}
+	if value, err := repo.GetConfig("core.abbrev"); err == nil {
+		if configured, err := strconv.Atoi(value); err == nil && configured > 0 {
+			return configured
+		}
+	}
+	return defaultAbbrev
+}
+
+// abbrevRef returns the short name of the ref a revision refers to
+func abbrevRef(repo *repository.Repository, rev string) (string, error) {
+	if rev == "HEAD" || rev == "@" {
+		branch, err := repo.Refs.CurrentBranch()
+		if err != nil {
+			// Detached HEAD
+			return "HEAD", nil
+		}
+		return branch, nil
+	}
+
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/", "refs/"} {
+		if strings.HasPrefix(rev, prefix) {
+			return strings.TrimPrefix(rev, prefix), nil
+		}
+	}
+
+	if _, err := repo.ResolveRevision(rev); err != nil {
+		return "", err
+	}
+	return rev, nil
+}
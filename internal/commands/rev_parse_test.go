@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+func TestRevParseVerifyQuiet(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+
+	for _, args := range [][]string{
+		{"rev-parse", "--verify", "-q", "nosuch"},
+		{"rev-parse", "--verify", "--quiet", "HEAD", "HEAD"},
+	} {
+		stderr, code := execute(t, args...)
+		if code != exitNo || stderr != "" {
+			t.Errorf("%v: exit %d with %q on stderr, want exit %d and nothing printed", args, code, stderr, exitNo)
+		}
+	}
+
+	stderr, code := execute(t, "rev-parse", "--verify", "nosuch")
+	if code != exitFatal || !strings.Contains(stderr, "needed a single revision") {
+		t.Errorf("without -q: exit %d with %q on stderr, want exit %d and an error", code, stderr, exitFatal)
+	}
+
+	if out := mustGogit(t, "rev-parse", "--verify", "-q", "HEAD"); len(strings.TrimSpace(out)) != 40 {
+		t.Errorf("rev-parse --verify -q HEAD = %q, want the commit's name", out)
+	}
+}
+
+func TestRevParseShow(t *testing.T) {
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	head := strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD"))
+
+	if err := os.Mkdir("sub", 0755); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, "sub")
+	for _, c := range []struct {
+		name     string
+		detached bool
+		args     []string
+		want     string
+	}{
+		{"toplevel", false, []string{"--show-toplevel"}, root},
+		{"git dir", false, []string{"--git-dir"}, root + "/.gogit"},
+		{"inside work tree", false, []string{"--is-inside-work-tree"}, "true"},
+		{"branch", false, []string{"--abbrev-ref", "HEAD"}, "main"},
+		{"verify", false, []string{"--verify", "main"}, head},
+		{"toplevel detached", true, []string{"--show-toplevel"}, root},
+		{"branch detached", true, []string{"--abbrev-ref", "HEAD"}, "HEAD"},
+		{"verify detached", true, []string{"--verify", "HEAD"}, head},
+	} {
+		if c.detached {
+			mustGogit(t, "checkout", head)
+		}
+		got := strings.TrimSpace(mustGogit(t, append([]string{"rev-parse"}, c.args...)...))
+		if got != c.want {
+			t.Errorf("%s: rev-parse %v = %q, want %q", c.name, c.args, got, c.want)
+		}
+	}
+
+	chdir(t, root)
+	if got := strings.TrimSpace(mustGogit(t, "rev-parse", "--git-dir")); got != ".gogit" {
+		t.Errorf("rev-parse --git-dir at the top = %q, want .gogit", got)
+	}
+}
+
+func TestRevParsePeeling(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	commitWorktree(t, "two", map[string]string{"f": "b\n", "dir/g": "c\n"})
+
+	// v1 is an annotated tag of HEAD, and nested a tag of that tag
+	tag := &object.Tag{
+		Object:  strings.TrimSpace(mustGogit(t, "rev-parse", "HEAD")),
+		ObjType: object.TypeCommit,
+		Name:    "v1",
+		Tagger:  object.ParseSignature("Tagger <tagger@example.com> 1700000000 +0000"),
+		Message: "release\n",
+	}
+	tagHash := writeObject(t, tag)
+	mustGogit(t, "tag", "v1", tagHash)
+	mustGogit(t, "tag", "nested", writeObject(t, &object.Tag{
+		Object: tagHash, ObjType: object.TypeTag, Name: "nested", Tagger: tag.Tagger, Message: "again\n",
+	}))
+
+	for _, rev := range []string{
+		"HEAD", "HEAD^{commit}", "HEAD^{tree}", "HEAD~1^{tree}", "HEAD^{}",
+		"v1", "v1^{}", "v1^{commit}", "v1^{tree}", "v1^{tag}",
+		"nested^{commit}", "nested^{tag}", "nested^{}",
+	} {
+		got := mustGogit(t, "rev-parse", rev)
+		want := git(t, root, "--git-dir=.gogit", "rev-parse", rev)
+		if got != want {
+			t.Errorf("rev-parse %s = %s, want %s", rev, strings.TrimSpace(got), strings.TrimSpace(want))
+		}
+	}
+
+	for _, rev := range []string{"HEAD^{tag}", "HEAD^{blob}", "HEAD^{nonsense}", "v1^{blob}"} {
+		if _, err := gogit(t, "rev-parse", "--verify", rev); err == nil {
+			t.Errorf("rev-parse %s succeeded", rev)
+		}
+	}
+}
+
+func TestRevParseShort(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+
+	for _, args := range [][]string{
+		{"--short", "HEAD"},
+		{"--short=10", "HEAD"},
+		{"--short=4", "HEAD"},
+		{"--short=1", "HEAD"},
+		{"--short", "HEAD^{tree}"},
+	} {
+		got := mustGogit(t, append([]string{"rev-parse"}, args...)...)
+		want := git(t, root, append([]string{"--git-dir=.gogit", "rev-parse"}, args...)...)
+		if got != want {
+			t.Errorf("rev-parse %v = %s, want %s", args, strings.TrimSpace(got), strings.TrimSpace(want))
+		}
+	}
+
+	mustGogit(t, "config", "core.abbrev", "12")
+	if got := strings.TrimSpace(mustGogit(t, "rev-parse", "--short", "HEAD")); len(got) != 12 {
+		t.Errorf("rev-parse --short with core.abbrev=12 = %s", got)
+	}
+}
@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/index"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	rmCached    bool
+	rmForce     bool
+	rmRecursive bool
+	rmQuiet     bool
+)
+
+var rmCmd = &cobra.Command{
+	Use:   "rm [-f] [--cached] [-r] [-q] <pathspec>...",
+	Short: "Remove files from the working tree and from the index",
+	Long: `Stop tracking files, deleting them from the working tree as well unless
+--cached is given. Only tracked files can be removed; a directory needs -r to
+remove the files under it, and quoted globs such as '*.log' match tracked
+files in subdirectories too.
+
+To keep work from being lost, a file whose staged content differs from HEAD,
+or whose working copy differs from the index, is only removed with -f.
+--cached may remove one that differs in just one of those ways, as the
+working copy still holds its content.`,
+	GroupID: groupMain,
+	Example: `  # Delete a file and stop tracking it
+  gogit rm notes.txt
+
+  # Stop tracking a directory but keep its files
+  gogit rm -r --cached build`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRm,
+}
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+	rmCmd.Flags().BoolVar(&rmCached, "cached", false, "Only remove from the index, keeping the working tree file")
+	rmCmd.Flags().BoolVarP(&rmForce, "force", "f", false, "Remove files even if they have staged or local changes")
+	rmCmd.Flags().BoolVarP(&rmRecursive, "recursive", "r", false, "Remove directories recursively")
+	rmCmd.Flags().BoolVarP(&rmQuiet, "quiet", "q", false, "Don't list the removed files")
+}
+
+func runRm(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	headFiles, idx, err := headAndIndex(repo)
+	if err != nil {
+		return err
+	}
+
+	// Every pathspec is matched before anything is removed
+	var paths []string
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		matches, err := rmMatches(repo, idx, arg)
+		if err != nil {
+			return err
+		}
+		for _, p := range matches {
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	if !rmForce {
+		if err := checkRmSafe(repo, headFiles, indexSnapshot(idx), paths); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range paths {
+		if !rmQuiet {
+			fmt.Printf("rm '%s'\n", p)
+		}
+		idx.RemoveEntry(p)
+	}
+
+	if !rmCached {
+		for _, p := range paths {
+			if err := removeWorktreeFile(repoRoot, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := idx.Write(repoRoot); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// rmMatches returns the tracked paths a pathspec names: a file, every file
+// in a directory (only with -r), or the files a glob matches
+func rmMatches(repo *repository.Repository, idx *index.Index, pathspec string) ([]string, error) {
+	rel, err := repoRelative(repo.Path, pathspec)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+
+	var match func(string) bool
+	if hasGlob(pathspec) {
+		prefix, err := repoRelative(repo.Path, ".")
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile("^" + pathspecGlob(path.Join(filepath.ToSlash(prefix), filepath.ToSlash(pathspec))) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pathspec '%s': %w", pathspec, err)
+		}
+		match = re.MatchString
+	} else {
+		match = func(p string) bool {
+			return rel == "." || p == rel || strings.HasPrefix(p, rel+"/")
+		}
+	}
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, entry := range idx.Entries {
+		p := entry.Path
+		if seen[p] || !match(p) {
+			continue
+		}
+		seen[p] = true
+		if p != rel && !hasGlob(pathspec) && !rmRecursive {
+			return nil, fmt.Errorf("not removing '%s' recursively without -r", pathspec)
+		}
+		matches = append(matches, p)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("pathspec '%s' did not match any files", pathspec)
+	}
+	return matches, nil
+}
+
+// checkRmSafe refuses to remove files whose content would be lost: their
+// staged content isn't in HEAD and, unless only the index entry is being
+// removed, their working copy isn't in the index. Conflicted paths are
+// always removable.
+func checkRmSafe(repo *repository.Repository, headFiles, indexFiles map[string]object.TreeEntry, paths []string) error {
+	var both, staged, local []string
+	for _, p := range paths {
+		i, inIndex := indexFiles[p]
+		if !inIndex || i.Mode == object.ModeGitlink {
+			continue
+		}
+		h, inHead := headFiles[p]
+		w, inWorktree := worktreeEntry(repo.Path, p)
+
+		stagedChanges := !sameEntry(i, true, h, inHead)
+		localChanges := inWorktree && !sameEntry(w, true, i, true)
+		switch {
+		case stagedChanges && localChanges:
+			both = append(both, p)
+		case rmCached:
+		case stagedChanges:
+			staged = append(staged, p)
+		case localChanges:
+			local = append(local, p)
+		}
+	}
+
+	var msgs []string
+	if len(both) > 0 {
+		msgs = append(msgs, rmRefusal(both,
+			"the following file has staged content different from both the\nfile and the HEAD:",
+			"the following files have staged content different from both the\nfile and the HEAD:",
+			"(use -f to force removal)"))
+	}
+	if len(staged) > 0 {
+		msgs = append(msgs, rmRefusal(staged,
+			"the following file has changes staged in the index:",
+			"the following files have changes staged in the index:",
+			"(use --cached to keep the file, or -f to force removal)"))
+	}
+	if len(local) > 0 {
+		msgs = append(msgs, rmRefusal(local,
+			"the following file has local modifications:",
+			"the following files have local modifications:",
+			"(use --cached to keep the file, or -f to force removal)"))
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("%s", strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// rmRefusal formats one of rm's reasons for refusing, listing the files
+func rmRefusal(paths []string, one, many, hint string) string {
+	header := one
+	if len(paths) > 1 {
+		header = many
+	}
+	return fmt.Sprintf("%s\n    %s\n%s", header, strings.Join(paths, "\n    "), hint)
+}
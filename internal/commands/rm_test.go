@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRm(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{
+		"a": "a\n", "kept": "kept\n", "dir/b": "b\n", "dir/sub/c": "c\n", "logs/x.log": "x\n",
+	})
+	gone := func(path string) bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}
+
+	if got := mustGogit(t, "rm", "a"); got != "rm 'a'\n" {
+		t.Errorf("rm a printed %q", got)
+	}
+	if !gone("a") {
+		t.Error("rm left a in the working tree")
+	}
+	mustGogit(t, "rm", "-q", "--cached", "kept")
+	if gone("kept") {
+		t.Error("rm --cached deleted the working tree file")
+	}
+
+	if _, err := gogit(t, "rm", "-q", "dir"); err == nil {
+		t.Error("rm removed a directory without -r")
+	}
+	mustGogit(t, "rm", "-q", "-r", "dir")
+	if !gone("dir") {
+		t.Error("rm -r left dir in the working tree")
+	}
+	mustGogit(t, "rm", "-q", "*.log")
+	if !gone("logs/x.log") {
+		t.Error("rm of a glob left logs/x.log")
+	}
+	if got := mustGogit(t, "ls-files"); got != "" {
+		t.Errorf("ls-files after removing everything: %q", got)
+	}
+
+	for _, path := range []string{"kept", "no-such-file"} {
+		if _, err := gogit(t, "rm", path); err == nil {
+			t.Errorf("rm %s succeeded on a file that isn't tracked", path)
+		}
+	}
+}
+
+func TestRmRefusesToLoseChanges(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"staged": "1\n", "local": "1\n", "both": "1\n"})
+	writeFile(t, "staged", "2\n")
+	mustGogit(t, "add", "staged")
+	writeFile(t, "local", "2\n")
+	writeFile(t, "both", "2\n")
+	mustGogit(t, "add", "both")
+	writeFile(t, "both", "3\n")
+
+	for _, path := range []string{"staged", "local", "both"} {
+		if _, err := gogit(t, "rm", path); err == nil {
+			t.Errorf("rm %s succeeded without -f", path)
+		}
+	}
+	// The working copy keeps the content --cached would drop from the index,
+	// unless it differs from both the index and HEAD
+	mustGogit(t, "rm", "-q", "--cached", "staged", "local")
+	if _, err := gogit(t, "rm", "--cached", "both"); err == nil {
+		t.Error("rm --cached succeeded on a file differing from both the index and HEAD")
+	}
+
+	mustGogit(t, "rm", "-q", "-f", "both")
+	if _, err := os.Stat("both"); !os.IsNotExist(err) {
+		t.Errorf("rm -f left both in the working tree: %v", err)
+	}
+	if got := mustGogit(t, "ls-files"); got != "" {
+		t.Errorf("ls-files after rm: %q", got)
+	}
+}
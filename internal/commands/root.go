@@ -1,29 +1,95 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/lockfile"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+	"github.com/yourusername/gogit/internal/utils"
 )
 
+// strict makes every command check the repository's refs and objects
+// before it runs
+var strict bool
+
+// noReplaceObjects makes objects read as stored, ignoring refs/replace/
+var noReplaceObjects bool
+
 var rootCmd = &cobra.Command{
 	Use:   "gogit",
 	Short: "A Git implementation in Go",
 	Long: `GoGit is a Git clone built from scratch in Go.
 It implements core Git functionality including objects,
 trees, commits, branches, and more.`,
+	PersistentPreRunE: prepare,
 }
 
+// Execute runs the command line and reports any error on stderr, except an
+// ExitError without an Err, which is an answer given by the exit code
+// alone. Use ExitCode to turn the returned error into the process exit code.
 func Execute() error {
-	return rootCmd.Execute()
+	// Locks a command still holds when it ends or is interrupted are
+	// released, so they can't block the next command
+	lockfile.HandleSignals()
+	defer lockfile.ReleaseAll()
+
+	err := rootCmd.Execute()
+	var exitErr *ExitError
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.Err != nil) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	return err
 }
 
 func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	// Errors are printed by Execute, so ExitErrors can stay silent
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "Check that refs point to readable objects before running the command")
+	rootCmd.PersistentFlags().BoolVar(&noReplaceObjects, "no-replace-objects", false, "Read objects as stored, ignoring replacements")
+
+	// Partial clones fetch the objects they left out when they are read
+	object.SetPromisorFetcher(repository.FetchPromised)
+}
+
+// prepare sets up what every command shares before it runs
+func prepare(cmd *cobra.Command, args []string) error {
+	// Replaced objects read as their replacements unless told otherwise
+	if noReplaceObjects || os.Getenv("GIT_NO_REPLACE_OBJECTS") != "" {
+		object.SetReplaceMap(nil)
+	} else {
+		object.SetReplaceMap(repository.ReplaceRefs)
+	}
+	return checkStrict(cmd, args)
+}
+
+// checkStrict runs the quick repository check when --strict is given.
+// Outside a repository there is nothing to check; the command itself says
+// so if it needs one.
+func checkStrict(cmd *cobra.Command, args []string) error {
+	if !strict {
+		return nil
+	}
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return nil
+	}
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := repo.Check(); err != nil {
+		return fmt.Errorf("repository check failed: %w", err)
+	}
+	return nil
 }
 
-// FindRepoRoot walks up the directory tree to find .gogit
+// FindRepoRoot walks up the directory tree to find a .gogit (or .git) directory
 func FindRepoRoot() (string, error) {
 	dir, err := os.Getwd()
 	if err != nil {
@@ -31,8 +97,7 @@ func FindRepoRoot() (string, error) {
 	}
 
 	for {
-		gogitPath := dir + "/.gogit"
-		if info, err := os.Stat(gogitPath); err == nil && info.IsDir() {
+		if info, err := os.Stat(utils.GitDir(dir)); err == nil && info.IsDir() {
 			return dir, nil
 		}
 
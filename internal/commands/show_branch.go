@@ -0,0 +1,281 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	showBranchAll     bool
+	showBranchRemotes bool
+	showBranchMore    int
+	showBranchList    bool
+)
+
+var showBranchCmd = &cobra.Command{
+	Use:   "show-branch [-a | -r] [--more=<n> | --list] [<rev>...]",
+	Short: "Show branches and their commits",
+	Long: `Show which commits are on which of the given branches (default: every local
+branch). A header lists each branch, marked "*" if it is the current one and
+"!" otherwise, indented so that its column lines up with the markers below.
+
+Then the commits not on every branch are listed newest first, keeping each
+line of history together, down to and including the first commit every
+branch has. Each is preceded by one column per branch: "*" for the current
+branch, "+" for the others, "-" if the commit is a merge, or a blank if the
+branch doesn't have the commit. Merges on only one branch are left out.
+Commits are named after a branch, as in "main~2" or "topic^2".
+
+--more=<n> shows n commits past the first common one; --list (the same as
+--more=-1) prints only the branches.`,
+	GroupID: groupMain,
+	Example: `  # Compare the current branch with main
+  gogit show-branch HEAD main
+
+  # List every branch with its latest commit
+  gogit show-branch --list`,
+	RunE: runShowBranch,
+}
+
+func init() {
+	rootCmd.AddCommand(showBranchCmd)
+	showBranchCmd.Flags().BoolVarP(&showBranchAll, "all", "a", false, "Show remote-tracking and local branches")
+	showBranchCmd.Flags().BoolVarP(&showBranchRemotes, "remotes", "r", false, "Show remote-tracking branches")
+	showBranchCmd.Flags().IntVar(&showBranchMore, "more", 0, "Show n more commits after the common ancestor")
+	showBranchCmd.Flags().BoolVar(&showBranchList, "list", false, "Show only the branches, as --more=-1 does")
+}
+
+func runShowBranch(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	more := showBranchMore
+	if showBranchList {
+		more = -1
+	}
+
+	heads, remotes := showBranchAll, showBranchAll || showBranchRemotes
+	if len(args) == 0 && !heads && !remotes {
+		heads = true
+	}
+
+	var tips, names []string
+	add := func(name, hash string) error {
+		commit, err := repo.ResolveCommit(hash)
+		if err != nil {
+			// Refs to trees and blobs have no history to show
+			return nil
+		}
+		for _, n := range names {
+			if n == name {
+				return nil
+			}
+		}
+		if len(tips) == repository.MaxShowBranchTips {
+			fmt.Fprintf(os.Stderr, "warning: ignoring %s; cannot handle more than %d refs\n", name, repository.MaxShowBranchTips)
+			return nil
+		}
+		tips = append(tips, commit)
+		names = append(names, name)
+		return nil
+	}
+
+	// Branches are named without their prefix unless the short name
+	// resolves to something else
+	for _, kind := range []struct {
+		want   bool
+		prefix string
+		list   func() ([]string, error)
+	}{
+		{heads, "heads/", repo.Refs.ListBranches},
+		{remotes, "remotes/", repo.Refs.ListRemoteBranches},
+	} {
+		if !kind.want {
+			continue
+		}
+		branches, err := kind.list()
+		if err != nil {
+			return err
+		}
+		for _, branch := range branches {
+			hash, err := repo.Refs.ResolveRef("refs/" + kind.prefix + branch)
+			if err != nil || hash == "" {
+				continue
+			}
+			name := branch
+			if short, err := repo.ResolveRevision(branch); err != nil || short != hash {
+				name = kind.prefix + branch
+			}
+			if err := add(name, hash); err != nil {
+				return err
+			}
+		}
+	}
+	for _, arg := range args {
+		hash, err := repo.ResolveRevision(arg)
+		if err != nil {
+			return fmt.Errorf("bad sha1 reference %s", arg)
+		}
+		if err := add(arg, hash); err != nil {
+			return err
+		}
+	}
+	if len(tips) == 0 {
+		fmt.Fprintln(os.Stderr, "No revs to be shown.")
+		return nil
+	}
+
+	head, err := repo.Refs.ResolveHead()
+	if err != nil {
+		return fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	headName := "HEAD"
+	if branch, err := repo.Refs.CurrentBranch(); err == nil {
+		headName = branch
+	}
+	headAt := -1
+	for i, name := range names {
+		if tips[i] == head && isHeadName(headName, name) {
+			headAt = i
+		}
+	}
+
+	subjects := make(map[string]string)
+	subject := func(hash string) (string, error) {
+		if s, ok := subjects[hash]; ok {
+			return s, nil
+		}
+		commit, err := repo.Objects.ReadCommit(hash)
+		if err != nil {
+			return "", err
+		}
+		subjects[hash] = onelineSubject(commit)
+		return subjects[hash], nil
+	}
+
+	if len(tips) > 1 || more < 0 {
+		for i, name := range names {
+			s, err := subject(tips[i])
+			if err != nil {
+				return err
+			}
+			isHead := tips[i] == head && isHeadName(headName, name)
+			switch {
+			case more < 0 && isHead:
+				fmt.Printf("* [%s] %s\n", name, s)
+			case more < 0:
+				fmt.Printf("  [%s] %s\n", name, s)
+			case isHead:
+				fmt.Printf("%s* [%s] %s\n", strings.Repeat(" ", i), name, s)
+			default:
+				fmt.Printf("%s! [%s] %s\n", strings.Repeat(" ", i), name, s)
+			}
+		}
+		if more < 0 {
+			return nil
+		}
+		fmt.Println(strings.Repeat("-", len(tips)))
+	}
+
+	commits, err := repo.ShowBranch(tips, names, more)
+	if err != nil {
+		return err
+	}
+
+	tipSet := make(map[string]bool, len(tips))
+	for _, tip := range tips {
+		tipSet[tip] = true
+	}
+
+	shownCommon := false
+	for _, c := range commits {
+		shownCommon = shownCommon || c.ReachedByAll()
+
+		if len(tips) == 1 || showBranchListed(c, tipSet) {
+			if len(tips) > 1 {
+				var marks strings.Builder
+				for i, reached := range c.Reached {
+					switch {
+					case !reached:
+						marks.WriteByte(' ')
+					case c.IsMerge():
+						marks.WriteByte('-')
+					case i == headAt:
+						marks.WriteByte('*')
+					default:
+						marks.WriteByte('+')
+					}
+				}
+				fmt.Print(marks.String(), " ")
+			}
+
+			s, err := subject(c.Hash)
+			if err != nil {
+				return err
+			}
+			name := c.Name
+			if name == "" {
+				if name, err = object.Abbreviate(repo.Path, c.Hash, defaultAbbrev); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("[%s] %s\n", name, s)
+		}
+
+		if shownCommon {
+			if more--; more < 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// showBranchListed reports whether a commit is listed: everything but a
+// merge that only one branch has, which says little about how they relate,
+// and that isn't itself a branch tip
+func showBranchListed(c repository.ShowBranchCommit, tips map[string]bool) bool {
+	if !c.IsMerge() || tips[c.Hash] {
+		return true
+	}
+	count := 0
+	for _, reached := range c.Reached {
+		if reached {
+			count++
+		}
+	}
+	return count != 1
+}
+
+// isHeadName reports whether a branch name given to show-branch names the
+// current branch, which may be spelled "main", "heads/main" or
+// "refs/heads/main"
+func isHeadName(current, name string) bool {
+	if !strings.HasPrefix(name, "refs/heads/") {
+		name = strings.TrimPrefix(name, "heads/")
+	}
+	return strings.TrimPrefix(name, "refs/heads/") == current
+}
+
+// onelineSubject returns a commit's subject as one line: the first
+// paragraph of its message with the lines joined by spaces
+func onelineSubject(commit *object.Commit) string {
+	paragraph, _, _ := strings.Cut(strings.TrimLeft(commit.Message, "\n"), "\n\n")
+	lines := strings.Split(strings.TrimSpace(paragraph), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimPrefix(strings.Join(lines, " "), "[PATCH] ")
+}
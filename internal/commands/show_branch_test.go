@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestShowBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newTestRepo(t)
+
+	// show-branch orders commits by date, so each gets its own minute
+	minute := 0
+	gitCommit := func(message string) {
+		t.Helper()
+		date := fmt.Sprintf("2024-01-01T12:%02d:00Z", minute)
+		minute++
+		t.Setenv("GIT_AUTHOR_DATE", date)
+		t.Setenv("GIT_COMMITTER_DATE", date)
+		writeFile(t, "f", message+"\n")
+		git(t, root, "--git-dir=.gogit", "--work-tree=.", "add", "f")
+		git(t, root, "--git-dir=.gogit", "--work-tree=.", "commit", "-q", "-m", message)
+	}
+	gitCommit("base")
+	gitCommit("shared")
+	git(t, root, "--git-dir=.gogit", "branch", "topic")
+	gitCommit("main one")
+	gitCommit("main two")
+	git(t, root, "--git-dir=.gogit", "--work-tree=.", "checkout", "-q", "topic")
+	gitCommit("topic one")
+	git(t, root, "--git-dir=.gogit", "--work-tree=.", "checkout", "-q", "main")
+
+	for _, args := range [][]string{
+		nil,
+		{"main", "topic"},
+		{"topic", "main"},
+		{"--more=1", "main", "topic"},
+		{"--list"},
+		{"-a"},
+		{"main"},
+	} {
+		got := plain(mustGogit(t, append([]string{"show-branch"}, args...)...))
+		want := git(t, root, append([]string{"--git-dir=.gogit", "show-branch"}, args...)...)
+		if got != want {
+			t.Errorf("show-branch %v:\n%s\nwant what git prints:\n%s", args, got, want)
+		}
+	}
+
+	want := "* [main] main two\n" +
+		" ! [topic] topic one\n" +
+		"--\n" +
+		" + [topic] topic one\n" +
+		"*  [main] main two\n" +
+		"*  [main^] main one\n" +
+		"*+ [topic^] shared\n"
+	if got := plain(mustGogit(t, "show-branch")); got != want {
+		t.Errorf("show-branch:\n%s\nwant:\n%s", got, want)
+	}
+}
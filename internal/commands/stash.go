@@ -0,0 +1,527 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/ignore"
+	"github.com/yourusername/gogit/internal/index"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+var (
+	stashKeepIndex        bool
+	stashIncludeUntracked bool
+	stashMessage          string
+)
+
+var stashCmd = &cobra.Command{
+	Use:   "stash [push [-k] [-u] [-m <message>] | list | apply [<stash>] | pop [<stash>] | drop [<stash>]]",
+	Short: "Stash the changes in a dirty working directory away",
+	Long: `Save local changes away and go back to a clean working tree matching HEAD.
+The changes are recorded as a stash entry, the newest of which is
+stash@{0}, and can be brought back later, on top of this commit or another.
+
+push (the default) saves the staged and unstaged changes to tracked files.
+With -k/--keep-index, the staged changes are also left in the index and
+working tree. With -u/--include-untracked, untracked files are saved and
+removed too; ignored files are left alone. -m gives the entry a
+description.
+
+apply brings back the changes in a stash entry (default stash@{0}), merging
+them with the current working tree; changes to files that were tracked
+come back unstaged, new files staged, and untracked files untracked. It
+refuses to overwrite local changes or untracked files. pop does the same
+and then drops the entry unless there were conflicts. drop removes an
+entry, and list shows them all.`,
+	GroupID: groupMain,
+	Example: `  # Put local changes aside, untracked files included
+  gogit stash -u
+
+  # Stash only what isn't staged
+  gogit stash push --keep-index -m "debug logging"
+
+  # Bring the latest changes back
+  gogit stash pop`,
+	Args: cobra.NoArgs,
+	RunE: runStashPush,
+}
+
+var stashPushCmd = &cobra.Command{
+	Use:   "push [-k] [-u] [-m <message>]",
+	Short: "Save local changes to a new stash entry",
+	Args:  cobra.NoArgs,
+	RunE:  runStashPush,
+}
+
+var stashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the stash entries",
+	Args:  cobra.NoArgs,
+	RunE:  runStashList,
+}
+
+var stashApplyCmd = &cobra.Command{
+	Use:   "apply [<stash>]",
+	Short: "Apply a stash entry on top of the working tree",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStashApply(args, false)
+	},
+}
+
+var stashPopCmd = &cobra.Command{
+	Use:   "pop [<stash>]",
+	Short: "Apply a stash entry and drop it",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStashApply(args, true)
+	},
+}
+
+var stashDropCmd = &cobra.Command{
+	Use:   "drop [<stash>]",
+	Short: "Remove a stash entry",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runStashDrop,
+}
+
+func init() {
+	rootCmd.AddCommand(stashCmd)
+	stashCmd.AddCommand(stashPushCmd, stashListCmd, stashApplyCmd, stashPopCmd, stashDropCmd)
+	for _, cmd := range []*cobra.Command{stashCmd, stashPushCmd} {
+		cmd.Flags().BoolVarP(&stashKeepIndex, "keep-index", "k", false, "Leave the staged changes in the index and working tree")
+		cmd.Flags().BoolVarP(&stashIncludeUntracked, "include-untracked", "u", false, "Also stash and remove untracked files")
+		cmd.Flags().StringVarP(&stashMessage, "message", "m", "", "Describe the stash entry")
+	}
+}
+
+// A stash entry is a commit of the working tree whose first parent is the
+// commit HEAD was at, second parent a commit of the index, and third
+// parent, with -u, a parentless commit of the untracked files, as in git
+const (
+	stashParentIndex     = 1
+	stashParentUntracked = 2
+)
+
+func runStashPush(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	headHash, _ := repo.Refs.ResolveHead()
+	if headHash == "" {
+		return fmt.Errorf("you do not have the initial commit yet")
+	}
+	head, err := repo.Objects.ReadCommit(headHash)
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	headFiles, idx, err := headAndIndex(repo)
+	if err != nil {
+		return err
+	}
+	if conflicts := idx.Conflicts(); len(conflicts) > 0 {
+		return fmt.Errorf("cannot save the current index state: %s needs merge", conflicts[0])
+	}
+
+	// Everything is queued on one batch, written once the stash is complete
+	batch := object.NewBatch(repoRoot)
+	indexFiles := indexSnapshot(idx)
+	worktreeFiles, err := stashWorktreeFiles(repoRoot, indexFiles, batch)
+	if err != nil {
+		return err
+	}
+	var untracked []string
+	if stashIncludeUntracked {
+		if untracked, err = untrackedFiles(repo, idx); err != nil {
+			return err
+		}
+	}
+
+	if len(diff.DiffEntries(headFiles, indexFiles)) == 0 && len(diff.DiffEntries(indexFiles, worktreeFiles)) == 0 && len(untracked) == 0 {
+		fmt.Println("No local changes to save")
+		return nil
+	}
+
+	ident, err := repo.GetUserInfo()
+	if err != nil {
+		return err
+	}
+	branch, err := repo.Refs.CurrentBranch()
+	if err != nil {
+		branch = "(no branch)"
+	}
+	onHead := fmt.Sprintf("%s: %s %s", branch, headHash[:7], strings.SplitN(head.Message, "\n", 2)[0])
+
+	indexTree, err := repo.BuildTreeRecursive(idx, batch)
+	if err != nil {
+		return fmt.Errorf("failed to build tree: %w", err)
+	}
+	indexCommit := batch.Add(object.NewCommit(indexTree, []string{headHash}, ident, "index on "+onHead+"\n"))
+
+	worktreeTree, err := treeOfFiles(repo, worktreeFiles, batch)
+	if err != nil {
+		return err
+	}
+	message := "WIP on " + onHead
+	if stashMessage != "" {
+		message = fmt.Sprintf("On %s: %s", branch, stashMessage)
+	}
+	stash := object.NewCommit(worktreeTree, []string{headHash, indexCommit}, ident, message+"\n")
+
+	if len(untracked) > 0 {
+		untrackedFiles := make(map[string]object.TreeEntry, len(untracked))
+		for _, path := range untracked {
+			info, err := os.Stat(filepath.Join(repoRoot, path))
+			if err != nil {
+				return fmt.Errorf("failed to stat %s: %w", path, err)
+			}
+			content, err := os.ReadFile(filepath.Join(repoRoot, path))
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			untrackedFiles[path] = object.TreeEntry{Mode: fileMode(info), Hash: batch.Add(object.NewBlob(content))}
+		}
+		untrackedTree, err := treeOfFiles(repo, untrackedFiles, batch)
+		if err != nil {
+			return err
+		}
+		untrackedCommit := batch.Add(object.NewCommit(untrackedTree, nil, ident, "untracked files on "+onHead+"\n"))
+		stash.Parents = append(stash.Parents, untrackedCommit)
+	}
+
+	stashHash := batch.Add(stash)
+	if err := batch.Flush(); err != nil {
+		return fmt.Errorf("failed to write stash: %w", err)
+	}
+	if err := repo.PushStash(stashHash, message); err != nil {
+		return err
+	}
+	fmt.Printf("Saved working directory and index state %s\n", message)
+
+	// The changes are safe in the stash: go back to HEAD, or with
+	// --keep-index to the staged changes
+	target := headFiles
+	if stashKeepIndex {
+		target = indexFiles
+	}
+	if err := applyReset(repoRoot, idx, planHardReset(repoRoot, indexFiles, target)); err != nil {
+		return err
+	}
+	for _, path := range untracked {
+		if err := removeWorktreeFile(repoRoot, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stashWorktreeFiles returns the tracked files as they are in the working
+// tree, queueing the blobs of changed ones on batch. Files deleted from the
+// working tree are left out.
+func stashWorktreeFiles(repoRoot string, indexFiles map[string]object.TreeEntry, batch *object.Batch) (map[string]object.TreeEntry, error) {
+	files := make(map[string]object.TreeEntry, len(indexFiles))
+	for path, entry := range indexFiles {
+		if entry.Mode == object.ModeGitlink {
+			files[path] = entry
+			continue
+		}
+		w, ok := worktreeEntry(repoRoot, path)
+		if !ok {
+			continue
+		}
+		if w.Hash != entry.Hash {
+			content, err := os.ReadFile(filepath.Join(repoRoot, path))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			w.Hash = batch.Add(object.NewBlob(content))
+		}
+		files[path] = w
+	}
+	return files, nil
+}
+
+// treeOfFiles queues the trees holding a set of files on batch and returns
+// the root tree's hash
+func treeOfFiles(repo *repository.Repository, files map[string]object.TreeEntry, batch *object.Batch) (string, error) {
+	idx := index.NewIndex()
+	for path, entry := range files {
+		mode, err := strconv.ParseUint(entry.Mode, 8, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid mode %s for %s", entry.Mode, path)
+		}
+		if err := idx.AddBlob(path, uint32(mode), entry.Hash); err != nil {
+			return "", err
+		}
+	}
+	tree, err := repo.BuildTreeRecursive(idx, batch)
+	if err != nil {
+		return "", fmt.Errorf("failed to build tree: %w", err)
+	}
+	return tree, nil
+}
+
+// untrackedFiles returns the files in the working tree that are neither
+// tracked nor ignored, sorted
+func untrackedFiles(repo *repository.Repository, idx *index.Index) ([]string, error) {
+	tracked := make(map[string]bool, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		tracked[entry.Path] = true
+	}
+
+	var files []string
+	matcher := ignore.NewMatcher(repo.Path, repo.GitDir)
+	err := filepath.Walk(repo.Path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(repo.Path, path)
+		if err != nil {
+			return err
+		}
+		slashPath := filepath.ToSlash(relPath)
+
+		if info.IsDir() {
+			if relPath == "." {
+				return nil
+			}
+			if utils.IsGitDirName(info.Name()) || tracked[slashPath] || matcher.Match(relPath, true) {
+				return filepath.SkipDir
+			}
+			matcher.LoadDir(relPath)
+			return nil
+		}
+		if !tracked[slashPath] && !matcher.Match(relPath, false) {
+			files = append(files, slashPath)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func runStashList(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	stashes, err := repo.Stashes()
+	if err != nil {
+		return err
+	}
+	for i, entry := range stashes {
+		fmt.Printf("stash@{%d}: %s\n", i, entry.Message)
+	}
+	return nil
+}
+
+// stashRefPattern matches the ways of naming a stash entry: stash@{n},
+// refs/stash@{n} or just n
+var stashRefPattern = regexp.MustCompile(`^(?:(?:refs/)?stash@\{(\d+)\}|(\d+))$`)
+
+// resolveStash returns which entry a stash argument names, stash@{0} if
+// there is none, and its commit
+func resolveStash(repo *repository.Repository, args []string) (int, string, error) {
+	n := 0
+	if len(args) > 0 {
+		m := stashRefPattern.FindStringSubmatch(args[0])
+		if m == nil {
+			return 0, "", fmt.Errorf("'%s' is not a stash reference", args[0])
+		}
+		n, _ = strconv.Atoi(m[1] + m[2])
+	}
+
+	stashes, err := repo.Stashes()
+	if err != nil {
+		return 0, "", err
+	}
+	if len(stashes) == 0 {
+		return 0, "", fmt.Errorf("no stash entries found")
+	}
+	if n >= len(stashes) {
+		return 0, "", fmt.Errorf("stash@{%d} is not a valid reference", n)
+	}
+	return n, stashes[n].NewHash, nil
+}
+
+func runStashApply(args []string, pop bool) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	n, stashHash, err := resolveStash(repo, args)
+	if err != nil {
+		return err
+	}
+	stash, err := repo.Objects.ReadCommit(stashHash)
+	if err != nil {
+		return err
+	}
+	parents := stash.Parents
+	if len(parents) < 2 {
+		return fmt.Errorf("%s is not a stash-like commit", stashHash)
+	}
+
+	_, idx, err := headAndIndex(repo)
+	if err != nil {
+		return err
+	}
+	if len(idx.Conflicts()) > 0 {
+		return fmt.Errorf("cannot apply a stash with unmerged paths in the index")
+	}
+	baseFiles, err := commitFiles(repo, parents[0])
+	if err != nil {
+		return err
+	}
+	stashFiles, err := diff.FlattenTree(repo.Objects, stash.TreeHash)
+	if err != nil {
+		return err
+	}
+	var untracked map[string]object.TreeEntry
+	if len(parents) > stashParentUntracked {
+		if untracked, err = commitFiles(repo, parents[stashParentUntracked]); err != nil {
+			return err
+		}
+	}
+
+	oursFiles := indexSnapshot(idx)
+	entries := mergeFiles(baseFiles, oursFiles, stashFiles)
+	if err := mergeContents(repo, entries); err != nil {
+		return err
+	}
+
+	// Refuse before touching anything if local changes or untracked files
+	// would be overwritten
+	var dirty, existing []string
+	for _, e := range entries {
+		if !e.conflict && sameEntry(e.result, e.exists, e.ours, e.inOurs) {
+			continue
+		}
+		w, inWorktree := worktreeEntry(repoRoot, e.path)
+		if !sameEntry(w, inWorktree, e.ours, e.inOurs) {
+			dirty = append(dirty, e.path)
+		}
+	}
+	for path := range untracked {
+		if _, err := os.Lstat(filepath.Join(repoRoot, path)); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	if len(dirty) > 0 {
+		return fmt.Errorf("your local changes to the following files would be overwritten by merge:\n\t%s\nPlease commit your changes or stash them before you merge", strings.Join(dirty, "\n\t"))
+	}
+	if len(existing) > 0 {
+		sort.Strings(existing)
+		return fmt.Errorf("the following untracked files would be overwritten:\n\t%s\ncould not restore untracked files from stash", strings.Join(existing, "\n\t"))
+	}
+
+	// Changes to tracked files come back unstaged; only new files are added
+	var conflicts []string
+	for _, e := range entries {
+		switch {
+		case e.conflict:
+			if err := recordConflict(repo, idx, e, "Stashed changes"); err != nil {
+				return err
+			}
+			conflicts = append(conflicts, e.path)
+		case sameEntry(e.result, e.exists, e.ours, e.inOurs):
+		case !e.exists:
+			if err := removeWorktreeFile(repoRoot, e.path); err != nil {
+				return err
+			}
+		default:
+			if err := checkoutEntry(repoRoot, e.path, e.result); err != nil {
+				return err
+			}
+			if !e.inOurs {
+				if err := stageCheckedOut(repoRoot, idx, e.path, e.result); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := idx.Write(repoRoot); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	paths := make([]string, 0, len(untracked))
+	for path := range untracked {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := checkoutEntry(repoRoot, path, untracked[path]); err != nil {
+			return err
+		}
+	}
+
+	if len(conflicts) > 0 {
+		for _, path := range conflicts {
+			fmt.Printf("CONFLICT (content): Merge conflict in %s\n", path)
+		}
+		if pop {
+			fmt.Println("The stash entry is kept in case you need it again.")
+		}
+		return &ExitError{Code: exitNo}
+	}
+
+	if pop {
+		if _, err := repo.DropStash(n); err != nil {
+			return err
+		}
+		fmt.Printf("Dropped refs/stash@{%d} (%s)\n", n, stashHash)
+	}
+	return nil
+}
+
+func runStashDrop(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	n, _, err := resolveStash(repo, args)
+	if err != nil {
+		return err
+	}
+	dropped, err := repo.DropStash(n)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Dropped refs/stash@{%d} (%s)\n", n, dropped)
+	return nil
+}
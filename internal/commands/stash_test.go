@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStashKeepIndex(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"a": "a\n", "b": "b\n"})
+	writeFile(t, "a", "a2\n")
+	mustGogit(t, "add", "a")
+	writeFile(t, "b", "b2\n")
+
+	mustGogit(t, "stash", "push", "-k")
+	if got := mustGogit(t, "diff", "--cached", "--name-only"); got != "a\n" {
+		t.Errorf("staged after stash -k: %q, want a", got)
+	}
+	if got := readFile(t, "a"); got != "a2\n" {
+		t.Errorf("a after stash -k: %q, want the staged content", got)
+	}
+	if got := readFile(t, "b"); got != "b\n" {
+		t.Errorf("b after stash -k: %q, want the unstaged change stashed", got)
+	}
+
+	mustGogit(t, "reset", "--hard", "-f")
+	mustGogit(t, "stash", "pop")
+	for path, want := range map[string]string{"a": "a2\n", "b": "b2\n"} {
+		if got := readFile(t, path); got != want {
+			t.Errorf("%s after pop: %q, want %q", path, got, want)
+		}
+	}
+	if got := mustGogit(t, "stash", "list"); got != "" {
+		t.Errorf("stash list after pop: %q, want nothing", got)
+	}
+}
+
+func TestStashIncludeUntracked(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"a": "a\n"})
+	writeFile(t, "a", "a2\n")
+	writeFile(t, "dir/untracked", "u\n")
+
+	// Without -u the untracked file stays where it is
+	mustGogit(t, "stash")
+	if got := readFile(t, "dir/untracked"); got != "u\n" {
+		t.Errorf("untracked file after stash: %q", got)
+	}
+	mustGogit(t, "stash", "pop")
+
+	mustGogit(t, "stash", "push", "-u")
+	if _, err := os.Stat("dir/untracked"); !os.IsNotExist(err) {
+		t.Errorf("untracked file after stash -u: %v, want it removed", err)
+	}
+	if got := plain(mustGogit(t, "status")); got != "On branch main\n\nnothing to commit, working tree clean\n" {
+		t.Errorf("status after stash -u:\n%s\nwant a clean tree", got)
+	}
+
+	mustGogit(t, "stash", "pop")
+	for path, want := range map[string]string{"a": "a2\n", "dir/untracked": "u\n"} {
+		if got := readFile(t, path); got != want {
+			t.Errorf("%s after pop: %q, want %q", path, got, want)
+		}
+	}
+	if got := mustGogit(t, "ls-files"); got != "a\n" {
+		t.Errorf("ls-files after pop: %q, want the untracked file left untracked", got)
+	}
+}
@@ -6,21 +6,38 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/ignore"
 	"github.com/yourusername/gogit/internal/index"
 	"github.com/yourusername/gogit/internal/object"
 	"github.com/yourusername/gogit/internal/repository"
 	"github.com/yourusername/gogit/internal/utils"
 )
 
+var (
+	statusIgnored bool
+)
+
 var statusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [--ignored]",
 	Short: "Show the working tree status",
-	Long:  `Display paths that have differences between the index and the current HEAD commit, and paths that have differences between the working tree and the index.`,
-	RunE:  runStatus,
+	Long: `Display paths that have differences between the index and the current HEAD commit, and paths that have differences between the working tree and the index.
+
+Untracked files matched by the rules in .gogitignore or .gitignore files, or
+in info/exclude, are left out; --ignored lists them in a section of their
+own. A directory whose whole contents are ignored is listed as "dir/".`,
+	GroupID: groupMain,
+	Example: `  # Show staged, unstaged and untracked changes
+  gogit status
+
+  # Also list the files ignore rules hide
+  gogit status --ignored`,
+	RunE: runStatus,
 }
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusIgnored, "ignored", false, "Also show ignored files")
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
@@ -29,14 +46,32 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
 	// Get current branch
-	refs := repository.NewRefs(repoRoot)
+	refs := repo.Refs
 	branch, err := refs.CurrentBranch()
 	if err != nil {
 		branch = "HEAD (detached)"
 	}
 
-	fmt.Printf("On branch %s\n\n", branch)
+	fmt.Printf("On branch %s\n", branch)
+	if mergeHead, _ := repo.MergeHead(); mergeHead != "" {
+		fmt.Println("You have unmerged paths or an unconcluded merge.")
+		fmt.Println("  (fix conflicts and run \"gogit commit\")")
+		fmt.Println("  (use \"gogit merge --abort\" to abort the merge)")
+	}
+	fmt.Println()
+
+	// An unborn branch says so whatever else is to be shown
+	headCommitHash, _ := refs.ResolveHead()
+	if headCommitHash == "" {
+		fmt.Println("No commits yet")
+		fmt.Println()
+	}
 
 	// Read index
 	idx, err := index.ReadIndex(repoRoot)
@@ -44,82 +79,140 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to read index: %w", err)
 	}
 
-	// Get HEAD tree (if exists)
-	headTree := make(map[string]string) // path -> hash
-	headCommitHash, err := refs.ResolveHead()
-	if err == nil && headCommitHash != "" {
-		obj, err := object.ReadObject(repoRoot, headCommitHash)
-		if err == nil {
-			if commit, ok := obj.(*object.Commit); ok {
-				treeObj, err := object.ReadObject(repoRoot, commit.TreeHash)
-				if err == nil {
-					if tree, ok := treeObj.(*object.Tree); ok {
-						for _, entry := range tree.Entries {
-							headTree[entry.Name] = entry.Hash
-						}
-					}
-				}
-			}
-		}
+	// Get HEAD tree (if exists), including files in subdirectories
+	headTreeHash, err := headTreeHash(repo)
+	if err != nil {
+		return err
+	}
+	headTree, err := diff.FlattenTree(repo.Objects, headTreeHash) // path -> entry
+	if err != nil {
+		return err
 	}
 
 	// Build index map
-	indexMap := make(map[string]string) // path -> hash
-	for _, entry := range idx.Entries {
-		indexMap[entry.Path] = entry.HashString()
+	indexMap := indexSnapshot(idx) // path -> entry
+
+	// Conflicted paths are reported on their own
+	unmerged := idx.Conflicts()
+	unmergedSet := make(map[string]bool, len(unmerged))
+	for _, path := range unmerged {
+		unmergedSet[path] = true
 	}
 
-	// Find staged changes (index vs HEAD)
-	var stagedNew, stagedModified, stagedDeleted []string
-	for path, hash := range indexMap {
-		if headHash, exists := headTree[path]; !exists {
-			stagedNew = append(stagedNew, path)
-		} else if headHash != hash {
-			stagedModified = append(stagedModified, path)
+	// Find staged changes (index vs HEAD), including mode-only changes,
+	// with a moved file shown as one rename
+	var staged []diff.FileChange
+	for _, change := range diff.DiffEntries(headTree, indexMap) {
+		if !unmergedSet[change.Path()] {
+			staged = append(staged, change)
 		}
 	}
-	for path := range headTree {
-		if _, exists := indexMap[path]; !exists {
-			stagedDeleted = append(stagedDeleted, path)
+	if repo.ConfigBool("status.renames", repo.ConfigBool("diff.renames", true)) {
+		opts, err := renameOptions(repo, "", -1)
+		if err != nil {
+			return err
 		}
+		var needed int
+		if staged, needed, err = diff.DetectRenames(repo.Objects, staged, opts); err != nil {
+			return err
+		}
+		warnRenameLimit(needed)
 	}
 
-	// Find working tree changes (working dir vs index)
-	var notStaged, untracked []string
+	// Find working tree changes (working dir vs index). Without
+	// core.filemode, executable bit differences are ignored.
+	trustExecBit := repo.ConfigBool("core.filemode", true)
+	eol := worktreeEOL(repo)
+	var notStaged, untracked, ignored []string
 	worktreeFiles := make(map[string]bool)
 
+	// An ignored directory is skipped unless it holds tracked files, in
+	// which case its untracked files are ignored along with it
+	matcher := ignore.NewMatcher(repoRoot, repo.GitDir)
+	trackedDirs := make(map[string]bool)
+	for path := range indexMap {
+		for dir := filepath.Dir(path); dir != "."; dir = filepath.Dir(dir) {
+			trackedDirs[dir] = true
+		}
+	}
+	ignoredDirs := make(map[string]bool)
+	refreshed := false
+	isIgnored := func(relPath string, isDir bool) bool {
+		return ignoredDirs[filepath.Dir(relPath)] || matcher.Match(relPath, isDir)
+	}
+
 	err = filepath.Walk(repoRoot, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
 
-		// Skip .gogit directory
-		if info.IsDir() && info.Name() == ".gogit" {
+		// Skip the metadata directory
+		if info.IsDir() && utils.IsGitDirName(info.Name()) {
 			return filepath.SkipDir
 		}
 
-		if info.IsDir() {
+		relPath, err := filepath.Rel(repoRoot, path)
+		if err != nil {
 			return nil
 		}
 
-		relPath, err := filepath.Rel(repoRoot, path)
-		if err != nil {
+		if info.IsDir() {
+			// A submodule's files belong to the submodule; only the commit
+			// it is at matters here
+			if entry, ok := indexMap[relPath]; ok && entry.Mode == object.ModeGitlink {
+				worktreeFiles[relPath] = true
+				if current, _ := gitlinkEntry(repoRoot, relPath, entry.Hash); current.Hash != entry.Hash {
+					notStaged = append(notStaged, relPath+" (new commits)")
+				}
+				return filepath.SkipDir
+			}
+			if relPath == "." {
+				return nil
+			}
+			if isIgnored(relPath, true) {
+				if !trackedDirs[relPath] {
+					ignored = append(ignored, relPath+string(filepath.Separator))
+					return filepath.SkipDir
+				}
+				ignoredDirs[relPath] = true
+			}
+			matcher.LoadDir(relPath)
 			return nil
 		}
 
 		worktreeFiles[relPath] = true
+		if unmergedSet[relPath] {
+			return nil
+		}
 
 		// Check if file is in index
-		if indexHash, exists := indexMap[relPath]; exists {
-			// Compare with working tree
+		if indexEntry, exists := indexMap[relPath]; exists {
+			if trustExecBit && fileMode(info) != indexEntry.Mode {
+				notStaged = append(notStaged, relPath)
+				return nil
+			}
+
+			// A file whose stat data matches its entry hasn't changed since
+			// it was staged, and needn't be read
+			entry := idx.GetEntry(relPath)
+			if entry.StatMatches(info) && !idx.IsRacy(entry) {
+				return nil
+			}
+
 			content, err := os.ReadFile(path)
 			if err != nil {
 				return nil
 			}
-			currentHash := utils.HashObject("blob", content)
-			if currentHash != indexHash {
+			if utils.HashObject("blob", content) != indexEntry.Hash &&
+				utils.HashObject("blob", cleanContent(repoRoot, eol, relPath, content, indexEntry.Hash)) != indexEntry.Hash {
 				notStaged = append(notStaged, relPath)
+			} else {
+				// Save reading it next time
+				entry.SetStat(info)
+				refreshed = true
 			}
+		} else if isIgnored(relPath, false) {
+			ignored = append(ignored, relPath)
 		} else {
 			untracked = append(untracked, relPath)
 		}
@@ -130,6 +223,12 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to walk working tree: %w", err)
 	}
 
+	// Another command may hold the index lock, in which case the stat data
+	// is simply refreshed another time
+	if refreshed {
+		idx.Write(repoRoot)
+	}
+
 	// Find deleted files (in index but not in working tree)
 	var deletedNotStaged []string
 	for path := range indexMap {
@@ -139,22 +238,36 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Print results
-	hasStaged := len(stagedNew) > 0 || len(stagedModified) > 0 || len(stagedDeleted) > 0
+	hasStaged := len(staged) > 0
 	hasNotStaged := len(notStaged) > 0 || len(deletedNotStaged) > 0
 	hasUntracked := len(untracked) > 0
+	hasIgnored := statusIgnored && len(ignored) > 0
 
 	if hasStaged {
 		fmt.Println("Changes to be committed:")
 		fmt.Println("  (use \"gogit restore --staged <file>...\" to unstage)")
 		fmt.Println()
-		for _, f := range stagedNew {
-			fmt.Printf("\t\033[32mnew file:   %s\033[0m\n", f)
-		}
-		for _, f := range stagedModified {
-			fmt.Printf("\t\033[32mmodified:   %s\033[0m\n", f)
+		for _, change := range staged {
+			switch change.Status {
+			case diff.StatusAdded:
+				fmt.Printf("\t\033[32mnew file:   %s\033[0m\n", change.NewPath)
+			case diff.StatusDeleted:
+				fmt.Printf("\t\033[32mdeleted:    %s\033[0m\n", change.OldPath)
+			case diff.StatusRenamed:
+				fmt.Printf("\t\033[32mrenamed:    %s -> %s\033[0m\n", change.OldPath, change.NewPath)
+			default:
+				fmt.Printf("\t\033[32mmodified:   %s\033[0m\n", change.Path())
+			}
 		}
-		for _, f := range stagedDeleted {
-			fmt.Printf("\t\033[32mdeleted:    %s\033[0m\n", f)
+		fmt.Println()
+	}
+
+	if len(unmerged) > 0 {
+		fmt.Println("Unmerged paths:")
+		fmt.Println("  (use \"gogit add <file>...\" to mark resolution)")
+		fmt.Println()
+		for _, f := range unmerged {
+			fmt.Printf("\t\033[31mboth modified:   %s\033[0m\n", f)
 		}
 		fmt.Println()
 	}
@@ -182,9 +295,19 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
-	if !hasStaged && !hasNotStaged && !hasUntracked {
+	if hasIgnored {
+		fmt.Println("Ignored files:")
+		fmt.Println("  (use \"gogit add <file>...\" to include in what will be committed)")
+		fmt.Println()
+		for _, f := range ignored {
+			fmt.Printf("\t\033[31m%s\033[0m\n", f)
+		}
+		fmt.Println()
+	}
+
+	if !hasStaged && !hasNotStaged && !hasUntracked && len(unmerged) == 0 {
 		if headCommitHash == "" {
-			fmt.Println("No commits yet")
+			fmt.Println("nothing to commit (create/copy files and use \"gogit add\" to track)")
 		} else {
 			fmt.Println("nothing to commit, working tree clean")
 		}
@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStatusSeesRacyChange changes a file within the same timestamp the
+// index was written in, keeping its size and mtime, which only the racy
+// entry check can catch
+func TestStatusSeesRacyChange(t *testing.T) {
+	root := newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "aaa\n"})
+
+	info, err := os.Stat("f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "f", "bbb\n")
+	stamp := info.ModTime()
+	for _, path := range []string{"f", filepath.Join(root, ".gogit", "index")} {
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if out := mustGogit(t, "status"); !strings.Contains(out, "modified:   f") {
+		t.Errorf("status:\n%s\nwant f reported as modified", out)
+	}
+
+	// Rewriting the index makes it newer than f; f's entry must not start
+	// looking trustworthy
+	writeFile(t, "g", "g\n")
+	mustGogit(t, "add", "g")
+	if out := mustGogit(t, "status"); !strings.Contains(out, "modified:   f") {
+		t.Errorf("status after the index was rewritten:\n%s\nwant f still reported as modified", out)
+	}
+}
+
+func TestStatusRefreshesStatData(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "aaa\n"})
+
+	// Touching a file changes its stat data but not its content
+	info, err := os.Stat("f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	earlier := info.ModTime().Add(-time.Hour)
+	if err := os.Chtimes("f", earlier, earlier); err != nil {
+		t.Fatal(err)
+	}
+
+	if out := mustGogit(t, "status"); !strings.Contains(out, "nothing to commit") {
+		t.Errorf("status:\n%s\nwant a clean tree", out)
+	}
+}
+
+// BenchmarkStatusClean runs status on an unchanged tree of 5000 files,
+// where stat data lets every file go unread
+func BenchmarkStatusClean(b *testing.B) {
+	newTestRepo(b)
+	for i := 0; i < 5000; i++ {
+		writeFile(b, fmt.Sprintf("dir%02d/file%04d.txt", i%50, i), fmt.Sprintf("content %d\n", i))
+	}
+	mustGogit(b, "add", ".")
+	mustGogit(b, "commit", "-m", "files")
+	mustGogit(b, "status") // Settle racy entries
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mustGogit(b, "status")
+	}
+}
+
+func TestStatusSeesModeChange(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"x": "x\n"})
+	if err := os.Chmod("x", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if out := plain(mustGogit(t, "status")); !strings.Contains(out, "modified:   x") {
+		t.Errorf("status:\n%s\nwant x reported as modified", out)
+	}
+	want := "diff --git a/x b/x\nold mode 100644\nnew mode 100755\n"
+	if got := plain(mustGogit(t, "diff")); got != want {
+		t.Errorf("diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	mustGogit(t, "add", "x")
+	want = ":100644 100755 587be6b4c3f93f93c489c0111bba5596147a26cb 587be6b4c3f93f93c489c0111bba5596147a26cb M\tx\n"
+	if got := plain(mustGogit(t, "diff", "--cached", "--raw")); got != want {
+		t.Errorf("diff --cached --raw after add:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStatusShowsStagedRename(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"old.txt": "some content\nover a few\nlines\n", "other": "x\n"})
+
+	mustGogit(t, "rm", "-q", "old.txt")
+	writeFile(t, "new.txt", "some content\nover a few\nlines\n")
+	mustGogit(t, "add", "new.txt")
+
+	out := plain(mustGogit(t, "status"))
+	if !strings.Contains(out, "renamed:    old.txt -> new.txt") {
+		t.Errorf("status:\n%s\nwant the move shown as a rename", out)
+	}
+	for _, unwanted := range []string{"deleted:", "new file:"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("status:\n%s\nwant no %q line for the renamed file", out, unwanted)
+		}
+	}
+}
+
+func TestFileModeConfig(t *testing.T) {
+	for _, fileMode := range []string{"false", "true"} {
+		t.Run("core.fileMode="+fileMode, func(t *testing.T) {
+			newTestRepo(t)
+			mustGogit(t, "config", "core.fileMode", fileMode)
+			commitWorktree(t, "one", map[string]string{"x": "x\n"})
+			if err := os.Chmod("x", 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			reported := fileMode == "true"
+			status := plain(mustGogit(t, "status"))
+			if strings.Contains(status, "modified:   x") != reported {
+				t.Errorf("status:\n%s\nwant the exec bit flip reported: %v", status, reported)
+			}
+			if out := mustGogit(t, "diff", "--name-only"); (out == "x\n") != reported {
+				t.Errorf("diff --name-only = %q, want the exec bit flip reported: %v", out, reported)
+			}
+
+			mustGogit(t, "add", "x")
+			if out := mustGogit(t, "diff", "--cached", "--name-only"); (out == "x\n") != reported {
+				t.Errorf("after add, diff --cached --name-only = %q, want the mode staged: %v", out, reported)
+			}
+		})
+	}
+}
+
+func TestStatusIgnored(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{".gogitignore": "*.log\nbuild/\n"})
+	writeFile(t, "notes.txt", "n\n")
+	writeFile(t, "debug.log", "d\n")
+	writeFile(t, "build/out", "o\n")
+	writeFile(t, "sub/trace.log", "t\n")
+
+	out := plain(mustGogit(t, "status"))
+	if !strings.Contains(out, "Untracked files:") || !strings.Contains(out, "\tnotes.txt\n") {
+		t.Errorf("status:\n%s\nwant notes.txt untracked", out)
+	}
+	for _, ignored := range []string{"debug.log", "build", "trace.log", "Ignored files:"} {
+		if strings.Contains(out, ignored) {
+			t.Errorf("status without --ignored:\n%s\nmentions %s", out, ignored)
+		}
+	}
+
+	out = plain(mustGogit(t, "status", "--ignored"))
+	untracked, ignored, ok := strings.Cut(out, "Ignored files:")
+	if !ok {
+		t.Fatalf("status --ignored:\n%s\nhas no ignored section", out)
+	}
+	for _, path := range []string{"\tdebug.log\n", "\tbuild/\n", "\tsub/trace.log\n"} {
+		if !strings.Contains(ignored, path) {
+			t.Errorf("ignored section:\n%s\nwant %q", ignored, path)
+		}
+		if strings.Contains(untracked, path) {
+			t.Errorf("status --ignored:\n%s\nlists %q as untracked too", untracked, path)
+		}
+	}
+	if strings.Contains(ignored, "notes.txt") || strings.Contains(ignored, "build/out") {
+		t.Errorf("ignored section:\n%s\nwant only the ignored paths, with build/ as one entry", ignored)
+	}
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	switchCreate string
+	switchDetach bool
+)
+
+var switchCmd = &cobra.Command{
+	Use:   "switch (<branch> | -c <new-branch> | --detach <commit> | -)",
+	Short: "Switch branches",
+	Long: `Switch to a branch, updating the index and working tree to match it.
+
+  -c <new-branch>  Create a branch at HEAD and switch to it
+  --detach         Check out a commit without being on any branch
+  -                Switch back to the branch you were on before`,
+	GroupID: groupMain,
+	Example: `  # Switch to a branch
+  gogit switch main
+
+  # Create a branch and switch to it
+  gogit switch -c feature
+
+  # Look at an old commit without a branch
+  gogit switch --detach v1.0`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if switchCreate != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: runSwitch,
+}
+
+func init() {
+	rootCmd.AddCommand(switchCmd)
+	switchCmd.Flags().StringVarP(&switchCreate, "create", "c", "", "Create a new branch and switch to it")
+	switchCmd.Flags().BoolVar(&switchDetach, "detach", false, "Detach HEAD at the named commit")
+}
+
+func runSwitch(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	if switchCreate != "" {
+		return createAndSwitchBranch(repo, switchCreate)
+	}
+
+	target := args[0]
+	detach := switchDetach
+	if target == "-" {
+		if target, err = repo.Refs.PreviousCheckout(); err != nil {
+			return err
+		}
+		// HEAD was detached before the last switch
+		if existing, _ := repo.Refs.GetBranchCommit(target); existing == "" && object.IsHash(target) {
+			detach = true
+		}
+	}
+
+	if detach {
+		commitHash, err := repo.ResolveCommit(target)
+		if err != nil {
+			return err
+		}
+		return detachHead(repo, commitHash)
+	}
+
+	branchCommit, err := repo.Refs.GetBranchCommit(target)
+	if err != nil {
+		return err
+	}
+	if branchCommit == "" {
+		return fmt.Errorf("invalid reference: %s", target)
+	}
+	return switchToBranch(repo, target, branchCommit)
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	tagDelete bool
+	tagList   bool
+	tagSort   string
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag [-l] [--sort=<key>] | <name> [<commit>] | -d <name>...",
+	Short: "List, create, or delete tags",
+	Long: `Without arguments, or with -l, list all tags. With a name, create a
+lightweight tag pointing to <commit>, or to HEAD if none is given.
+
+--sort orders the listing by refname (the default), creatordate (the tagger
+date of an annotated tag, the committer date otherwise) or version:refname,
+which compares numbers in tag names by value so v1.10 comes after v1.9.
+Prefix the key with "-" to reverse the order.`,
+	GroupID: groupMain,
+	Example: `  # List tags in version order
+  gogit tag --sort=version:refname
+
+  # Tag the current commit
+  gogit tag v1.2.0
+
+  # Delete a tag
+  gogit tag -d v1.2.0`,
+	RunE: runTag,
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.Flags().BoolVarP(&tagDelete, "delete", "d", false, "Delete tags")
+	tagCmd.Flags().BoolVarP(&tagList, "list", "l", false, "List tags")
+	tagCmd.Flags().StringVar(&tagSort, "sort", "refname", "Sort tags by refname, creatordate or version:refname; prefix with - to reverse")
+}
+
+func runTag(cmd *cobra.Command, args []string) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	// Delete tags
+	if tagDelete {
+		if len(args) == 0 {
+			return fmt.Errorf("tag name required for deletion")
+		}
+		for _, name := range args {
+			hash, err := repo.Refs.DeleteTag(name)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Deleted tag '%s' (was %s)\n", name, hash[:7])
+		}
+		return nil
+	}
+
+	// Create tag
+	if len(args) > 0 && !tagList {
+		if len(args) > 2 {
+			return fmt.Errorf("too many arguments")
+		}
+		rev := "HEAD"
+		if len(args) == 2 {
+			rev = args[1]
+		}
+		hash, err := repo.ResolveRevision(rev)
+		if err != nil {
+			return err
+		}
+		return repo.Refs.CreateTag(args[0], hash)
+	}
+
+	// List tags
+	tags, err := repo.Refs.ListTags()
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	if err := sortRefNames(repoRoot, "refs/tags/", tags, tagSort); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		fmt.Println(tag)
+	}
+	return nil
+}
+
+// sortRefNames sorts names given relative to a ref prefix, such as branch
+// names under "refs/heads/", by a --sort key
+func sortRefNames(repoRoot, prefix string, names []string, key string) error {
+	if err := repository.CheckSortKey(key); err != nil {
+		return err
+	}
+	if key == "refname" {
+		return nil // Listings already come sorted by name
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+	refs := make([]string, len(names))
+	for i, name := range names {
+		refs[i] = prefix + name
+	}
+	if err := repo.SortRefs(refs, key); err != nil {
+		return err
+	}
+	for i, ref := range refs {
+		names[i] = strings.TrimPrefix(ref, prefix)
+	}
+	return nil
+}
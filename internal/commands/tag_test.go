@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTagSort(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	for _, name := range []string{"v1.9", "v1.10", "v2.0", "v1.2", "v1.2.1"} {
+		mustGogit(t, "tag", name)
+	}
+
+	for _, c := range []struct {
+		sort string
+		want string
+	}{
+		{"", "v1.10 v1.2 v1.2.1 v1.9 v2.0"},
+		{"refname", "v1.10 v1.2 v1.2.1 v1.9 v2.0"},
+		{"-refname", "v2.0 v1.9 v1.2.1 v1.2 v1.10"},
+		{"version:refname", "v1.2 v1.2.1 v1.9 v1.10 v2.0"},
+		{"-version:refname", "v2.0 v1.10 v1.9 v1.2.1 v1.2"},
+	} {
+		args := []string{"tag"}
+		if c.sort != "" {
+			args = append(args, "--sort="+c.sort)
+		}
+		if got := strings.Join(strings.Fields(plain(mustGogit(t, args...))), " "); got != c.want {
+			t.Errorf("%v: %s, want %s", args, got, c.want)
+		}
+	}
+
+	if _, err := gogit(t, "tag", "--sort=nosuch"); err == nil {
+		t.Error("tag --sort accepted an unknown key")
+	}
+}
+
+func TestBranchSort(t *testing.T) {
+	newTestRepo(t)
+	commitWorktree(t, "one", map[string]string{"f": "a\n"})
+	for _, name := range []string{"release-10", "release-9", "feature"} {
+		mustGogit(t, "branch", name)
+	}
+
+	for _, c := range []struct {
+		sort string
+		want string
+	}{
+		{"refname", "feature main release-10 release-9"},
+		{"-refname", "release-9 release-10 main feature"},
+		{"version:refname", "feature main release-9 release-10"},
+	} {
+		out := strings.ReplaceAll(plain(mustGogit(t, "branch", "--sort="+c.sort)), "*", "")
+		if got := strings.Join(strings.Fields(out), " "); got != c.want {
+			t.Errorf("branch --sort=%s: %s, want %s", c.sort, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/gogit/internal/gpg"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+var (
+	verifyVerbose bool
+)
+
+var verifyCommitCmd = &cobra.Command{
+	Use:     "verify-commit <commit>...",
+	Short:   "Check the GPG signature of commits",
+	Long:    `Validate the gpgsig header of each commit against the public keys in the user's keyring.`,
+	GroupID: groupAncillary,
+	Example: `  # Check the signature on the latest commit
+  gogit verify-commit HEAD`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runVerifyCommit,
+}
+
+var verifyTagCmd = &cobra.Command{
+	Use:     "verify-tag <tag>...",
+	Short:   "Check the GPG signature of tags",
+	Long:    `Validate the signature appended to each annotated tag against the public keys in the user's keyring.`,
+	GroupID: groupAncillary,
+	Example: `  # Check the signature on a release tag
+  gogit verify-tag -v v1.2.0`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runVerifyTag,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCommitCmd)
+	rootCmd.AddCommand(verifyTagCmd)
+	verifyCommitCmd.Flags().BoolVarP(&verifyVerbose, "verbose", "v", false, "Print the contents of the commit object before validating it")
+	verifyTagCmd.Flags().BoolVarP(&verifyVerbose, "verbose", "v", false, "Print the contents of the tag object before validating it")
+}
+
+func runVerifyCommit(cmd *cobra.Command, args []string) error {
+	return runVerify(args, object.TypeCommit, object.ExtractCommitSignature)
+}
+
+func runVerifyTag(cmd *cobra.Command, args []string) error {
+	return runVerify(args, object.TypeTag, object.ExtractTagSignature)
+}
+
+func runVerify(args []string, want object.Type, extract func([]byte) ([]byte, []byte, bool)) error {
+	repoRoot, err := FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.Open(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	for _, rev := range args {
+		hash, err := repo.ResolveRevision(rev)
+		if err != nil {
+			return err
+		}
+
+		objType, content, err := object.ReadRawObject(repoRoot, hash)
+		if err != nil {
+			return err
+		}
+		if objType != want {
+			return fmt.Errorf("%s: cannot verify a non-%s object of type %s", rev, want, objType)
+		}
+
+		if verifyVerbose {
+			fmt.Print(string(content))
+		}
+
+		payload, signature, ok := extract(content)
+		if !ok {
+			return fmt.Errorf("%s: no signature found", rev)
+		}
+
+		result, err := gpg.Verify(payload, signature)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(os.Stderr, result.Output)
+		if !result.Good {
+			return fmt.Errorf("%s: signature verification failed", rev)
+		}
+
+		fmt.Printf("Good signature from \"%s\" (key %s)\n", result.Signer, result.KeyID)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/gogit/internal/attributes"
+	"github.com/yourusername/gogit/internal/index"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// worktreeEntry hashes a working tree file and returns it in tree-entry form
+func worktreeEntry(repoRoot, path string) (object.TreeEntry, bool) {
+	absPath := filepath.Join(repoRoot, path)
+	info, err := os.Stat(absPath)
+	if err != nil || info.IsDir() {
+		return object.TreeEntry{}, false
+	}
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return object.TreeEntry{}, false
+	}
+
+	return object.TreeEntry{
+		Mode: fileMode(info),
+		Name: filepath.Base(path),
+		Hash: utils.HashObject("blob", content),
+	}, true
+}
+
+// worktreeEOL returns how line endings in the working tree are normalized
+// before its files are compared with what is stored
+func worktreeEOL(repo *repository.Repository) *attributes.EOL {
+	autocrlf, _ := repo.GetConfig("core.autocrlf")
+	return attributes.NewEOL(attributes.NewMatcher(repo.Path, repo.GitDir), autocrlf)
+}
+
+// cleanContent normalizes the line endings of a working tree file's
+// content, given the blob stored for it now, if any
+func cleanContent(repoRoot string, eol *attributes.EOL, path string, content []byte, stored string) []byte {
+	return eol.Clean(path, content, func() []byte {
+		data, _ := readBlobContent(repoRoot, stored)
+		return []byte(data)
+	})
+}
+
+// gitlinkEntry returns the commit a submodule's working tree is at, in
+// tree-entry form. A submodule that isn't populated, leaving just the
+// placeholder directory, counts as being at the recorded commit.
+func gitlinkEntry(repoRoot, path, recorded string) (object.TreeEntry, bool) {
+	absPath := filepath.Join(repoRoot, path)
+	info, err := os.Stat(absPath)
+	if err != nil || !info.IsDir() {
+		return object.TreeEntry{}, false
+	}
+
+	entry := object.TreeEntry{Mode: object.ModeGitlink, Name: filepath.Base(path), Hash: recorded}
+	if head, err := repository.NewRefs(absPath).ResolveHead(); err == nil && object.IsHash(head) {
+		entry.Hash = head
+	}
+	return entry, true
+}
+
+// fileMode returns the Git mode string for a working tree file
+func fileMode(info os.FileInfo) string {
+	if info.Mode()&0111 != 0 {
+		return "100755"
+	}
+	return "100644"
+}
+
+// checkoutEntry writes a blob from the object store to the working tree.
+// A gitlink gets an empty directory as a placeholder for the submodule.
+func checkoutEntry(repoRoot, path string, entry object.TreeEntry) error {
+	if entry.Mode == object.ModeGitlink {
+		if err := os.MkdirAll(filepath.Join(repoRoot, path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		return nil
+	}
+
+	blobObj, err := object.ReadObject(repoRoot, entry.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", path, err)
+	}
+
+	blob, ok := blobObj.(*object.Blob)
+	if !ok {
+		return fmt.Errorf("object %s is not a blob", entry.Hash)
+	}
+
+	filePath := filepath.Join(repoRoot, path)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	mode := os.FileMode(0644)
+	if entry.Mode == "100755" {
+		mode = 0755
+	}
+
+	// Remove first so the mode of an existing file is replaced too
+	os.Remove(filePath)
+	if err := os.WriteFile(filePath, blob.Content(), mode); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// stageCheckedOut records a path just written by checkoutEntry in the
+// index. Gitlinks are staged from the entry, as there is no file to hash.
+func stageCheckedOut(repoRoot string, idx *index.Index, path string, entry object.TreeEntry) error {
+	if entry.Mode == object.ModeGitlink {
+		return idx.AddBlob(path, 0160000, entry.Hash)
+	}
+	if err := idx.AddFile(repoRoot, filepath.Join(repoRoot, path)); err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	return nil
+}
+
+// removeWorktreeFile deletes a file and any directories it leaves empty
+func removeWorktreeFile(repoRoot, path string) error {
+	filePath := filepath.Join(repoRoot, path)
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	for dir := filepath.Dir(filePath); dir != repoRoot && dir != "."; dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,332 @@
+// Package commitgraph reads and writes Git's commit-graph file, which stores
+// each commit's tree, parents, generation number and commit date so history
+// can be walked without inflating commit objects.
+package commitgraph
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// Chunk identifiers
+const (
+	chunkFanout     = 0x4f494446 // "OIDF"
+	chunkLookup     = 0x4f49444c // "OIDL"
+	chunkCommitData = 0x43444154 // "CDAT"
+	chunkExtraEdges = 0x45444745 // "EDGE"
+)
+
+const (
+	signature     = "CGPH"
+	version       = 1
+	hashVersion   = 1 // SHA-1
+	hashLen       = 20
+	commitDataLen = hashLen + 16
+
+	parentNone     = 0x70000000
+	parentExtended = 0x80000000
+	lastEdge       = 0x80000000
+
+	// GenerationMax is the largest generation number the format can store
+	GenerationMax = 0x3fffffff
+)
+
+// Commit is one commit's entry in the graph
+type Commit struct {
+	Hash       string
+	Tree       string
+	Parents    []string
+	Generation uint32 // 1 for root commits, 1 + max(parents) otherwise
+	Time       int64  // Committer date in seconds since the epoch
+}
+
+// Graph is a loaded commit-graph file
+type Graph struct {
+	oids  []byte
+	data  []byte
+	edges []byte
+	count int
+}
+
+// Open reads the commit-graph file at path. The error satisfies
+// os.IsNotExist if there is no graph.
+func Open(path string) (*Graph, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit-graph %s: %w", path, err)
+	}
+	return g, nil
+}
+
+func parse(content []byte) (*Graph, error) {
+	if len(content) < 8+hashLen || string(content[:4]) != signature {
+		return nil, fmt.Errorf("bad signature")
+	}
+	if content[4] != version || content[5] != hashVersion {
+		return nil, fmt.Errorf("unsupported version %d (hash version %d)", content[4], content[5])
+	}
+
+	// Chunk table: (id, offset) pairs terminated by a zero id
+	numChunks := int(content[6])
+	chunks := make(map[uint32][]byte)
+	table := 8
+	if len(content) < table+(numChunks+1)*12 {
+		return nil, fmt.Errorf("truncated chunk table")
+	}
+	for i := 0; i < numChunks; i++ {
+		entry := content[table+i*12:]
+		id := binary.BigEndian.Uint32(entry)
+		start := binary.BigEndian.Uint64(entry[4:])
+		end := binary.BigEndian.Uint64(entry[16:])
+		if start > end || end > uint64(len(content)) {
+			return nil, fmt.Errorf("chunk %08x out of range", id)
+		}
+		chunks[id] = content[start:end]
+	}
+
+	g := &Graph{
+		oids:  chunks[chunkLookup],
+		data:  chunks[chunkCommitData],
+		edges: chunks[chunkExtraEdges],
+	}
+	if len(chunks[chunkFanout]) != 256*4 || g.oids == nil || g.data == nil {
+		return nil, fmt.Errorf("missing required chunk")
+	}
+	g.count = int(binary.BigEndian.Uint32(chunks[chunkFanout][255*4:]))
+	if len(g.oids) < g.count*hashLen || len(g.data) < g.count*commitDataLen {
+		return nil, fmt.Errorf("truncated chunk")
+	}
+
+	return g, nil
+}
+
+// Len returns the number of commits in the graph
+func (g *Graph) Len() int {
+	return g.count
+}
+
+func (g *Graph) oid(i int) []byte {
+	return g.oids[i*hashLen : (i+1)*hashLen]
+}
+
+// position returns the index of hash in the graph, or -1
+func (g *Graph) position(hash string) int {
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) != hashLen {
+		return -1
+	}
+	i := sort.Search(g.count, func(i int) bool {
+		return bytes.Compare(g.oid(i), raw) >= 0
+	})
+	if i < g.count && bytes.Equal(g.oid(i), raw) {
+		return i
+	}
+	return -1
+}
+
+// Lookup returns the graph entry for a commit
+func (g *Graph) Lookup(hash string) (Commit, bool) {
+	pos := g.position(hash)
+	if pos < 0 {
+		return Commit{}, false
+	}
+
+	data := g.data[pos*commitDataLen : (pos+1)*commitDataLen]
+	c := Commit{
+		Hash: hash,
+		Tree: hex.EncodeToString(data[:hashLen]),
+	}
+
+	parent1 := binary.BigEndian.Uint32(data[hashLen:])
+	parent2 := binary.BigEndian.Uint32(data[hashLen+4:])
+	if parent1 != parentNone {
+		c.Parents = append(c.Parents, g.hashAt(parent1))
+	}
+	switch {
+	case parent2 == parentNone:
+	case parent2&parentExtended != 0:
+		// Octopus merge: the remaining parents are in the edge list
+		for i := int(parent2 &^ parentExtended); (i+1)*4 <= len(g.edges); i++ {
+			edge := binary.BigEndian.Uint32(g.edges[i*4:])
+			c.Parents = append(c.Parents, g.hashAt(edge&^lastEdge))
+			if edge&lastEdge != 0 {
+				break
+			}
+		}
+	default:
+		c.Parents = append(c.Parents, g.hashAt(parent2))
+	}
+
+	genAndTime := binary.BigEndian.Uint64(data[hashLen+8:])
+	c.Generation = uint32(genAndTime >> 34)
+	c.Time = int64(genAndTime & (1<<34 - 1))
+
+	return c, true
+}
+
+func (g *Graph) hashAt(pos uint32) string {
+	if int(pos) >= g.count {
+		return ""
+	}
+	return hex.EncodeToString(g.oid(int(pos)))
+}
+
+// Write writes a commit-graph for commits to path. Every parent must be in
+// commits; generation numbers are computed here.
+func Write(path string, commits []Commit) error {
+	sorted := make([]Commit, len(commits))
+	copy(sorted, commits)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Hash < sorted[j].Hash })
+
+	positions := make(map[string]uint32, len(sorted))
+	for i, c := range sorted {
+		positions[c.Hash] = uint32(i)
+	}
+	for _, c := range sorted {
+		for _, parent := range c.Parents {
+			if _, ok := positions[parent]; !ok {
+				return fmt.Errorf("commit %s: parent %s is not in the graph", c.Hash, parent)
+			}
+		}
+	}
+	generations := computeGenerations(sorted, positions)
+
+	var fanout, oids, data, edges bytes.Buffer
+	var counts [256]uint32
+	for i, c := range sorted {
+		raw, err := hex.DecodeString(c.Hash)
+		if err != nil || len(raw) != hashLen {
+			return fmt.Errorf("invalid commit hash: %s", c.Hash)
+		}
+		tree, err := hex.DecodeString(c.Tree)
+		if err != nil || len(tree) != hashLen {
+			return fmt.Errorf("invalid tree hash for %s: %s", c.Hash, c.Tree)
+		}
+		counts[raw[0]]++
+		oids.Write(raw)
+		data.Write(tree)
+
+		parent1, parent2 := uint32(parentNone), uint32(parentNone)
+		if len(c.Parents) > 0 {
+			parent1 = positions[c.Parents[0]]
+		}
+		switch {
+		case len(c.Parents) == 2:
+			parent2 = positions[c.Parents[1]]
+		case len(c.Parents) > 2:
+			parent2 = parentExtended | uint32(edges.Len()/4)
+			for j, parent := range c.Parents[1:] {
+				edge := positions[parent]
+				if j == len(c.Parents)-2 {
+					edge |= lastEdge
+				}
+				binary.Write(&edges, binary.BigEndian, edge)
+			}
+		}
+		binary.Write(&data, binary.BigEndian, parent1)
+		binary.Write(&data, binary.BigEndian, parent2)
+
+		date := uint64(c.Time) & (1<<34 - 1)
+		binary.Write(&data, binary.BigEndian, uint64(generations[i])<<34|date)
+	}
+	for i := 1; i < 256; i++ {
+		counts[i] += counts[i-1]
+	}
+	binary.Write(&fanout, binary.BigEndian, counts[:])
+
+	type chunk struct {
+		id   uint32
+		data []byte
+	}
+	chunkList := []chunk{
+		{chunkFanout, fanout.Bytes()},
+		{chunkLookup, oids.Bytes()},
+		{chunkCommitData, data.Bytes()},
+	}
+	if edges.Len() > 0 {
+		chunkList = append(chunkList, chunk{chunkExtraEdges, edges.Bytes()})
+	}
+
+	var out bytes.Buffer
+	out.WriteString(signature)
+	out.Write([]byte{version, hashVersion, byte(len(chunkList)), 0})
+
+	offset := uint64(8 + (len(chunkList)+1)*12)
+	for _, c := range chunkList {
+		binary.Write(&out, binary.BigEndian, c.id)
+		binary.Write(&out, binary.BigEndian, offset)
+		offset += uint64(len(c.data))
+	}
+	binary.Write(&out, binary.BigEndian, uint32(0))
+	binary.Write(&out, binary.BigEndian, offset)
+
+	for _, c := range chunkList {
+		out.Write(c.data)
+	}
+	sum := sha1.Sum(out.Bytes())
+	out.Write(sum[:])
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := utils.WriteFileAtomic(path, out.Bytes(), 0444); err != nil {
+		return fmt.Errorf("failed to write commit-graph: %w", err)
+	}
+	return nil
+}
+
+// computeGenerations assigns each commit 1 + the largest generation of its
+// parents, without recursion so deep histories can't overflow the stack
+func computeGenerations(commits []Commit, positions map[string]uint32) []uint32 {
+	generations := make([]uint32, len(commits))
+
+	for start := range commits {
+		if generations[start] != 0 {
+			continue
+		}
+		stack := []int{start}
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			if generations[i] != 0 {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+
+			var max uint32
+			pending := false
+			for _, parent := range commits[i].Parents {
+				p := positions[parent]
+				if generations[p] == 0 {
+					stack = append(stack, int(p))
+					pending = true
+				} else if generations[p] > max {
+					max = generations[p]
+				}
+			}
+			if pending {
+				continue
+			}
+
+			stack = stack[:len(stack)-1]
+			if max < GenerationMax {
+				max++
+			}
+			generations[i] = max
+		}
+	}
+
+	return generations
+}
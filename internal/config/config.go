@@ -0,0 +1,276 @@
+// Package config reads Git-style INI configuration files
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config holds the values of a configuration file, keyed by canonical name:
+// "section.key" or "section.subsection.key", with the section and key
+// lowercased (they are case-insensitive) and the subsection kept as written
+type Config struct {
+	values  map[string][]string
+	entries []Entry // Every value in the order set
+}
+
+// Entry is one value set in a configuration file
+type Entry struct {
+	Key   string // Canonical name
+	Value string
+}
+
+// Load reads the configuration file at path
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse reads configuration from r
+func Parse(r io.Reader) (*Config, error) {
+	cfg := &Config{values: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+	section := ""
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+
+		// A trailing backslash continues the value on the next line
+		for strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") && scanner.Scan() {
+			lineNo++
+			line = line[:len(line)-1] + scanner.Text()
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			name, rest, err := parseSectionHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			section = name
+			line = strings.TrimSpace(rest)
+			if line == "" || line[0] == '#' || line[0] == ';' {
+				continue
+			}
+		}
+
+		if section == "" {
+			return nil, fmt.Errorf("line %d: key outside of any section", lineNo)
+		}
+
+		key, value, hasValue := strings.Cut(line, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !isValidKey(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNo, key)
+		}
+		if !hasValue {
+			// A bare key is a boolean true
+			value = "true"
+		} else {
+			var err error
+			if value, err = parseValue(value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+		}
+
+		cfg.add(section+"."+key, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// parseSectionHeader parses `[section]` or `[section "subsection"]` and
+// returns the canonical section name and whatever follows the header
+func parseSectionHeader(line string) (string, string, error) {
+	end := strings.IndexByte(line, ']')
+	if end < 0 {
+		return "", "", fmt.Errorf("unterminated section header")
+	}
+
+	inner := line[1:end]
+	name, sub, hasSub := strings.Cut(inner, " ")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", "", fmt.Errorf("empty section name")
+	}
+	if !hasSub {
+		// Old-style [section.subsection]; the subsection is case-insensitive
+		return name, line[end+1:], nil
+	}
+
+	sub = strings.TrimSpace(sub)
+	if len(sub) < 2 || sub[0] != '"' || sub[len(sub)-1] != '"' {
+		return "", "", fmt.Errorf("malformed subsection in %q", line[:end+1])
+	}
+	var sb strings.Builder
+	for i := 1; i < len(sub)-1; i++ {
+		if sub[i] == '\\' && i+1 < len(sub)-1 {
+			i++
+		}
+		sb.WriteByte(sub[i])
+	}
+
+	return name + "." + sb.String(), line[end+1:], nil
+}
+
+// parseValue unquotes a raw value, strips trailing comments and resolves
+// escape sequences
+func parseValue(raw string) (string, error) {
+	var sb strings.Builder
+	inQuotes := false
+	pendingSpace := ""
+
+	raw = strings.TrimSpace(raw)
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			sb.WriteString(pendingSpace)
+			pendingSpace = ""
+			inQuotes = !inQuotes
+		case !inQuotes && (c == '#' || c == ';'):
+			i = len(raw) // Comment
+		case !inQuotes && (c == ' ' || c == '\t'):
+			// Inner whitespace is kept, trailing whitespace dropped
+			pendingSpace += string(c)
+		case c == '\\':
+			if i+1 >= len(raw) {
+				return "", fmt.Errorf("incomplete escape sequence")
+			}
+			i++
+			sb.WriteString(pendingSpace)
+			pendingSpace = ""
+			switch raw[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'b':
+				// Backspace removes the previous character
+				if s := sb.String(); len(s) > 0 {
+					sb.Reset()
+					sb.WriteString(s[:len(s)-1])
+				}
+			case '"', '\\':
+				sb.WriteByte(raw[i])
+			default:
+				return "", fmt.Errorf("invalid escape sequence \\%c", raw[i])
+			}
+		default:
+			sb.WriteString(pendingSpace)
+			pendingSpace = ""
+			sb.WriteByte(c)
+		}
+	}
+	if inQuotes {
+		return "", fmt.Errorf("unterminated quoted value")
+	}
+
+	return sb.String(), nil
+}
+
+// isValidKey reports whether name is a valid variable name: alphanumeric
+// characters and dashes, starting with a letter
+func isValidKey(name string) bool {
+	if name == "" || !isLetter(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isLetter(name[i]) && (name[i] < '0' || name[i] > '9') && name[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func (c *Config) add(key, value string) {
+	c.entries = append(c.entries, Entry{Key: key, Value: value})
+	c.values[key] = append(c.values[key], value)
+}
+
+// Entries returns every value set, in the order they appear
+func (c *Config) Entries() []Entry {
+	return c.entries
+}
+
+// Get returns the value of a dotted key such as "core.filemode". When a
+// key is set more than once, the last value wins.
+func (c *Config) Get(key string) (string, bool) {
+	values := c.values[CanonicalKey(key)]
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// CanonicalKey lowercases the section and variable name of a dotted key,
+// leaving any subsection alone
+func CanonicalKey(key string) string {
+	first := strings.IndexByte(key, '.')
+	last := strings.LastIndexByte(key, '.')
+	if first < 0 {
+		return strings.ToLower(key)
+	}
+	return strings.ToLower(key[:first]) + key[first:last] + strings.ToLower(key[last:])
+}
+
+// ParseBool interprets a value as a boolean the way Git does
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean value '%s'", value)
+}
+
+// ParseInt interprets a value as an integer the way Git does, allowing a
+// k, m or g suffix for units of 1024, 1024² and 1024³
+func ParseInt(value string) (int64, error) {
+	digits, unit := value, int64(1)
+	if n := len(value); n > 0 {
+		switch value[n-1] {
+		case 'k', 'K':
+			unit = 1 << 10
+		case 'm', 'M':
+			unit = 1 << 20
+		case 'g', 'G':
+			unit = 1 << 30
+		}
+		if unit != 1 {
+			digits = value[:n-1]
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid numeric value '%s'", value)
+	}
+	return n * unit, nil
+}
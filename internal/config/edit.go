@@ -0,0 +1,209 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/gogit/internal/lockfile"
+)
+
+// ErrNotSet is returned by Unset for a key the file doesn't set
+var ErrNotSet = errors.New("key not set")
+
+// Set sets a dotted key such as "user.name" in the configuration file at
+// path, creating the file and the section if needed. Only the line holding
+// the key is rewritten, or a line added at the end of its section; the rest
+// of the file, comments and formatting included, is kept as it was. A key
+// set more than once can't be overwritten with a single value.
+func Set(path, key, value string) error {
+	section, name, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+	line := "\t" + name + " = " + formatValue(value)
+
+	return edit(path, func(lines []string) ([]string, error) {
+		spans := scanLines(lines)
+		matches := matchingSpans(spans, section, name)
+		if len(matches) > 1 {
+			return nil, fmt.Errorf("cannot overwrite multiple values of %s with a single value", key)
+		}
+		if len(matches) == 1 {
+			s := matches[0]
+			return splice(lines, s.start, s.end, line), nil
+		}
+
+		// Add the key after the last line of the section's last occurrence
+		last := -1
+		for _, s := range spans {
+			if s.section == section && (s.header || s.key != "") {
+				last = s.end
+			}
+		}
+		if last >= 0 {
+			return splice(lines, last+1, last, line), nil
+		}
+		return append(lines, sectionHeader(section), line), nil
+	})
+}
+
+// Unset removes a dotted key from the configuration file at path, leaving
+// its section in place even if it is now empty. It returns ErrNotSet if the
+// key isn't set.
+func Unset(path, key string) error {
+	section, name, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+
+	return edit(path, func(lines []string) ([]string, error) {
+		matches := matchingSpans(scanLines(lines), section, name)
+		switch len(matches) {
+		case 0:
+			return nil, ErrNotSet
+		case 1:
+			return splice(lines, matches[0].start, matches[0].end), nil
+		}
+		return nil, fmt.Errorf("%s has multiple values", key)
+	})
+}
+
+// edit rewrites the configuration file at path with the lines fn returns,
+// holding its lock throughout
+func edit(path string, fn func(lines []string) ([]string, error)) error {
+	lock, err := lockfile.Acquire(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		lock.Release()
+		return err
+	}
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	lines, err = fn(lines)
+	if err != nil {
+		lock.Release()
+		return err
+	}
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	return lock.Commit([]byte(content))
+}
+
+// lineSpan is one logical line of a configuration file: a physical line
+// together with any continuation lines after it
+type lineSpan struct {
+	start, end int    // Indexes of its first and last physical lines
+	section    string // Canonical name of the section it's in
+	key        string // Lowercased variable it sets, if any
+	header     bool   // It opens a section
+}
+
+// scanLines finds the section headers and variables in a file's lines.
+// Lines that can't be parsed are treated like comments.
+func scanLines(lines []string) []lineSpan {
+	var spans []lineSpan
+	section := ""
+	for i := 0; i < len(lines); i++ {
+		s := lineSpan{start: i}
+		line := lines[i]
+		for strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + lines[i]
+		}
+		s.end = i
+
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[':
+			if name, _, err := parseSectionHeader(line); err == nil {
+				section = name
+				s.header = true
+			}
+		default:
+			key, _, _ := strings.Cut(line, "=")
+			if key = strings.ToLower(strings.TrimSpace(key)); isValidKey(key) {
+				s.key = key
+			}
+		}
+		s.section = section
+		spans = append(spans, s)
+	}
+	return spans
+}
+
+// matchingSpans returns the lines that set a variable in a section
+func matchingSpans(spans []lineSpan, section, name string) []lineSpan {
+	var matches []lineSpan
+	for _, s := range spans {
+		if s.section == section && s.key == strings.ToLower(name) {
+			matches = append(matches, s)
+		}
+	}
+	return matches
+}
+
+// splice replaces lines[start:end+1] with replacement
+func splice(lines []string, start, end int, replacement ...string) []string {
+	result := append([]string(nil), lines[:start]...)
+	result = append(result, replacement...)
+	return append(result, lines[end+1:]...)
+}
+
+// CheckKey reports whether a dotted key is well-formed: a section, an
+// optional subsection and a variable name
+func CheckKey(key string) error {
+	_, _, err := splitKey(key)
+	return err
+}
+
+// splitKey splits a dotted key into the canonical name of its section and
+// its variable name as written, checking that both are valid
+func splitKey(key string) (string, string, error) {
+	last := strings.LastIndexByte(key, '.')
+	if last < 0 {
+		return "", "", fmt.Errorf("key does not contain a section: %s", key)
+	}
+	section, name := key[:last], key[last+1:]
+	first, sub, hasSub := strings.Cut(section, ".")
+	if first == "" || !isValidKey(strings.ToLower(first)) || !isValidKey(strings.ToLower(name)) {
+		return "", "", fmt.Errorf("invalid key: %s", key)
+	}
+
+	section = strings.ToLower(first)
+	if hasSub {
+		section += "." + sub
+	}
+	return section, name, nil
+}
+
+// sectionHeader formats the header line opening a section
+func sectionHeader(section string) string {
+	name, sub, hasSub := strings.Cut(section, ".")
+	if !hasSub {
+		return "[" + name + "]"
+	}
+	sub = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(sub)
+	return fmt.Sprintf("[%s \"%s\"]", name, sub)
+}
+
+// formatValue escapes a value for writing, quoting it if its surrounding
+// whitespace or a comment character would otherwise be lost
+func formatValue(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(value)
+	if value != strings.TrimSpace(value) || strings.ContainsAny(value, "#;") {
+		return `"` + escaped + `"`
+	}
+	return escaped
+}
@@ -16,7 +16,11 @@ type Change struct {
 	Type    ChangeType
 	OldLine int
 	NewLine int
-	Text    string
+	Text    string // The line, without its newline
+
+	// NoNewline marks the last line of a file that doesn't end in a
+	// newline, which patches follow with "\ No newline at end of file"
+	NoNewline bool
 }
 
 // ChangeType represents the type of change
@@ -28,102 +32,242 @@ const (
 	ChangeDelete
 )
 
-// Diff computes the difference between two strings
-func Diff(oldText, newText string) []Change {
-	oldLines := strings.Split(oldText, "\n")
-	newLines := strings.Split(newText, "\n")
+// binaryProbe is how much of a file IsBinary looks at, as in Git
+const binaryProbe = 8000
 
-	return diffLines(oldLines, newLines)
+// IsBinary reports whether content looks binary rather than text: whether
+// it has a NUL byte near the start
+func IsBinary(content string) bool {
+	return strings.IndexByte(content[:min(len(content), binaryProbe)], 0) >= 0
 }
 
-// diffLines implements a simple line-based diff algorithm
-// This is a simplified version - a full implementation would use Myers diff
-func diffLines(oldLines, newLines []string) []Change {
-	// Simple LCS-based diff
-	m, n := len(oldLines), len(newLines)
+// Diff computes the line by line difference between two strings. A last
+// line without a newline differs from the same text with one, as in git.
+func Diff(oldText, newText string) []Change {
+	return diffLines(splitLines(oldText), splitLines(newText))
+}
 
-	// Create LCS table
-	lcs := make([][]int, m+1)
-	for i := range lcs {
-		lcs[i] = make([]int, n+1)
+// splitLines splits text into lines, each keeping its newline. Text ending
+// in a newline has no empty line after it.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
 	}
+	return lines
+}
 
-	for i := 1; i <= m; i++ {
-		for j := 1; j <= n; j++ {
-			if oldLines[i-1] == newLines[j-1] {
-				lcs[i][j] = lcs[i-1][j-1] + 1
-			} else {
-				lcs[i][j] = max(lcs[i-1][j], lcs[i][j-1])
+// newChange returns the change for a line that still has its newline, if
+// it has one
+func newChange(changeType ChangeType, oldLine, newLine int, line string) Change {
+	text, ok := strings.CutSuffix(line, "\n")
+	return Change{Type: changeType, OldLine: oldLine, NewLine: newLine, Text: text, NoNewline: !ok}
+}
+
+// diffLines finds a shortest edit script turning oldLines into newLines
+// with Myers' O(ND) algorithm, in its linear space form, and returns it
+// with each run of changed lines as its deletions followed by its
+// insertions
+func diffLines(oldLines, newLines []string) []Change {
+	// Lines are compared as small integers, equal lines sharing one
+	ids := make(map[string]int, len(oldLines)+len(newLines))
+	intern := func(lines []string) []int {
+		seq := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
 			}
+			seq[i] = id
 		}
+		return seq
 	}
 
-	// Backtrack to find changes
-	i, j := m, n
+	m := &myers{a: intern(oldLines), b: intern(newLines)}
+	m.removed = make([]bool, len(m.a))
+	m.added = make([]bool, len(m.b))
+	size := 2*(len(m.a)+len(m.b)) + 2
+	m.forward, m.backward = make([]int, size), make([]int, size)
+	m.compare(0, len(m.a), 0, len(m.b))
+
 	var result []Change
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		if i < len(oldLines) && j < len(newLines) && !m.removed[i] && !m.added[j] {
+			result = append(result, newChange(ChangeEqual, i+1, j+1, oldLines[i]))
+			i++
+			j++
+			continue
+		}
+		for ; i < len(oldLines) && m.removed[i]; i++ {
+			result = append(result, newChange(ChangeDelete, i+1, 0, oldLines[i]))
+		}
+		for ; j < len(newLines) && m.added[j]; j++ {
+			result = append(result, newChange(ChangeInsert, 0, j+1, newLines[j]))
+		}
+	}
+	return result
+}
+
+// myers holds the state of one diff: the two sequences, which of their
+// elements the edit script removes or adds, and the furthest reaching
+// paths of the current search
+type myers struct {
+	a, b              []int
+	removed, added    []bool
+	forward, backward []int
+}
 
-	for i > 0 || j > 0 {
-		if i > 0 && j > 0 && oldLines[i-1] == newLines[j-1] {
-			result = append(result, Change{
-				Type:    ChangeEqual,
-				OldLine: i,
-				NewLine: j,
-				Text:    oldLines[i-1],
-			})
-			i--
-			j--
-		} else if j > 0 && (i == 0 || lcs[i][j-1] >= lcs[i-1][j]) {
-			result = append(result, Change{
-				Type:    ChangeInsert,
-				NewLine: j,
-				Text:    newLines[j-1],
-			})
-			j--
-		} else if i > 0 {
-			result = append(result, Change{
-				Type:    ChangeDelete,
-				OldLine: i,
-				Text:    oldLines[i-1],
-			})
-			i--
+// compare marks the elements of a[aLo:aHi] and b[bLo:bHi] that a shortest
+// edit script between them removes or adds
+func (m *myers) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && m.a[aLo] == m.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && m.a[aHi-1] == m.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for ; bLo < bHi; bLo++ {
+			m.added[bLo] = true
+		}
+	case bLo == bHi:
+		for ; aLo < aHi; aLo++ {
+			m.removed[aLo] = true
 		}
+	default:
+		x, y := m.split(aLo, aHi, bLo, bHi)
+		m.compare(aLo, x, bLo, y)
+		m.compare(x, aHi, y, bHi)
 	}
+}
+
+// split finds a point that a shortest edit script between a[aLo:aHi] and
+// b[bLo:bHi] passes through, by searching from both ends at once until
+// the paths meet. The ranges must be non-empty and differ in their first
+// and last elements, which keeps the point away from either corner.
+func (m *myers) split(aLo, aHi, bLo, bHi int) (int, int) {
+	n, k := aHi-aLo, bHi-bLo
+	delta := n - k
+	odd := delta%2 != 0
+
+	// forward[off+d] is how far along a the furthest forward path on
+	// diagonal d (x-y) reaches; backward[off+d] the same from the end
+	off := n + k + 1
+	forward, backward := m.forward, m.backward
+	forward[off+1], backward[off+1] = 0, 0
+
+	// The paths meet within (n+k+1)/2 steps
+	for d := 0; ; d++ {
+		for diag := -d; diag <= d; diag += 2 {
+			var x int
+			if diag == -d || (diag != d && forward[off+diag-1] < forward[off+diag+1]) {
+				x = forward[off+diag+1]
+			} else {
+				x = forward[off+diag-1] + 1
+			}
+			y := x - diag
+			for x < n && y < k && m.a[aLo+x] == m.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[off+diag] = x
+
+			if back := delta - diag; odd && back >= -(d-1) && back <= d-1 && x+backward[off+back] >= n {
+				return aLo + x, bLo + y
+			}
+		}
+
+		for diag := -d; diag <= d; diag += 2 {
+			var x int
+			if diag == -d || (diag != d && backward[off+diag-1] < backward[off+diag+1]) {
+				x = backward[off+diag+1]
+			} else {
+				x = backward[off+diag-1] + 1
+			}
+			y := x - diag
+			for x < n && y < k && m.a[aHi-1-x] == m.b[bHi-1-y] {
+				x++
+				y++
+			}
+			backward[off+diag] = x
 
-	// Reverse to get correct order
-	for left, right := 0, len(result)-1; left < right; left, right = left+1, right-1 {
-		result[left], result[right] = result[right], result[left]
+			if fwd := delta - diag; !odd && fwd >= -d && fwd <= d && x+forward[off+fwd] >= n {
+				return aHi - x, bHi - y
+			}
+		}
 	}
+}
 
-	return result
+// Prefixes are put in front of the old and new file names in patch headers
+type Prefixes struct {
+	Src string
+	Dst string
 }
 
-// Format formats the diff as a unified diff string
-func Format(oldName, newName string, changes []Change) string {
+// DefaultPrefixes are the usual "a/" and "b/"
+var DefaultPrefixes = Prefixes{Src: "a/", Dst: "b/"}
+
+// Format formats the diff as a unified diff string. A name of "/dev/null"
+// stands for the missing side of an added or deleted file.
+func Format(oldName, newName string, prefixes Prefixes, changes []Change) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("--- a/%s\n", oldName))
-	sb.WriteString(fmt.Sprintf("+++ b/%s\n", newName))
+	sb.WriteString(fmt.Sprintf("--- %s\n", patchName(prefixes.Src, oldName)))
+	sb.WriteString(fmt.Sprintf("+++ %s\n", patchName(prefixes.Dst, newName)))
+
+	formatHunks(&sb, changes, true)
+
+	return sb.String()
+}
+
+// FormatBinary returns the line a patch shows for a binary file in place of
+// hunks
+func FormatBinary(oldName, newName string, prefixes Prefixes) string {
+	return fmt.Sprintf("Binary files %s and %s differ\n", patchName(prefixes.Src, oldName), patchName(prefixes.Dst, newName))
+}
+
+// patchName prefixes a file name for a patch header, leaving /dev/null as is
+func patchName(prefix, name string) string {
+	if name == "/dev/null" {
+		return name
+	}
+	return prefix + name
+}
+
+// formatHunks writes the hunks of a diff, optionally colored for a terminal
+func formatHunks(sb *strings.Builder, changes []Change, color bool) {
+	added, deleted, reset := "\033[32m", "\033[31m", "\033[0m"
+	if !color {
+		added, deleted, reset = "", "", ""
+	}
 
 	// Group changes into hunks
 	hunks := groupIntoHunks(changes, 3)
 
 	for _, hunk := range hunks {
 		oldStart, oldCount, newStart, newCount := hunkHeader(hunk)
-		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount))
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount)))
 
 		for _, change := range hunk {
 			switch change.Type {
 			case ChangeEqual:
 				sb.WriteString(fmt.Sprintf(" %s\n", change.Text))
 			case ChangeInsert:
-				sb.WriteString(fmt.Sprintf("\033[32m+%s\033[0m\n", change.Text))
+				sb.WriteString(fmt.Sprintf("%s+%s%s\n", added, change.Text, reset))
 			case ChangeDelete:
-				sb.WriteString(fmt.Sprintf("\033[31m-%s\033[0m\n", change.Text))
+				sb.WriteString(fmt.Sprintf("%s-%s%s\n", deleted, change.Text, reset))
+			}
+			if change.NoNewline {
+				sb.WriteString("\\ No newline at end of file\n")
 			}
 		}
 	}
-
-	return sb.String()
 }
 
 // groupIntoHunks groups changes into hunks with context
@@ -186,7 +330,8 @@ func hunkHeader(hunk []Change) (oldStart, oldCount, newStart, newCount int) {
 		return 1, 0, 1, 0
 	}
 
-	// Find first line numbers
+	// Find first line numbers. A side with no lines in the hunk is an
+	// empty file, which is left starting at line 0.
 	for _, change := range hunk {
 		if change.OldLine > 0 {
 			oldStart = change.OldLine
@@ -200,13 +345,6 @@ func hunkHeader(hunk []Change) (oldStart, oldCount, newStart, newCount int) {
 		}
 	}
 
-	if oldStart == 0 {
-		oldStart = 1
-	}
-	if newStart == 0 {
-		newStart = 1
-	}
-
 	// Count lines
 	for _, change := range hunk {
 		switch change.Type {
@@ -223,6 +361,15 @@ func hunkHeader(hunk []Change) (oldStart, oldCount, newStart, newCount int) {
 	return
 }
 
+// hunkRange formats one side of a hunk header as git does: "start,count",
+// or just "start" for a single line
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -0,0 +1,181 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// script renders changes compactly, one "<op><text>" per line with " ",
+// "-" or "+" as the op, to compare with the expected edit script
+func script(changes []Change) string {
+	ops := map[ChangeType]string{ChangeEqual: " ", ChangeDelete: "-", ChangeInsert: "+"}
+	var parts []string
+	for _, c := range changes {
+		parts = append(parts, ops[c.Type]+c.Text)
+	}
+	return strings.Join(parts, "|")
+}
+
+func TestDiffEditScript(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"both empty", "", "", ""},
+		{"added file", "", "a\nb\n", "+a|+b"},
+		{"deleted file", "a\nb\n", "", "-a|-b"},
+		{"unchanged", "a\nb\n", "a\nb\n", " a| b"},
+		{"line changed", "a\nb\nc\n", "a\nx\nc\n", " a|-b|+x| c"},
+		{"insert in middle", "a\nc\n", "a\nb\nc\n", " a|+b| c"},
+		{"delete at end", "a\nb\nc\n", "a\nb\n", " a| b|-c"},
+		{"deletions before insertions", "a\nb\nc\nd\n", "a\nx\ny\nd\n", " a|-b|-c|+x|+y| d"},
+		{"moved line", "a\nb\nc\n", "b\nc\na\n", "-a| b| c|+a"},
+		{"separate edits", "1\n2\n3\n4\n5\n", "0\n2\n3\n4\n6\n", "-1|+0| 2| 3| 4|-5|+6"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := script(Diff(c.old, c.new)); got != c.want {
+				t.Errorf("Diff(%q, %q) = %q, want %q", c.old, c.new, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDiffLineNumbers(t *testing.T) {
+	changes := Diff("a\nb\nc\n", "a\nx\nc\nd\n")
+	want := []Change{
+		{Type: ChangeEqual, OldLine: 1, NewLine: 1, Text: "a"},
+		{Type: ChangeDelete, OldLine: 2, Text: "b"},
+		{Type: ChangeInsert, NewLine: 2, Text: "x"},
+		{Type: ChangeEqual, OldLine: 3, NewLine: 3, Text: "c"},
+		{Type: ChangeInsert, NewLine: 4, Text: "d"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %+v, want %+v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+}
+
+func TestDiffMissingNewline(t *testing.T) {
+	changes := Diff("a\nb", "a\nb\n")
+	if got := script(changes); got != " a|-b|+b" {
+		t.Fatalf("script = %q, want the last line changed", got)
+	}
+	if !changes[1].NoNewline || changes[2].NoNewline {
+		t.Errorf("NoNewline = %v, %v, want only the old line marked", changes[1].NoNewline, changes[2].NoNewline)
+	}
+}
+
+// TestDiffIsMinimal checks on random inputs that the edit script turns
+// the old lines into the new ones and is as short as the LCS allows
+func TestDiffIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomText := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a'+rng.Intn(4))) + "\n"
+		}
+		return lines
+	}
+
+	for iter := 0; iter < 500; iter++ {
+		oldLines, newLines := randomText(), randomText()
+		changes := Diff(strings.Join(oldLines, ""), strings.Join(newLines, ""))
+
+		var rebuilt []string
+		edits := 0
+		for _, c := range changes {
+			if c.Type != ChangeEqual {
+				edits++
+			}
+			if c.Type != ChangeDelete {
+				rebuilt = append(rebuilt, c.Text+"\n")
+			}
+		}
+		if strings.Join(rebuilt, "") != strings.Join(newLines, "") {
+			t.Fatalf("applying the script to %q gives %q, want %q", oldLines, rebuilt, newLines)
+		}
+
+		common := 0
+		for _, c := range lcsDiff(oldLines, newLines) {
+			if c.Type == ChangeEqual {
+				common++
+			}
+		}
+		if want := len(oldLines) + len(newLines) - 2*common; edits != want {
+			t.Fatalf("diff of %q and %q has %d edits, want %d", oldLines, newLines, edits, want)
+		}
+	}
+}
+
+// lcsDiff is the dynamic programming diff Myers replaced, kept to check
+// minimality against and to benchmark: it fills an (m+1)*(n+1) table of
+// common subsequence lengths and backtracks through it
+func lcsDiff(oldLines, newLines []string) []Change {
+	m, n := len(oldLines), len(newLines)
+	lcs := make([][]int, m+1)
+	for i := range lcs {
+		lcs[i] = make([]int, n+1)
+	}
+	for i := 1; i <= m; i++ {
+		for j := 1; j <= n; j++ {
+			if oldLines[i-1] == newLines[j-1] {
+				lcs[i][j] = lcs[i-1][j-1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i-1][j], lcs[i][j-1])
+			}
+		}
+	}
+
+	var result []Change
+	for i, j := m, n; i > 0 || j > 0; {
+		switch {
+		case i > 0 && j > 0 && oldLines[i-1] == newLines[j-1]:
+			result = append(result, newChange(ChangeEqual, i, j, oldLines[i-1]))
+			i--
+			j--
+		case j > 0 && (i == 0 || lcs[i][j-1] >= lcs[i-1][j]):
+			result = append(result, newChange(ChangeInsert, 0, j, newLines[j-1]))
+			j--
+		default:
+			result = append(result, newChange(ChangeDelete, i, 0, oldLines[i-1]))
+			i--
+		}
+	}
+	for l, r := 0, len(result)-1; l < r; l, r = l+1, r-1 {
+		result[l], result[r] = result[r], result[l]
+	}
+	return result
+}
+
+// BenchmarkDiffLargeFile diffs a 10,000-line file against itself with a
+// few lines changed in the middle, with Myers and with the LCS table
+func BenchmarkDiffLargeFile(b *testing.B) {
+	oldLines := make([]string, 10000)
+	for i := range oldLines {
+		oldLines[i] = fmt.Sprintf("line %d\n", i)
+	}
+	newLines := append([]string(nil), oldLines...)
+	for i := 4998; i < 5002; i++ {
+		newLines[i] = fmt.Sprintf("changed %d\n", i)
+	}
+
+	b.Run("myers", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			diffLines(oldLines, newLines)
+		}
+	})
+	b.Run("lcs", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			lcsDiff(oldLines, newLines)
+		}
+	})
+}
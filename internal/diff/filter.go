@@ -0,0 +1,70 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Filter selects changes by status letter, as in --diff-filter
+type Filter struct {
+	include map[byte]bool
+	exclude map[byte]bool
+}
+
+// ParseFilter parses a --diff-filter spec such as "AM" or "d".
+// Uppercase letters select statuses; lowercase letters exclude them.
+// An empty spec yields a nil filter, which matches everything.
+func ParseFilter(spec string) (*Filter, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	f := &Filter{
+		include: make(map[byte]bool),
+		exclude: make(map[byte]bool),
+	}
+
+	for i := 0; i < len(spec); i++ {
+		c := spec[i]
+		upper := strings.ToUpper(string(c))[0]
+		if !strings.ContainsRune("ACDMRTUXB", rune(upper)) {
+			return nil, fmt.Errorf("unknown change class '%c' in --diff-filter=%s", c, spec)
+		}
+		if c == upper {
+			f.include[c] = true
+		} else {
+			f.exclude[upper] = true
+		}
+	}
+
+	return f, nil
+}
+
+// Match reports whether a change with the given status passes the filter
+func (f *Filter) Match(status byte) bool {
+	if f == nil {
+		return true
+	}
+	if f.exclude[status] {
+		return false
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	return f.include[status]
+}
+
+// Apply returns the changes that pass the filter
+func (f *Filter) Apply(changes []FileChange) []FileChange {
+	if f == nil {
+		return changes
+	}
+
+	var result []FileChange
+	for _, change := range changes {
+		if f.Match(change.Status) {
+			result = append(result, change)
+		}
+	}
+	return result
+}
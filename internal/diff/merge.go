@@ -0,0 +1,139 @@
+package diff
+
+import "strings"
+
+// Conflict markers, as git writes them
+const (
+	markerOurs   = "<<<<<<<"
+	markerSep    = "======="
+	markerTheirs = ">>>>>>>"
+)
+
+// edit replaces the base lines [start, end) with lines
+type edit struct {
+	start, end int
+	lines      []string
+}
+
+// Merge3 merges the changes ours and theirs each made to base, line by
+// line. Changes to separate parts of base are both taken, as is the same
+// change made on both sides. Changes that overlap or touch without being
+// the same conflict: the merged text has both versions of that part,
+// between conflict markers labelled with oursName and theirsName. Merge3
+// returns the merged text and how many conflicts it has.
+func Merge3(base, ours, theirs, oursName, theirsName string) (string, int) {
+	baseLines := splitLines(base)
+	oursEdits := edits(Diff(base, ours))
+	theirsEdits := edits(Diff(base, theirs))
+
+	var sb strings.Builder
+	conflicts := 0
+	pos, i, j := 0, 0, 0
+	for i < len(oursEdits) || j < len(theirsEdits) {
+		// The next region starts at whichever side's next edit comes first,
+		// and grows while an edit on either side overlaps or touches it
+		lo, hi := len(baseLines), len(baseLines)
+		if i < len(oursEdits) {
+			lo, hi = oursEdits[i].start, oursEdits[i].end
+		}
+		if j < len(theirsEdits) && theirsEdits[j].start < lo {
+			lo, hi = theirsEdits[j].start, theirsEdits[j].end
+		}
+		oi, tj := i, j
+		for grew := true; grew; {
+			grew = false
+			if i < len(oursEdits) && oursEdits[i].start <= hi {
+				hi = max(hi, oursEdits[i].end)
+				i++
+				grew = true
+			}
+			if j < len(theirsEdits) && theirsEdits[j].start <= hi {
+				hi = max(hi, theirsEdits[j].end)
+				j++
+				grew = true
+			}
+		}
+
+		writeLines(&sb, baseLines[pos:lo])
+		pos = hi
+
+		oursText := applyEdits(baseLines, lo, hi, oursEdits[oi:i])
+		theirsText := applyEdits(baseLines, lo, hi, theirsEdits[tj:j])
+		switch {
+		case oi == i:
+			sb.WriteString(theirsText)
+		case tj == j, oursText == theirsText:
+			sb.WriteString(oursText)
+		default:
+			conflicts++
+			sb.WriteString(markerOurs + " " + oursName + "\n")
+			sb.WriteString(withNewline(oursText))
+			sb.WriteString(markerSep + "\n")
+			sb.WriteString(withNewline(theirsText))
+			sb.WriteString(markerTheirs + " " + theirsName + "\n")
+		}
+	}
+	writeLines(&sb, baseLines[pos:])
+	return sb.String(), conflicts
+}
+
+// edits groups a diff against base into the base ranges it replaces
+func edits(changes []Change) []edit {
+	var result []edit
+	pos := 0
+	for k := 0; k < len(changes); {
+		if changes[k].Type == ChangeEqual {
+			pos++
+			k++
+			continue
+		}
+		e := edit{start: pos}
+		for ; k < len(changes) && changes[k].Type != ChangeEqual; k++ {
+			if changes[k].Type == ChangeDelete {
+				pos++
+			} else {
+				e.lines = append(e.lines, changeLine(changes[k]))
+			}
+		}
+		e.end = pos
+		result = append(result, e)
+	}
+	return result
+}
+
+// applyEdits returns the base lines [lo, hi) with one side's edits inside
+// them applied
+func applyEdits(baseLines []string, lo, hi int, sideEdits []edit) string {
+	var sb strings.Builder
+	pos := lo
+	for _, e := range sideEdits {
+		writeLines(&sb, baseLines[pos:e.start])
+		writeLines(&sb, e.lines)
+		pos = e.end
+	}
+	writeLines(&sb, baseLines[pos:hi])
+	return sb.String()
+}
+
+// changeLine returns a change's line with its newline, if it had one
+func changeLine(c Change) string {
+	if c.NoNewline {
+		return c.Text
+	}
+	return c.Text + "\n"
+}
+
+func writeLines(sb *strings.Builder, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(line)
+	}
+}
+
+// withNewline ends text with a newline, so that a marker after it starts
+// a line of its own
+func withNewline(text string) string {
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		return text + "\n"
+	}
+	return text
+}
@@ -0,0 +1,91 @@
+package diff
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	for _, c := range []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		conflicts          int
+	}{
+		{
+			name: "no changes",
+			base: "a\nb\n", ours: "a\nb\n", theirs: "a\nb\n",
+			want: "a\nb\n",
+		},
+		{
+			name: "only ours changed",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "only theirs changed",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nC\n",
+			want: "a\nb\nC\n",
+		},
+		{
+			name: "separate changes",
+			base: "1\n2\n3\n4\n5\n", ours: "one\n2\n3\n4\n5\n", theirs: "1\n2\n3\n4\nfive\n",
+			want: "one\n2\n3\n4\nfive\n",
+		},
+		{
+			name: "same change on both sides",
+			base: "a\nb\nc\n", ours: "a\nx\nc\n", theirs: "a\nx\nc\n",
+			want: "a\nx\nc\n",
+		},
+		{
+			name: "insertions in different places",
+			base: "a\nb\nc\n", ours: "top\na\nb\nc\n", theirs: "a\nb\nc\nbottom\n",
+			want: "top\na\nb\nc\nbottom\n",
+		},
+		{
+			name: "overlapping changes",
+			base: "a\nb\nc\n", ours: "a\nours\nc\n", theirs: "a\ntheirs\nc\n",
+			want:      "a\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> side\nc\n",
+			conflicts: 1,
+		},
+		{
+			name: "adjacent changes conflict",
+			base: "a\nb\nc\n", ours: "A\nb\nc\n", theirs: "a\nB\nc\n",
+			want:      "<<<<<<< HEAD\nA\nb\n=======\na\nB\n>>>>>>> side\nc\n",
+			conflicts: 1,
+		},
+		{
+			name: "one side deletes what the other changes",
+			base: "a\nb\nc\n", ours: "a\nc\n", theirs: "a\nB\nc\n",
+			want:      "a\n<<<<<<< HEAD\n=======\nB\n>>>>>>> side\nc\n",
+			conflicts: 1,
+		},
+		{
+			name: "add/add with no base",
+			base: "", ours: "ours\n", theirs: "theirs\n",
+			want:      "<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> side\n",
+			conflicts: 1,
+		},
+		{
+			name: "two conflicts",
+			base: "1\n2\n3\n4\n5\n", ours: "x\n2\n3\n4\nx\n", theirs: "y\n2\n3\n4\ny\n",
+			want:      "<<<<<<< HEAD\nx\n=======\ny\n>>>>>>> side\n2\n3\n4\n<<<<<<< HEAD\nx\n=======\ny\n>>>>>>> side\n",
+			conflicts: 2,
+		},
+		{
+			name: "missing newline at end",
+			base: "a\nb", ours: "a\nb", theirs: "A\nb",
+			want: "A\nb",
+		},
+		{
+			name: "conflict without final newlines",
+			base: "a", ours: "b", theirs: "c",
+			want:      "<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> side\n",
+			conflicts: 1,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got, conflicts := Merge3(c.base, c.ours, c.theirs, "HEAD", "side")
+			if got != c.want || conflicts != c.conflicts {
+				t.Errorf("Merge3 = %q with %d conflicts, want %q with %d", got, conflicts, c.want, c.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package diff
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// CommitPatchID returns the patch ID of a commit's changes against its
+// first parent, or "" if the commit changes nothing. Two commits making the
+// same change have the same patch ID regardless of where they apply.
+func CommitPatchID(store *object.Store, commit *object.Commit) (string, error) {
+	parentTree := ""
+	if parentHash := commit.FirstParent(); parentHash != "" {
+		parent, err := store.ReadCommit(parentHash)
+		if err != nil {
+			return "", err
+		}
+		parentTree = parent.TreeHash
+	}
+
+	changes, err := DiffTrees(store, parentTree, commit.TreeHash)
+	if err != nil {
+		return "", err
+	}
+
+	var patch strings.Builder
+	for _, change := range changes {
+		oldContent, err := entryContent(store, change.OldHash, change.OldMode)
+		if err != nil {
+			return "", err
+		}
+		newContent, err := entryContent(store, change.NewHash, change.NewMode)
+		if err != nil {
+			return "", err
+		}
+		writePatch(&patch, change, oldContent, newContent)
+	}
+
+	id, length, _, err := readPatchID(bufio.NewReader(strings.NewReader(patch.String())))
+	if err != nil || length == 0 {
+		return "", err
+	}
+	return id, nil
+}
+
+// ReadPatchIDs reads patches, such as the output of "log -p" or "diff", and
+// calls fn with each one's patch ID and the commit it belongs to, taken from
+// the preceding "commit <hash>" line (ZeroHash when there is none)
+func ReadPatchIDs(r io.Reader, fn func(patchID, commit string) error) error {
+	br := bufio.NewReader(r)
+	commit := ZeroHash
+
+	for {
+		if _, err := br.Peek(1); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		id, length, next, err := readPatchID(br)
+		if err != nil {
+			return err
+		}
+		if length > 0 {
+			if err := fn(id, commit); err != nil {
+				return err
+			}
+		}
+
+		commit = next
+		if commit == "" {
+			commit = ZeroHash
+		}
+	}
+}
+
+// writePatch writes a change as an uncolored git-style patch
+func writePatch(sb *strings.Builder, change FileChange, oldContent, newContent string) {
+	oldPath, newPath := change.OldPath, change.NewPath
+	if oldPath == "" {
+		oldPath = newPath
+	}
+	if newPath == "" {
+		newPath = oldPath
+	}
+	fmt.Fprintf(sb, "diff --git a/%s b/%s\n", oldPath, newPath)
+
+	oldName, newName := "a/"+oldPath, "b/"+newPath
+	switch {
+	case change.OldHash == "":
+		fmt.Fprintf(sb, "new file mode %s\n", change.NewMode)
+		oldName = "/dev/null"
+	case change.NewHash == "":
+		fmt.Fprintf(sb, "deleted file mode %s\n", change.OldMode)
+		newName = "/dev/null"
+	case change.OldMode != change.NewMode:
+		fmt.Fprintf(sb, "old mode %s\nnew mode %s\n", change.OldMode, change.NewMode)
+	}
+
+	if oldContent == newContent {
+		return
+	}
+	fmt.Fprintf(sb, "--- %s\n+++ %s\n", oldName, newName)
+	formatHunks(sb, Diff(oldContent, newContent), false)
+}
+
+// entryContent returns what a patch shows for one side of a change: the
+// blob, or the commit line of a gitlink
+func entryContent(store *object.Store, hash, mode string) (string, error) {
+	if mode == object.ModeGitlink {
+		return GitlinkContent(hash), nil
+	}
+	return blobContent(store, hash)
+}
+
+// GitlinkContent is the text a patch shows in place of the content of a
+// gitlink, which names a commit of another repository
+func GitlinkContent(hash string) string {
+	if hash == "" {
+		return ""
+	}
+	return "Subproject commit " + hash + "\n"
+}
+
+// blobContent returns the content of a blob, or "" for an empty hash
+func blobContent(store *object.Store, hash string) (string, error) {
+	if hash == "" {
+		return "", nil
+	}
+	obj, err := store.Read(hash)
+	if err != nil {
+		return "", err
+	}
+	blob, ok := obj.(*object.Blob)
+	if !ok {
+		return "", fmt.Errorf("object %s is not a blob", hash)
+	}
+	return string(blob.Content()), nil
+}
+
+// readPatchID reads one patch and returns its ID computed like
+// "git patch-id --stable": each file's diff is hashed with whitespace and
+// line numbers removed, and the file hashes are summed so the order of
+// files doesn't matter. Reading stops at the next "commit <hash>" line,
+// whose hash is returned as next. A length of 0 means there was no patch.
+func readPatchID(r *bufio.Reader) (id string, length int, next string, err error) {
+	var result [sha1.Size]byte
+	h := sha1.New()
+	var preHash, postHash string
+	before, after := -1, -1
+	binary := false
+
+	for {
+		line, readErr := r.ReadString('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return "", 0, "", readErr
+		}
+		if line == "" && readErr != nil {
+			break
+		}
+
+		// Possibly skip over the prefix added by "log" or "format-patch"
+		p := line
+		if rest, ok := strings.CutPrefix(line, "commit "); ok {
+			p = rest
+		} else if rest, ok := strings.CutPrefix(line, "From "); ok {
+			p = rest
+		} else if strings.HasPrefix(line, "\\ ") && len(line) > 12 {
+			// "\ No newline at end of file"
+			continue
+		}
+		if len(p) >= 40 && object.IsHash(p[:40]) {
+			next = p[:40]
+			break
+		}
+
+		// Ignore commit comments
+		if length == 0 && !strings.HasPrefix(line, "diff ") {
+			continue
+		}
+
+		// Parsing the diff header?
+		if before == -1 {
+			if strings.HasPrefix(line, "GIT binary patch") || strings.HasPrefix(line, "Binary files") {
+				binary = true
+				before = 0
+				h.Write([]byte(preHash))
+				h.Write([]byte(postHash))
+				flushPatchID(&result, h)
+				continue
+			} else if rest, ok := strings.CutPrefix(line, "index "); ok {
+				if pre, post, ok := strings.Cut(strings.TrimRight(rest, "\n"), ".."); ok {
+					preHash = pre
+					postHash, _, _ = strings.Cut(post, " ")
+				}
+				continue
+			} else if strings.HasPrefix(line, "--- ") {
+				before, after = 1, 1
+			} else if !isASCIILetter(line[0]) {
+				break
+			}
+		}
+
+		if binary {
+			if strings.HasPrefix(line, "diff ") {
+				binary = false
+				before = -1
+			}
+			continue
+		}
+
+		// Looking for a valid hunk header?
+		if before == 0 && after == 0 {
+			if strings.HasPrefix(line, "@@ -") {
+				// Parse the next hunk, but ignore line numbers
+				before, after = scanHunkHeader(line)
+				continue
+			}
+
+			// Split at the end of the patch
+			if !strings.HasPrefix(line, "diff ") {
+				break
+			}
+
+			// Else we're parsing another file's header
+			flushPatchID(&result, h)
+			before, after = -1, -1
+		}
+
+		// Inside a hunk
+		if line[0] == '-' || line[0] == ' ' {
+			before--
+		}
+		if line[0] == '+' || line[0] == ' ' {
+			after--
+		}
+
+		stripped := removeSpace(line)
+		length += len(stripped)
+		h.Write([]byte(stripped))
+	}
+
+	flushPatchID(&result, h)
+	return hex.EncodeToString(result[:]), length, next, nil
+}
+
+// flushPatchID adds the hash of one file's diff to the running sum, with
+// carry, and resets the hash for the next file
+func flushPatchID(result *[sha1.Size]byte, h hash.Hash) {
+	sum := h.Sum(nil)
+	h.Reset()
+
+	carry := 0
+	for i := range result {
+		carry += int(result[i]) + int(sum[i])
+		result[i] = byte(carry)
+		carry >>= 8
+	}
+}
+
+// scanHunkHeader returns the old and new line counts of "@@ -a,b +c,d @@"
+func scanHunkHeader(line string) (before, after int) {
+	q := line[4:]
+	n := countDigits(q)
+	before = 1
+	if n < len(q) && q[n] == ',' {
+		q = q[n+1:]
+		before, _ = strconv.Atoi(q[:countDigits(q)])
+		n = countDigits(q)
+	}
+	if n == 0 || n+1 >= len(q) || q[n] != ' ' || q[n+1] != '+' {
+		return 0, 0
+	}
+
+	r := q[n+2:]
+	n = countDigits(r)
+	after = 1
+	if n < len(r) && r[n] == ',' {
+		r = r[n+1:]
+		after, _ = strconv.Atoi(r[:countDigits(r)])
+		n = countDigits(r)
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return before, after
+}
+
+func countDigits(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// removeSpace drops every whitespace character from a line
+func removeSpace(line string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, line)
+}
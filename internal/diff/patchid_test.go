@@ -0,0 +1,123 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+const patchF = `diff --git a/f b/f
+index 0123456..789abcd 100644
+--- a/f
++++ b/f
+@@ -1,3 +1,3 @@
+ 1
+-2
++two
+ 3
+`
+
+const patchG = `diff --git a/g b/g
+index 0123456..789abcd 100644
+--- a/g
++++ b/g
+@@ -10,2 +10,2 @@
+ x
+-y
++why
+`
+
+// patchIDs returns the patch ID of each patch in text
+func patchIDs(t *testing.T, text string) []string {
+	t.Helper()
+	var ids []string
+	err := ReadPatchIDs(strings.NewReader(text), func(patchID, commit string) error {
+		ids = append(ids, patchID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestPatchIDMatchesGit(t *testing.T) {
+	// As "git patch-id --stable" gives for the same patch
+	want := "d3bd1f8a12d2f39fcd659e6d8a49ff1c5416fc61"
+	if ids := patchIDs(t, patchF+patchG); len(ids) != 1 || ids[0] != want {
+		t.Errorf("patch ID %v, want [%s]", ids, want)
+	}
+}
+
+func TestPatchIDIgnoresPlacement(t *testing.T) {
+	want := patchIDs(t, patchF+patchG)[0]
+	for name, patch := range map[string]string{
+		"files in the other order": patchG + patchF,
+		"other line numbers":       strings.Replace(patchF, "@@ -1,3 +1,3 @@", "@@ -41,3 +41,3 @@", 1) + patchG,
+		"other blob names":         strings.Replace(patchF, "index 0123456..789abcd", "index fedcba9..1111111", 1) + patchG,
+		"other whitespace":         strings.Replace(patchF, "+two", "+ t w o", 1) + patchG,
+		"with commit lines":        "commit 1111111111111111111111111111111111111111\n\n" + patchF + patchG,
+	} {
+		if ids := patchIDs(t, patch); len(ids) != 1 || ids[0] != want {
+			t.Errorf("%s: patch ID %v, want [%s]", name, ids, want)
+		}
+	}
+
+	if ids := patchIDs(t, strings.Replace(patchF, "+two", "+three", 1)+patchG); ids[0] == want {
+		t.Error("a different change has the same patch ID")
+	}
+}
+
+func TestCommitPatchID(t *testing.T) {
+	repoPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoPath, ".gogit", "objects"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(obj object.Object) string {
+		hash, err := object.WriteObject(repoPath, obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	commit := func(parents []string, files map[string]string) *object.Commit {
+		tree := object.NewTree()
+		for _, name := range []string{"f", "g"} {
+			if content, ok := files[name]; ok {
+				tree.AddEntry(object.ModeFile, name, write(object.NewBlob([]byte(content))))
+			}
+		}
+		c := object.NewCommit(write(tree), parents, "Test <test@example.com>", "message\n")
+		write(c)
+		return c
+	}
+
+	// The same change to f, made on top of two different histories
+	base1 := commit(nil, map[string]string{"f": "1\n2\n3\n"})
+	base2 := commit(nil, map[string]string{"f": "1\n2\n3\n", "g": "other\n"})
+	change1 := commit([]string{base1.Hash()}, map[string]string{"f": "1\ntwo\n3\n"})
+	change2 := commit([]string{base2.Hash()}, map[string]string{"f": "1\ntwo\n3\n", "g": "other\n"})
+	different := commit([]string{base1.Hash()}, map[string]string{"f": "1\nthree\n3\n"})
+
+	store := object.NewStore(repoPath)
+	id := func(c *object.Commit) string {
+		t.Helper()
+		id, err := CommitPatchID(store, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	if a, b := id(change1), id(change2); a == "" || a != b {
+		t.Errorf("patch IDs %q and %q, want the same change to match", a, b)
+	}
+	if id(change1) == id(different) {
+		t.Error("different changes have the same patch ID")
+	}
+	if got := id(commit([]string{change1.Hash()}, map[string]string{"f": "1\ntwo\n3\n"})); got != "" {
+		t.Errorf("a commit changing nothing has patch ID %q", got)
+	}
+}
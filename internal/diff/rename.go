@@ -0,0 +1,316 @@
+package diff
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// DefaultRenameScore is the similarity, in percent, at which a deleted and
+// an added file are paired up as a rename
+const DefaultRenameScore = 50
+
+// DefaultRenameLimit is the default RenameOptions.Limit
+const DefaultRenameLimit = 1000
+
+// RenameOptions tunes rename and copy detection
+type RenameOptions struct {
+	MinScore int // Similarity, in percent, a pair of files needs
+	// Limit caps the work of inexact matching, which compares every source
+	// with every destination: with more than Limit×Limit pairs to compare,
+	// only identical files are matched. 0 means no limit.
+	Limit int
+}
+
+// DefaultRenameOptions are the options used when nothing is configured
+var DefaultRenameOptions = RenameOptions{MinScore: DefaultRenameScore, Limit: DefaultRenameLimit}
+
+// tooManyCandidates reports whether comparing sources with destinations
+// exceeds the limit, and if so the limit that would have been needed
+func (o RenameOptions) tooManyCandidates(sources, destinations int) (int, bool) {
+	if o.Limit <= 0 || sources*destinations <= o.Limit*o.Limit {
+		return 0, false
+	}
+	return max(sources, destinations), true
+}
+
+// DetectRenames pairs deleted files with added files of identical or
+// similar content and replaces each pair with one rename. Identical content
+// is matched first; the remaining pairs must be at least opts.MinScore
+// percent similar. The result is sorted by path like the input.
+//
+// If the remaining pairs are too many for opts.Limit, only identical files
+// are paired and the limit that would have been needed is returned, so the
+// caller can say so; otherwise it returns 0.
+func DetectRenames(store *object.Store, changes []FileChange, opts RenameOptions) ([]FileChange, int, error) {
+	var deleted, added []int
+	for i, change := range changes {
+		// A gitlink's commit isn't in this repository to compare
+		if change.OldMode == object.ModeGitlink || change.NewMode == object.ModeGitlink {
+			continue
+		}
+		switch change.Status {
+		case StatusDeleted:
+			deleted = append(deleted, i)
+		case StatusAdded:
+			added = append(added, i)
+		}
+	}
+	if len(deleted) == 0 || len(added) == 0 {
+		return changes, 0, nil
+	}
+
+	paired := make(map[int]bool) // Indexes of changes consumed by a rename
+	var renames []FileChange
+	pair := func(src, dst, score int) {
+		paired[src], paired[dst] = true, true
+		renames = append(renames, renamed(changes[src], changes[dst], score))
+	}
+
+	// Exact renames first; empty files are too common to pair up reliably
+	byHash := make(map[string][]int)
+	for _, i := range deleted {
+		if changes[i].OldHash != emptyBlobHash {
+			byHash[changes[i].OldHash] = append(byHash[changes[i].OldHash], i)
+		}
+	}
+	for _, dst := range added {
+		candidates := byHash[changes[dst].NewHash]
+		if len(candidates) == 0 {
+			continue
+		}
+		pair(candidates[0], dst, 100)
+		byHash[changes[dst].NewHash] = candidates[1:]
+	}
+
+	// Then every remaining pair, by similarity, if there aren't too many
+	var sources, destinations []int
+	for _, src := range deleted {
+		if !paired[src] && changes[src].OldHash != emptyBlobHash {
+			sources = append(sources, src)
+		}
+	}
+	for _, dst := range added {
+		if !paired[dst] && changes[dst].NewHash != emptyBlobHash {
+			destinations = append(destinations, dst)
+		}
+	}
+	needed, limited := opts.tooManyCandidates(len(sources), len(destinations))
+	if limited {
+		sources = nil
+	}
+
+	load := newBlobLoader(store).load
+
+	type candidate struct{ src, dst, score int }
+	var candidates []candidate
+	for _, dst := range destinations {
+		if len(sources) == 0 {
+			break
+		}
+		dstContent, err := load(changes[dst].NewHash)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, src := range sources {
+			srcContent, err := load(changes[src].OldHash)
+			if err != nil {
+				return nil, 0, err
+			}
+			if score := similarity(srcContent, dstContent, opts.MinScore); score >= opts.MinScore {
+				candidates = append(candidates, candidate{src, dst, score})
+			}
+		}
+	}
+
+	// Best matches win; ties go to the earlier paths
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	for _, c := range candidates {
+		if !paired[c.src] && !paired[c.dst] {
+			pair(c.src, c.dst, c.score)
+		}
+	}
+
+	if len(renames) == 0 {
+		return changes, needed, nil
+	}
+
+	result := renames
+	for i, change := range changes {
+		if !paired[i] {
+			result = append(result, change)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Path() < result[j].Path()
+	})
+	return result, needed, nil
+}
+
+// DetectCopies marks added files whose content matches, or is at least
+// opts.MinScore percent similar to, a file in sources (the old snapshot) as
+// copies of it. Unlike a rename, a copy leaves its source in place, so any
+// source file may be copied any number of times. Like DetectRenames, it
+// falls back to identical files only when there are too many pairs for
+// opts.Limit, returning the limit that would have been needed.
+func DetectCopies(store *object.Store, changes []FileChange, sources map[string]object.TreeEntry, opts RenameOptions) ([]FileChange, int, error) {
+	paths := make([]string, 0, len(sources))
+	byHash := make(map[string]string) // Blob hash -> first source path holding it
+	for path := range sources {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if sources[path].Mode == object.ModeGitlink {
+			continue
+		}
+		if _, ok := byHash[sources[path].Hash]; !ok {
+			byHash[sources[path].Hash] = path
+		}
+	}
+
+	isCandidate := func(change FileChange) bool {
+		return change.Status == StatusAdded && change.NewHash != emptyBlobHash && change.NewMode != object.ModeGitlink
+	}
+	destinations := 0
+	for _, change := range changes {
+		if isCandidate(change) {
+			destinations++
+		}
+	}
+	needed, limited := opts.tooManyCandidates(len(paths), destinations)
+
+	load := newBlobLoader(store).load
+	result := make([]FileChange, len(changes))
+	copy(result, changes)
+
+	for i, change := range result {
+		if !isCandidate(change) {
+			continue
+		}
+
+		best, bestScore := "", 0
+		if src, ok := byHash[change.NewHash]; ok {
+			best, bestScore = src, 100
+		} else if !limited {
+			dstContent, err := load(change.NewHash)
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, path := range paths {
+				entry := sources[path]
+				if entry.Hash == emptyBlobHash || entry.Mode == object.ModeGitlink {
+					continue
+				}
+				srcContent, err := load(entry.Hash)
+				if err != nil {
+					return nil, 0, err
+				}
+				if score := similarity(srcContent, dstContent, opts.MinScore); score >= opts.MinScore && score > bestScore {
+					best, bestScore = path, score
+				}
+			}
+		}
+		if best == "" {
+			continue
+		}
+
+		src := sources[best]
+		result[i] = FileChange{
+			Status:  StatusCopied,
+			OldPath: best,
+			NewPath: change.NewPath,
+			OldMode: src.Mode,
+			NewMode: change.NewMode,
+			OldHash: src.Hash,
+			NewHash: change.NewHash,
+			Score:   bestScore,
+		}
+	}
+
+	return result, needed, nil
+}
+
+// blobLoader reads blobs, keeping each one so repeated comparisons of the
+// same file don't reread it
+type blobLoader struct {
+	store    *object.Store
+	contents map[string][]byte
+}
+
+func newBlobLoader(store *object.Store) *blobLoader {
+	return &blobLoader{store: store, contents: make(map[string][]byte)}
+}
+
+func (l *blobLoader) load(hash string) ([]byte, error) {
+	if content, ok := l.contents[hash]; ok {
+		return content, nil
+	}
+	content, err := blobContent(l.store, hash)
+	if err != nil {
+		return nil, err
+	}
+	l.contents[hash] = []byte(content)
+	return l.contents[hash], nil
+}
+
+// emptyBlobHash is the name of the empty blob
+const emptyBlobHash = "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"
+
+// renamed combines a deletion and an addition into a rename
+func renamed(src, dst FileChange, score int) FileChange {
+	return FileChange{
+		Status:  StatusRenamed,
+		OldPath: src.OldPath,
+		NewPath: dst.NewPath,
+		OldMode: src.OldMode,
+		NewMode: dst.NewMode,
+		OldHash: src.OldHash,
+		NewHash: dst.NewHash,
+		Score:   score,
+	}
+}
+
+// similarity estimates, in percent, how much of the larger of two files
+// consists of lines the other file also has. Scores that can't reach
+// minScore because the sizes differ too much are reported as 0.
+func similarity(a, b []byte, minScore int) int {
+	small, large := len(a), len(b)
+	if small > large {
+		small, large = large, small
+	}
+	if large == 0 || small*100 < large*minScore {
+		return 0
+	}
+
+	lines := make(map[string]int)
+	for _, line := range splitLinesKeepEnds(a) {
+		lines[string(line)]++
+	}
+	common := 0
+	for _, line := range splitLinesKeepEnds(b) {
+		if lines[string(line)] > 0 {
+			lines[string(line)]--
+			common += len(line)
+		}
+	}
+
+	return common * 100 / large
+}
+
+// splitLinesKeepEnds splits content after each newline
+func splitLinesKeepEnds(content []byte) [][]byte {
+	var lines [][]byte
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, '\n') + 1
+		if end == 0 {
+			end = len(content)
+		}
+		lines = append(lines, content[:end])
+		content = content[end:]
+	}
+	return lines
+}
@@ -0,0 +1,226 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultStatWidth is the width --stat output fits in when it isn't
+// written to a terminal
+const DefaultStatWidth = 80
+
+// FileStat counts the lines one file change added and deleted
+type FileStat struct {
+	Name    string // Path, or "old => new" for a rename or copy
+	Added   int    // For a binary file, the new size in bytes
+	Deleted int    // For a binary file, the old size in bytes
+	Binary  bool
+}
+
+// NewFileStat counts the inserted and deleted lines of a file's diff
+func NewFileStat(fc FileChange, changes []Change) FileStat {
+	stat := FileStat{Name: StatName(fc)}
+	for _, c := range changes {
+		switch c.Type {
+		case ChangeInsert:
+			stat.Added++
+		case ChangeDelete:
+			stat.Deleted++
+		}
+	}
+	return stat
+}
+
+// NewBinaryFileStat records the sizes of a binary file's two versions,
+// which --stat shows instead of line counts
+func NewBinaryFileStat(fc FileChange, oldSize, newSize int) FileStat {
+	return FileStat{Name: StatName(fc), Added: newSize, Deleted: oldSize, Binary: true}
+}
+
+// StatName returns the name a change is listed under in --stat output. A
+// rename or copy is shown as "old => new", with the directories and file
+// name parts the two paths share pulled out, as in "dir/{a => b}/file".
+func StatName(fc FileChange) string {
+	if fc.Status != StatusRenamed && fc.Status != StatusCopied {
+		return fc.Path()
+	}
+	a, b := fc.OldPath, fc.NewPath
+
+	// The common prefix ends at a slash
+	prefix := 0
+	for i := 0; i < len(a) && i < len(b) && a[i] == b[i]; i++ {
+		if a[i] == '/' {
+			prefix = i + 1
+		}
+	}
+
+	// The common suffix starts at a slash, which may be the one ending the
+	// prefix
+	suffix := 0
+	stop := prefix
+	if prefix > 0 {
+		stop--
+	}
+	for i, j := len(a)-1, len(b)-1; i >= stop && j >= stop && a[i] == b[j]; i, j = i-1, j-1 {
+		if a[i] == '/' {
+			suffix = len(a) - i
+		}
+	}
+
+	aMid := len(a) - prefix - suffix
+	bMid := len(b) - prefix - suffix
+	if aMid < 0 {
+		aMid = 0
+	}
+	if bMid < 0 {
+		bMid = 0
+	}
+
+	if prefix+suffix == 0 {
+		return a + " => " + b
+	}
+	return a[:prefix] + "{" + a[prefix:prefix+aMid] + " => " + b[prefix:prefix+bMid] + "}" + a[len(a)-suffix:]
+}
+
+// FormatStat renders the --stat histogram of a set of changes, one line
+// per file followed by the summary line, fitted into width columns. The
+// bars are scaled down when the largest change wouldn't fit.
+func FormatStat(stats []FileStat, width int) string {
+	if len(stats) == 0 {
+		return ""
+	}
+
+	// Binary files show "Bin <old> -> <new> bytes" instead of a count and
+	// bar, so they take no part in scaling
+	maxChange, maxName, binWidth := 0, 0, 0
+	for _, s := range stats {
+		maxName = max(maxName, utf8.RuneCountInString(s.Name))
+		if s.Binary {
+			binWidth = max(binWidth, 14+len(fmt.Sprint(s.Added))+len(fmt.Sprint(s.Deleted)))
+			continue
+		}
+		maxChange = max(maxChange, s.Added+s.Deleted)
+	}
+	numberWidth := len(fmt.Sprint(maxChange))
+	if binWidth > 0 {
+		numberWidth = max(numberWidth, 3) // Wide enough for "Bin"
+	}
+
+	// Always leave room for a short name and a short bar
+	if width < 16+6+numberWidth {
+		width = 16 + 6 + numberWidth
+	}
+
+	// " name | NNN bar" needs 6 columns besides the name, count and bar;
+	// when there isn't room the bar gets at most 3/8 of the width and the
+	// name what is left
+	graphWidth, nameWidth := maxChange, maxName
+	if binWidth > 0 && maxChange+4 <= binWidth {
+		graphWidth = binWidth - 4
+	}
+	if nameWidth+numberWidth+6+graphWidth > width {
+		if graphWidth > width*3/8-numberWidth-6 {
+			graphWidth = max(width*3/8-numberWidth-6, 6)
+		}
+		if nameWidth > width-numberWidth-6-graphWidth {
+			nameWidth = width - numberWidth - 6 - graphWidth
+		} else {
+			graphWidth = width - numberWidth - 6 - nameWidth
+		}
+	}
+
+	var sb strings.Builder
+	for _, s := range stats {
+		name := s.Name
+		prefix := ""
+		if utf8.RuneCountInString(name) > nameWidth {
+			// Keep the end of the path, from a directory boundary if possible
+			prefix = "..."
+			runes := []rune(name)
+			name = string(runes[len(runes)-max(nameWidth-3, 0):])
+			if slash := strings.IndexByte(name, '/'); slash >= 0 {
+				name = name[slash:]
+			}
+		}
+		padding := max(nameWidth-len(prefix)-utf8.RuneCountInString(name), 0)
+
+		if s.Binary {
+			fmt.Fprintf(&sb, " %s%s%s | %*s", prefix, name, strings.Repeat(" ", padding), numberWidth, "Bin")
+			if s.Added != 0 || s.Deleted != 0 {
+				fmt.Fprintf(&sb, " %d -> %d bytes", s.Deleted, s.Added)
+			}
+			sb.WriteString("\n")
+			continue
+		}
+
+		total := s.Added + s.Deleted
+		added, deleted := s.Added, s.Deleted
+		if graphWidth < maxChange {
+			scaled := scaleLinear(total, graphWidth, maxChange)
+			if scaled < 2 && added > 0 && deleted > 0 {
+				scaled = 2
+			}
+			if added < deleted {
+				added = scaleLinear(added, graphWidth, maxChange)
+				deleted = scaled - added
+			} else {
+				deleted = scaleLinear(deleted, graphWidth, maxChange)
+				added = scaled - deleted
+			}
+		}
+
+		fmt.Fprintf(&sb, " %s%s%s | %*d", prefix, name, strings.Repeat(" ", padding), numberWidth, total)
+		if total > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(strings.Repeat("+", added))
+		sb.WriteString(strings.Repeat("-", deleted))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(ShortStat(stats))
+	return sb.String()
+}
+
+// scaleLinear scales a count into a bar of at most width characters, so
+// that any change gets at least one
+func scaleLinear(n, width, maxChange int) int {
+	if n == 0 {
+		return 0
+	}
+	return 1 + n*(width-1)/maxChange
+}
+
+// ShortStat returns the --shortstat summary line of a set of changes, such
+// as " 2 files changed, 5 insertions(+), 1 deletion(-)"
+func ShortStat(stats []FileStat) string {
+	if len(stats) == 0 {
+		return ""
+	}
+
+	added, deleted := 0, 0
+	for _, s := range stats {
+		if !s.Binary {
+			added += s.Added
+			deleted += s.Deleted
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, " %d %s changed", len(stats), plural(len(stats), "file", "files"))
+	if added > 0 || deleted == 0 {
+		fmt.Fprintf(&sb, ", %d %s(+)", added, plural(added, "insertion", "insertions"))
+	}
+	if deleted > 0 || added == 0 {
+		fmt.Fprintf(&sb, ", %d %s(-)", deleted, plural(deleted, "deletion", "deletions"))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,249 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// Status letters used by name-status and raw output
+const (
+	StatusAdded    = 'A'
+	StatusCopied   = 'C'
+	StatusDeleted  = 'D'
+	StatusModified = 'M'
+	StatusRenamed  = 'R'
+)
+
+// FileChange describes how a single path differs between two snapshots
+type FileChange struct {
+	Status  byte
+	OldPath string
+	NewPath string
+	OldMode string
+	NewMode string
+	OldHash string
+	NewHash string
+	Score   int // Similarity percentage of a rename or copy
+}
+
+// Path returns the path the change is best known by
+func (fc *FileChange) Path() string {
+	if fc.NewPath != "" {
+		return fc.NewPath
+	}
+	return fc.OldPath
+}
+
+// NameStatus formats the change as a name-status line
+func (fc *FileChange) NameStatus() string {
+	return fmt.Sprintf("%s\t%s", fc.statusField(), fc.pathField())
+}
+
+// statusField is the status letter, followed by the score for a rename or copy
+func (fc *FileChange) statusField() string {
+	if fc.Status == StatusRenamed || fc.Status == StatusCopied {
+		return fmt.Sprintf("%c%03d", fc.Status, fc.Score)
+	}
+	return string(fc.Status)
+}
+
+// pathField is the path, or "old<TAB>new" for a rename or copy
+func (fc *FileChange) pathField() string {
+	if fc.Status == StatusRenamed || fc.Status == StatusCopied {
+		return fc.OldPath + "\t" + fc.NewPath
+	}
+	return fc.Path()
+}
+
+// ZeroHash stands for a missing object in raw output
+const ZeroHash = "0000000000000000000000000000000000000000"
+
+// Raw formats the change in the plumbing raw format:
+// ":<old-mode> <new-mode> <old-sha> <new-sha> <status>\t<path>"
+func (fc *FileChange) Raw() string {
+	oldMode, newMode := fc.OldMode, fc.NewMode
+	oldHash, newHash := fc.OldHash, fc.NewHash
+	if oldHash == "" {
+		oldMode, oldHash = "000000", ZeroHash
+	}
+	if newHash == "" {
+		newMode, newHash = "000000", ZeroHash
+	}
+
+	return fmt.Sprintf(":%06s %06s %s %s %s\t%s", oldMode, newMode, oldHash, newHash, fc.statusField(), fc.pathField())
+}
+
+// FlattenTree recursively reads a tree and returns its blobs keyed by full path
+func FlattenTree(store *object.Store, treeHash string) (map[string]object.TreeEntry, error) {
+	files := make(map[string]object.TreeEntry)
+	if treeHash == "" {
+		return files, nil
+	}
+	err := store.WalkTree(treeHash, func(path string, entry object.TreeEntry) error {
+		if !entry.IsTree() {
+			files[path] = entry
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// DiffTrees compares two trees and returns the changed files sorted by path.
+// Either hash may be empty to stand for the empty tree.
+func DiffTrees(store *object.Store, oldTree, newTree string) ([]FileChange, error) {
+	oldFiles, err := FlattenTree(store, oldTree)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := FlattenTree(store, newTree)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffEntries(oldFiles, newFiles), nil
+}
+
+// DiffEntries compares two flattened snapshots and returns the changed files sorted by path
+func DiffEntries(oldFiles, newFiles map[string]object.TreeEntry) []FileChange {
+	var changes []FileChange
+
+	for path, oldEntry := range oldFiles {
+		newEntry, exists := newFiles[path]
+		if !exists {
+			changes = append(changes, FileChange{
+				Status:  StatusDeleted,
+				OldPath: path,
+				OldMode: oldEntry.Mode,
+				OldHash: oldEntry.Hash,
+			})
+			continue
+		}
+		if newEntry.Hash != oldEntry.Hash || newEntry.Mode != oldEntry.Mode {
+			changes = append(changes, FileChange{
+				Status:  StatusModified,
+				OldPath: path,
+				NewPath: path,
+				OldMode: oldEntry.Mode,
+				NewMode: newEntry.Mode,
+				OldHash: oldEntry.Hash,
+				NewHash: newEntry.Hash,
+			})
+		}
+	}
+
+	for path, newEntry := range newFiles {
+		if _, exists := oldFiles[path]; !exists {
+			changes = append(changes, FileChange{
+				Status:  StatusAdded,
+				NewPath: path,
+				NewMode: newEntry.Mode,
+				NewHash: newEntry.Hash,
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path() < changes[j].Path()
+	})
+
+	return changes
+}
+
+// DiffTreeEntries compares the entries directly in two trees, without
+// descending into subtrees, so a subtree with any change in it is a single
+// modified entry. An entry that turns from a subtree into something else,
+// or back, is reported as deleted and then added. The changes are in tree
+// order; either hash may be empty to stand for the empty tree.
+func DiffTreeEntries(store *object.Store, oldTree, newTree string) ([]FileChange, error) {
+	oldEntries, err := treeEntries(store, oldTree)
+	if err != nil {
+		return nil, err
+	}
+	newEntries, err := treeEntries(store, newTree)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trees sort as if their names ended in "/", as they do in a tree
+	type keyed struct {
+		key    string
+		change FileChange
+	}
+	var changes []keyed
+	sortKey := func(entry object.TreeEntry) string {
+		if entry.IsTree() {
+			return entry.Name + "/"
+		}
+		return entry.Name
+	}
+
+	for name, oldEntry := range oldEntries {
+		newEntry, exists := newEntries[name]
+		if exists && newEntry.IsTree() == oldEntry.IsTree() {
+			if newEntry.Hash != oldEntry.Hash || newEntry.Mode != oldEntry.Mode {
+				changes = append(changes, keyed{sortKey(oldEntry), FileChange{
+					Status:  StatusModified,
+					OldPath: name,
+					NewPath: name,
+					OldMode: oldEntry.Mode,
+					NewMode: newEntry.Mode,
+					OldHash: oldEntry.Hash,
+					NewHash: newEntry.Hash,
+				}})
+			}
+			continue
+		}
+		changes = append(changes, keyed{sortKey(oldEntry), FileChange{
+			Status:  StatusDeleted,
+			OldPath: name,
+			OldMode: oldEntry.Mode,
+			OldHash: oldEntry.Hash,
+		}})
+	}
+
+	for name, newEntry := range newEntries {
+		if oldEntry, exists := oldEntries[name]; exists && oldEntry.IsTree() == newEntry.IsTree() {
+			continue
+		}
+		changes = append(changes, keyed{sortKey(newEntry), FileChange{
+			Status:  StatusAdded,
+			NewPath: name,
+			NewMode: newEntry.Mode,
+			NewHash: newEntry.Hash,
+		}})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].key != changes[j].key {
+			return changes[i].key < changes[j].key
+		}
+		return changes[i].change.Status == StatusDeleted
+	})
+
+	result := make([]FileChange, len(changes))
+	for i, c := range changes {
+		result[i] = c.change
+	}
+	return result, nil
+}
+
+// treeEntries returns the entries of a tree keyed by name
+func treeEntries(store *object.Store, treeHash string) (map[string]object.TreeEntry, error) {
+	entries := make(map[string]object.TreeEntry)
+	if treeHash == "" {
+		return entries, nil
+	}
+	tree, err := store.ReadTree(treeHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range tree.Entries {
+		entries[entry.Name] = entry
+	}
+	return entries, nil
+}
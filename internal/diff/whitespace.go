@@ -0,0 +1,182 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Whitespace errors a WhitespaceRule can look for, named as in
+// core.whitespace
+const (
+	wsBlankAtEOL = 1 << iota
+	wsBlankAtEOF
+	wsSpaceBeforeTab
+	wsIndentWithNonTab
+	wsTabInIndent
+	wsCRAtEOL // Not an error: a CR ending a line isn't trailing whitespace
+)
+
+// WhitespaceRule selects the whitespace errors that added lines are
+// checked for
+type WhitespaceRule struct {
+	checks   int
+	tabWidth int
+}
+
+// DefaultWhitespaceRule looks for trailing whitespace, blank lines added at
+// the end of a file and spaces before a tab in an indent
+var DefaultWhitespaceRule = WhitespaceRule{checks: wsBlankAtEOL | wsBlankAtEOF | wsSpaceBeforeTab, tabWidth: 8}
+
+// ParseWhitespaceRule parses a core.whitespace value: a comma-separated
+// list of checks to turn on, or off when prefixed with "-", applied to the
+// defaults. The checks are blank-at-eol, blank-at-eof, trailing-space (both
+// of those), space-before-tab, indent-with-non-tab, tab-in-indent and
+// cr-at-eol, and tabwidth=<n> sets how many spaces indent-with-non-tab
+// allows. Unknown names are ignored.
+func ParseWhitespaceRule(value string) (WhitespaceRule, error) {
+	rule := DefaultWhitespaceRule
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		negated := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+
+		if width, ok := strings.CutPrefix(name, "tabwidth="); ok {
+			n, err := strconv.Atoi(width)
+			if err != nil || n < 1 || n > 63 {
+				return WhitespaceRule{}, fmt.Errorf("tabwidth %s out of range", width)
+			}
+			rule.tabWidth = n
+			continue
+		}
+
+		var check int
+		switch name {
+		case "blank-at-eol":
+			check = wsBlankAtEOL
+		case "blank-at-eof":
+			check = wsBlankAtEOF
+		case "trailing-space":
+			check = wsBlankAtEOL | wsBlankAtEOF
+		case "space-before-tab":
+			check = wsSpaceBeforeTab
+		case "indent-with-non-tab":
+			check = wsIndentWithNonTab
+		case "tab-in-indent":
+			check = wsTabInIndent
+		case "cr-at-eol":
+			check = wsCRAtEOL
+		}
+		if negated {
+			rule.checks &^= check
+		} else {
+			rule.checks |= check
+		}
+	}
+
+	if rule.checks&wsIndentWithNonTab != 0 && rule.checks&wsTabInIndent != 0 {
+		return WhitespaceRule{}, fmt.Errorf("cannot enforce both tab-in-indent and indent-with-non-tab")
+	}
+	return rule, nil
+}
+
+// WhitespaceError is a whitespace error on an added line
+type WhitespaceError struct {
+	Line       int    // Line number in the new file
+	Text       string // The line, without its newline
+	Problem    string // Such as "trailing whitespace"
+	BlankAtEOF bool   // Blank lines were added at the end of the file, starting at Line
+}
+
+// CheckWhitespace looks for whitespace errors in the lines a diff inserts
+// into newText, and for blank lines it adds at the end of the file
+func CheckWhitespace(oldText, newText string, changes []Change, rule WhitespaceRule) []WhitespaceError {
+	var errs []WhitespaceError
+	for _, c := range changes {
+		if c.Type != ChangeInsert {
+			continue
+		}
+		if problem := rule.check(c.Text); problem != "" {
+			errs = append(errs, WhitespaceError{Line: c.NewLine, Text: c.Text, Problem: problem})
+		}
+	}
+
+	if rule.checks&wsBlankAtEOF != 0 {
+		oldBlank, newBlank := trailingBlankLines(oldText), trailingBlankLines(newText)
+		if newBlank > oldBlank {
+			errs = append(errs, WhitespaceError{
+				Line:       countLines(newText) - newBlank + 1,
+				Problem:    "new blank line at EOF",
+				BlankAtEOF: true,
+			})
+		}
+	}
+	return errs
+}
+
+// check returns the whitespace errors in one line, described as git does,
+// or "" if there are none
+func (rule WhitespaceRule) check(line string) string {
+	var problems []string
+
+	if rule.checks&wsBlankAtEOL != 0 {
+		text := line
+		if rule.checks&wsCRAtEOL != 0 {
+			text = strings.TrimSuffix(text, "\r")
+		}
+		if text != strings.TrimRight(text, " \t\r\v\f") {
+			problems = append(problems, "trailing whitespace")
+		}
+	}
+
+	// The indent ends at the first character that isn't a space or tab;
+	// written is where the last tab in it ends. A tab after spaces counts
+	// as a space before a tab rather than a tab in the indent.
+	written, i := 0, 0
+	spaceBeforeTab, tabInIndent := false, false
+	for ; i < len(line) && (line[i] == ' ' || line[i] == '\t'); i++ {
+		if line[i] != '\t' {
+			continue
+		}
+		if rule.checks&wsSpaceBeforeTab != 0 && written < i {
+			spaceBeforeTab = true
+		} else if rule.checks&wsTabInIndent != 0 {
+			tabInIndent = true
+		}
+		written = i + 1
+	}
+	if spaceBeforeTab {
+		problems = append(problems, "space before tab in indent")
+	}
+	if rule.checks&wsIndentWithNonTab != 0 && i-written >= rule.tabWidth {
+		problems = append(problems, "indent with spaces")
+	}
+	if tabInIndent {
+		problems = append(problems, "tab in indent")
+	}
+
+	return strings.Join(problems, ", ")
+}
+
+// trailingBlankLines counts the lines at the end of text holding nothing
+// but whitespace
+func trailingBlankLines(text string) int {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		return 0
+	}
+	n := 0
+	for i := len(lines) - 1; i >= 0 && strings.TrimSpace(lines[i]) == ""; i-- {
+		n++
+	}
+	return n
+}
+
+// countLines counts the lines in text, including a last one without a
+// newline
+func countLines(text string) int {
+	if text == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(text, "\n"), "\n") + 1
+}
@@ -0,0 +1,78 @@
+package gpg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Program is the gpg binary used for verification
+var Program = "gpg"
+
+// Result describes the outcome of a signature verification
+type Result struct {
+	Good   bool
+	KeyID  string
+	Signer string
+	Output string // Human-readable gpg output
+}
+
+// Verify checks a detached signature over payload using the user's keyring
+func Verify(payload, signature []byte) (*Result, error) {
+	sigFile, err := os.CreateTemp("", "gogit-sig-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature file: %w", err)
+	}
+	defer os.Remove(sigFile.Name())
+
+	if _, err := sigFile.Write(signature); err != nil {
+		sigFile.Close()
+		return nil, fmt.Errorf("failed to write signature file: %w", err)
+	}
+	if err := sigFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write signature file: %w", err)
+	}
+
+	var status, output bytes.Buffer
+	cmd := exec.Command(Program, "--status-fd=1", "--keyid-format=long", "--verify", sigFile.Name(), "-")
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &status
+	cmd.Stderr = &output
+
+	runErr := cmd.Run()
+	if _, ok := runErr.(*exec.ExitError); runErr != nil && !ok {
+		return nil, fmt.Errorf("failed to run %s: %w", Program, runErr)
+	}
+
+	result := &Result{Output: output.String()}
+	scanner := bufio.NewScanner(&status)
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimPrefix(scanner.Text(), "[GNUPG:] "), " ", 3)
+		switch fields[0] {
+		case "GOODSIG":
+			result.Good = true
+			if len(fields) > 1 {
+				result.KeyID = fields[1]
+			}
+			if len(fields) > 2 {
+				result.Signer = fields[2]
+			}
+		case "BADSIG", "ERRSIG", "EXPSIG", "EXPKEYSIG", "REVKEYSIG":
+			result.Good = false
+			if len(fields) > 1 {
+				result.KeyID = fields[1]
+			}
+			return result, nil
+		}
+	}
+
+	// gpg must also exit cleanly for a signature to count as good
+	if runErr != nil {
+		result.Good = false
+	}
+
+	return result, nil
+}
@@ -0,0 +1,176 @@
+// Package ignore matches paths against gitignore-style rules read from
+// .gogitignore and .gitignore files and the repository's info/exclude
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileNames are the per-directory ignore files, read in this order so that
+// .gogitignore rules win over .gitignore ones
+var FileNames = []string{".gitignore", ".gogitignore"}
+
+// rule is one pattern line from an ignore file
+type rule struct {
+	base    string // Directory of the file the rule came from, relative to the root; "" for the root
+	re      *regexp.Regexp
+	negate  bool // "!pattern" re-includes what earlier rules excluded
+	dirOnly bool // "pattern/" only matches directories
+}
+
+// Matcher decides which paths in a working tree are ignored. Rules from
+// deeper directories are added later and so take precedence, as in Git.
+type Matcher struct {
+	root  string
+	rules []rule
+}
+
+// NewMatcher returns a matcher for the working tree at root, loaded with
+// the rules in gitDir's info/exclude and the root directory's ignore files.
+// Rules in subdirectories are added with LoadDir as the walk reaches them.
+func NewMatcher(root, gitDir string) *Matcher {
+	m := &Matcher{root: root}
+	m.loadFile("", filepath.Join(gitDir, "info", "exclude"))
+	m.LoadDir("")
+	return m
+}
+
+// LoadDir adds the rules from the ignore files in dir, given relative to
+// the root. Missing or unreadable files are skipped.
+func (m *Matcher) LoadDir(dir string) {
+	for _, name := range FileNames {
+		m.loadFile(dir, filepath.Join(m.root, dir, name))
+	}
+}
+
+func (m *Matcher) loadFile(base, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if r, ok := parseRule(base, scanner.Text()); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// Match reports whether a path relative to the root is ignored. It looks
+// only at the path itself; a file inside an ignored directory is ignored
+// too, which callers walking the tree handle by not entering the directory.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	path = filepath.ToSlash(path)
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel := path
+		if r.base != "" {
+			prefix := filepath.ToSlash(r.base) + "/"
+			if !strings.HasPrefix(path, prefix) {
+				continue
+			}
+			rel = strings.TrimPrefix(path, prefix)
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// parseRule parses one line of an ignore file, reporting false for blank
+// lines and comments
+func parseRule(base, line string) (rule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	re, err := CompilePattern(line)
+	if err != nil {
+		return rule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// CompilePattern compiles a gitignore-style glob into a regexp matching the
+// paths, relative to the pattern file's directory, that it names. A pattern
+// with a slash is relative to that directory; one without matches a name at
+// any depth. .gitattributes patterns follow the same rules.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	expr := globToRegexp(pattern)
+	if !anchored {
+		expr = "(.*/)?" + expr
+	}
+	return regexp.Compile("^" + expr + "$")
+}
+
+// globToRegexp translates a gitignore glob: "*" and "?" don't cross
+// directories, "**" does, and [...] is a character class
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
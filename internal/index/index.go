@@ -1,21 +1,34 @@
 package index
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/sha1"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"time"
 
+	"github.com/yourusername/gogit/internal/lockfile"
 	"github.com/yourusername/gogit/internal/utils"
 )
 
 const (
 	IndexSignature = "DIRC"
-	IndexVersion   = 2
+	IndexVersion   = 2 // Written by default
+)
+
+// flagExtended marks an entry followed by extended flags, in version 3 and
+// later
+const flagExtended = 0x4000
+
+// Extended flags of an index entry
+const (
+	FlagSkipWorktree = 0x4000 // The working tree file is left alone
+	FlagIntentToAdd  = 0x2000 // Added with add -N, without content yet
 )
 
 // Entry represents a single entry in the index
@@ -33,13 +46,42 @@ type Entry struct {
 	Hash      [20]byte
 	Flags     uint16
 	Path      string
+
+	// ExtendedFlags holds the flags only versions 3 and 4 can record.
+	// Writing an entry that has any as version 2 writes version 3 instead.
+	ExtendedFlags uint16
 }
 
 // Index represents the Git index (staging area)
 type Index struct {
 	Entries []Entry
+
+	// Version is the format version the index was read in: 2, 3 or 4, or
+	// 0 if it was not read from a file
+	Version uint32
+
+	// ModTime is when the index file was last written, or zero if it was
+	// not read from a file
+	ModTime time.Time
+
+	// Extensions holds the optional extensions that followed the entries,
+	// such as the TREE cache, undecoded and keyed by signature. Write puts
+	// them back, except those describing the entries once they've changed.
+	Extensions map[string][]byte
+
+	// entriesDigest identifies the entries as they were read, to tell
+	// whether extensions describing them still apply
+	entriesDigest [sha1.Size]byte
 }
 
+// Optional extensions that describe the entries and go stale when they
+// change: the cached trees, the untracked cache and the fsmonitor bitmap
+var entryExtensions = map[string]bool{"TREE": true, "UNTR": true, "FSMN": true}
+
+// Optional extensions that describe the layout of the file itself, which
+// are never written back
+var layoutExtensions = map[string]bool{"EOIE": true, "IEOT": true}
+
 // NewIndex creates a new empty index
 func NewIndex() *Index {
 	return &Index{Entries: make([]Entry, 0)}
@@ -47,7 +89,7 @@ func NewIndex() *Index {
 
 // ReadIndex reads the index file from the repository
 func ReadIndex(repoPath string) (*Index, error) {
-	indexPath := filepath.Join(repoPath, ".gogit", "index")
+	indexPath := filepath.Join(utils.GitDir(repoPath), "index")
 
 	data, err := os.ReadFile(indexPath)
 	if err != nil {
@@ -57,7 +99,31 @@ func ReadIndex(repoPath string) (*Index, error) {
 		return nil, fmt.Errorf("failed to read index: %w", err)
 	}
 
-	return parseIndex(data)
+	idx, err := parseIndex(data)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(indexPath); err == nil {
+		idx.ModTime = info.ModTime()
+	}
+	return idx, nil
+}
+
+// Scan calls fn for each index entry in order, decoding one entry at a time
+// so the whole index never has to be held in memory. A missing index has no
+// entries. Returning an error from fn stops the scan and returns that error.
+func Scan(repoPath string, fn func(Entry) error) error {
+	f, err := os.Open(filepath.Join(utils.GitDir(repoPath), "index"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	defer f.Close()
+
+	_, err = decodeIndex(bufio.NewReaderSize(f, 64*1024), fn)
+	return err
 }
 
 func parseIndex(data []byte) (*Index, error) {
@@ -65,80 +131,258 @@ func parseIndex(data []byte) (*Index, error) {
 		return nil, fmt.Errorf("index too small")
 	}
 
+	index := NewIndex()
+	r := bufio.NewReader(bytes.NewReader(data))
+	version, err := decodeIndex(r, func(entry Entry) error {
+		index.Entries = append(index.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	index.Version = version
+
+	rest, _ := io.ReadAll(r)
+	if index.Extensions, err = parseExtensions(rest); err != nil {
+		return nil, err
+	}
+	index.entriesDigest = index.digest()
+
+	return index, nil
+}
+
+// parseExtensions splits what follows the entries into extensions, each a
+// 4-byte signature, a 4-byte length and that many bytes of data, until the
+// trailing checksum. As in git, an extension whose signature starts with an
+// uppercase letter is optional; any other must be understood to read the
+// index, and none are.
+func parseExtensions(data []byte) (map[string][]byte, error) {
+	var extensions map[string][]byte
+	for len(data) > sha1.Size {
+		if len(data) < 8+sha1.Size {
+			return nil, fmt.Errorf("truncated index extension")
+		}
+		sig := string(data[:4])
+		size := binary.BigEndian.Uint32(data[4:8])
+		if uint64(size) > uint64(len(data)-8-sha1.Size) {
+			return nil, fmt.Errorf("truncated index extension: %s", sig)
+		}
+		if sig[0] < 'A' || sig[0] > 'Z' {
+			return nil, fmt.Errorf("index uses %s extension, which gogit does not understand", sig)
+		}
+
+		if extensions == nil {
+			extensions = make(map[string][]byte)
+		}
+		extensions[sig] = data[8 : 8+size]
+		data = data[8+size:]
+	}
+	if len(data) != sha1.Size {
+		return nil, fmt.Errorf("index too small")
+	}
+	return extensions, nil
+}
+
+// digest identifies the entries by what extensions can describe of them:
+// their paths, stages, modes and objects
+func (idx *Index) digest() [sha1.Size]byte {
+	h := sha1.New()
+	var buf [10]byte
+	for _, entry := range idx.Entries {
+		h.Write([]byte(entry.Path))
+		binary.BigEndian.PutUint16(buf[0:], entry.Flags)
+		binary.BigEndian.PutUint32(buf[2:], entry.Mode)
+		binary.BigEndian.PutUint16(buf[6:], entry.ExtendedFlags)
+		h.Write(buf[:8])
+		h.Write(entry.Hash[:])
+	}
+	var sum [sha1.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// decodeIndex reads the index header and hands each decoded entry to fn,
+// returning the index's version. Versions 2, 3 and 4 are understood: 3 adds
+// extended flags to entries that need them, and 4 drops the padding after
+// each path and stores it as the length of the previous entry's path to
+// remove, then the rest of the path.
+func decodeIndex(r *bufio.Reader, fn func(Entry) error) (uint32, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, fmt.Errorf("index too small")
+	}
+
 	// Check signature
-	sig := string(data[0:4])
+	sig := string(header[0:4])
 	if sig != IndexSignature {
-		return nil, fmt.Errorf("invalid index signature: %s", sig)
+		return 0, fmt.Errorf("invalid index signature: %s", sig)
 	}
 
 	// Check version
-	version := binary.BigEndian.Uint32(data[4:8])
-	if version != IndexVersion {
-		return nil, fmt.Errorf("unsupported index version: %d", version)
+	version := binary.BigEndian.Uint32(header[4:8])
+	if version < 2 || version > 4 {
+		return 0, fmt.Errorf("unsupported index version: %d", version)
 	}
 
 	// Entry count
-	entryCount := binary.BigEndian.Uint32(data[8:12])
-
-	index := NewIndex()
-	pos := 12
+	entryCount := binary.BigEndian.Uint32(header[8:12])
 
+	var fixed [62]byte
+	var previous string
 	for i := uint32(0); i < entryCount; i++ {
-		if pos+62 > len(data) {
-			return nil, fmt.Errorf("truncated index entry")
+		if _, err := io.ReadFull(r, fixed[:]); err != nil {
+			return 0, fmt.Errorf("truncated index entry")
 		}
 
 		entry := Entry{}
-		entry.CTimeSec = binary.BigEndian.Uint32(data[pos:])
-		entry.CTimeNano = binary.BigEndian.Uint32(data[pos+4:])
-		entry.MTimeSec = binary.BigEndian.Uint32(data[pos+8:])
-		entry.MTimeNano = binary.BigEndian.Uint32(data[pos+12:])
-		entry.Dev = binary.BigEndian.Uint32(data[pos+16:])
-		entry.Ino = binary.BigEndian.Uint32(data[pos+20:])
-		entry.Mode = binary.BigEndian.Uint32(data[pos+24:])
-		entry.UID = binary.BigEndian.Uint32(data[pos+28:])
-		entry.GID = binary.BigEndian.Uint32(data[pos+32:])
-		entry.Size = binary.BigEndian.Uint32(data[pos+36:])
-		copy(entry.Hash[:], data[pos+40:pos+60])
-		entry.Flags = binary.BigEndian.Uint16(data[pos+60:])
-
-		pos += 62
+		entry.CTimeSec = binary.BigEndian.Uint32(fixed[0:])
+		entry.CTimeNano = binary.BigEndian.Uint32(fixed[4:])
+		entry.MTimeSec = binary.BigEndian.Uint32(fixed[8:])
+		entry.MTimeNano = binary.BigEndian.Uint32(fixed[12:])
+		entry.Dev = binary.BigEndian.Uint32(fixed[16:])
+		entry.Ino = binary.BigEndian.Uint32(fixed[20:])
+		entry.Mode = binary.BigEndian.Uint32(fixed[24:])
+		entry.UID = binary.BigEndian.Uint32(fixed[28:])
+		entry.GID = binary.BigEndian.Uint32(fixed[32:])
+		entry.Size = binary.BigEndian.Uint32(fixed[36:])
+		copy(entry.Hash[:], fixed[40:60])
+		entry.Flags = binary.BigEndian.Uint16(fixed[60:])
+
+		entryLen := 62
+		if entry.Flags&flagExtended != 0 {
+			if version < 3 {
+				return 0, fmt.Errorf("invalid index entry: extended flags in a version %d index", version)
+			}
+			var extended [2]byte
+			if _, err := io.ReadFull(r, extended[:]); err != nil {
+				return 0, fmt.Errorf("truncated index entry: missing extended flags")
+			}
+			entry.Flags &^= flagExtended
+			entry.ExtendedFlags = binary.BigEndian.Uint16(extended[:])
+			entryLen += 2
+		}
+
+		var strip uint64
+		if version == 4 {
+			var err error
+			if strip, err = readPrefixLength(r); err != nil {
+				return 0, err
+			}
+			if strip > uint64(len(previous)) {
+				return 0, fmt.Errorf("invalid index entry: removes %d bytes from a %d byte path", strip, len(previous))
+			}
+		}
 
 		// Read path (null-terminated)
-		pathEnd := bytes.IndexByte(data[pos:], 0)
-		if pathEnd == -1 {
-			return nil, fmt.Errorf("invalid index entry: no null terminator")
+		path, err := r.ReadBytes(0)
+		if err != nil {
+			return 0, fmt.Errorf("invalid index entry: no null terminator")
+		}
+		entry.Path = string(path[:len(path)-1])
+
+		if version == 4 {
+			entry.Path = previous[:len(previous)-int(strip)] + entry.Path
+			previous = entry.Path
+		} else {
+			// Padding to 8-byte boundary
+			entryLen += len(path)
+			padding := (8 - (entryLen % 8)) % 8
+			if _, err := r.Discard(padding); err != nil {
+				return 0, fmt.Errorf("truncated index entry")
+			}
 		}
-		entry.Path = string(data[pos : pos+pathEnd])
-		pos += pathEnd + 1
 
-		// Padding to 8-byte boundary
-		entryLen := 62 + pathEnd + 1
-		padding := (8 - (entryLen % 8)) % 8
-		pos += padding
+		if err := fn(entry); err != nil {
+			return 0, err
+		}
+	}
 
-		index.Entries = append(index.Entries, entry)
+	return version, nil
+}
+
+// readPrefixLength reads the variable-length number that starts a version
+// 4 entry's path, encoded as pack offsets are: each byte holds 7 bits, with
+// the high bit set on all but the last, and each continuation adds one
+func readPrefixLength(r *bufio.Reader) (uint64, error) {
+	var n uint64
+	for i := 0; ; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("truncated index entry: missing path prefix length")
+		}
+		if i > 0 {
+			n++
+		}
+		if n > 1<<56 {
+			return 0, fmt.Errorf("invalid index entry: path prefix length overflows")
+		}
+		n = n<<7 | uint64(b&0x7f)
+		if b&0x80 == 0 {
+			return n, nil
+		}
 	}
+}
 
-	return index, nil
+// writePrefixLength writes a number as readPrefixLength reads it
+func writePrefixLength(buf *bytes.Buffer, n uint64) {
+	var encoded [10]byte
+	pos := len(encoded) - 1
+	encoded[pos] = byte(n & 0x7f)
+	for n >>= 7; n > 0; n >>= 7 {
+		n--
+		pos--
+		encoded[pos] = 0x80 | byte(n&0x7f)
+	}
+	buf.Write(encoded[pos:])
 }
 
-// Write writes the index to the repository
+// Write writes the index to the repository as version 2, or version 3 if
+// an entry has extended flags
 func (idx *Index) Write(repoPath string) error {
-	// Sort entries by path
-	sort.Slice(idx.Entries, func(i, j int) bool {
-		return idx.Entries[i].Path < idx.Entries[j].Path
+	return idx.WriteVersion(repoPath, IndexVersion)
+}
+
+// WriteVersion writes the index to the repository in the given format
+// version, such as the Version it was read in. Version 2 is upgraded to 3
+// if an entry has extended flags.
+func (idx *Index) WriteVersion(repoPath string, version uint32) error {
+	if version < 2 || version > 4 {
+		return fmt.Errorf("unsupported index version: %d", version)
+	}
+	idx.smudgeRacyEntries(repoPath)
+
+	// Sort entries by path, then by stage
+	sort.SliceStable(idx.Entries, func(i, j int) bool {
+		if idx.Entries[i].Path != idx.Entries[j].Path {
+			return idx.Entries[i].Path < idx.Entries[j].Path
+		}
+		return idx.Entries[i].Stage() < idx.Entries[j].Stage()
 	})
 
 	var buf bytes.Buffer
 
+	if version == 2 {
+		for _, entry := range idx.Entries {
+			if entry.ExtendedFlags != 0 {
+				version = 3
+				break
+			}
+		}
+	}
+
 	// Write header
 	buf.WriteString(IndexSignature)
-	binary.Write(&buf, binary.BigEndian, uint32(IndexVersion))
+	binary.Write(&buf, binary.BigEndian, version)
 	binary.Write(&buf, binary.BigEndian, uint32(len(idx.Entries)))
 
 	// Write entries
+	var previous string
 	for _, entry := range idx.Entries {
+		flags := entry.Flags &^ flagExtended
+		if entry.ExtendedFlags != 0 {
+			flags |= flagExtended
+		}
+
 		binary.Write(&buf, binary.BigEndian, entry.CTimeSec)
 		binary.Write(&buf, binary.BigEndian, entry.CTimeNano)
 		binary.Write(&buf, binary.BigEndian, entry.MTimeSec)
@@ -150,24 +394,85 @@ func (idx *Index) Write(repoPath string) error {
 		binary.Write(&buf, binary.BigEndian, entry.GID)
 		binary.Write(&buf, binary.BigEndian, entry.Size)
 		buf.Write(entry.Hash[:])
-		binary.Write(&buf, binary.BigEndian, entry.Flags)
+		binary.Write(&buf, binary.BigEndian, flags)
+		entryLen := 62
+		if entry.ExtendedFlags != 0 {
+			binary.Write(&buf, binary.BigEndian, entry.ExtendedFlags)
+			entryLen += 2
+		}
+
+		if version == 4 {
+			common := 0
+			for common < len(previous) && common < len(entry.Path) && previous[common] == entry.Path[common] {
+				common++
+			}
+			writePrefixLength(&buf, uint64(len(previous)-common))
+			buf.WriteString(entry.Path[common:])
+			buf.WriteByte(0)
+			previous = entry.Path
+			continue
+		}
+
 		buf.WriteString(entry.Path)
 		buf.WriteByte(0)
 
 		// Padding to 8-byte boundary
-		entryLen := 62 + len(entry.Path) + 1
+		entryLen += len(entry.Path) + 1
 		padding := (8 - (entryLen % 8)) % 8
 		for i := 0; i < padding; i++ {
 			buf.WriteByte(0)
 		}
 	}
 
+	// Extensions go after the entries, in signature order
+	changed := idx.digest() != idx.entriesDigest
+	sigs := make([]string, 0, len(idx.Extensions))
+	for sig := range idx.Extensions {
+		if layoutExtensions[sig] || (changed && entryExtensions[sig]) {
+			continue
+		}
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+	for _, sig := range sigs {
+		buf.WriteString(sig)
+		binary.Write(&buf, binary.BigEndian, uint32(len(idx.Extensions[sig])))
+		buf.Write(idx.Extensions[sig])
+	}
+
 	// Calculate and append checksum
 	checksum := sha1.Sum(buf.Bytes())
 	buf.Write(checksum[:])
 
-	indexPath := filepath.Join(repoPath, ".gogit", "index")
-	return os.WriteFile(indexPath, buf.Bytes(), 0644)
+	lock, err := lockfile.Acquire(filepath.Join(utils.GitDir(repoPath), "index"))
+	if err != nil {
+		return err
+	}
+	return lock.Commit(buf.Bytes())
+}
+
+// smudgeRacyEntries clears the modification time of each racy entry whose
+// file no longer has the content staged for it. Once the index is written
+// again the entry won't look racy, and its stat data would hide the change.
+func (idx *Index) smudgeRacyEntries(repoPath string) {
+	if idx.ModTime.IsZero() {
+		return
+	}
+	for i := range idx.Entries {
+		e := &idx.Entries[i]
+		if e.Stage() != 0 || e.Mode&0170000 != 0100000 || !idx.IsRacy(e) {
+			continue
+		}
+		path := filepath.Join(repoPath, e.Path)
+		info, err := os.Lstat(path)
+		if err != nil || !e.StatMatches(info) {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || utils.HashObject("blob", content) != e.HashString() {
+			e.MTimeSec, e.MTimeNano = 0, 0
+		}
+	}
 }
 
 // AddFile adds or updates a file in the index
@@ -201,15 +506,11 @@ func (idx *Index) AddFile(repoPath, filePath string) error {
 
 	// Create entry
 	entry := Entry{
-		CTimeSec:  uint32(info.ModTime().Unix()),
-		CTimeNano: uint32(info.ModTime().Nanosecond()),
-		MTimeSec:  uint32(info.ModTime().Unix()),
-		MTimeNano: uint32(info.ModTime().Nanosecond()),
-		Mode:      0100644, // Regular file
-		Size:      uint32(info.Size()),
-		Flags:     uint16(len(relPath)),
-		Path:      relPath,
+		Mode:  0100644, // Regular file
+		Flags: nameFlags(relPath),
+		Path:  relPath,
 	}
+	entry.SetStat(info)
 	copy(entry.Hash[:], hashBytes)
 
 	if info.Mode()&0111 != 0 {
@@ -222,35 +523,125 @@ func (idx *Index) AddFile(repoPath, filePath string) error {
 	return nil
 }
 
-// UpdateEntry updates an existing entry or adds a new one
+// AddBlob stages an existing blob at path without touching the working tree.
+// Stat data is left zero so the entry is always re-checked against the working tree.
+func (idx *Index) AddBlob(path string, mode uint32, hash string) error {
+	return idx.AddStage(path, 0, mode, hash)
+}
+
+// AddStage records a blob at a merge stage: 1 for the common ancestor,
+// 2 for ours and 3 for theirs. Stage 0 is a normal, resolved entry.
+func (idx *Index) AddStage(path string, stage int, mode uint32, hash string) error {
+	if stage < 0 || stage > 3 {
+		return fmt.Errorf("invalid stage %d for %s", stage, path)
+	}
+	hashBytes, err := utils.HexToBytes(hash)
+	if err != nil || len(hashBytes) != 20 {
+		return fmt.Errorf("invalid blob hash: %s", hash)
+	}
+
+	entry := Entry{
+		Mode:  mode,
+		Flags: uint16(stage)<<12 | nameFlags(path),
+		Path:  path,
+	}
+	copy(entry.Hash[:], hashBytes)
+
+	idx.UpdateEntry(entry)
+	return nil
+}
+
+// UpdateEntry updates an existing entry or adds a new one.
+// A stage 0 entry resolves the path, replacing any conflict stages.
 func (idx *Index) UpdateEntry(entry Entry) {
+	if entry.Stage() == 0 {
+		idx.RemoveEntry(entry.Path)
+		idx.Entries = append(idx.Entries, entry)
+		return
+	}
+
 	for i := range idx.Entries {
-		if idx.Entries[i].Path == entry.Path {
-			idx.Entries[i] = entry
+		existing := &idx.Entries[i]
+		if existing.Path != entry.Path {
+			continue
+		}
+		if existing.Stage() == 0 {
+			// Entering a conflict: the resolved entry goes away
+			idx.Entries = append(idx.Entries[:i], idx.Entries[i+1:]...)
+			break
+		}
+		if existing.Stage() == entry.Stage() {
+			*existing = entry
 			return
 		}
 	}
 	idx.Entries = append(idx.Entries, entry)
 }
 
-// RemoveEntry removes an entry by path
+// RemoveEntry removes every entry for path, including conflict stages
 func (idx *Index) RemoveEntry(path string) {
+	kept := idx.Entries[:0]
+	for _, entry := range idx.Entries {
+		if entry.Path != path {
+			kept = append(kept, entry)
+		}
+	}
+	idx.Entries = kept
+}
+
+// RenameEntry moves every entry for oldPath, conflict stages included, to
+// newPath, keeping its hash, mode and stat data
+func (idx *Index) RenameEntry(oldPath, newPath string) {
 	for i := range idx.Entries {
-		if idx.Entries[i].Path == path {
-			idx.Entries = append(idx.Entries[:i], idx.Entries[i+1:]...)
-			return
+		entry := &idx.Entries[i]
+		if entry.Path == oldPath {
+			entry.Path = newPath
+			entry.Flags = entry.Flags&^0xFFF | nameFlags(newPath)
 		}
 	}
 }
 
-// GetEntry gets an entry by path
+// GetEntry gets an entry by path, preferring the resolved (stage 0) entry
 func (idx *Index) GetEntry(path string) *Entry {
+	var found *Entry
 	for i := range idx.Entries {
 		if idx.Entries[i].Path == path {
-			return &idx.Entries[i]
+			if idx.Entries[i].Stage() == 0 {
+				return &idx.Entries[i]
+			}
+			if found == nil {
+				found = &idx.Entries[i]
+			}
 		}
 	}
-	return nil
+	return found
+}
+
+// Conflicts returns the sorted, de-duplicated paths that have stage>0 entries
+func (idx *Index) Conflicts() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, entry := range idx.Entries {
+		if entry.Stage() > 0 && !seen[entry.Path] {
+			seen[entry.Path] = true
+			paths = append(paths, entry.Path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// nameFlags returns the name-length bits of the entry flags, saturated at 0xFFF
+func nameFlags(path string) uint16 {
+	if len(path) > 0xFFF {
+		return 0xFFF
+	}
+	return uint16(len(path))
+}
+
+// Stage returns the merge stage stored in the entry flags (0 when resolved)
+func (e *Entry) Stage() int {
+	return int(e.Flags>>12) & 0x3
 }
 
 // HashString returns the hash as a hex string
@@ -262,3 +653,28 @@ func (e *Entry) HashString() string {
 func (e *Entry) ModTime() time.Time {
 	return time.Unix(int64(e.MTimeSec), int64(e.MTimeNano))
 }
+
+// SetStat records a file's size and times as the entry's stat data
+func (e *Entry) SetStat(info os.FileInfo) {
+	e.CTimeSec = uint32(info.ModTime().Unix())
+	e.CTimeNano = uint32(info.ModTime().Nanosecond())
+	e.MTimeSec = uint32(info.ModTime().Unix())
+	e.MTimeNano = uint32(info.ModTime().Nanosecond())
+	e.Size = uint32(info.Size())
+}
+
+// StatMatches reports whether a file has the size and modification time
+// recorded for the entry, so that it can be taken to be unchanged without
+// reading it, unless the entry is racy
+func (e *Entry) StatMatches(info os.FileInfo) bool {
+	return e.Size == uint32(info.Size()) &&
+		e.MTimeSec == uint32(info.ModTime().Unix()) &&
+		e.MTimeNano == uint32(info.ModTime().Nanosecond())
+}
+
+// IsRacy reports whether an entry's stat data can't be trusted: its file
+// was modified no earlier than the index was written, so it may have been
+// changed again within the same timestamp after it was staged
+func (idx *Index) IsRacy(e *Entry) bool {
+	return idx.ModTime.IsZero() || !e.ModTime().Before(idx.ModTime)
+}
@@ -0,0 +1,419 @@
+package index
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// testBlob is the name of the empty blob, which every test entry stages
+const testBlob = "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"
+
+// writeTestIndex writes an index of n entries spread over nested
+// directories, in the given version, into a new repository and returns
+// the repository's path
+func writeTestIndex(tb testing.TB, n int, version uint32) string {
+	tb.Helper()
+	repoPath := tb.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoPath, ".gogit"), 0755); err != nil {
+		tb.Fatal(err)
+	}
+
+	hash, err := utils.HexToBytes(testBlob)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	idx := NewIndex()
+	idx.Entries = make([]Entry, 0, n)
+	for i := 0; i < n; i++ {
+		path := fmt.Sprintf("dir%03d/sub%02d/file%06d.txt", i%1000, i%100, i)
+		entry := Entry{Mode: 0100644, Size: uint32(i), Flags: nameFlags(path), Path: path}
+		copy(entry.Hash[:], hash)
+		idx.Entries = append(idx.Entries, entry)
+	}
+	if err := idx.WriteVersion(repoPath, version); err != nil {
+		tb.Fatal(err)
+	}
+	return repoPath
+}
+
+func TestScanMatchesReadIndex(t *testing.T) {
+	for _, version := range []uint32{2, 3, 4} {
+		t.Run(fmt.Sprint("version ", version), func(t *testing.T) {
+			repoPath := writeTestIndex(t, 500, version)
+			idx, err := ReadIndex(repoPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if idx.Version != version {
+				t.Errorf("read version %d, want %d", idx.Version, version)
+			}
+
+			var scanned []Entry
+			if err := Scan(repoPath, func(e Entry) error {
+				scanned = append(scanned, e)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if len(scanned) != len(idx.Entries) {
+				t.Fatalf("scanned %d entries, read %d", len(scanned), len(idx.Entries))
+			}
+			for i := range scanned {
+				if scanned[i] != idx.Entries[i] {
+					t.Fatalf("entry %d: scanned %+v, read %+v", i, scanned[i], idx.Entries[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScanStopsOnError(t *testing.T) {
+	repoPath := writeTestIndex(t, 10, 2)
+	stop := errors.New("stop")
+	seen := 0
+	err := Scan(repoPath, func(Entry) error {
+		seen++
+		if seen == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || seen != 3 {
+		t.Errorf("Scan returned %v after %d entries, want %v after 3", err, seen, stop)
+	}
+}
+
+func TestScanMissingIndex(t *testing.T) {
+	if err := Scan(t.TempDir(), func(Entry) error {
+		t.Error("called for an entry of a missing index")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// BenchmarkReadLargeIndex compares loading a 200,000-entry index whole with
+// scanning it one entry at a time
+func BenchmarkReadLargeIndex(b *testing.B) {
+	repoPath := writeTestIndex(b, 200000, 2)
+
+	b.Run("ReadIndex", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ReadIndex(repoPath); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := Scan(repoPath, func(Entry) error { return nil }); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// versionTestIndex returns an index whose paths share prefixes, with
+// extended flags on one entry
+func versionTestIndex(t *testing.T) *Index {
+	t.Helper()
+	hash, err := utils.HexToBytes(testBlob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := NewIndex()
+	for i, path := range []string{"a", "dir/file", "dir/file2", "dir/sub/deeper", "z"} {
+		entry := Entry{Mode: 0100644, Size: uint32(i), Flags: nameFlags(path), Path: path}
+		copy(entry.Hash[:], hash)
+		if path == "dir/file2" {
+			entry.ExtendedFlags = FlagSkipWorktree
+		}
+		idx.Entries = append(idx.Entries, entry)
+	}
+	return idx
+}
+
+func TestIndexVersionsRoundTrip(t *testing.T) {
+	for _, c := range []struct{ write, read uint32 }{{2, 3}, {3, 3}, {4, 4}} {
+		repoPath := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(repoPath, ".gogit"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		want := versionTestIndex(t)
+		if err := want.WriteVersion(repoPath, c.write); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ReadIndex(repoPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Version != c.read {
+			t.Errorf("written as version %d, read as %d, want %d", c.write, got.Version, c.read)
+		}
+		if len(got.Entries) != len(want.Entries) {
+			t.Fatalf("version %d: read %d entries, want %d", c.write, len(got.Entries), len(want.Entries))
+		}
+		for i := range want.Entries {
+			if got.Entries[i] != want.Entries[i] {
+				t.Errorf("version %d: entry %d read as %+v, want %+v", c.write, i, got.Entries[i], want.Entries[i])
+			}
+		}
+	}
+
+	if err := NewIndex().WriteVersion(t.TempDir(), 5); err == nil {
+		t.Error("WriteVersion accepted version 5")
+	}
+}
+
+func TestReadTruncatedExtendedFlags(t *testing.T) {
+	repoPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoPath, ".gogit"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	idx := versionTestIndex(t)
+	idx.Entries = idx.Entries[2:3]
+	if err := idx.WriteVersion(repoPath, 3); err != nil {
+		t.Fatal(err)
+	}
+	indexPath := filepath.Join(repoPath, ".gogit", "index")
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The header and the fixed part of the entry, then one of the two bytes
+	// of extended flags
+	if err := os.WriteFile(indexPath, data[:12+62+1], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadIndex(repoPath); err == nil || !strings.Contains(err.Error(), "extended flags") {
+		t.Errorf("reading truncated extended flags: %v, want an error about them", err)
+	}
+
+	// Extended flags are only valid from version 3 on
+	data[7] = 2
+	if err := os.WriteFile(indexPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadIndex(repoPath); err == nil {
+		t.Error("read extended flags in a version 2 index")
+	}
+}
+
+func TestReadGitIndexVersions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return string(out)
+	}
+	git("init", "-q")
+	for _, path := range []string{"a", "dir/file", "dir/file2", "dir/sub/deeper"} {
+		if err := os.MkdirAll(filepath.Join(repoPath, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repoPath, path), []byte(path+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("add", ".")
+	git("update-index", "--skip-worktree", "dir/file2")
+
+	for _, version := range []string{"3", "4"} {
+		git("update-index", "--index-version", version)
+		idx, err := ReadIndex(repoPath)
+		if err != nil {
+			t.Fatalf("version %s: %v", version, err)
+		}
+		if fmt.Sprint(idx.Version) != version {
+			t.Errorf("read version %d, want %s", idx.Version, version)
+		}
+
+		var got strings.Builder
+		for _, entry := range idx.Entries {
+			fmt.Fprintf(&got, "%o %s 0\t%s\n", entry.Mode, entry.HashString(), entry.Path)
+			if skip := entry.ExtendedFlags&FlagSkipWorktree != 0; skip != (entry.Path == "dir/file2") {
+				t.Errorf("version %s: %s has skip-worktree %v", version, entry.Path, skip)
+			}
+		}
+		if want := git("ls-files", "-s"); got.String() != want {
+			t.Errorf("version %s: entries\n%s\nwant what git lists:\n%s", version, got.String(), want)
+		}
+	}
+}
+
+// appendExtensions rewrites the index in repoPath with the given
+// extensions, each a signature followed by its data, after the entries
+func appendExtensions(t *testing.T, repoPath string, extensions ...string) {
+	t.Helper()
+	indexPath := filepath.Join(repoPath, ".gogit", "index")
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = data[:len(data)-sha1.Size]
+	for _, ext := range extensions {
+		data = append(data, ext[:4]...)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(ext)-4))
+		data = append(data, ext[4:]...)
+	}
+	checksum := sha1.Sum(data)
+	if err := os.WriteFile(indexPath, append(data, checksum[:]...), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// extensionNames reads the index in repoPath and lists its extensions
+func extensionNames(t *testing.T, repoPath string) (*Index, string) {
+	t.Helper()
+	idx, err := ReadIndex(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs := make([]string, 0, len(idx.Extensions))
+	for sig := range idx.Extensions {
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+	return idx, strings.Join(sigs, " ")
+}
+
+func TestExtensionsKeptUntilEntriesChange(t *testing.T) {
+	repoPath := writeTestIndex(t, 3, 2)
+	appendExtensions(t, repoPath, "TREEcached trees", "UNTRuntracked", "FSMNbitmap", "EOIEoffsets", "IEOToffsets", "ABCDkept")
+
+	idx, sigs := extensionNames(t, repoPath)
+	if want := "ABCD EOIE FSMN IEOT TREE UNTR"; sigs != want {
+		t.Fatalf("read extensions %q, want %q", sigs, want)
+	}
+
+	// EOIE and IEOT describe the file itself and are never written
+	if err := idx.Write(repoPath); err != nil {
+		t.Fatal(err)
+	}
+	idx, sigs = extensionNames(t, repoPath)
+	if want := "ABCD FSMN TREE UNTR"; sigs != want {
+		t.Errorf("after writing unchanged entries, extensions %q, want %q", sigs, want)
+	}
+	if got := string(idx.Extensions["TREE"]); got != "cached trees" {
+		t.Errorf("TREE extension written back as %q", got)
+	}
+
+	// Only the stat data changed, which the extensions don't describe
+	idx.Entries[0].MTimeSec++
+	if err := idx.Write(repoPath); err != nil {
+		t.Fatal(err)
+	}
+	idx, sigs = extensionNames(t, repoPath)
+	if want := "ABCD FSMN TREE UNTR"; sigs != want {
+		t.Errorf("after changing stat data, extensions %q, want %q", sigs, want)
+	}
+
+	idx.RemoveEntry(idx.Entries[0].Path)
+	if err := idx.Write(repoPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, sigs = extensionNames(t, repoPath); sigs != "ABCD" {
+		t.Errorf("after removing an entry, extensions %q, want %q", sigs, "ABCD")
+	}
+}
+
+func TestReadUnknownExtensions(t *testing.T) {
+	repoPath := writeTestIndex(t, 1, 2)
+	appendExtensions(t, repoPath, "linkshared index")
+	if _, err := ReadIndex(repoPath); err == nil || !strings.Contains(err.Error(), "does not understand") {
+		t.Errorf("reading a required extension: %v, want an error that it isn't understood", err)
+	}
+
+	repoPath = writeTestIndex(t, 1, 2)
+	appendExtensions(t, repoPath, "TREEcached trees")
+	indexPath := filepath.Join(repoPath, ".gogit", "index")
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Claim more data than the extension has
+	pos := len(data) - sha1.Size - len("cached trees") - 4
+	binary.BigEndian.PutUint32(data[pos:], 100)
+	if err := os.WriteFile(indexPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadIndex(repoPath); err == nil || !strings.Contains(err.Error(), "truncated index extension") {
+		t.Errorf("reading a truncated extension: %v, want an error about it", err)
+	}
+}
+
+func TestWriteKeepsGitTreeExtension(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return string(out)
+	}
+	git("init", "-q")
+	if err := os.MkdirAll(filepath.Join(repoPath, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"a", "dir/b"} {
+		if err := os.WriteFile(filepath.Join(repoPath, path), []byte(path+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("add", ".")
+	tree := git("write-tree")
+
+	idx, err := ReadIndex(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := string(idx.Extensions["TREE"])
+	if cached == "" {
+		t.Fatal("git's cached trees were not read")
+	}
+	if err := idx.Write(repoPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// git checks the checksum, so it rejects anything but a well-formed index
+	if got := git("write-tree"); got != tree {
+		t.Errorf("git write-tree gives %s after gogit wrote the index, want %s", got, tree)
+	}
+	idx, err = ReadIndex(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(idx.Extensions["TREE"]) != cached {
+		t.Error("cached trees changed after writing unchanged entries")
+	}
+}
@@ -0,0 +1,199 @@
+// Package lockfile guards a file against concurrent writers the way Git
+// does: the writer creates "<file>.lock" exclusively, writes the new
+// content there and renames it over the file. While it is held the lock
+// records the owner's pid and host, so a lock left behind by a process that
+// is gone can be recognized and removed.
+package lockfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// Suffix is appended to a file's path to name its lock
+const Suffix = ".lock"
+
+// Lock is a held lock on a file
+type Lock struct {
+	target string
+	path   string
+	file   *os.File
+}
+
+// held are the locks this process holds, removed on exit or interruption
+var (
+	heldMu sync.Mutex
+	held   = make(map[*Lock]bool)
+)
+
+var handleSignals sync.Once
+
+// Acquire locks target by creating target+".lock". A lock left by a
+// process on this host that no longer runs is removed first; any other
+// existing lock is an error explaining how to recover.
+func Acquire(target string) (*Lock, error) {
+	path := target + Suffix
+	file, err := create(path)
+	if os.IsExist(err) && removeStale(path) {
+		file, err = create(path)
+	}
+	if os.IsExist(err) {
+		return nil, fmt.Errorf(`unable to create '%s': File exists.
+
+Another gogit process seems to be running in this repository. Please make
+sure all processes are terminated, then try again. If it still fails, a
+gogit process may have crashed in this repository earlier: remove the file
+manually to continue`, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create '%s': %w", path, err)
+	}
+
+	lock := &Lock{target: target, path: path, file: file}
+	if _, err := fmt.Fprintf(file, "%d %s\n", os.Getpid(), hostname()); err != nil {
+		lock.Release()
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	heldMu.Lock()
+	held[lock] = true
+	heldMu.Unlock()
+	return lock, nil
+}
+
+func create(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+}
+
+// Commit replaces the locked file with data and releases the lock
+func (l *Lock) Commit(data []byte) error {
+	defer l.forget()
+
+	err := l.file.Truncate(0)
+	if err == nil {
+		_, err = l.file.WriteAt(data, 0)
+	}
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = utils.ReplaceFile(l.path, l.target)
+	}
+	if err != nil {
+		os.Remove(l.path)
+		return fmt.Errorf("failed to write %s: %w", l.target, err)
+	}
+	return nil
+}
+
+// Release gives up the lock, leaving the locked file unchanged
+func (l *Lock) Release() {
+	l.file.Close()
+	os.Remove(l.path)
+	l.forget()
+}
+
+func (l *Lock) forget() {
+	heldMu.Lock()
+	delete(held, l)
+	heldMu.Unlock()
+}
+
+// ReleaseAll gives up every lock this process still holds. Commands that
+// fail part way leave their locks to it.
+func ReleaseAll() {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	for lock := range held {
+		lock.file.Close()
+		os.Remove(lock.path)
+		delete(held, lock)
+	}
+}
+
+// HandleSignals makes an interrupt or termination release every held lock
+// before the process exits, as a shell expects, with 128 plus the signal
+// number. Only the first call sets up the handler.
+func HandleSignals() {
+	handleSignals.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			sig := <-signals
+			ReleaseAll()
+			code := 1
+			if s, ok := sig.(syscall.Signal); ok {
+				code = 128 + int(s)
+			}
+			os.Exit(code)
+		}()
+	})
+}
+
+// removeStale removes the lock at path if the process that took it ran on
+// this host and has exited, reporting whether it did
+func removeStale(path string) bool {
+	info, data, err := readLock(path)
+	if err != nil {
+		// Released in the meantime, so worth another try
+		return errors.Is(err, os.ErrNotExist)
+	}
+
+	// A lock being committed holds the new content instead of an owner
+	fields := strings.Fields(strings.SplitN(string(data), "\n", 2)[0])
+	if len(fields) != 2 || fields[1] != hostname() {
+		return false
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil || pid <= 0 || processAlive(pid) {
+		return false
+	}
+	return removeUnchanged(path, info, data)
+}
+
+// removeUnchanged removes the lock at path if it is still the file that was
+// read as info and data. Another process may have removed the stale lock
+// and taken a fresh one since, which must not be removed from under it.
+func removeUnchanged(path string, info os.FileInfo, data []byte) bool {
+	current, currentData, err := readLock(path)
+	if err != nil {
+		return errors.Is(err, os.ErrNotExist)
+	}
+	if !os.SameFile(info, current) || !current.ModTime().Equal(info.ModTime()) || !bytes.Equal(currentData, data) {
+		return false
+	}
+	return os.Remove(path) == nil
+}
+
+// readLock reads the lock at path along with what identifies the file
+func readLock(path string) (os.FileInfo, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(f)
+	return info, data, err
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" || strings.ContainsAny(name, " \n") {
+		return "unknown"
+	}
+	return name
+}
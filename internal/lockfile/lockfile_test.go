@@ -0,0 +1,196 @@
+package lockfile
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestAcquireCommitRelease(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "index")
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Acquire(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := os.ReadFile(target + Suffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("%d %s\n", os.Getpid(), hostname()); string(owner) != want {
+		t.Errorf("lock holds %q, want %q", owner, want)
+	}
+	if _, err := Acquire(target); err == nil || !strings.Contains(err.Error(), "Another gogit process seems to be running") {
+		t.Errorf("second Acquire: %v", err)
+	}
+
+	if err := lock.Commit([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "new" {
+		t.Errorf("target = %q after Commit", content)
+	}
+	if _, err := os.Stat(target + Suffix); !os.IsNotExist(err) {
+		t.Error("the lock is still there after Commit")
+	}
+
+	lock, err = Acquire(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.Release()
+	if content, _ := os.ReadFile(target); string(content) != "new" {
+		t.Errorf("target = %q after Release", content)
+	}
+	if _, err := os.Stat(target + Suffix); !os.IsNotExist(err) {
+		t.Error("the lock is still there after Release")
+	}
+}
+
+func TestAcquireRemovesStaleLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("owners are always assumed alive on Windows")
+	}
+	// A pid that was just in use and now belongs to no process
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	dead := cmd.Process.Pid
+
+	for _, c := range []struct {
+		name   string
+		owner  string
+		stolen bool
+	}{
+		{"dead owner", fmt.Sprintf("%d %s\n", dead, hostname()), true},
+		{"live owner", fmt.Sprintf("%d %s\n", os.Getpid(), hostname()), false},
+		{"owner on another host", fmt.Sprintf("%d elsewhere.example.com\n", dead), false},
+		{"new content being committed", "DIRC\x00\x00\x00\x02", false},
+	} {
+		target := filepath.Join(t.TempDir(), "index")
+		if err := os.WriteFile(target+Suffix, []byte(c.owner), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lock, err := Acquire(target)
+		if c.stolen {
+			if err != nil {
+				t.Errorf("%s: %v", c.name, err)
+			} else {
+				lock.Release()
+			}
+		} else if err == nil {
+			t.Errorf("%s: Acquire took the lock", c.name)
+			lock.Release()
+		}
+	}
+}
+
+func TestStaleLockTakenOverMeanwhile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index") + Suffix
+	owner := "1 " + hostname() + "\n"
+	if err := os.WriteFile(path, []byte(owner), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, data, err := readLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process removes the stale lock and takes the lock itself,
+	// with content that happens to be the same
+	if err := os.WriteFile(path+".new", []byte(owner), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path+".new", path); err != nil {
+		t.Fatal(err)
+	}
+	if removeUnchanged(path, info, data) {
+		t.Error("removed a lock that replaced the stale one")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("the new lock is gone: %v", err)
+	}
+
+	if info, data, err = readLock(path); err != nil {
+		t.Fatal(err)
+	}
+	if !removeUnchanged(path, info, data) {
+		t.Error("kept a lock that was unchanged")
+	}
+}
+
+func TestReleaseAll(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a", "b"} {
+		target := filepath.Join(dir, name)
+		if _, err := Acquire(target); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, target+Suffix)
+	}
+	ReleaseAll()
+	for _, path := range paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s is still there", path)
+		}
+	}
+}
+
+// TestHelperHoldLock is run in a child process by TestInterruptReleasesLock:
+// it takes the lock named in its environment, says so and waits
+func TestHelperHoldLock(t *testing.T) {
+	target := os.Getenv("LOCKFILE_TEST_TARGET")
+	if target == "" {
+		t.Skip("only run by TestInterruptReleasesLock")
+	}
+	HandleSignals()
+	if _, err := Acquire(target); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println("locked")
+	select {}
+}
+
+func TestInterruptReleasesLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no SIGINT on Windows")
+	}
+	target := filepath.Join(t.TempDir(), "index")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperHoldLock$")
+	cmd.Env = append(os.Environ(), "LOCKFILE_TEST_TARGET="+target)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "locked\n" {
+		cmd.Process.Kill()
+		t.Fatalf("helper said %q: %v", line, err)
+	}
+
+	if err := cmd.Process.Signal(syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	err = cmd.Wait()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 128+int(syscall.SIGINT) {
+		t.Errorf("helper exited with %v, want status %d", err, 128+int(syscall.SIGINT))
+	}
+	if _, err := os.Stat(target + Suffix); !os.IsNotExist(err) {
+		t.Error("the interrupted process left its lock behind")
+	}
+}
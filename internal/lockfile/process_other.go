@@ -0,0 +1,16 @@
+//go:build !windows
+
+package lockfile
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with the given pid exists.
+// Signal 0 checks without delivering anything; EPERM means it exists but
+// belongs to someone else.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
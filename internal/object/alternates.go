@@ -0,0 +1,111 @@
+package object
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// maxAlternateDepth limits how many levels of alternates of alternates are
+// followed, as in git
+const maxAlternateDepth = 5
+
+var (
+	alternatesMu  sync.Mutex
+	alternateDirs = make(map[string][]string)
+)
+
+// ObjectDir returns the directory holding a repository's own objects
+func ObjectDir(repoPath string) string {
+	return filepath.Join(utils.GitDir(repoPath), "objects")
+}
+
+// Alternates returns the extra object directories a repository borrows
+// objects from: those listed one per line in its objects/info/alternates,
+// followed in turn by the ones they list. Relative paths are relative to
+// the objects directory naming them; blank lines, lines starting with "#"
+// and directories that don't exist are skipped.
+func Alternates(repoPath string) []string {
+	alternatesMu.Lock()
+	defer alternatesMu.Unlock()
+
+	if dirs, ok := alternateDirs[repoPath]; ok {
+		return dirs
+	}
+	own := filepath.Clean(ObjectDir(repoPath))
+	seen := map[string]bool{own: true}
+	dirs := readAlternates(own, seen, 0)
+	alternateDirs[repoPath] = dirs
+	return dirs
+}
+
+// readAlternates returns the alternates of the object directory dir that
+// aren't in seen, depth levels down from the repository's own
+func readAlternates(dir string, seen map[string]bool, depth int) []string {
+	if depth >= maxAlternateDepth {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(dir, "info", "alternates"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var dirs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		alt := filepath.FromSlash(line)
+		if !filepath.IsAbs(alt) {
+			alt = filepath.Join(dir, alt)
+		}
+		alt = filepath.Clean(alt)
+		if seen[alt] {
+			continue
+		}
+		if info, err := os.Stat(alt); err != nil || !info.IsDir() {
+			continue
+		}
+		seen[alt] = true
+		dirs = append(dirs, alt)
+		dirs = append(dirs, readAlternates(alt, seen, depth+1)...)
+	}
+	return dirs
+}
+
+// objectDirs returns the repository's own object directory followed by its
+// alternates, in the order objects are looked up
+func objectDirs(repoPath string) []string {
+	return append([]string{ObjectDir(repoPath)}, Alternates(repoPath)...)
+}
+
+// loosePath returns where an object directory keeps the loose copy of an
+// object
+func loosePath(dir, hash string) string {
+	return filepath.Join(dir, hash[:2], hash[2:])
+}
+
+// IsLocal reports whether the repository holds an object itself, loose or
+// packed, rather than only borrowing it from an alternate
+func IsLocal(repoPath, hash string) bool {
+	if _, err := os.Stat(loosePath(ObjectDir(repoPath), hash)); err == nil {
+		return true
+	}
+	packs, err := Packs(repoPath)
+	if err != nil {
+		return false
+	}
+	for _, p := range packs {
+		if p.Contains(hash) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package object
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAlternates(t *testing.T) {
+	repo := newObjectRepo(t)
+	shared := newObjectRepo(t)
+	deeper := newObjectRepo(t)
+
+	// The main repository names shared relatively, and shared names deeper
+	rel, err := filepath.Rel(ObjectDir(repo), ObjectDir(shared))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for dir, content := range map[string]string{
+		ObjectDir(repo):   "# shared objects\n\n" + rel + "\n/no/such/directory\n",
+		ObjectDir(shared): ObjectDir(deeper) + "\n" + ObjectDir(repo) + "\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, "info"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "info", "alternates"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	borrowed, err := WriteObject(shared, NewBlob([]byte("only in shared\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deepest, err := WriteObject(deeper, NewBlob([]byte("only in deeper\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	own, err := WriteObject(repo, NewBlob([]byte("own\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{filepath.Clean(ObjectDir(shared)), filepath.Clean(ObjectDir(deeper))}
+	if got := Alternates(repo); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Alternates: %v, want %v", got, want)
+	}
+
+	for hash, content := range map[string]string{borrowed: "only in shared\n", deepest: "only in deeper\n"} {
+		if !Exists(repo, hash) {
+			t.Errorf("Exists(%s) is false for an object in an alternate", hash)
+		}
+		if IsLocal(repo, hash) {
+			t.Errorf("IsLocal(%s) is true for an object in an alternate", hash)
+		}
+		obj, err := ReadObject(repo, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(obj.(*Blob).Content()); got != content {
+			t.Errorf("ReadObject(%s): %q, want %q", hash, got, content)
+		}
+		if full, err := ExpandHash(repo, hash[:7]); err != nil || full != hash {
+			t.Errorf("ExpandHash(%s): %s, %v", hash[:7], full, err)
+		}
+	}
+	if !IsLocal(repo, own) {
+		t.Error("IsLocal is false for the repository's own object")
+	}
+
+	seen := map[string]bool{}
+	err = ForEachObject(repo, func(hash string) error {
+		seen[hash] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 3 || !seen[borrowed] || !seen[deepest] || !seen[own] {
+		t.Errorf("ForEachObject visited %v, want the own and both borrowed objects", seen)
+	}
+}
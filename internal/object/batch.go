@@ -0,0 +1,71 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// Batch collects new objects in memory and writes them all at once, so a
+// command that fails part way through never leaves some of them behind
+type Batch struct {
+	repoPath string
+	objects  map[string]batchObject
+	order    []string // Hashes in the order they were added
+}
+
+type batchObject struct {
+	objType Type
+	content []byte
+}
+
+// NewBatch creates an empty Batch for the repository at repoPath
+func NewBatch(repoPath string) *Batch {
+	return &Batch{
+		repoPath: repoPath,
+		objects:  make(map[string]batchObject),
+	}
+}
+
+// Add queues an object for writing and returns its hash
+func (b *Batch) Add(obj Object) string {
+	return b.AddRaw(obj.Type(), obj.Content())
+}
+
+// AddRaw queues content as an object of the given type and returns its hash
+func (b *Batch) AddRaw(objType Type, content []byte) string {
+	hash := utils.HashObject(string(objType), content)
+	if _, ok := b.objects[hash]; !ok {
+		b.objects[hash] = batchObject{objType: objType, content: content}
+		b.order = append(b.order, hash)
+	}
+	return hash
+}
+
+// Len returns the number of objects queued
+func (b *Batch) Len() int {
+	return len(b.order)
+}
+
+// Flush writes every queued object. Either all of them end up in the
+// repository or, on error, none of the ones this batch created do.
+func (b *Batch) Flush() error {
+	var created []string
+	for _, hash := range b.order {
+		if Exists(b.repoPath, hash) {
+			continue
+		}
+		obj := b.objects[hash]
+		if _, err := WriteRawObject(b.repoPath, obj.objType, obj.content); err != nil {
+			for _, written := range created {
+				RemoveLoose(b.repoPath, written)
+			}
+			return fmt.Errorf("failed to write objects: %w", err)
+		}
+		created = append(created, hash)
+	}
+
+	b.objects = make(map[string]batchObject)
+	b.order = nil
+	return nil
+}
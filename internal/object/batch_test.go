@@ -0,0 +1,54 @@
+package object
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// blockFanout puts a file where the fan-out directory for hash would go,
+// so that writing any object starting with the same two digits fails
+func blockFanout(t *testing.T, repoPath, hash string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(ObjectDir(repoPath), hash[:2]), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchFlushIsAllOrNothing(t *testing.T) {
+	repoPath := newObjectRepo(t)
+
+	// Blobs whose names start differently, so one can be blocked alone
+	var blobs []*Blob
+	prefixes := make(map[string]bool)
+	for i := 0; len(blobs) < 4; i++ {
+		blob := NewBlob([]byte(fmt.Sprintf("blob %d\n", i)))
+		if !prefixes[blob.Hash()[:2]] {
+			prefixes[blob.Hash()[:2]] = true
+			blobs = append(blobs, blob)
+		}
+	}
+	existing := blobs[0]
+	if _, err := WriteObject(repoPath, existing); err != nil {
+		t.Fatal(err)
+	}
+	blockFanout(t, repoPath, blobs[3].Hash())
+
+	batch := NewBatch(repoPath)
+	for _, blob := range blobs {
+		batch.Add(blob)
+	}
+	if err := batch.Flush(); err == nil {
+		t.Fatal("Flush succeeded with an object it couldn't write")
+	}
+
+	for _, blob := range blobs[1:3] {
+		if Exists(repoPath, blob.Hash()) {
+			t.Errorf("%s was left behind by the failed batch", blob.Hash())
+		}
+	}
+	if !Exists(repoPath, existing.Hash()) {
+		t.Error("the failed batch removed an object that was there before it")
+	}
+}
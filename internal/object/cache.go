@@ -0,0 +1,85 @@
+package object
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultCacheSize is the number of parsed objects kept by a Store
+const DefaultCacheSize = 4096
+
+// Cache is a fixed-size LRU cache of parsed objects keyed by hash
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	hash string
+	obj  Object
+}
+
+// NewCache creates a new Cache holding at most size objects
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &Cache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached object for hash, if present
+func (c *Cache) Get(hash string) (Object, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[hash]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).obj, true
+}
+
+// Add stores an object in the cache, evicting the least recently used entry if full
+func (c *Cache) Add(hash string, obj Object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[hash]; ok {
+		elem.Value.(*cacheEntry).obj = obj
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[hash] = c.order.PushFront(&cacheEntry{hash: hash, obj: obj})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).hash)
+	}
+}
+
+// Remove drops an object from the cache
+func (c *Cache) Remove(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[hash]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, hash)
+	}
+}
+
+// Len returns the number of cached objects
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
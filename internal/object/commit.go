@@ -1,6 +1,7 @@
 package object
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
@@ -10,29 +11,53 @@ import (
 
 // Commit represents a Git commit object
 type Commit struct {
-	TreeHash    string
-	ParentHash  string // Empty for initial commit, single parent for now
-	Author      string
-	AuthorTime  time.Time
-	Committer   string
-	CommitTime  time.Time
-	Message     string
+	TreeHash  string
+	Parents   []string // In order, first parent first; none for an initial commit
+	Author    Signature
+	Committer Signature
+	Headers   []ExtraHeader // Headers other than tree/parent/author/committer, in order
+	Message   string        // Exactly as stored, trailing newline included
+
+	// crlf is set when the header lines of a parsed commit ended in "\r\n",
+	// so that it re-serializes to the same bytes
+	crlf bool
+
+	// noBody is set when a parsed commit ended after its headers, without
+	// the blank line that starts the message
+	noBody bool
 }
 
-// NewCommit creates a new Commit
-func NewCommit(treeHash, parentHash, author, message string) *Commit {
-	now := time.Now()
+// ExtraHeader is a commit header gogit doesn't interpret (gpgsig, encoding,
+// mergetag, ...), kept so parsed commits re-serialize byte for byte
+type ExtraHeader struct {
+	Key   string
+	Value string // Multi-line values are joined with "\n"
+}
+
+// NewCommit creates a new Commit with the given parents, authored and
+// committed now by author, given as "Name <email>". The message is stored
+// as given, so it should end in a newline as git's do.
+func NewCommit(treeHash string, parents []string, author, message string) *Commit {
+	sig := ParseSignature(author)
+	sig.When = time.Now()
 	return &Commit{
-		TreeHash:   treeHash,
-		ParentHash: parentHash,
-		Author:     author,
-		AuthorTime: now,
-		Committer:  author,
-		CommitTime: now,
-		Message:    message,
+		TreeHash:  treeHash,
+		Parents:   parents,
+		Author:    sig,
+		Committer: sig,
+		Message:   message,
 	}
 }
 
+// FirstParent returns the commit's first parent, or "" for an initial
+// commit
+func (c *Commit) FirstParent() string {
+	if len(c.Parents) == 0 {
+		return ""
+	}
+	return c.Parents[0]
+}
+
 // Type returns the object type
 func (c *Commit) Type() Type {
 	return TypeCommit
@@ -41,24 +66,29 @@ func (c *Commit) Type() Type {
 // Content returns the commit content in Git format
 func (c *Commit) Content() []byte {
 	var sb strings.Builder
+	eol := "\n"
+	if c.crlf {
+		eol = "\r\n"
+	}
 
-	sb.WriteString(fmt.Sprintf("tree %s\n", c.TreeHash))
+	sb.WriteString(fmt.Sprintf("tree %s%s", c.TreeHash, eol))
 
-	if c.ParentHash != "" {
-		sb.WriteString(fmt.Sprintf("parent %s\n", c.ParentHash))
+	for _, parent := range c.Parents {
+		sb.WriteString(fmt.Sprintf("parent %s%s", parent, eol))
 	}
 
 	// Format: "author Name <email> timestamp timezone"
-	authorTime := c.AuthorTime.Unix()
-	_, offset := c.AuthorTime.Zone()
-	tzOffset := fmt.Sprintf("%+03d%02d", offset/3600, (offset%3600)/60)
-	sb.WriteString(fmt.Sprintf("author %s %d %s\n", c.Author, authorTime, tzOffset))
-	sb.WriteString(fmt.Sprintf("committer %s %d %s\n", c.Committer, c.CommitTime.Unix(), tzOffset))
-
-	sb.WriteString("\n")
-	sb.WriteString(c.Message)
-	if !strings.HasSuffix(c.Message, "\n") {
-		sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("author %s%s", c.Author.Header(), eol))
+	sb.WriteString(fmt.Sprintf("committer %s%s", c.Committer.Header(), eol))
+
+	// Continuation lines of multi-line headers start with a space
+	for _, h := range c.Headers {
+		sb.WriteString(fmt.Sprintf("%s %s%s", h.Key, strings.ReplaceAll(h.Value, "\n", eol+" "), eol))
+	}
+
+	if !c.noBody {
+		sb.WriteString(eol)
+		sb.WriteString(c.Message)
 	}
 
 	return []byte(sb.String())
@@ -69,7 +99,9 @@ func (c *Commit) Hash() string {
 	return utils.HashObject(string(TypeCommit), c.Content())
 }
 
-// ParseCommit parses commit content into a Commit object
+// ParseCommit parses commit content into a Commit object. Header lines may
+// end in "\r\n" as well as "\n"; the message is kept byte for byte, so the
+// commit re-serializes to the content it was parsed from.
 func ParseCommit(content []byte) (*Commit, error) {
 	commit := &Commit{}
 	lines := strings.Split(string(content), "\n")
@@ -77,16 +109,30 @@ func ParseCommit(content []byte) (*Commit, error) {
 	inMessage := false
 	var messageLines []string
 
-	for _, line := range lines {
+	for i, line := range lines {
 		if inMessage {
 			messageLines = append(messageLines, line)
 			continue
 		}
 
-		if line == "" {
+		if line == "" && i == len(lines)-1 {
+			// The content ended with the headers
+			commit.noBody = true
+			break
+		}
+		if line == "" || line == "\r" {
+			commit.crlf = line == "\r"
 			inMessage = true
 			continue
 		}
+		line = strings.TrimSuffix(line, "\r")
+
+		// Continuation of the previous multi-line header
+		if strings.HasPrefix(line, " ") && len(commit.Headers) > 0 {
+			last := &commit.Headers[len(commit.Headers)-1]
+			last.Value += "\n" + line[1:]
+			continue
+		}
 
 		parts := strings.SplitN(line, " ", 2)
 		if len(parts) != 2 {
@@ -100,65 +146,37 @@ func ParseCommit(content []byte) (*Commit, error) {
 		case "tree":
 			commit.TreeHash = value
 		case "parent":
-			commit.ParentHash = value
+			commit.Parents = append(commit.Parents, value)
 		case "author":
-			commit.Author, commit.AuthorTime = parseAuthorLine(value)
+			commit.Author = ParseSignature(value)
 		case "committer":
-			commit.Committer, commit.CommitTime = parseAuthorLine(value)
+			commit.Committer = ParseSignature(value)
+		default:
+			commit.Headers = append(commit.Headers, ExtraHeader{Key: key, Value: value})
 		}
 	}
 
-	commit.Message = strings.TrimRight(strings.Join(messageLines, "\n"), "\n")
+	commit.Message = strings.Join(messageLines, "\n")
+
+	if !IsHash(commit.TreeHash) {
+		return nil, fmt.Errorf("invalid commit: missing or malformed tree header")
+	}
 
 	return commit, nil
 }
 
-// parseAuthorLine parses "Name <email> timestamp timezone"
-func parseAuthorLine(line string) (string, time.Time) {
-	// Find the last two space-separated values (timestamp and timezone)
-	parts := strings.Split(line, " ")
-	if len(parts) < 3 {
-		return line, time.Time{}
+// IsHash reports whether s is a full 40-character hex object name
+func IsHash(s string) bool {
+	if len(s) != 40 {
+		return false
 	}
-
-	// Timezone is last, timestamp is second to last
-	tzStr := parts[len(parts)-1]
-	tsStr := parts[len(parts)-2]
-	name := strings.Join(parts[:len(parts)-2], " ")
-
-	var ts int64
-	fmt.Sscanf(tsStr, "%d", &ts)
-
-	// Parse timezone offset
-	var tzHour, tzMin int
-	fmt.Sscanf(tzStr, "%03d%02d", &tzHour, &tzMin)
-	offset := tzHour*3600 + tzMin*60
-
-	loc := time.FixedZone("", offset)
-	t := time.Unix(ts, 0).In(loc)
-
-	return name, t
+	_, err := hex.DecodeString(s)
+	return err == nil
 }
 
 // PrettyPrint returns a formatted representation of the commit
 func (c *Commit) PrettyPrint() string {
-	var sb strings.Builder
-
-	sb.WriteString(fmt.Sprintf("tree %s\n", c.TreeHash))
-	if c.ParentHash != "" {
-		sb.WriteString(fmt.Sprintf("parent %s\n", c.ParentHash))
-	}
-
-	authorTime := c.AuthorTime.Unix()
-	_, offset := c.AuthorTime.Zone()
-	tzOffset := fmt.Sprintf("%+03d%02d", offset/3600, (offset%3600)/60)
-	sb.WriteString(fmt.Sprintf("author %s %d %s\n", c.Author, authorTime, tzOffset))
-	sb.WriteString(fmt.Sprintf("committer %s %d %s\n", c.CommitTime.Unix(), c.Committer, tzOffset))
-	sb.WriteString("\n")
-	sb.WriteString(c.Message)
-	sb.WriteString("\n")
-
-	return sb.String()
+	return string(c.Content())
 }
 
 // ShortHash returns the first 7 characters of the hash
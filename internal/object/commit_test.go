@@ -0,0 +1,194 @@
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// parseRoundTrip parses raw commit content and checks it serializes back
+// to the same bytes
+func parseRoundTrip(t *testing.T, raw string) *Commit {
+	t.Helper()
+	commit, err := ParseCommit([]byte(raw))
+	if err != nil {
+		t.Fatalf("ParseCommit: %v", err)
+	}
+	if got := string(commit.Content()); got != raw {
+		t.Errorf("re-serialized as:\n%s\nwant:\n%s", got, raw)
+	}
+	return commit
+}
+
+func TestCommitSignatureFields(t *testing.T) {
+	commit := parseRoundTrip(t, "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n"+
+		"author A U Thor <author@example.com> 1700000000 +0100\n"+
+		"committer C O Mitter <committer@example.com> 1700000060 -0500\n"+
+		"\nmessage\n")
+
+	if commit.Author.Name != "A U Thor" || commit.Author.Email != "author@example.com" {
+		t.Errorf("author %q <%q>", commit.Author.Name, commit.Author.Email)
+	}
+	if commit.Committer.Name != "C O Mitter" || commit.Committer.Email != "committer@example.com" {
+		t.Errorf("committer %q <%q>", commit.Committer.Name, commit.Committer.Email)
+	}
+	if got := commit.Author.String(); got != "A U Thor <author@example.com>" {
+		t.Errorf("author as a string: %q", got)
+	}
+}
+
+func TestCommitMalformedIdentities(t *testing.T) {
+	for _, c := range []struct {
+		ident       string
+		name, email string
+	}{
+		{"Just A Name", "Just A Name", ""},
+		{"Name <unclosed@example.com", "Name <unclosed@example.com", ""},
+		{"Spaced  Out  <  odd@example.com  >", "Spaced  Out", "  odd@example.com  "},
+		{"<>", "", ""},
+	} {
+		raw := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+			"author " + c.ident + " 1700000000 +0000\n" +
+			"committer " + c.ident + " 1700000000 +0000\n" +
+			"\nmessage\n"
+		commit := parseRoundTrip(t, raw)
+		if commit.Author.Name != c.name || commit.Author.Email != c.email {
+			t.Errorf("%q: name %q, email %q; want %q, %q", c.ident, commit.Author.Name, commit.Author.Email, c.name, c.email)
+		}
+		if got := commit.Author.String(); got != c.ident {
+			t.Errorf("%q: as a string %q, want it as written", c.ident, got)
+		}
+		if commit.Author.When.Unix() != 1700000000 {
+			t.Errorf("%q: date lost, got %v", c.ident, commit.Author.When)
+		}
+	}
+}
+
+func TestCommitZoneRoundTrip(t *testing.T) {
+	for _, zones := range [][2]string{
+		{"-0000", "-0000"},
+		{"-0000", "+0000"},
+		{"+0000", "-0000"},
+		{"+0530", "-0000"},
+		{"-1200", "+1400"},
+	} {
+		raw := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+			"author A U Thor <author@example.com> 1700000000 " + zones[0] + "\n" +
+			"committer C O Mitter <committer@example.com> 1700000060 " + zones[1] + "\n" +
+			"\nmessage\n"
+		commit := parseRoundTrip(t, raw)
+
+		if got := commit.Author.TZ(); got != zones[0] {
+			t.Errorf("author zone %s, want %s", got, zones[0])
+		}
+		if got := commit.Committer.TZ(); got != zones[1] {
+			t.Errorf("committer zone %s, want %s", got, zones[1])
+		}
+		if want := utils.HashObject("commit", []byte(raw)); commit.Hash() != want {
+			t.Errorf("%v: hash %s, want %s", zones, commit.Hash(), want)
+		}
+	}
+}
+
+func TestTagZoneRoundTrip(t *testing.T) {
+	raw := "object 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"type tree\n" +
+		"tag v1\n" +
+		"tagger T Agger <tagger@example.com> 1700000000 -0000\n" +
+		"\nrelease\n"
+	tag, err := ParseTag([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(tag.Content()); got != raw {
+		t.Errorf("re-serialized as:\n%s\nwant:\n%s", got, raw)
+	}
+}
+
+func TestCommitCRLFHeaders(t *testing.T) {
+	tree := "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	parent := "1111111111111111111111111111111111111111"
+	raw := "tree " + tree + "\r\n" +
+		"parent " + parent + "\r\n" +
+		"author A U Thor <author@example.com> 1700000000 +0100\r\n" +
+		"committer C O Mitter <committer@example.com> 1700000060 -0500\r\n" +
+		"\r\nmessage\r\n"
+	commit, err := ParseCommit([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if commit.TreeHash != tree {
+		t.Errorf("tree %q, want %q", commit.TreeHash, tree)
+	}
+	if got := commit.Parents; len(got) != 1 || got[0] != parent {
+		t.Errorf("parents %q, want [%q]", got, parent)
+	}
+	if commit.Author.Email != "author@example.com" || commit.Committer.TZ() != "-0500" {
+		t.Errorf("author <%s>, committer zone %s", commit.Author.Email, commit.Committer.TZ())
+	}
+	if commit.Message != "message\r\n" {
+		t.Errorf("message %q, want it as stored", commit.Message)
+	}
+	if want := utils.HashObject("commit", []byte(raw)); commit.Hash() != want {
+		t.Errorf("re-serialized as:\n%q\nwant:\n%q", commit.Content(), raw)
+	}
+
+	// The tree it names can be read
+	repoPath := newObjectRepo(t)
+	if _, err := WriteObject(repoPath, NewTree()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadObject(repoPath, commit.TreeHash); err != nil {
+		t.Errorf("reading the commit's tree: %v", err)
+	}
+}
+
+func TestCommitMessageRoundTrip(t *testing.T) {
+	const headers = "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"author A U Thor <author@example.com> 1700000000 +0100\n" +
+		"committer C O Mitter <committer@example.com> 1700000060 -0500\n"
+	for _, c := range []struct{ raw, message string }{
+		{headers + "\nmessage\n", "message\n"},
+		{headers + "\nmessage\n\n", "message\n\n"},
+		{headers + "\nmessage", "message"},
+		{headers + "\n", ""},
+		{headers, ""},
+		{headers + "\n\nleading blank line\n", "\nleading blank line\n"},
+	} {
+		commit := parseRoundTrip(t, c.raw)
+		if commit.Message != c.message {
+			t.Errorf("%q: message %q, want %q", c.raw, commit.Message, c.message)
+		}
+		want := utils.HashObject("commit", []byte(c.raw))
+		if commit.Hash() != want || commit.ShortHash() != want[:7] {
+			t.Errorf("%q: hash %s, want %s", c.raw, commit.Hash(), want)
+		}
+		if got := commit.PrettyPrint(); got != c.raw {
+			t.Errorf("%q: pretty-printed as %q", c.raw, got)
+		}
+	}
+}
+
+func TestCommitDateRoundTrip(t *testing.T) {
+	parseRoundTrip(t, "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n"+
+		"author A U Thor <author@example.com> garbage\n"+
+		"committer Just A Name  1700000060  +0100\n"+
+		"\nmessage\n")
+
+	// A date that changes is written the usual way
+	commit := parseRoundTrip(t, "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n"+
+		"author A U Thor <author@example.com>\n"+
+		"committer C O Mitter <committer@example.com> 1700000060 -0000\n"+
+		"\nmessage\n")
+	commit.Author.When = time.Unix(1700000000, 0).In(time.FixedZone("", 3600))
+	commit.Committer.When = commit.Committer.When.Add(time.Minute)
+	want := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"author A U Thor <author@example.com> 1700000000 +0100\n" +
+		"committer C O Mitter <committer@example.com> 1700000120 -0000\n" +
+		"\nmessage\n"
+	if got := string(commit.Content()); got != want {
+		t.Errorf("re-serialized as:\n%s\nwant:\n%s", got, want)
+	}
+}
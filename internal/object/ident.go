@@ -0,0 +1,242 @@
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature records who authored, committed or tagged something, and when.
+// Not to be confused with the cryptographic signatures in signature.go.
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+
+	ident string // Identity as written when it isn't "Name <email>", kept so it re-serializes unchanged
+
+	// date is the text after the identity as written, and dateWhen the
+	// time parsed from it; while When is unchanged the date re-serializes
+	// as written, even when it's missing or malformed
+	date     string
+	dateWhen time.Time
+}
+
+// ParseSignature parses "Name <email> timestamp timezone". The date is
+// optional; an identity without an email keeps the whole text as Name.
+func ParseSignature(line string) Signature {
+	ident, date, when := parseAuthorLine(line)
+	sig := Signature{When: when, date: date, dateWhen: when}
+
+	lt := strings.IndexByte(ident, '<')
+	if lt < 0 || !strings.HasSuffix(ident, ">") {
+		sig.Name = strings.TrimSpace(ident)
+		sig.ident = ident
+		return sig
+	}
+	sig.Name = strings.TrimSpace(ident[:lt])
+	sig.Email = ident[lt+1 : len(ident)-1]
+	if sig.String() != ident {
+		sig.ident = ident
+	}
+	return sig
+}
+
+// String returns the identity as "Name <email>", the form the Author and
+// Committer fields held before they were split up
+func (s Signature) String() string {
+	if s.ident != "" {
+		return s.ident
+	}
+	if s.Name == "" {
+		return fmt.Sprintf("<%s>", s.Email)
+	}
+	return fmt.Sprintf("%s <%s>", s.Name, s.Email)
+}
+
+// Header formats the signature as it appears in an object header
+func (s Signature) Header() string {
+	if s.When == s.dateWhen {
+		return s.String() + s.date
+	}
+	return fmt.Sprintf("%s %d %s", s, s.When.Unix(), formatTZ(s.When))
+}
+
+// TZ returns the zone of the signature's date as written in an object
+// header, such as "+0200"
+func (s Signature) TZ() string {
+	return formatTZ(s.When)
+}
+
+// parseAuthorLine parses "Name <email> timestamp timezone". The identity and
+// the date are returned exactly as written, together making up the line;
+// everything after the closing ">" is the date. A missing or malformed date
+// yields the zero time, a missing zone UTC.
+// The zone is named after the offset as written, so that formatTZ gives
+// back exactly that, "-0000" included.
+func parseAuthorLine(line string) (string, string, time.Time) {
+	ident, date := line, ""
+	if gt := strings.LastIndexByte(line, '>'); gt >= 0 {
+		ident, date = line[:gt+1], line[gt+1:]
+	} else {
+		// No email to anchor on; take trailing numeric fields as the date
+		fields := strings.Fields(line)
+		n := 0
+		if _, ok := parseTZ(lastField(fields, 0)); ok && isDigits(lastField(fields, 1)) {
+			n = 2
+		} else if isDigits(lastField(fields, 0)) {
+			n = 1
+		}
+		end := len(line)
+		for ; n > 0; n-- {
+			end = strings.LastIndexAny(strings.TrimRight(line[:end], " \t"), " \t") + 1
+		}
+		end = len(strings.TrimRight(line[:end], " \t"))
+		ident, date = line[:end], line[end:]
+	}
+
+	fields := strings.Fields(date)
+	if len(fields) == 0 {
+		return ident, date, time.Time{}
+	}
+	ts, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return ident, date, time.Time{}
+	}
+
+	offset, name := 0, ""
+	if len(fields) > 1 {
+		var ok bool
+		if offset, ok = parseTZ(fields[1]); ok {
+			name = fields[1]
+		}
+	}
+	return ident, date, time.Unix(ts, 0).In(time.FixedZone(name, offset))
+}
+
+// parseTZ parses a "+HHMM" or "-HHMM" zone into seconds east of UTC
+func parseTZ(tz string) (int, bool) {
+	if len(tz) != 5 || (tz[0] != '+' && tz[0] != '-') || !isDigits(tz[1:]) {
+		return 0, false
+	}
+	hours, _ := strconv.Atoi(tz[1:3])
+	minutes, _ := strconv.Atoi(tz[3:5])
+	offset := hours*3600 + minutes*60
+	if tz[0] == '-' {
+		offset = -offset
+	}
+	return offset, true
+}
+
+// lastField returns the field i places from the end, or "" if there are too few
+func lastField(fields []string, i int) string {
+	if i >= len(fields) {
+		return ""
+	}
+	return fields[len(fields)-1-i]
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTZ formats a time's zone offset as "+HHMM". A zone parsed from an
+// object keeps its original spelling, such as "-0000" for UTC.
+func formatTZ(t time.Time) string {
+	name, offset := t.Zone()
+	if parsed, ok := parseTZ(name); ok && parsed == offset {
+		return name
+	}
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, offset/3600, (offset%3600)/60)
+}
+
+// expiryUnits are the units ParseExpiry accepts in "<n> <unit> ago"
+var expiryUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// ParseExpiry parses an expiry cutoff such as "2 weeks ago" (or
+// "2.weeks.ago"), "now", "never" or any date ParseDate accepts. Things
+// dated at or before the cutoff have expired; "never" returns the zero
+// time, which nothing is dated before.
+func ParseExpiry(value string, now time.Time) (time.Time, error) {
+	switch strings.TrimSpace(value) {
+	case "now", "all":
+		return now, nil
+	case "never", "false":
+		return time.Time{}, nil
+	}
+
+	fields := strings.Fields(strings.ReplaceAll(value, ".", " "))
+	if len(fields) == 3 && fields[2] == "ago" {
+		n, err := strconv.Atoi(fields[0])
+		unit, ok := expiryUnits[strings.TrimSuffix(fields[1], "s")]
+		if err == nil && ok {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+
+	when, err := ParseDate(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry date: %s", value)
+	}
+	return when, nil
+}
+
+// dateLayouts are the formats ParseDate accepts besides Unix timestamps
+var dateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon Jan 2 15:04:05 2006 -0700", // As log prints dates
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseDate parses a date given on the command line: any of dateLayouts,
+// a Unix timestamp with an optional "+HHMM" zone, or "@<timestamp>".
+// Dates without a zone are in local time.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	fields := strings.Fields(strings.TrimPrefix(value, "@"))
+	if len(fields) > 0 && len(fields) <= 2 && isDigits(fields[0]) {
+		if ts, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			if len(fields) == 1 {
+				return time.Unix(ts, 0), nil
+			}
+			if offset, ok := parseTZ(fields[1]); ok {
+				return time.Unix(ts, 0).In(time.FixedZone(fields[1], offset)), nil
+			}
+		}
+	}
+
+	for _, layout := range dateLayouts {
+		if when, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return when, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date format: %s", value)
+}
@@ -0,0 +1,54 @@
+package object
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSignature(t *testing.T) {
+	for _, c := range []struct {
+		line        string
+		name, email string
+		unix        int64
+		offset      int // Seconds east of UTC
+		tz          string
+	}{
+		{"A U Thor <author@example.com> 1700000000 +0000", "A U Thor", "author@example.com", 1700000000, 0, "+0000"},
+		{"Asha Rao <asha@example.in> 1700000000 +0530", "Asha Rao", "asha@example.in", 1700000000, 5*3600 + 30*60, "+0530"},
+		{"Pat <pat@example.com> 1700000000 -0800", "Pat", "pat@example.com", 1700000000, -8 * 3600, "-0800"},
+		{"Sam <sam@example.com> 1700000000 -0330", "Sam", "sam@example.com", 1700000000, -(3*3600 + 30*60), "-0330"},
+		{"Agent 007 <bond@example.com> 1700000000 +0100", "Agent 007", "bond@example.com", 1700000000, 3600, "+0100"},
+		{"R2 D2 1138 <r2@example.com> 1700000000 +0000", "R2 D2 1138", "r2@example.com", 1700000000, 0, "+0000"},
+		{"<nobody@example.com> 1700000000 +0200", "", "nobody@example.com", 1700000000, 2 * 3600, "+0200"},
+		{"No Zone <nz@example.com> 1700000000", "No Zone", "nz@example.com", 1700000000, 0, "+0000"},
+	} {
+		sig := ParseSignature(c.line)
+		if sig.Name != c.name || sig.Email != c.email {
+			t.Errorf("%q: name %q, email %q; want %q, %q", c.line, sig.Name, sig.Email, c.name, c.email)
+		}
+		if sig.When.Unix() != c.unix {
+			t.Errorf("%q: timestamp %d, want %d", c.line, sig.When.Unix(), c.unix)
+		}
+		if _, offset := sig.When.Zone(); offset != c.offset {
+			t.Errorf("%q: offset %d, want %d", c.line, offset, c.offset)
+		}
+		if tz := sig.TZ(); tz != c.tz {
+			t.Errorf("%q: zone %q, want %q", c.line, tz, c.tz)
+		}
+	}
+}
+
+func TestParseSignatureWithoutDate(t *testing.T) {
+	sig := ParseSignature("A U Thor <author@example.com>")
+	if sig.Name != "A U Thor" || sig.Email != "author@example.com" || !sig.When.IsZero() {
+		t.Errorf("got %q <%q> at %v, want no date", sig.Name, sig.Email, sig.When)
+	}
+
+	sig = ParseSignature("A U Thor <author@example.com> garbage +0100")
+	if !sig.When.IsZero() {
+		t.Errorf("malformed date parsed as %v", sig.When)
+	}
+	if want := time.Unix(0, 0); !ParseSignature("x <y> 0 +0000").When.Equal(want) {
+		t.Error("timestamp 0 not parsed as the epoch")
+	}
+}
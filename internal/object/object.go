@@ -2,10 +2,13 @@ package object
 
 import (
 	"bytes"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/yourusername/gogit/internal/utils"
 )
@@ -20,6 +23,12 @@ const (
 	TypeTag    Type = "tag"
 )
 
+// Errors returned when looking up objects by name
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrAmbiguousHash  = errors.New("short object ID is ambiguous")
+)
+
 // Object represents a Git object
 type Object interface {
 	Type() Type
@@ -29,10 +38,36 @@ type Object interface {
 
 // ParseObject parses a raw object (after decompression)
 func ParseObject(data []byte) (Object, error) {
+	objType, content, err := splitObject(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseContent(objType, content)
+}
+
+// ParseContent parses object content of a known type, validating its structure
+func ParseContent(objType Type, content []byte) (Object, error) {
+	switch objType {
+	case TypeBlob:
+		return &Blob{content: content}, nil
+	case TypeTree:
+		return ParseTree(content)
+	case TypeCommit:
+		return ParseCommit(content)
+	case TypeTag:
+		return ParseTag(content)
+	default:
+		return nil, fmt.Errorf("unknown object type: %s", objType)
+	}
+}
+
+// splitObject separates a raw object into its type and content, validating the header
+func splitObject(data []byte) (Type, []byte, error) {
 	// Find the null byte separating header from content
 	nullIdx := bytes.IndexByte(data, 0)
 	if nullIdx == -1 {
-		return nil, fmt.Errorf("invalid object: no null byte found")
+		return "", nil, fmt.Errorf("invalid object: no null byte found")
 	}
 
 	header := string(data[:nullIdx])
@@ -41,62 +76,104 @@ func ParseObject(data []byte) (Object, error) {
 	// Parse header: "<type> <size>"
 	spaceIdx := bytes.IndexByte([]byte(header), ' ')
 	if spaceIdx == -1 {
-		return nil, fmt.Errorf("invalid object header: %s", header)
+		return "", nil, fmt.Errorf("invalid object header: %s", header)
 	}
 
 	objType := Type(header[:spaceIdx])
 	sizeStr := header[spaceIdx+1:]
 	size, err := strconv.Atoi(sizeStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid object size: %s", sizeStr)
+		return "", nil, fmt.Errorf("invalid object size: %s", sizeStr)
 	}
 
 	if len(content) != size {
-		return nil, fmt.Errorf("object size mismatch: expected %d, got %d", size, len(content))
+		return "", nil, fmt.Errorf("object size mismatch: expected %d, got %d", size, len(content))
 	}
 
-	switch objType {
-	case TypeBlob:
-		return &Blob{content: content}, nil
-	case TypeTree:
-		return ParseTree(content)
-	case TypeCommit:
-		return ParseCommit(content)
-	default:
-		return nil, fmt.Errorf("unknown object type: %s", objType)
-	}
+	return objType, content, nil
 }
 
 // ReadObject reads an object from the repository
 func ReadObject(repoPath, hash string) (Object, error) {
+	objType, content, err := ReadRawObject(repoPath, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseContent(objType, content)
+}
+
+// ReadRawObject reads an object and returns its type and undecoded content.
+// A replaced object reads as its replacement.
+func ReadRawObject(repoPath, hash string) (Type, []byte, error) {
+	return readRawObject(repoPath, hash, true)
+}
+
+// readRawObject reads an object, or its replacement if replace is set
+func readRawObject(repoPath, hash string, replace bool) (Type, []byte, error) {
 	if len(hash) < 4 {
-		return nil, fmt.Errorf("hash too short: %s", hash)
+		return "", nil, fmt.Errorf("hash too short: %s", hash)
+	}
+	if replace {
+		var err error
+		if hash, err = Replacement(repoPath, hash); err != nil {
+			return "", nil, err
+		}
 	}
 
-	objPath := filepath.Join(repoPath, ".gogit", "objects", hash[:2], hash[2:])
+	objType, content, err := readLoose(repoPath, hash)
+	if os.IsNotExist(err) {
+		return readPromised(repoPath, hash)
+	}
+	return objType, content, err
+}
 
-	compressed, err := os.ReadFile(objPath)
+// readLoose reads the loose copy of an object, from the repository or else
+// one of its alternates. A missing object is reported with an error
+// satisfying os.IsNotExist.
+func readLoose(repoPath, hash string) (Type, []byte, error) {
+	compressed, err := readLooseFile(repoPath, hash)
+	if os.IsNotExist(err) {
+		return "", nil, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read object %s: %w", hash, err)
+		return "", nil, fmt.Errorf("failed to read object %s: %w", hash, err)
 	}
 
 	data, err := utils.Decompress(compressed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decompress object %s: %w", hash, err)
+		return "", nil, fmt.Errorf("failed to decompress object %s: %w", hash, err)
 	}
 
-	return ParseObject(data)
+	return splitObject(data)
+}
+
+// readLooseFile reads the compressed loose copy of an object from the first
+// object directory holding one
+func readLooseFile(repoPath, hash string) ([]byte, error) {
+	var err error
+	for _, dir := range objectDirs(repoPath) {
+		var compressed []byte
+		if compressed, err = os.ReadFile(loosePath(dir, hash)); !os.IsNotExist(err) {
+			return compressed, err
+		}
+	}
+	return nil, err
 }
 
 // WriteObject writes an object to the repository
 func WriteObject(repoPath string, obj Object) (string, error) {
-	content := obj.Content()
-	header := fmt.Sprintf("%s %d\x00", obj.Type(), len(content))
+	return WriteRawObject(repoPath, obj.Type(), obj.Content())
+}
+
+// WriteRawObject writes content to the repository as an object of the given type, byte for byte
+func WriteRawObject(repoPath string, objType Type, content []byte) (string, error) {
+	header := fmt.Sprintf("%s %d\x00", objType, len(content))
 	store := append([]byte(header), content...)
 
 	hash := utils.HashBytes(store)
 
-	dir := filepath.Join(repoPath, ".gogit", "objects", hash[:2])
+	dir := filepath.Join(ObjectDir(repoPath), hash[:2])
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create object directory: %w", err)
 	}
@@ -113,25 +190,35 @@ func WriteObject(repoPath string, obj Object) (string, error) {
 		return "", fmt.Errorf("failed to compress object: %w", err)
 	}
 
-	// Write to temp file first, then rename (atomic)
-	tmpPath := objPath + ".tmp"
-	if err := os.WriteFile(tmpPath, compressed, 0444); err != nil {
+	if err := utils.WriteFileAtomic(objPath, compressed, 0444); err != nil {
 		return "", fmt.Errorf("failed to write object: %w", err)
 	}
 
-	if err := os.Rename(tmpPath, objPath); err != nil {
-		os.Remove(tmpPath)
-		return "", fmt.Errorf("failed to rename object: %w", err)
-	}
-
 	return hash, nil
 }
 
-// GetObjectInfo returns type and size without fully parsing
+// GetObjectInfo returns type and size without fully parsing. A replaced
+// object is described by its replacement.
 func GetObjectInfo(repoPath, hash string) (Type, int, error) {
-	objPath := filepath.Join(repoPath, ".gogit", "objects", hash[:2], hash[2:])
+	return objectInfo(repoPath, hash, true)
+}
 
-	compressed, err := os.ReadFile(objPath)
+// objectInfo describes an object, or its replacement if replace is set
+func objectInfo(repoPath, hash string, replace bool) (Type, int, error) {
+	if replace {
+		var err error
+		if hash, err = Replacement(repoPath, hash); err != nil {
+			return "", 0, err
+		}
+	}
+	compressed, err := readLooseFile(repoPath, hash)
+	if os.IsNotExist(err) {
+		objType, content, err := readPromised(repoPath, hash)
+		if err != nil {
+			return "", 0, err
+		}
+		return objType, len(content), nil
+	}
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to read object %s: %w", hash, err)
 	}
@@ -161,3 +248,112 @@ func GetObjectInfo(repoPath, hash string) (Type, int, error) {
 
 	return objType, size, nil
 }
+
+// Exists reports whether an object is present in the repository or one of
+// its alternates. A replaced object is there if its replacement is.
+func Exists(repoPath, hash string) bool {
+	return exists(repoPath, hash, true)
+}
+
+// exists reports whether an object is present, or its replacement if
+// replace is set
+func exists(repoPath, hash string, replace bool) bool {
+	if len(hash) < 4 {
+		return false
+	}
+	if replace {
+		var err error
+		if hash, err = Replacement(repoPath, hash); err != nil {
+			return false
+		}
+	}
+	for _, dir := range objectDirs(repoPath) {
+		if _, err := os.Stat(loosePath(dir, hash)); err == nil {
+			return true
+		}
+	}
+	return inPack(repoPath, hash)
+}
+
+// ExpandHash resolves an abbreviated hash to the full hash of a unique object
+func ExpandHash(repoPath, prefix string) (string, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < 4 || len(prefix) > 40 {
+		return "", fmt.Errorf("invalid object name: %s", prefix)
+	}
+	if _, err := hex.DecodeString(prefix[:len(prefix)/2*2]); err != nil {
+		return "", fmt.Errorf("invalid object name: %s", prefix)
+	}
+
+	if len(prefix) == 40 {
+		if !Exists(repoPath, prefix) && !fetchPromised(repoPath, prefix) {
+			return "", fmt.Errorf("%w: %s", ErrObjectNotFound, prefix)
+		}
+		return prefix, nil
+	}
+
+	candidates, err := hashesWithPrefix(repoPath, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	var match string
+	for _, candidate := range candidates {
+		if match != "" && candidate != match {
+			return "", fmt.Errorf("%w: %s", ErrAmbiguousHash, prefix)
+		}
+		match = candidate
+	}
+
+	if match == "" {
+		return "", fmt.Errorf("%w: %s", ErrObjectNotFound, prefix)
+	}
+	return match, nil
+}
+
+// hashesWithPrefix returns the objects, loose or packed and in the
+// repository or its alternates, whose names start with a lowercase hex
+// prefix of at least two characters. An object stored more than once is
+// listed more than once.
+func hashesWithPrefix(repoPath, prefix string) ([]string, error) {
+	candidates, err := packedWithPrefix(repoPath, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, objectsDir := range objectDirs(repoPath) {
+		entries, err := os.ReadDir(filepath.Join(objectsDir, prefix[:2]))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read object directory: %w", err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if len(name) == 38 && strings.HasPrefix(name, prefix[2:]) {
+				candidates = append(candidates, prefix[:2]+name)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// Abbreviate returns the shortest prefix of hash, at least minLen (and at
+// least 4) characters long, that no other object in the repository shares
+func Abbreviate(repoPath, hash string, minLen int) (string, error) {
+	for n := max(minLen, 4); n < len(hash); n++ {
+		candidates, err := hashesWithPrefix(repoPath, hash[:n])
+		if err != nil {
+			return "", err
+		}
+		unique := true
+		for _, candidate := range candidates {
+			if candidate != hash {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return hash[:n], nil
+		}
+	}
+	return hash, nil
+}
@@ -0,0 +1,97 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newObjectRepo(t *testing.T) string {
+	t.Helper()
+	repoPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoPath, ".gogit", "objects"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return repoPath
+}
+
+// TestConcurrentWritesAndReads has many goroutines write the same large
+// objects while others read them back through one Store, and checks that
+// every read sees the whole object and that no temporary file is left
+func TestConcurrentWritesAndReads(t *testing.T) {
+	repoPath := newObjectRepo(t)
+	store := NewStore(repoPath)
+
+	blobs := make([]*Blob, 4)
+	for i := range blobs {
+		blobs[i] = NewBlob(bytes.Repeat([]byte{byte('a' + i), '\n'}, 1<<16))
+	}
+	tree := NewTree()
+	for i, blob := range blobs {
+		tree.AddEntry(ModeFile, string(rune('a'+i)), blob.Hash())
+	}
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*(len(blobs)+1)*2)
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for _, blob := range blobs {
+				if _, err := WriteObject(repoPath, blob); err != nil {
+					errs <- err
+				}
+			}
+			if _, err := store.Write(tree); err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, blob := range blobs {
+				// Until some writer has renamed the object into place it
+				// is missing, never partly there
+				obj, err := ReadObject(repoPath, blob.Hash())
+				if err != nil {
+					continue
+				}
+				if !bytes.Equal(obj.Content(), blob.Content()) {
+					errs <- fmt.Errorf("read a corrupt copy of blob %s", blob.Hash())
+				}
+			}
+			if read, err := store.ReadTree(tree.Hash()); err == nil && read.Hash() != tree.Hash() {
+				errs <- fmt.Errorf("read a corrupt copy of tree %s", tree.Hash())
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for _, blob := range blobs {
+		obj, err := ReadObject(repoPath, blob.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(obj.Content(), blob.Content()) {
+			t.Errorf("blob %s is corrupt", blob.Hash())
+		}
+	}
+
+	err := filepath.Walk(ObjectDir(repoPath), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && len(info.Name()) != 38 {
+			t.Errorf("left behind %s", strings.TrimPrefix(path, repoPath))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
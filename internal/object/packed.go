@@ -0,0 +1,275 @@
+package object
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/gogit/internal/pack"
+)
+
+// packSet is the list of packs opened from one pack directory, valid as
+// long as the directory is unchanged
+type packSet struct {
+	modTime time.Time
+	packs   []*pack.Pack
+}
+
+var (
+	packsMu   sync.Mutex
+	openPacks = make(map[string]*packSet)
+)
+
+// PackDir returns the directory holding a repository's packfiles
+func PackDir(repoPath string) string {
+	return filepath.Join(ObjectDir(repoPath), "pack")
+}
+
+// Packs returns the packs of a repository, reopening them if the pack
+// directory has changed since they were last opened. The packs of its
+// alternates aren't included.
+func Packs(repoPath string) ([]*pack.Pack, error) {
+	return packsIn(PackDir(repoPath))
+}
+
+// allPacks returns the packs of a repository followed by those of its
+// alternates
+func allPacks(repoPath string) ([]*pack.Pack, error) {
+	var packs []*pack.Pack
+	for _, dir := range objectDirs(repoPath) {
+		dirPacks, err := packsIn(filepath.Join(dir, "pack"))
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, dirPacks...)
+	}
+	return packs, nil
+}
+
+// packsIn returns the packs in a pack directory, opening them if needed
+func packsIn(dir string) ([]*pack.Pack, error) {
+	packsMu.Lock()
+	defer packsMu.Unlock()
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read pack directory: %w", err)
+	}
+
+	if set, ok := openPacks[dir]; ok {
+		if set.modTime.Equal(info.ModTime()) {
+			return set.packs, nil
+		}
+		for _, p := range set.packs {
+			p.Close()
+		}
+		delete(openPacks, dir)
+	}
+
+	idxFiles, err := filepath.Glob(filepath.Join(dir, "pack-*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(idxFiles)
+
+	set := &packSet{modTime: info.ModTime()}
+	for _, idxPath := range idxFiles {
+		p, err := pack.Open(idxPath)
+		if err != nil {
+			for _, opened := range set.packs {
+				opened.Close()
+			}
+			return nil, err
+		}
+		set.packs = append(set.packs, p)
+	}
+	openPacks[dir] = set
+
+	return set.packs, nil
+}
+
+// ClosePacks closes the packs opened for a repository so the next lookup
+// sees packs that were just written or removed
+func ClosePacks(repoPath string) {
+	dir := PackDir(repoPath)
+
+	packsMu.Lock()
+	defer packsMu.Unlock()
+
+	if set, ok := openPacks[dir]; ok {
+		for _, p := range set.packs {
+			p.Close()
+		}
+		delete(openPacks, dir)
+	}
+}
+
+// readPacked reads an object from the packs of the repository and its
+// alternates
+func readPacked(repoPath, hash string) (Type, []byte, error) {
+	packs, err := allPacks(repoPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, p := range packs {
+		objType, content, err := p.Read(hash)
+		if errors.Is(err, pack.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return Type(objType), content, nil
+	}
+
+	return "", nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+}
+
+// inPack reports whether any pack of the repository or its alternates
+// holds the object
+func inPack(repoPath, hash string) bool {
+	packs, err := allPacks(repoPath)
+	if err != nil {
+		return false
+	}
+	for _, p := range packs {
+		if p.Contains(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// packedWithPrefix returns the packed objects, in the repository or its
+// alternates, whose names start with prefix
+func packedWithPrefix(repoPath, prefix string) ([]string, error) {
+	packs, err := allPacks(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, p := range packs {
+		matches = append(matches, p.HashesWithPrefix(prefix)...)
+	}
+	return matches, nil
+}
+
+// LooseObjects returns the names of all loose objects in the repository,
+// leaving out those of its alternates
+func LooseObjects(repoPath string) ([]string, error) {
+	return looseIn(ObjectDir(repoPath))
+}
+
+// looseIn returns the names of the loose objects in an object directory
+func looseIn(objectsDir string) ([]string, error) {
+	dirs, err := os.ReadDir(objectsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object directory: %w", err)
+	}
+
+	var hashes []string
+	for _, dir := range dirs {
+		// Skip "pack", "info" and anything else that isn't a fan-out directory
+		if !dir.IsDir() || len(dir.Name()) != 2 {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(objectsDir, dir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read object directory: %w", err)
+		}
+		for _, entry := range entries {
+			hash := dir.Name() + entry.Name()
+			if IsHash(hash) {
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+
+	return hashes, nil
+}
+
+// ForEachObject calls fn with the name of every object in the repository
+// and its alternates, loose or packed, once each and in hash order
+func ForEachObject(repoPath string, fn func(hash string) error) error {
+	var hashes []string
+	for _, dir := range objectDirs(repoPath) {
+		loose, err := looseIn(dir)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, loose...)
+	}
+	packs, err := allPacks(repoPath)
+	if err != nil {
+		return err
+	}
+	for _, p := range packs {
+		for i := 0; i < p.Len(); i++ {
+			hashes = append(hashes, p.Hash(i))
+		}
+	}
+
+	sort.Strings(hashes)
+	for i, hash := range hashes {
+		if i > 0 && hash == hashes[i-1] {
+			continue
+		}
+		if err := fn(hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveLoose deletes the loose copy of an object, along with its fan-out
+// directory if that leaves it empty
+func RemoveLoose(repoPath, hash string) error {
+	dir := filepath.Join(ObjectDir(repoPath), hash[:2])
+	if err := os.Remove(filepath.Join(dir, hash[2:])); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove object %s: %w", hash, err)
+	}
+	os.Remove(dir) // Only succeeds if empty
+	return nil
+}
+
+// LooseModTime returns when the loose copy of an object was last written
+func LooseModTime(repoPath, hash string) (time.Time, error) {
+	info, err := os.Stat(loosePath(ObjectDir(repoPath), hash))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// PackedDuplicate reports whether a loose object is also held by a pack,
+// of the repository or an alternate.
+// Both copies are read, and an error is returned if they disagree in type
+// or content, in which case neither should be trusted to replace the other.
+func PackedDuplicate(repoPath, hash string) (bool, error) {
+	if !inPack(repoPath, hash) {
+		return false, nil
+	}
+
+	looseType, looseContent, err := readLoose(repoPath, hash)
+	if err != nil {
+		return false, err
+	}
+	packedType, packedContent, err := readPacked(repoPath, hash)
+	if err != nil {
+		return false, err
+	}
+	if looseType != packedType || !bytes.Equal(looseContent, packedContent) {
+		return false, fmt.Errorf("loose object %s differs from its packed copy", hash)
+	}
+	return true, nil
+}
@@ -0,0 +1,67 @@
+package object
+
+import "errors"
+
+// PromisorFetcher downloads objects that a partial clone left out. It must
+// leave them readable, usually by adding a pack, before returning.
+type PromisorFetcher func(repoPath string, hashes []string) error
+
+// ErrNotPromised is returned by a PromisorFetcher for a repository that
+// isn't a partial clone, where a missing object is simply missing
+var ErrNotPromised = errors.New("repository has no promisor remote")
+
+var promisorFetcher PromisorFetcher
+
+// SetPromisorFetcher installs the function used to fetch objects missing
+// from a partial clone when they are read
+func SetPromisorFetcher(fn PromisorFetcher) {
+	promisorFetcher = fn
+}
+
+// Prefetch fetches, in one request, those of hashes that are missing from
+// a partial clone. It does nothing in other repositories.
+func Prefetch(repoPath string, hashes []string) error {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, hash := range hashes {
+		if !seen[hash] && !Exists(repoPath, hash) {
+			missing = append(missing, hash)
+		}
+		seen[hash] = true
+	}
+	if len(missing) == 0 || promisorFetcher == nil {
+		return nil
+	}
+
+	err := promisorFetcher(repoPath, missing)
+	if errors.Is(err, ErrNotPromised) {
+		return nil
+	}
+	return err
+}
+
+// fetchPromised fetches a missing object from the promisor remote of a
+// partial clone and reports whether it is now present
+func fetchPromised(repoPath, hash string) bool {
+	if promisorFetcher == nil || promisorFetcher(repoPath, []string{hash}) != nil {
+		return false
+	}
+	return Exists(repoPath, hash)
+}
+
+// readPromised reads an object that isn't in the repository, fetching it
+// from the promisor remote first if this is a partial clone
+func readPromised(repoPath, hash string) (Type, []byte, error) {
+	objType, content, err := readPacked(repoPath, hash)
+	if !errors.Is(err, ErrObjectNotFound) || promisorFetcher == nil {
+		return objType, content, err
+	}
+
+	if fetchErr := promisorFetcher(repoPath, []string{hash}); fetchErr != nil {
+		if errors.Is(fetchErr, ErrNotPromised) {
+			return "", nil, err
+		}
+		return "", nil, fetchErr
+	}
+	return readPacked(repoPath, hash)
+}
@@ -0,0 +1,46 @@
+package object
+
+import "fmt"
+
+// maxReplaceDepth bounds a chain of replacements, as in git
+const maxReplaceDepth = 5
+
+// ReplaceMap returns the replacements made in a repository: the objects
+// named under refs/replace/, each mapped to the object that stands in for it
+type ReplaceMap func(repoPath string) map[string]string
+
+var replaceMap ReplaceMap
+
+// SetReplaceMap installs the function used to find a repository's
+// replacements, so that reading a replaced object returns its replacement
+// instead. nil turns replacement off.
+func SetReplaceMap(fn ReplaceMap) {
+	replaceMap = fn
+}
+
+// HasReplacements reports whether reads in a repository may be replaced.
+// Data derived from the objects as stored, like the commit-graph, can't be
+// trusted then.
+func HasReplacements(repoPath string) bool {
+	return replaceMap != nil && len(replaceMap(repoPath)) > 0
+}
+
+// Replacement returns the object that reads of hash return: its
+// replacement, following replacements of replacements, or hash itself
+func Replacement(repoPath, hash string) (string, error) {
+	if replaceMap == nil {
+		return hash, nil
+	}
+	replacements := replaceMap(repoPath)
+	current := hash
+	for depth := 0; ; depth++ {
+		next, ok := replacements[current]
+		if !ok {
+			return current, nil
+		}
+		if depth == maxReplaceDepth {
+			return "", fmt.Errorf("replace depth too high for object %s", hash)
+		}
+		current = next
+	}
+}
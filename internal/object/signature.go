@@ -0,0 +1,83 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Signature armor headers recognized at the start of a tag signature
+var signatureHeaders = []string{
+	"-----BEGIN PGP SIGNATURE-----",
+	"-----BEGIN PGP MESSAGE-----",
+	"-----BEGIN SSH SIGNATURE-----",
+	"-----BEGIN SIGNED MESSAGE-----",
+}
+
+// ExtractCommitSignature splits raw commit content into the signed payload
+// (the commit without its gpgsig header) and the signature itself.
+// It works on raw bytes so the payload matches exactly what was signed.
+func ExtractCommitSignature(content []byte) (payload, signature []byte, ok bool) {
+	var out, sig bytes.Buffer
+	inSig := false
+	inHeader := true
+
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, '\n')
+		var line []byte
+		if end == -1 {
+			line = content
+			content = nil
+		} else {
+			line = content[:end+1]
+			content = content[end+1:]
+		}
+
+		if inHeader {
+			if inSig && bytes.HasPrefix(line, []byte(" ")) {
+				sig.Write(line[1:])
+				continue
+			}
+			inSig = false
+
+			if bytes.HasPrefix(line, []byte("gpgsig ")) || bytes.HasPrefix(line, []byte("gpgsig-sha256 ")) {
+				inSig = true
+				ok = true
+				sig.Write(line[bytes.IndexByte(line, ' ')+1:])
+				continue
+			}
+
+			if len(line) == 1 && line[0] == '\n' {
+				inHeader = false
+			}
+		}
+
+		out.Write(line)
+	}
+
+	if !ok {
+		return nil, nil, false
+	}
+	return out.Bytes(), sig.Bytes(), true
+}
+
+// ExtractTagSignature splits raw tag content into the signed payload and
+// the trailing armored signature appended to the message.
+func ExtractTagSignature(content []byte) (payload, signature []byte, ok bool) {
+	text := string(content)
+	start := -1
+
+	for _, header := range signatureHeaders {
+		idx := strings.LastIndex(text, header)
+		if idx == -1 || (idx > 0 && text[idx-1] != '\n') {
+			continue
+		}
+		if idx > start {
+			start = idx
+		}
+	}
+
+	if start == -1 {
+		return nil, nil, false
+	}
+	return content[:start], content[start:], true
+}
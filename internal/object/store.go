@@ -0,0 +1,103 @@
+package object
+
+import "fmt"
+
+// Store is a repository-scoped object reader that caches parsed commits and trees.
+// Objects returned by a Store may be shared with other callers and must not be modified.
+type Store struct {
+	repoPath string
+	cache    *Cache
+	stored   bool // Objects are read as stored, ignoring replacements
+}
+
+// NewStore creates a new Store for the repository at repoPath
+func NewStore(repoPath string) *Store {
+	return &Store{
+		repoPath: repoPath,
+		cache:    NewCache(DefaultCacheSize),
+	}
+}
+
+// AsStored returns a Store for the same repository that reads objects as
+// stored, ignoring replacements, for work on history as it was written
+func (s *Store) AsStored() *Store {
+	return &Store{repoPath: s.repoPath, cache: NewCache(DefaultCacheSize), stored: true}
+}
+
+// HasReplacements reports whether reads through the store may be replaced
+func (s *Store) HasReplacements() bool {
+	return !s.stored && HasReplacements(s.repoPath)
+}
+
+// Read reads an object, serving commits and trees from the cache when possible
+func (s *Store) Read(hash string) (Object, error) {
+	if obj, ok := s.cache.Get(hash); ok {
+		return obj, nil
+	}
+
+	objType, content, err := s.ReadRaw(hash)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := ParseContent(objType, content)
+	if err != nil {
+		return nil, err
+	}
+
+	switch obj.(type) {
+	case *Commit, *Tree:
+		s.cache.Add(hash, obj)
+	}
+
+	return obj, nil
+}
+
+// ReadRaw reads an object's type and undecoded content, bypassing the cache
+func (s *Store) ReadRaw(hash string) (Type, []byte, error) {
+	return readRawObject(s.repoPath, hash, !s.stored)
+}
+
+// Info returns an object's type and size
+func (s *Store) Info(hash string) (Type, int, error) {
+	return objectInfo(s.repoPath, hash, !s.stored)
+}
+
+// Exists reports whether an object is present
+func (s *Store) Exists(hash string) bool {
+	return exists(s.repoPath, hash, !s.stored)
+}
+
+// ReadCommit reads an object and checks that it is a commit
+func (s *Store) ReadCommit(hash string) (*Commit, error) {
+	obj, err := s.Read(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, ok := obj.(*Commit)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a commit", hash)
+	}
+	return commit, nil
+}
+
+// ReadTree reads an object and checks that it is a tree
+func (s *Store) ReadTree(hash string) (*Tree, error) {
+	obj, err := s.Read(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, ok := obj.(*Tree)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a tree", hash)
+	}
+	return tree, nil
+}
+
+// Write writes an object to the repository.
+// The written object is not cached: the caller still owns it and may modify it,
+// so the cache is only ever populated with objects parsed from disk.
+func (s *Store) Write(obj Object) (string, error) {
+	return WriteObject(s.repoPath, obj)
+}
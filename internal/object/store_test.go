@@ -0,0 +1,130 @@
+package object
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeHistory stores a linear history of n commits, each changing one of
+// a handful of files, and returns their hashes newest first
+func writeHistory(tb testing.TB, repoPath string, n int) []string {
+	tb.Helper()
+	if err := os.MkdirAll(filepath.Join(repoPath, ".gogit", "objects"), 0755); err != nil {
+		tb.Fatal(err)
+	}
+
+	const files = 8
+	blobs := make([]string, files)
+	hashes := make([]string, n)
+	var parents []string
+	for i := 0; i < n; i++ {
+		blob, err := WriteObject(repoPath, NewBlob([]byte(fmt.Sprintf("version %d\n", i))))
+		if err != nil {
+			tb.Fatal(err)
+		}
+		blobs[i%files] = blob
+
+		tree := NewTree()
+		for j, hash := range blobs {
+			if hash != "" {
+				tree.AddEntry(ModeFile, fmt.Sprintf("file%d", j), hash)
+			}
+		}
+		treeHash, err := WriteObject(repoPath, tree)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		commit := NewCommit(treeHash, parents, "Test <test@example.com>", fmt.Sprintf("commit %d\n", i))
+		commit.Author.When = time.Unix(int64(1700000000+i), 0).UTC()
+		commit.Committer.When = commit.Author.When
+		hash, err := WriteObject(repoPath, commit)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		hashes[n-1-i] = hash
+		parents = []string{hash}
+	}
+	return hashes
+}
+
+// BenchmarkHistoryWalk walks a 1000-commit history the way a path-limited
+// log does, reading every commit's tree and its parent's, with and without
+// a Store
+func BenchmarkHistoryWalk(b *testing.B) {
+	repoPath := b.TempDir()
+	head := writeHistory(b, repoPath, 1000)[0]
+
+	walk := func(b *testing.B, readCommit func(string) (*Commit, error), readTree func(string) (*Tree, error)) {
+		for hash := head; hash != ""; {
+			commit, err := readCommit(hash)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := readTree(commit.TreeHash); err != nil {
+				b.Fatal(err)
+			}
+			if parentHash := commit.FirstParent(); parentHash != "" {
+				parent, err := readCommit(parentHash)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := readTree(parent.TreeHash); err != nil {
+					b.Fatal(err)
+				}
+			}
+			hash = commit.FirstParent()
+		}
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		readCommit := func(hash string) (*Commit, error) {
+			obj, err := ReadObject(repoPath, hash)
+			if err != nil {
+				return nil, err
+			}
+			return obj.(*Commit), nil
+		}
+		readTree := func(hash string) (*Tree, error) {
+			obj, err := ReadObject(repoPath, hash)
+			if err != nil {
+				return nil, err
+			}
+			return obj.(*Tree), nil
+		}
+		for i := 0; i < b.N; i++ {
+			walk(b, readCommit, readTree)
+		}
+	})
+
+	b.Run("store", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			store := NewStore(repoPath)
+			walk(b, store.ReadCommit, store.ReadTree)
+		}
+	})
+}
+
+func TestStoreCachesParsedObjects(t *testing.T) {
+	repoPath := t.TempDir()
+	head := writeHistory(t, repoPath, 3)[0]
+
+	store := NewStore(repoPath)
+	first, err := store.ReadCommit(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := store.ReadCommit(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("reading a commit twice parsed it twice, want the cached copy")
+	}
+	if _, err := store.ReadTree(head); err == nil {
+		t.Error("ReadTree of a commit succeeded, want a type error")
+	}
+}
@@ -0,0 +1,122 @@
+package object
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxHeaderLen bounds the "<type> <size>" header of a loose object
+const maxHeaderLen = 64
+
+// OpenObject opens an object for reading its content as a stream, with its
+// type and size. A loose object is decompressed as it is read, so even a
+// very large blob never has to be held in memory; packed and promised
+// objects are read whole first, as deltas need their base. A replaced
+// object reads as its replacement. The caller must close the reader.
+func OpenObject(repoPath, hash string) (Type, int64, io.ReadCloser, error) {
+	if len(hash) < 4 {
+		return "", 0, nil, fmt.Errorf("hash too short: %s", hash)
+	}
+	hash, err := Replacement(repoPath, hash)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	objType, size, r, err := openLoose(repoPath, hash)
+	if os.IsNotExist(err) {
+		objType, content, err := readPromised(repoPath, hash)
+		if err != nil {
+			return "", 0, nil, err
+		}
+		return objType, int64(len(content)), io.NopCloser(bytes.NewReader(content)), nil
+	}
+	return objType, size, r, err
+}
+
+// openLoose opens the loose copy of an object, in the repository or else
+// one of its alternates, and reads its header. A missing object is reported
+// with an error satisfying os.IsNotExist.
+func openLoose(repoPath, hash string) (Type, int64, io.ReadCloser, error) {
+	var f *os.File
+	var err error
+	for _, dir := range objectDirs(repoPath) {
+		if f, err = os.Open(loosePath(dir, hash)); !os.IsNotExist(err) {
+			break
+		}
+	}
+	if os.IsNotExist(err) {
+		return "", 0, nil, err
+	}
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+
+	z, err := zlib.NewReader(bufio.NewReader(f))
+	if err != nil {
+		f.Close()
+		return "", 0, nil, fmt.Errorf("failed to decompress object %s: %w", hash, err)
+	}
+	br := bufio.NewReader(z)
+	header, err := br.ReadSlice(0)
+	if err != nil || len(header) > maxHeaderLen {
+		z.Close()
+		f.Close()
+		return "", 0, nil, fmt.Errorf("invalid object %s: no null byte found", hash)
+	}
+
+	typeName, sizeStr, ok := strings.Cut(string(header[:len(header)-1]), " ")
+	if !ok {
+		z.Close()
+		f.Close()
+		return "", 0, nil, fmt.Errorf("invalid object header: %s", header[:len(header)-1])
+	}
+	size, err := strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil || size < 0 {
+		z.Close()
+		f.Close()
+		return "", 0, nil, fmt.Errorf("invalid object size: %s", sizeStr)
+	}
+
+	return Type(typeName), size, &looseReader{r: br, z: z, f: f, left: size}, nil
+}
+
+// looseReader reads the content of a loose object, checking that it is
+// as long as its header said
+type looseReader struct {
+	r    *bufio.Reader
+	z    io.ReadCloser
+	f    *os.File
+	left int64
+}
+
+func (l *looseReader) Read(p []byte) (int, error) {
+	if l.left == 0 {
+		if n, _ := l.r.Read(make([]byte, 1)); n > 0 {
+			return 0, fmt.Errorf("object size mismatch: content is longer than its header says")
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.left {
+		p = p[:l.left]
+	}
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	if err == io.EOF && l.left > 0 {
+		return n, fmt.Errorf("object size mismatch: content is shorter than its header says")
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+func (l *looseReader) Close() error {
+	l.z.Close()
+	return l.f.Close()
+}
@@ -0,0 +1,96 @@
+package object
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+func TestOpenObjectStreamsLargeBlob(t *testing.T) {
+	repoPath := newObjectRepo(t)
+	content := make([]byte, 8<<20)
+	rand.New(rand.NewSource(1)).Read(content)
+	hash, err := WriteObject(repoPath, NewBlob(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content = nil
+	runtime.GC()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	objType, size, r, err := OpenObject(repoPath, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha1.New()
+	io.WriteString(h, "blob 8388608\x00")
+	n, err := io.Copy(h, r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runtime.ReadMemStats(&after)
+	if objType != TypeBlob || size != 8<<20 || n != size {
+		t.Errorf("read a %s of %d bytes, %d of them, want a blob of %d", objType, size, n, 8<<20)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != hash {
+		t.Errorf("streamed content hashes to %s, want %s", got, hash)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("streaming an 8 MiB blob allocated %d bytes", allocated)
+	}
+}
+
+// writeLooseRaw stores data, compressed, as the loose object hash without
+// checking it
+func writeLooseRaw(t *testing.T, repoPath, hash, data string) {
+	t.Helper()
+	var buf bytes.Buffer
+	z := zlib.NewWriter(&buf)
+	io.WriteString(z, data)
+	z.Close()
+	path := loosePath(ObjectDir(repoPath), hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0444); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenObjectChecksSize(t *testing.T) {
+	repoPath := newObjectRepo(t)
+	for _, c := range []struct {
+		data, wantErr string
+	}{
+		{"blob 10\x00short", "shorter than its header"},
+		{"blob 2\x00too long", "longer than its header"},
+		{"blob x\x00content", "invalid object size"},
+		{"no header at all", "no null byte"},
+	} {
+		hash := utils.HashObject("blob", []byte(c.data))
+		writeLooseRaw(t, repoPath, hash, c.data)
+
+		_, _, r, err := OpenObject(repoPath, hash)
+		if err == nil {
+			_, err = io.Copy(io.Discard, r)
+			r.Close()
+		}
+		if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("reading %q: %v, want an error saying %q", c.data, err, c.wantErr)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// Tag represents an annotated Git tag object
+type Tag struct {
+	Object  string
+	ObjType Type
+	Name    string
+	Tagger  Signature
+	Message string // Everything after the header, including any signature
+}
+
+// Type returns the object type
+func (t *Tag) Type() Type {
+	return TypeTag
+}
+
+// Content returns the tag content in Git format
+func (t *Tag) Content() []byte {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("object %s\n", t.Object))
+	sb.WriteString(fmt.Sprintf("type %s\n", t.ObjType))
+	sb.WriteString(fmt.Sprintf("tag %s\n", t.Name))
+	if t.Tagger.String() != "" {
+		sb.WriteString(fmt.Sprintf("tagger %s\n", t.Tagger.Header()))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(t.Message)
+
+	return []byte(sb.String())
+}
+
+// Hash computes the SHA-1 hash of the tag
+func (t *Tag) Hash() string {
+	return utils.HashObject(string(TypeTag), t.Content())
+}
+
+// ParseTag parses tag content into a Tag object
+func ParseTag(content []byte) (*Tag, error) {
+	tag := &Tag{}
+	text := string(content)
+
+	header := text
+	if idx := strings.Index(text, "\n\n"); idx != -1 {
+		header = text[:idx]
+		tag.Message = text[idx+2:]
+	}
+
+	for _, line := range strings.Split(header, "\n") {
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch parts[0] {
+		case "object":
+			tag.Object = parts[1]
+		case "type":
+			tag.ObjType = Type(parts[1])
+		case "tag":
+			tag.Name = parts[1]
+		case "tagger":
+			tag.Tagger = ParseSignature(parts[1])
+		}
+	}
+
+	if tag.Object == "" || tag.ObjType == "" {
+		return nil, fmt.Errorf("invalid tag: missing object or type header")
+	}
+
+	return tag, nil
+}
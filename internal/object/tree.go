@@ -5,18 +5,60 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/yourusername/gogit/internal/utils"
 )
 
+// The canonical modes of tree entries, spelled as Git writes them: octal
+// with no leading zero, so a subtree is "40000" rather than "040000"
+const (
+	ModeTree       = "40000"
+	ModeFile       = "100644"
+	ModeExecutable = "100755"
+	ModeSymlink    = "120000"
+)
+
+// ModeGitlink is the mode of a tree entry that records the commit a
+// submodule is at, rather than naming an object in this repository
+const ModeGitlink = "160000"
+
 // TreeEntry represents a single entry in a tree object
 type TreeEntry struct {
-	Mode string // File mode (100644 for file, 100755 for executable, 040000 for directory)
+	Mode string // File mode (100644 for file, 100755 for executable, 40000 for directory)
 	Name string // File or directory name
 	Hash string // SHA-1 hash of the object
 }
 
+// IsTree reports whether the entry is a subtree
+func (e TreeEntry) IsTree() bool {
+	return e.Mode == ModeTree || e.Mode == "0"+ModeTree
+}
+
+// CanonicalMode returns the mode Git would record for an entry of the given
+// octal mode: the file type is kept, and a regular file's permissions are
+// reduced to 644 or, if any execute bit is set, 755. Modes that don't parse
+// are returned unchanged.
+func CanonicalMode(mode string) string {
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return mode
+	}
+	switch m & 0170000 {
+	case 0040000:
+		return ModeTree
+	case 0120000:
+		return ModeSymlink
+	case 0160000:
+		return ModeGitlink
+	}
+	if m&0111 != 0 {
+		return ModeExecutable
+	}
+	return ModeFile
+}
+
 // Tree represents a Git tree object (directory listing)
 type Tree struct {
 	Entries []TreeEntry
@@ -27,9 +69,9 @@ func NewTree() *Tree {
 	return &Tree{Entries: make([]TreeEntry, 0)}
 }
 
-// AddEntry adds an entry to the tree
+// AddEntry adds an entry to the tree, with its mode in canonical form
 func (t *Tree) AddEntry(mode, name, hash string) {
-	t.Entries = append(t.Entries, TreeEntry{Mode: mode, Name: name, Hash: hash})
+	t.Entries = append(t.Entries, TreeEntry{Mode: CanonicalMode(mode), Name: name, Hash: hash})
 }
 
 // Type returns the object type
@@ -43,8 +85,8 @@ func (t *Tree) Content() []byte {
 	sorted := make([]TreeEntry, len(t.Entries))
 	copy(sorted, t.Entries)
 	sort.Slice(sorted, func(i, j int) bool {
-		// Directories come before files with same prefix
-		return sorted[i].Name < sorted[j].Name
+		// Git compares directory names as if they ended in "/"
+		return sortKey(sorted[i]) < sortKey(sorted[j])
 	})
 
 	var buf bytes.Buffer
@@ -62,6 +104,14 @@ func (t *Tree) Content() []byte {
 	return buf.Bytes()
 }
 
+// sortKey returns the name Git uses when ordering tree entries
+func sortKey(entry TreeEntry) string {
+	if entry.IsTree() {
+		return entry.Name + "/"
+	}
+	return entry.Name
+}
+
 // Hash computes the SHA-1 hash of the tree
 func (t *Tree) Hash() string {
 	return utils.HashObject(string(TypeTree), t.Content())
@@ -78,7 +128,12 @@ func ParseTree(content []byte) (*Tree, error) {
 		if spaceIdx == -1 {
 			return nil, fmt.Errorf("invalid tree entry: no space found")
 		}
+		// The mode is kept as written, even if it isn't canonical, so that
+		// the tree serializes back to the same bytes and hash
 		mode := string(content[pos : pos+spaceIdx])
+		if _, err := strconv.ParseUint(mode, 8, 32); err != nil {
+			return nil, fmt.Errorf("invalid tree entry: bad mode %q", mode)
+		}
 		pos += spaceIdx + 1
 
 		// Find null byte after name
@@ -87,6 +142,9 @@ func ParseTree(content []byte) (*Tree, error) {
 			return nil, fmt.Errorf("invalid tree entry: no null byte found")
 		}
 		name := string(content[pos : pos+nullIdx])
+		if name == "" {
+			return nil, fmt.Errorf("invalid tree entry: empty name")
+		}
 		pos += nullIdx + 1
 
 		// Read 20-byte hash
@@ -111,8 +169,11 @@ func (t *Tree) PrettyPrint() string {
 	var sb strings.Builder
 	for _, entry := range t.Entries {
 		objType := "blob"
-		if entry.Mode == "40000" || entry.Mode == "040000" {
+		switch entry.Mode {
+		case ModeTree, "0" + ModeTree:
 			objType = "tree"
+		case ModeGitlink:
+			objType = "commit"
 		}
 		sb.WriteString(fmt.Sprintf("%06s %s %s\t%s\n", entry.Mode, objType, entry.Hash, entry.Name))
 	}
@@ -0,0 +1,91 @@
+package object
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+const (
+	emptyBlob = "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"
+	emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	someHash  = "1111111111111111111111111111111111111111"
+)
+
+// gitTreeHash names the tree git mktree writes for the entries of
+// TestTreeMatchesGit, whose bytes gitTreeContent spells out
+const gitTreeHash = "b1897047c92052edfe19f66af7901ce4da74e8d1"
+
+func gitTreeContent(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, e := range []struct{ mode, name, hash string }{
+		{"100644", "dir.txt", emptyBlob},
+		{"40000", "dir", emptyTree},
+		{"100644", "file", emptyBlob},
+		{"120000", "link", emptyBlob},
+		{"100755", "run", emptyBlob},
+		{"160000", "sub", someHash},
+	} {
+		buf.WriteString(e.mode + " " + e.name + "\x00")
+		raw, err := hex.DecodeString(e.hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(raw)
+	}
+	return buf.Bytes()
+}
+
+func TestTreeMatchesGit(t *testing.T) {
+	tree := NewTree()
+	// Modes as the index, a stat call or an old tree might spell them
+	tree.AddEntry("100664", "file", emptyBlob)
+	tree.AddEntry("100775", "run", emptyBlob)
+	tree.AddEntry("0120000", "link", emptyBlob)
+	tree.AddEntry("040000", "dir", emptyTree)
+	tree.AddEntry("160000", "sub", someHash)
+	tree.AddEntry("644", "dir.txt", emptyBlob)
+
+	want := gitTreeContent(t)
+	if got := tree.Content(); !bytes.Equal(got, want) {
+		t.Errorf("serialized tree:\n%q\nwant git's:\n%q", got, want)
+	}
+	if got := tree.Hash(); got != gitTreeHash {
+		t.Errorf("tree hash = %s, want %s", got, gitTreeHash)
+	}
+}
+
+func TestParseTreeKeepsGitBytes(t *testing.T) {
+	content := gitTreeContent(t)
+	tree, err := ParseTree(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.Content(); !bytes.Equal(got, content) {
+		t.Errorf("re-serialized tree:\n%q\nwant:\n%q", got, content)
+	}
+	for _, entry := range tree.Entries {
+		if entry.Name == "dir" && !entry.IsTree() {
+			t.Errorf("dir has mode %s and isn't taken for a tree", entry.Mode)
+		}
+	}
+}
+
+func TestCanonicalMode(t *testing.T) {
+	for mode, want := range map[string]string{
+		"100644":  ModeFile,
+		"100600":  ModeFile,
+		"100755":  ModeExecutable,
+		"100744":  ModeExecutable,
+		"40000":   ModeTree,
+		"040000":  ModeTree,
+		"120000":  ModeSymlink,
+		"160000":  ModeGitlink,
+		"garbage": "garbage",
+	} {
+		if got := CanonicalMode(mode); got != want {
+			t.Errorf("CanonicalMode(%q) = %q, want %q", mode, got, want)
+		}
+	}
+}
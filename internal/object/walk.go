@@ -0,0 +1,63 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// TreeWalkFunc is called by WalkTree for each entry, with path being the
+// entry's path from the top of the walked tree. Returning fs.SkipDir for a
+// subtree skips its contents; for any other entry it skips the rest of the
+// tree containing it. Returning fs.SkipAll ends the walk. Any other error
+// stops the walk and is returned by WalkTree.
+type TreeWalkFunc func(path string, entry TreeEntry) error
+
+// WalkTree calls fn for every entry of a tree and its subtrees, in tree
+// order, with each subtree reported before its contents. Gitlinks are
+// reported but not entered, since their commits live in another repository.
+func WalkTree(repoPath, treeHash string, fn TreeWalkFunc) error {
+	return NewStore(repoPath).WalkTree(treeHash, fn)
+}
+
+// WalkTree is like the package-level WalkTree, reading trees through the
+// store's cache
+func (s *Store) WalkTree(treeHash string, fn TreeWalkFunc) error {
+	err := s.walkTree(treeHash, "", fn)
+	if errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) walkTree(treeHash, prefix string, fn TreeWalkFunc) error {
+	tree, err := s.ReadTree(treeHash)
+	if err != nil {
+		return fmt.Errorf("failed to read tree %s: %w", treeHash, err)
+	}
+
+	for _, entry := range tree.Entries {
+		path := entry.Name
+		if prefix != "" {
+			path = filepath.Join(prefix, entry.Name)
+		}
+
+		err := fn(path, entry)
+		switch {
+		case errors.Is(err, fs.SkipDir) && entry.IsTree():
+			continue
+		case errors.Is(err, fs.SkipDir):
+			return nil
+		case err != nil:
+			return err
+		}
+
+		if entry.IsTree() {
+			if err := s.walkTree(entry.Hash, path, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
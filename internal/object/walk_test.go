@@ -0,0 +1,85 @@
+package object
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+// writeNestedTree stores a tree holding a, dir/b, dir/sub/c, dir/sub/d, a
+// gitlink at mod and z, and returns its hash
+func writeNestedTree(t *testing.T, repoPath string) string {
+	t.Helper()
+	write := func(obj Object) string {
+		hash, err := WriteObject(repoPath, obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	blob := write(NewBlob([]byte("content\n")))
+
+	sub := NewTree()
+	sub.AddEntry(ModeFile, "c", blob)
+	sub.AddEntry(ModeFile, "d", blob)
+	dir := NewTree()
+	dir.AddEntry(ModeFile, "b", blob)
+	dir.AddEntry(ModeTree, "sub", write(sub))
+	top := NewTree()
+	top.AddEntry(ModeFile, "a", blob)
+	top.AddEntry(ModeTree, "dir", write(dir))
+	top.AddEntry(ModeGitlink, "mod", "1111111111111111111111111111111111111111")
+	top.AddEntry(ModeFile, "z", blob)
+	return write(top)
+}
+
+func TestWalkTree(t *testing.T) {
+	repoPath := newObjectRepo(t)
+	tree := writeNestedTree(t, repoPath)
+
+	for _, c := range []struct {
+		name string
+		stop map[string]error // What fn returns for a path
+		want []string
+	}{
+		{"everything", nil, []string{"a", "dir", "dir/b", "dir/sub", "dir/sub/c", "dir/sub/d", "mod", "z"}},
+		{"pruning a subtree", map[string]error{"dir/sub": fs.SkipDir}, []string{"a", "dir", "dir/b", "dir/sub", "mod", "z"}},
+		{"pruning from a file", map[string]error{"dir/sub/c": fs.SkipDir}, []string{"a", "dir", "dir/b", "dir/sub", "dir/sub/c", "mod", "z"}},
+		{"stopping", map[string]error{"dir/b": fs.SkipAll}, []string{"a", "dir", "dir/b"}},
+	} {
+		var visited []string
+		err := WalkTree(repoPath, tree, func(path string, entry TreeEntry) error {
+			visited = append(visited, path)
+			return c.stop[path]
+		})
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(visited, c.want) {
+			t.Errorf("%s: visited %v, want %v", c.name, visited, c.want)
+		}
+	}
+}
+
+func TestWalkTreeError(t *testing.T) {
+	repoPath := newObjectRepo(t)
+	tree := writeNestedTree(t, repoPath)
+
+	failure := errors.New("failure")
+	var visited int
+	err := WalkTree(repoPath, tree, func(path string, entry TreeEntry) error {
+		visited++
+		if path == "dir/b" {
+			return failure
+		}
+		return nil
+	})
+	if !errors.Is(err, failure) || visited != 3 {
+		t.Errorf("WalkTree returned %v after %d entries, want the callback's error after 3", err, visited)
+	}
+
+	if err := WalkTree(repoPath, "0000000000000000000000000000000000000000", func(string, TreeEntry) error { return nil }); err == nil {
+		t.Error("WalkTree of a missing tree succeeded")
+	}
+}
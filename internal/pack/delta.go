@@ -0,0 +1,202 @@
+package pack
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Delta instructions copy ranges of the base or insert literal bytes.
+// blockSize is the granularity at which the base is indexed for matches.
+const (
+	blockSize   = 16
+	maxCopySize = 0x10000
+	maxInsert   = 0x7f
+)
+
+// Delta computes a Git delta that rebuilds target from base
+func Delta(base, target []byte) []byte {
+	var out bytes.Buffer
+	writeSize(&out, len(base))
+	writeSize(&out, len(target))
+
+	// Index the base by fixed-size blocks; later blocks win, which keeps
+	// the map small for repetitive input without hurting typical matches
+	blocks := make(map[string]int, len(base)/blockSize+1)
+	for i := 0; i+blockSize <= len(base); i += blockSize {
+		blocks[string(base[i:i+blockSize])] = i
+	}
+
+	var pending []byte
+	flushInsert := func() {
+		for len(pending) > 0 {
+			n := len(pending)
+			if n > maxInsert {
+				n = maxInsert
+			}
+			out.WriteByte(byte(n))
+			out.Write(pending[:n])
+			pending = pending[n:]
+		}
+	}
+
+	for i := 0; i < len(target); {
+		if i+blockSize <= len(target) {
+			if offset, ok := blocks[string(target[i:i+blockSize])]; ok {
+				// Extend the match forwards...
+				length := blockSize
+				for offset+length < len(base) && i+length < len(target) && base[offset+length] == target[i+length] {
+					length++
+				}
+				// ...and backwards into bytes queued for insertion
+				for offset > 0 && len(pending) > 0 && base[offset-1] == pending[len(pending)-1] {
+					offset--
+					length++
+					pending = pending[:len(pending)-1]
+					i--
+				}
+
+				flushInsert()
+				writeCopy(&out, offset, length)
+				i += length
+				continue
+			}
+		}
+
+		pending = append(pending, target[i])
+		i++
+	}
+	flushInsert()
+
+	return out.Bytes()
+}
+
+// ApplyDelta rebuilds a target object from its base and a Git delta
+func ApplyDelta(base, delta []byte) ([]byte, error) {
+	baseSize, pos, err := readSize(delta, 0)
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != len(base) {
+		return nil, fmt.Errorf("delta base size mismatch: expected %d, got %d", baseSize, len(base))
+	}
+	targetSize, pos, err := readSize(delta, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, targetSize)
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+
+		switch {
+		case op&0x80 != 0:
+			// Copy from base: the low bits say which offset/size bytes follow
+			var offset, size int
+			for bit := 0; bit < 4; bit++ {
+				if op&(1<<bit) != 0 {
+					if pos >= len(delta) {
+						return nil, fmt.Errorf("truncated delta copy instruction")
+					}
+					offset |= int(delta[pos]) << (8 * bit)
+					pos++
+				}
+			}
+			for bit := 0; bit < 3; bit++ {
+				if op&(0x10<<bit) != 0 {
+					if pos >= len(delta) {
+						return nil, fmt.Errorf("truncated delta copy instruction")
+					}
+					size |= int(delta[pos]) << (8 * bit)
+					pos++
+				}
+			}
+			if size == 0 {
+				size = maxCopySize
+			}
+			if offset+size > len(base) {
+				return nil, fmt.Errorf("delta copy out of range")
+			}
+			result = append(result, base[offset:offset+size]...)
+		case op != 0:
+			// Insert literal bytes
+			n := int(op)
+			if pos+n > len(delta) {
+				return nil, fmt.Errorf("truncated delta insert instruction")
+			}
+			result = append(result, delta[pos:pos+n]...)
+			pos += n
+		default:
+			return nil, fmt.Errorf("invalid delta instruction")
+		}
+	}
+
+	if len(result) != targetSize {
+		return nil, fmt.Errorf("delta target size mismatch: expected %d, got %d", targetSize, len(result))
+	}
+	return result, nil
+}
+
+// writeCopy emits copy instructions for base[offset:offset+length]
+func writeCopy(out *bytes.Buffer, offset, length int) {
+	for length > 0 {
+		size := length
+		if size > maxCopySize {
+			size = maxCopySize
+		}
+
+		op := byte(0x80)
+		var args []byte
+		for bit := 0; bit < 4; bit++ {
+			if b := byte(offset >> (8 * bit)); b != 0 {
+				op |= 1 << bit
+				args = append(args, b)
+			}
+		}
+		// A size of 0x10000 is encoded as no size bytes at all
+		if size != maxCopySize {
+			for bit := 0; bit < 3; bit++ {
+				if b := byte(size >> (8 * bit)); b != 0 {
+					op |= 0x10 << bit
+					args = append(args, b)
+				}
+			}
+		}
+
+		out.WriteByte(op)
+		out.Write(args)
+
+		offset += size
+		length -= size
+	}
+}
+
+// writeSize writes a little-endian base-128 size as used in delta headers
+func writeSize(out *bytes.Buffer, size int) {
+	for {
+		b := byte(size & 0x7f)
+		size >>= 7
+		if size == 0 {
+			out.WriteByte(b)
+			return
+		}
+		out.WriteByte(b | 0x80)
+	}
+}
+
+// readSize reads a delta header size starting at pos
+func readSize(data []byte, pos int) (int, int, error) {
+	size, shift := 0, 0
+	for {
+		if pos >= len(data) {
+			return 0, 0, fmt.Errorf("truncated delta header")
+		}
+		b := data[pos]
+		pos++
+		size |= int(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return size, pos, nil
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package pack
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// receivedEntry is an entry of a pack being indexed
+type receivedEntry struct {
+	offset   int64
+	crc      uint32
+	code     int
+	data     []byte // Inflated content; the delta itself until resolved
+	base     int64  // OFS_DELTA base offset
+	baseHash string // REF_DELTA base name
+	hash     string // Set once resolved
+}
+
+// Index stores a pack received from a remote in dir, building its index by
+// inflating every entry and resolving its deltas, and returns the pack's
+// name ("pack-<checksum>"). Delta bases must be in the pack itself.
+func Index(dir string, data []byte) (string, error) {
+	if len(data) < 12+20 || !bytes.Equal(data[:4], packSignature) {
+		return "", fmt.Errorf("invalid pack: bad signature")
+	}
+	if version := binary.BigEndian.Uint32(data[4:8]); version != formatVersion {
+		return "", fmt.Errorf("unsupported pack version: %d", version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+
+	body := data[:len(data)-20]
+	var packSum [20]byte
+	copy(packSum[:], data[len(data)-20:])
+	if sha1.Sum(body) != packSum {
+		return "", fmt.Errorf("invalid pack: checksum mismatch")
+	}
+
+	entries := make([]*receivedEntry, 0, count)
+	byOffset := make(map[int64]*receivedEntry, count)
+	pos := int64(12)
+	for i := 0; i < count; i++ {
+		e, next, err := readReceivedEntry(body, pos)
+		if err != nil {
+			return "", fmt.Errorf("invalid pack entry at offset %d: %w", pos, err)
+		}
+		entries = append(entries, e)
+		byOffset[pos] = e
+		pos = next
+	}
+	if pos != int64(len(body)) {
+		return "", fmt.Errorf("invalid pack: %d trailing bytes", int64(len(body))-pos)
+	}
+
+	if err := resolveReceived(entries, byOffset); err != nil {
+		return "", err
+	}
+
+	indexed := make([]*packEntry, len(entries))
+	for i, e := range entries {
+		raw, _ := hex.DecodeString(e.hash)
+		indexed[i] = &packEntry{offset: e.offset, crc: e.crc}
+		copy(indexed[i].hash[:], raw)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create pack directory: %w", err)
+	}
+	name := "pack-" + hex.EncodeToString(packSum[:])
+	if err := writeFileAtomic(filepath.Join(dir, name+".pack"), data); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, name+".idx"), buildIndex(indexed, packSum)); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// readReceivedEntry inflates the entry at pos and returns it along with
+// the offset of the next entry
+func readReceivedEntry(body []byte, pos int64) (*receivedEntry, int64, error) {
+	r := bytes.NewReader(body[pos:])
+	e := &receivedEntry{offset: pos}
+
+	code, size, err := readEntryHeader(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	e.code = code
+
+	switch code {
+	case typeCommit, typeTree, typeBlob, typeTag:
+	case typeOfsDelta:
+		distance, err := readOffset(r)
+		if err != nil {
+			return nil, 0, err
+		}
+		if distance <= 0 || distance > pos {
+			return nil, 0, fmt.Errorf("delta base offset out of range")
+		}
+		e.base = pos - distance
+	case typeRefDelta:
+		var raw [20]byte
+		if _, err := io.ReadFull(r, raw[:]); err != nil {
+			return nil, 0, fmt.Errorf("truncated delta base name")
+		}
+		e.baseHash = hex.EncodeToString(raw[:])
+	default:
+		return nil, 0, fmt.Errorf("unknown pack object type: %d", code)
+	}
+
+	// The reader is an io.ByteReader, so inflating doesn't read past the
+	// end of the entry and r.Len tells where the next one starts
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to inflate: %w", err)
+	}
+	e.data, err = io.ReadAll(zr)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to inflate: %w", err)
+	}
+	if len(e.data) != size {
+		return nil, 0, fmt.Errorf("size mismatch: expected %d, got %d", size, len(e.data))
+	}
+
+	next := int64(len(body)) - int64(r.Len())
+	e.crc = crc32.ChecksumIEEE(body[pos:next])
+	return e, next, nil
+}
+
+// resolveReceived applies every delta and names every entry. Bases usually
+// come before their deltas, so this takes a single pass in practice; REF_DELTA
+// bases that appear later are picked up by another pass.
+func resolveReceived(entries []*receivedEntry, byOffset map[int64]*receivedEntry) error {
+	byHash := make(map[string]*receivedEntry, len(entries))
+	name := func(e *receivedEntry) {
+		typ, _ := typeName(e.code)
+		e.hash = utils.HashObject(typ, e.data)
+		byHash[e.hash] = e
+	}
+
+	pending := 0
+	for _, e := range entries {
+		if e.code == typeOfsDelta || e.code == typeRefDelta {
+			pending++
+			continue
+		}
+		name(e)
+	}
+
+	for pending > 0 {
+		progress := false
+		for _, e := range entries {
+			if e.hash != "" {
+				continue
+			}
+
+			var base *receivedEntry
+			if e.code == typeOfsDelta {
+				base = byOffset[e.base]
+				if base == nil {
+					return fmt.Errorf("delta base at offset %d is not an entry", e.base)
+				}
+			} else {
+				base = byHash[e.baseHash]
+			}
+			if base == nil || base.hash == "" {
+				continue
+			}
+
+			result, err := ApplyDelta(base.data, e.data)
+			if err != nil {
+				return fmt.Errorf("failed to resolve delta at offset %d: %w", e.offset, err)
+			}
+			e.code, e.data = base.code, result
+			name(e)
+			pending--
+			progress = true
+		}
+		if !progress {
+			return fmt.Errorf("invalid pack: %d deltas have no base in the pack", pending)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package pack reads and writes Git packfiles (version 2) and their
+// version 2 index files.
+package pack
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Object type codes used in pack entry headers
+const (
+	typeCommit   = 1
+	typeTree     = 2
+	typeBlob     = 3
+	typeTag      = 4
+	typeOfsDelta = 6
+	typeRefDelta = 7
+)
+
+// ErrNotFound is returned when an object is not in a pack
+var ErrNotFound = errors.New("object not in pack")
+
+var (
+	packSignature = []byte("PACK")
+	idxSignature  = []byte{0xff, 't', 'O', 'c'}
+)
+
+const formatVersion = 2
+
+// Object is a whole (undeltified) object to be written to a pack
+type Object struct {
+	Hash string
+	Type string // "commit", "tree", "blob" or "tag"
+	Data []byte
+}
+
+func typeCode(name string) (int, error) {
+	switch name {
+	case "commit":
+		return typeCommit, nil
+	case "tree":
+		return typeTree, nil
+	case "blob":
+		return typeBlob, nil
+	case "tag":
+		return typeTag, nil
+	}
+	return 0, fmt.Errorf("unknown object type: %s", name)
+}
+
+func typeName(code int) (string, error) {
+	switch code {
+	case typeCommit:
+		return "commit", nil
+	case typeTree:
+		return "tree", nil
+	case typeBlob:
+		return "blob", nil
+	case typeTag:
+		return "tag", nil
+	}
+	return "", fmt.Errorf("unknown pack object type: %d", code)
+}
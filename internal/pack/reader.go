@@ -0,0 +1,260 @@
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Pack is a packfile opened through its version 2 index
+type Pack struct {
+	path    string // Path of the .pack file
+	file    *os.File
+	size    int64
+	hashes  []byte // Sorted 20-byte object names
+	offsets []int64
+}
+
+// Open opens the pack whose index is at idxPath
+func Open(idxPath string) (*Pack, error) {
+	idx, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack index: %w", err)
+	}
+
+	p := &Pack{path: strings.TrimSuffix(idxPath, ".idx") + ".pack"}
+	if err := p.parseIndex(idx); err != nil {
+		return nil, fmt.Errorf("%s: %w", idxPath, err)
+	}
+
+	p.file, err = os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pack: %w", err)
+	}
+	info, err := p.file.Stat()
+	if err != nil {
+		p.file.Close()
+		return nil, fmt.Errorf("failed to stat pack: %w", err)
+	}
+	p.size = info.Size()
+
+	return p, nil
+}
+
+// parseIndex loads object names and offsets from a version 2 index
+func (p *Pack) parseIndex(idx []byte) error {
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:4], idxSignature) {
+		return fmt.Errorf("unsupported pack index format")
+	}
+	if version := binary.BigEndian.Uint32(idx[4:8]); version != formatVersion {
+		return fmt.Errorf("unsupported pack index version: %d", version)
+	}
+
+	count := int(binary.BigEndian.Uint32(idx[8+255*4:]))
+	namesStart := 8 + 256*4
+	crcStart := namesStart + count*20
+	offsetsStart := crcStart + count*4
+	largeStart := offsetsStart + count*4
+	if len(idx) < largeStart+40 {
+		return fmt.Errorf("truncated pack index")
+	}
+
+	p.hashes = idx[namesStart:crcStart]
+	p.offsets = make([]int64, count)
+	for i := 0; i < count; i++ {
+		offset := binary.BigEndian.Uint32(idx[offsetsStart+i*4:])
+		if offset&0x80000000 == 0 {
+			p.offsets[i] = int64(offset)
+			continue
+		}
+		pos := largeStart + int(offset&0x7fffffff)*8
+		if pos+8 > len(idx)-40 {
+			return fmt.Errorf("truncated pack index")
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(idx[pos:]))
+	}
+
+	return nil
+}
+
+// Close closes the packfile
+func (p *Pack) Close() error {
+	return p.file.Close()
+}
+
+// Path returns the path of the .pack file
+func (p *Pack) Path() string {
+	return p.path
+}
+
+// Len returns the number of objects in the pack
+func (p *Pack) Len() int {
+	return len(p.offsets)
+}
+
+// Hash returns the name of the i-th object in hash order
+func (p *Pack) Hash(i int) string {
+	return hex.EncodeToString(p.hashes[i*20 : i*20+20])
+}
+
+// find returns the position of hash in the index, or -1
+func (p *Pack) find(hash string) int {
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) != 20 {
+		return -1
+	}
+	i := sort.Search(p.Len(), func(i int) bool {
+		return bytes.Compare(p.hashes[i*20:i*20+20], raw) >= 0
+	})
+	if i < p.Len() && bytes.Equal(p.hashes[i*20:i*20+20], raw) {
+		return i
+	}
+	return -1
+}
+
+// Contains reports whether the pack holds the object
+func (p *Pack) Contains(hash string) bool {
+	return p.find(hash) >= 0
+}
+
+// HashesWithPrefix returns the names of objects starting with a hex prefix
+func (p *Pack) HashesWithPrefix(prefix string) []string {
+	start := sort.Search(p.Len(), func(i int) bool {
+		return p.Hash(i) >= prefix
+	})
+
+	var matches []string
+	for i := start; i < p.Len(); i++ {
+		hash := p.Hash(i)
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		matches = append(matches, hash)
+	}
+	return matches
+}
+
+// Read returns the type and content of an object, resolving deltas
+func (p *Pack) Read(hash string) (string, []byte, error) {
+	i := p.find(hash)
+	if i < 0 {
+		return "", nil, fmt.Errorf("%w: %s", ErrNotFound, hash)
+	}
+
+	code, data, err := p.readAt(p.offsets[i], 0)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read packed object %s: %w", hash, err)
+	}
+	name, err := typeName(code)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, data, nil
+}
+
+// maxChain bounds delta chains so a corrupt pack can't recurse forever
+const maxChain = 10000
+
+// readAt reads the entry at offset, applying deltas down to a whole object
+func (p *Pack) readAt(offset int64, depth int) (int, []byte, error) {
+	if depth > maxChain {
+		return 0, nil, fmt.Errorf("delta chain too long")
+	}
+	if offset < 12 || offset >= p.size {
+		return 0, nil, fmt.Errorf("invalid pack offset %d", offset)
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(p.file, offset, p.size-offset))
+
+	code, size, err := readEntryHeader(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var baseCode int
+	var baseData []byte
+	switch code {
+	case typeOfsDelta:
+		distance, err := readOffset(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		if baseCode, baseData, err = p.readAt(offset-distance, depth+1); err != nil {
+			return 0, nil, err
+		}
+	case typeRefDelta:
+		var raw [20]byte
+		if _, err := io.ReadFull(r, raw[:]); err != nil {
+			return 0, nil, fmt.Errorf("truncated delta base name")
+		}
+		i := p.find(hex.EncodeToString(raw[:]))
+		if i < 0 {
+			return 0, nil, fmt.Errorf("delta base %x not in pack", raw)
+		}
+		if baseCode, baseData, err = p.readAt(p.offsets[i], depth+1); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to inflate pack entry: %w", err)
+	}
+	defer zr.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return 0, nil, fmt.Errorf("failed to inflate pack entry: %w", err)
+	}
+
+	if code == typeOfsDelta || code == typeRefDelta {
+		result, err := ApplyDelta(baseData, data)
+		if err != nil {
+			return 0, nil, err
+		}
+		return baseCode, result, nil
+	}
+	return code, data, nil
+}
+
+// readEntryHeader reads the type and inflated size of a pack entry
+func readEntryHeader(r io.ByteReader) (int, int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, fmt.Errorf("truncated pack entry")
+	}
+	code := int(b>>4) & 0x7
+	size := int(b & 0x0f)
+	shift := 4
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, 0, fmt.Errorf("truncated pack entry")
+		}
+		size |= int(b&0x7f) << shift
+		shift += 7
+	}
+	return code, size, nil
+}
+
+// readOffset reads the distance back to an OFS_DELTA base
+func readOffset(r io.ByteReader) (int64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("truncated delta offset")
+	}
+	offset := int64(b & 0x7f)
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, fmt.Errorf("truncated delta offset")
+		}
+		offset = ((offset + 1) << 7) | int64(b&0x7f)
+	}
+	return offset, nil
+}
@@ -0,0 +1,237 @@
+package pack
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yourusername/gogit/internal/utils"
+)
+
+// WriteOptions controls the delta search when writing a pack
+type WriteOptions struct {
+	Window int // Number of preceding objects tried as delta bases
+	Depth  int // Maximum length of a delta chain
+}
+
+// Delta search settings matching git's defaults and gc --aggressive
+var (
+	DefaultWriteOptions    = WriteOptions{Window: 10, Depth: 50}
+	AggressiveWriteOptions = WriteOptions{Window: 250, Depth: 50}
+)
+
+// minDeltaSize is the smallest object worth deltifying
+const minDeltaSize = 50
+
+// packEntry is an object as laid out in the pack being written
+type packEntry struct {
+	obj    Object
+	hash   [20]byte
+	offset int64
+	crc    uint32
+	base   *packEntry // Delta base, or nil for a whole object
+	delta  []byte
+	depth  int
+}
+
+// Write writes objects into a new pack and index in dir and returns the
+// pack's name ("pack-<checksum>"). Objects are deltified against similar
+// objects of the same type found within the options' window.
+func Write(dir string, objects []Object, opts WriteOptions) (string, error) {
+	entries := make([]*packEntry, len(objects))
+	for i, obj := range objects {
+		raw, err := hex.DecodeString(obj.Hash)
+		if err != nil || len(raw) != 20 {
+			return "", fmt.Errorf("invalid object hash: %s", obj.Hash)
+		}
+		entries[i] = &packEntry{obj: obj}
+		copy(entries[i].hash[:], raw)
+	}
+
+	// Similar objects end up near each other: group by type, largest first,
+	// so smaller objects are deltified against bigger ones
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].obj, entries[j].obj
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return len(a.Data) > len(b.Data)
+	})
+	findDeltas(entries, opts)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create pack directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(packSignature)
+	binary.Write(&buf, binary.BigEndian, uint32(formatVersion))
+	binary.Write(&buf, binary.BigEndian, uint32(len(entries)))
+
+	for _, e := range entries {
+		if err := writeEntry(&buf, e); err != nil {
+			return "", err
+		}
+	}
+	packSum := sha1.Sum(buf.Bytes())
+	buf.Write(packSum[:])
+
+	name := "pack-" + hex.EncodeToString(packSum[:])
+	if err := writeFileAtomic(filepath.Join(dir, name+".pack"), buf.Bytes()); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, name+".idx"), buildIndex(entries, packSum)); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// findDeltas picks, for each entry, the base within the window that gives
+// the smallest delta, keeping chains within the depth limit
+func findDeltas(entries []*packEntry, opts WriteOptions) {
+	for i, e := range entries {
+		if len(e.obj.Data) < minDeltaSize {
+			continue
+		}
+
+		// Only worth it if the delta is clearly smaller than the object
+		best := len(e.obj.Data) / 2
+		for j := i - 1; j >= 0 && j >= i-opts.Window; j-- {
+			candidate := entries[j]
+			if candidate.obj.Type != e.obj.Type {
+				break
+			}
+			if candidate.depth >= opts.Depth || len(candidate.obj.Data) < minDeltaSize {
+				continue
+			}
+
+			delta := Delta(candidate.obj.Data, e.obj.Data)
+			if len(delta) < best {
+				best = len(delta)
+				e.base, e.delta, e.depth = candidate, delta, candidate.depth+1
+			}
+		}
+	}
+}
+
+// writeEntry appends one pack entry, recording its offset and CRC
+func writeEntry(buf *bytes.Buffer, e *packEntry) error {
+	e.offset = int64(buf.Len())
+
+	var entry bytes.Buffer
+	data := e.obj.Data
+	if e.base != nil {
+		data = e.delta
+		writeEntryHeader(&entry, typeOfsDelta, len(data))
+		writeOffset(&entry, e.offset-e.base.offset)
+	} else {
+		code, err := typeCode(e.obj.Type)
+		if err != nil {
+			return err
+		}
+		writeEntryHeader(&entry, code, len(data))
+	}
+
+	zw := zlib.NewWriter(&entry)
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("failed to compress object %s: %w", e.obj.Hash, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress object %s: %w", e.obj.Hash, err)
+	}
+
+	e.crc = crc32.ChecksumIEEE(entry.Bytes())
+	buf.Write(entry.Bytes())
+	return nil
+}
+
+// writeEntryHeader writes the type and inflated size of a pack entry
+func writeEntryHeader(buf *bytes.Buffer, code, size int) {
+	b := byte(code<<4) | byte(size&0x0f)
+	size >>= 4
+	for size > 0 {
+		buf.WriteByte(b | 0x80)
+		b = byte(size & 0x7f)
+		size >>= 7
+	}
+	buf.WriteByte(b)
+}
+
+// writeOffset writes the distance back to an OFS_DELTA base
+func writeOffset(buf *bytes.Buffer, offset int64) {
+	var tmp [10]byte
+	pos := len(tmp) - 1
+	tmp[pos] = byte(offset & 0x7f)
+	for offset >>= 7; offset > 0; offset >>= 7 {
+		offset--
+		pos--
+		tmp[pos] = 0x80 | byte(offset&0x7f)
+	}
+	buf.Write(tmp[pos:])
+}
+
+// buildIndex returns a version 2 pack index for the written entries
+func buildIndex(entries []*packEntry, packSum [20]byte) []byte {
+	sorted := make([]*packEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].hash[:], sorted[j].hash[:]) < 0
+	})
+
+	var buf bytes.Buffer
+	buf.Write(idxSignature)
+	binary.Write(&buf, binary.BigEndian, uint32(formatVersion))
+
+	// Fan-out: number of objects whose first byte is <= i
+	var fanout [256]uint32
+	for _, e := range sorted {
+		fanout[e.hash[0]]++
+	}
+	for i := 1; i < 256; i++ {
+		fanout[i] += fanout[i-1]
+	}
+	binary.Write(&buf, binary.BigEndian, fanout[:])
+
+	for _, e := range sorted {
+		buf.Write(e.hash[:])
+	}
+	for _, e := range sorted {
+		binary.Write(&buf, binary.BigEndian, e.crc)
+	}
+
+	// Offsets that don't fit in 31 bits go in a separate 64-bit table
+	var large []uint64
+	for _, e := range sorted {
+		if e.offset < 0x80000000 {
+			binary.Write(&buf, binary.BigEndian, uint32(e.offset))
+			continue
+		}
+		binary.Write(&buf, binary.BigEndian, uint32(0x80000000|len(large)))
+		large = append(large, uint64(e.offset))
+	}
+	for _, offset := range large {
+		binary.Write(&buf, binary.BigEndian, offset)
+	}
+
+	buf.Write(packSum[:])
+	idxSum := sha1.Sum(buf.Bytes())
+	buf.Write(idxSum[:])
+
+	return buf.Bytes()
+}
+
+// writeFileAtomic writes a read-only file via a temp file and rename
+func writeFileAtomic(path string, data []byte) error {
+	if err := utils.WriteFileAtomic(path, data, 0444); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
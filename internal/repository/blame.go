@@ -0,0 +1,389 @@
+package repository
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// blameCopyScore is how many letters and digits a run of lines needs before
+// -C believes it was copied from another file rather than matching by chance
+const blameCopyScore = 40
+
+// BlameOptions tunes how far blame looks for the origin of a line
+type BlameOptions struct {
+	// Copies follows lines copied or moved from other files that were
+	// changed in the same commit
+	Copies bool
+}
+
+// BlameEntry is a run of consecutive lines of the blamed file that came
+// from consecutive lines of one commit's version of a file
+type BlameEntry struct {
+	Commit       string
+	Path         string // The file the lines were taken from in Commit
+	OrigLine     int    // First line in Commit's version of Path, from 1
+	FinalLine    int    // First line in the blamed file, from 1
+	NumLines     int
+	Previous     string // The parent Commit's version was compared with, if any
+	PreviousPath string
+}
+
+// blameLine ties a line of the blamed file to the line it is in a
+// suspect's version, both counted from 0
+type blameLine struct {
+	final int
+	orig  int
+}
+
+// blameSuspect is a commit and file that some lines may have come from
+type blameSuspect struct {
+	commit string
+	path   string
+	time   int64
+	seq    int
+	lines  []blameLine
+}
+
+type suspectQueue []*blameSuspect
+
+func (q suspectQueue) Len() int { return len(q) }
+func (q suspectQueue) Less(i, j int) bool {
+	if q[i].time != q[j].time {
+		return q[i].time > q[j].time
+	}
+	return q[i].seq < q[j].seq
+}
+func (q suspectQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *suspectQueue) Push(x any)   { *q = append(*q, x.(*blameSuspect)) }
+func (q *suspectQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// blamer holds the state of one Blame run
+type blamer struct {
+	r       *Repository
+	opts    BlameOptions
+	queue   suspectQueue
+	pending map[string]*blameSuspect // Queued suspects by commit and path
+	seq     int
+}
+
+// Blame finds the commit each line of path, as of commit start, came from.
+// History is walked newest first, and each commit keeps the lines its
+// parents don't have; emit is called with those lines as soon as the
+// commit is done, so entries arrive in history order rather than line
+// order. Lines are followed through renames, and with opts.Copies through
+// copies from other files changed in the same commit.
+func (r *Repository) Blame(start, path string, opts BlameOptions, emit func(BlameEntry) error) error {
+	content, ok, err := r.FileAt(start, path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no such path '%s' in %s", path, start)
+	}
+
+	b := &blamer{r: r, opts: opts, pending: make(map[string]*blameSuspect)}
+	lines := make([]blameLine, len(splitBlameLines(content)))
+	for i := range lines {
+		lines[i] = blameLine{final: i, orig: i}
+	}
+	if err := b.push(start, path, lines); err != nil {
+		return err
+	}
+
+	for b.queue.Len() > 0 {
+		s := heap.Pop(&b.queue).(*blameSuspect)
+		delete(b.pending, s.commit+"\x00"+s.path)
+		if err := b.blameSuspect(s, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// push hands lines to a suspect, queueing it if it isn't already
+func (b *blamer) push(commit, path string, lines []blameLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	key := commit + "\x00" + path
+	if s, ok := b.pending[key]; ok {
+		s.lines = append(s.lines, lines...)
+		return nil
+	}
+
+	node, err := b.r.lookupCommit(commit)
+	if err != nil {
+		return fmt.Errorf("failed to read commit %s: %w", commit, err)
+	}
+	s := &blameSuspect{commit: commit, path: path, time: node.time, seq: b.seq, lines: lines}
+	b.seq++
+	b.pending[key] = s
+	heap.Push(&b.queue, s)
+	return nil
+}
+
+// blameSuspect passes the lines a suspect's parents also have on to them
+// and emits the rest, which the suspect introduced
+func (b *blamer) blameSuspect(s *blameSuspect, emit func(BlameEntry) error) error {
+	content, _, err := b.r.FileAt(s.commit, s.path)
+	if err != nil {
+		return err
+	}
+	ours := splitBlameLines(content)
+
+	commit, err := b.r.Objects.ReadCommit(s.commit)
+	if err != nil {
+		return err
+	}
+
+	remaining := s.lines
+	var previous, previousPath string
+	var changed []diff.FileChange // Files the first parent changed, for -C
+	for i, parent := range commit.Parents {
+		parentCommit, err := b.r.Objects.ReadCommit(parent)
+		if err != nil {
+			return err
+		}
+		changes, err := diff.DiffTrees(b.r.Objects, parentCommit.TreeHash, commit.TreeHash)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			changed = changes
+		}
+
+		parentPath, ok, err := b.parentPath(parent, s.path, changes)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if previous == "" {
+			previous, previousPath = parent, parentPath
+		}
+
+		theirContent, _, err := b.r.FileAt(parent, parentPath)
+		if err != nil {
+			return err
+		}
+		matches := matchLines(splitBlameLines(theirContent), ours)
+		if remaining, err = b.pass(remaining, matches, ours, parent, parentPath, 0); err != nil {
+			return err
+		}
+	}
+
+	if b.opts.Copies && len(remaining) > 0 && len(commit.Parents) > 0 {
+		parent := commit.Parents[0]
+		for _, change := range changed {
+			if change.OldPath == "" || change.OldPath == s.path || change.OldMode == object.ModeGitlink {
+				continue
+			}
+			theirContent, err := b.r.blobContent(change.OldHash)
+			if err != nil {
+				return err
+			}
+			matches := matchLines(splitBlameLines(theirContent), ours)
+			if remaining, err = b.pass(remaining, matches, ours, parent, change.OldPath, blameCopyScore); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, entry := range blameEntries(remaining) {
+		entry.Commit, entry.Path = s.commit, s.path
+		entry.Previous, entry.PreviousPath = previous, previousPath
+		if err := emit(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parentPath finds the path a file had in a parent: the same path, or the
+// old name if the commit renamed it
+func (b *blamer) parentPath(parent, path string, changes []diff.FileChange) (string, bool, error) {
+	var added bool
+	for _, change := range changes {
+		if change.NewPath == path {
+			added = change.Status == diff.StatusAdded
+			break
+		}
+	}
+	if !added {
+		// Unchanged or modified, so the parent has it under the same name
+		return path, true, nil
+	}
+
+	renames, _, err := diff.DetectRenames(b.r.Objects, changes, diff.RenameOptions{MinScore: diff.DefaultRenameScore})
+	if err != nil {
+		return "", false, err
+	}
+	for _, change := range renames {
+		if change.Status == diff.StatusRenamed && change.NewPath == path {
+			return change.OldPath, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// pass hands the lines with a match in a parent's file over to that parent
+// and returns the ones left. With a minimum score, only runs of matching
+// lines with at least that many letters and digits in ours are handed over.
+func (b *blamer) pass(lines []blameLine, matches map[int]int, ours []string, parent, path string, minScore int) ([]blameLine, error) {
+	var kept, passed, run []blameLine
+	flush := func() {
+		if minScore == 0 || alnumCount(run, ours) >= minScore {
+			for _, line := range run {
+				passed = append(passed, blameLine{final: line.final, orig: matches[line.orig]})
+			}
+		} else {
+			kept = append(kept, run...)
+		}
+		run = nil
+	}
+
+	sorted := append([]blameLine(nil), lines...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].orig < sorted[j].orig })
+	for _, line := range sorted {
+		theirs, ok := matches[line.orig]
+		if !ok {
+			flush()
+			kept = append(kept, line)
+			continue
+		}
+		if n := len(run); n > 0 {
+			last := run[n-1]
+			if last.orig+1 != line.orig || matches[last.orig]+1 != theirs {
+				flush()
+			}
+		}
+		run = append(run, line)
+	}
+	flush()
+
+	return kept, b.push(parent, path, passed)
+}
+
+// alnumCount counts the letters and digits on a run of our lines
+func alnumCount(run []blameLine, ours []string) int {
+	n := 0
+	for _, line := range run {
+		for _, r := range ours[line.orig] {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// blameEntries groups lines into entries of consecutive lines
+func blameEntries(lines []blameLine) []BlameEntry {
+	sorted := append([]blameLine(nil), lines...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].final < sorted[j].final })
+
+	var entries []BlameEntry
+	for _, line := range sorted {
+		if n := len(entries); n > 0 {
+			last := &entries[n-1]
+			if last.FinalLine+last.NumLines == line.final+1 && last.OrigLine+last.NumLines == line.orig+1 {
+				last.NumLines++
+				continue
+			}
+		}
+		entries = append(entries, BlameEntry{OrigLine: line.orig + 1, FinalLine: line.final + 1, NumLines: 1})
+	}
+	return entries
+}
+
+// matchLines maps each line of ours that is unchanged from theirs to its
+// line number there
+func matchLines(theirs, ours []string) map[int]int {
+	matches := make(map[int]int)
+	if len(theirs) == 0 || len(ours) == 0 {
+		return matches
+	}
+	// Every line gets its newline back, so the last ones compare like the rest
+	for _, c := range diff.Diff(strings.Join(theirs, "\n")+"\n", strings.Join(ours, "\n")+"\n") {
+		if c.Type == diff.ChangeEqual {
+			matches[c.NewLine-1] = c.OldLine - 1
+		}
+	}
+	return matches
+}
+
+// splitBlameLines splits content into lines without their newlines
+func splitBlameLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// FileAt returns the content of the file at path in a commit, reporting
+// false if the commit has no such file
+func (r *Repository) FileAt(commitHash, path string) (string, bool, error) {
+	commit, err := r.Objects.ReadCommit(commitHash)
+	if err != nil {
+		return "", false, err
+	}
+
+	entry, ok, err := r.EntryAt(commit.TreeHash, path)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	content, err := r.blobContent(entry.Hash)
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
+}
+
+// EntryAt looks up the entry at a slash-separated path in a tree, reporting
+// false if there is none. The path "." names the tree itself.
+func (r *Repository) EntryAt(treeHash, path string) (object.TreeEntry, bool, error) {
+	entry := object.TreeEntry{Mode: object.ModeTree, Hash: treeHash}
+	if path == "." || path == "" {
+		return entry, true, nil
+	}
+	for _, name := range strings.Split(path, "/") {
+		if !entry.IsTree() {
+			return object.TreeEntry{}, false, nil
+		}
+		tree, err := r.Objects.ReadTree(entry.Hash)
+		if err != nil {
+			return object.TreeEntry{}, false, err
+		}
+		found := tree.GetEntryByName(name)
+		if found == nil {
+			return object.TreeEntry{}, false, nil
+		}
+		entry = *found
+	}
+	return entry, true, nil
+}
+
+// blobContent returns the content of a blob
+func (r *Repository) blobContent(hash string) (string, error) {
+	obj, err := r.Objects.Read(hash)
+	if err != nil {
+		return "", err
+	}
+	blob, ok := obj.(*object.Blob)
+	if !ok {
+		return "", fmt.Errorf("object %s is a %s, not a blob", hash, obj.Type())
+	}
+	return string(blob.Content()), nil
+}
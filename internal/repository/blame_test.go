@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestBlameLinearHistory(t *testing.T) {
+	tr := newTestRepo(t)
+	c1 := tr.commit("one", map[string]string{"f": "one\n"})
+	c2 := tr.commit("two", map[string]string{"f": "one\ntwo\n"}, c1)
+	c3 := tr.commit("three", map[string]string{"f": "one\ntwo\nthree\n"}, c2)
+
+	var entries []BlameEntry
+	err := tr.Blame(c3, "f", BlameOptions{}, func(e BlameEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].FinalLine < entries[j].FinalLine })
+
+	want := []BlameEntry{
+		{Commit: c1, Path: "f", OrigLine: 1, FinalLine: 1, NumLines: 1},
+		{Commit: c2, Path: "f", OrigLine: 2, FinalLine: 2, NumLines: 1, Previous: c1, PreviousPath: "f"},
+		{Commit: c3, Path: "f", OrigLine: 3, FinalLine: 3, NumLines: 1, Previous: c2, PreviousPath: "f"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries %+v, want %d", len(entries), entries, len(want))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("line %d: got %+v, want %+v", i+1, entries[i], want[i])
+		}
+	}
+}
+
+func TestBlameLastLineChanged(t *testing.T) {
+	tr := newTestRepo(t)
+	c1 := tr.commit("one", map[string]string{"f": "a\nb\n"})
+	c2 := tr.commit("two", map[string]string{"f": "a\nc\n"}, c1)
+
+	got := make(map[int]string)
+	err := tr.Blame(c2, "f", BlameOptions{}, func(e BlameEntry) error {
+		for n := 0; n < e.NumLines; n++ {
+			got[e.FinalLine+n] = e.Commit
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[1] != c1 || got[2] != c2 {
+		t.Errorf("got line 1 from %s and line 2 from %s, want %s and %s", got[1], got[2], c1, c2)
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/yourusername/gogit/internal/commitgraph"
+)
+
+// commitNode is the part of a commit that history walks need
+type commitNode struct {
+	parents    []string
+	time       int64
+	generation uint32 // 0 when the commit isn't in the commit-graph
+}
+
+// CommitGraphPath returns where the commit-graph file is stored
+func (r *Repository) CommitGraphPath() string {
+	return filepath.Join(r.GitDir, "objects", "info", "commit-graph")
+}
+
+// WriteCommitGraph writes a commit-graph covering every reachable commit
+// and returns the number of commits it contains
+func (r *Repository) WriteCommitGraph() (int, error) {
+	if len(r.Grafts()) > 0 {
+		return 0, fmt.Errorf("not supported in a repository with grafts or shallow commits")
+	}
+
+	hashes, err := r.ReachableCommits()
+	if err != nil {
+		return 0, err
+	}
+
+	commits := make([]commitgraph.Commit, 0, len(hashes))
+	for _, hash := range hashes {
+		commit, err := r.Objects.ReadCommit(hash)
+		if err != nil {
+			return 0, err
+		}
+		commits = append(commits, commitgraph.Commit{
+			Hash:    hash,
+			Tree:    commit.TreeHash,
+			Parents: commit.Parents,
+			Time:    commit.Committer.When.Unix(),
+		})
+	}
+
+	if err := commitgraph.Write(r.CommitGraphPath(), commits); err != nil {
+		return 0, err
+	}
+
+	// Pick up the new graph on next use
+	r.graphOnce = sync.Once{}
+	r.graph = nil
+	return len(commits), nil
+}
+
+// commitGraph loads the commit-graph on first use. A missing or unreadable
+// graph just means every lookup falls back to reading objects.
+func (r *Repository) commitGraph() *commitgraph.Graph {
+	r.graphOnce.Do(func() {
+		// The graph describes commits as stored, not as replaced or grafted
+		if r.Objects.HasReplacements() || len(r.Grafts()) > 0 {
+			return
+		}
+		if g, err := commitgraph.Open(r.CommitGraphPath()); err == nil {
+			r.graph = g
+		}
+	})
+	return r.graph
+}
+
+// lookupCommit returns a commit's parents and date, from the commit-graph
+// when it covers the commit and from the commit object otherwise
+func (r *Repository) lookupCommit(hash string) (commitNode, error) {
+	if g := r.commitGraph(); g != nil {
+		if c, ok := g.Lookup(hash); ok {
+			return commitNode{parents: c.Parents, time: c.Time, generation: c.Generation}, nil
+		}
+	}
+
+	commit, err := r.ReadHistoryCommit(hash)
+	if err != nil {
+		return commitNode{}, err
+	}
+	return commitNode{parents: commit.Parents, time: commit.Committer.When.Unix()}, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+)
+
+// forkedHistory builds a main line of n commits with a ten-commit side
+// branch forking from its middle, and returns the fork point and the tips
+func forkedHistory(tr *testRepo, n int) (fork, mainTip, sideTip string) {
+	files := map[string]string{"f": "content\n"}
+	for i := 0; i < n; i++ {
+		var parents []string
+		if mainTip != "" {
+			parents = []string{mainTip}
+		}
+		mainTip = tr.commit(fmt.Sprint("main ", i), files, parents...)
+		if i == n/2 {
+			fork = mainTip
+		}
+	}
+	sideTip = fork
+	for i := 0; i < 10; i++ {
+		sideTip = tr.commit(fmt.Sprint("side ", i), map[string]string{"f": fmt.Sprint("side ", i, "\n")}, sideTip)
+	}
+	// Leave HEAD on main so that both tips are reachable
+	if err := tr.Refs.UpdateRef("refs/heads/side", sideTip); err != nil {
+		tr.t.Fatal(err)
+	}
+	if err := tr.Refs.UpdateRef("refs/heads/main", mainTip); err != nil {
+		tr.t.Fatal(err)
+	}
+	return fork, mainTip, sideTip
+}
+
+func TestCommitGraphAnswersMatchObjects(t *testing.T) {
+	tr := newTestRepo(t)
+	fork, mainTip, sideTip := forkedHistory(tr, 50)
+
+	check := func(r *Repository) {
+		t.Helper()
+		base, err := r.MergeBase(mainTip, sideTip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if base != fork {
+			t.Errorf("merge base = %s, want %s", base, fork)
+		}
+		for _, c := range []struct {
+			ancestor, descendant string
+			want                 bool
+		}{
+			{fork, mainTip, true},
+			{fork, sideTip, true},
+			{sideTip, mainTip, false},
+			{mainTip, sideTip, false},
+		} {
+			got, err := r.IsAncestor(c.ancestor, c.descendant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("IsAncestor(%s, %s) = %v, want %v", c.ancestor, c.descendant, got, c.want)
+			}
+		}
+	}
+
+	check(tr.Repository)
+	n, err := tr.WriteCommitGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 60 {
+		t.Errorf("commit-graph has %d commits, want 60", n)
+	}
+	r, err := Open(tr.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.commitGraph() == nil {
+		t.Fatal("commit-graph was not loaded")
+	}
+	node, err := r.lookupCommit(mainTip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.generation != 50 {
+		t.Errorf("main tip has generation %d, want 50", node.generation)
+	}
+	check(r)
+}
+
+// BenchmarkCommitGraph finds a merge base and answers an ancestry query on
+// a 5000-commit history, reading commit objects and using the commit-graph
+func BenchmarkCommitGraph(b *testing.B) {
+	tr := newTestRepo(b)
+	_, mainTip, sideTip := forkedHistory(tr, 5000)
+
+	run := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			// A fresh repository each time, so no parsed commit is cached
+			r, err := Open(tr.Path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := r.MergeBase(mainTip, sideTip); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := r.IsAncestor(sideTip, mainTip); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("objects", run)
+	if _, err := tr.WriteCommitGraph(); err != nil {
+		b.Fatal(err)
+	}
+	b.Run("commit-graph", run)
+}
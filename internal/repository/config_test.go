@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetConfig(t *testing.T) {
+	tr := newTestRepo(t)
+	configPath := filepath.Join(tr.GitDir, "config")
+	content := `# written by hand
+[core]
+	filemode = false
+	  autocrlf=input   ; trailing comment
+[Remote "Origin"]
+	URL = https://example.com/repo.git
+[user]
+	name = "Quoted  Name"
+[core]
+	filemode = true
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"core.filemode":     "true", // The last value wins
+		"core.autocrlf":     "input",
+		"CORE.AutoCRLF":     "input",
+		"remote.Origin.url": "https://example.com/repo.git",
+		"user.name":         "Quoted  Name",
+	} {
+		got, err := tr.GetConfig(key)
+		if err != nil || got != want {
+			t.Errorf("GetConfig(%q) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+	for _, key := range []string{"core.bare", "remote.origin.url", "nosection"} {
+		if _, err := tr.GetConfig(key); !errors.Is(err, ErrConfigNotFound) {
+			t.Errorf("GetConfig(%q): %v, want ErrConfigNotFound", key, err)
+		}
+	}
+
+	// A missing file has no keys; one that can't be read is an error
+	if err := os.Remove(configPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.GetConfig("core.filemode"); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("GetConfig without a config file: %v, want ErrConfigNotFound", err)
+	}
+	if err := os.Mkdir(configPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.GetConfig("core.filemode"); err == nil || errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("GetConfig with an unreadable config file: %v, want a read error", err)
+	}
+}
+
+func TestGetUserInfoPrefersConfig(t *testing.T) {
+	tr := newTestRepo(t)
+	t.Setenv("GIT_AUTHOR_NAME", "Env Name")
+	t.Setenv("GIT_AUTHOR_EMAIL", "env@example.com")
+
+	if got, err := tr.GetUserInfo(); err != nil || got != "Test <test@example.com>" {
+		t.Errorf("GetUserInfo with user.name and user.email set: %q, %v", got, err)
+	}
+	if err := tr.UnsetConfig("user.email"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := tr.GetUserInfo(); err != nil || got != "Test <env@example.com>" {
+		t.Errorf("GetUserInfo with only user.name set: %q, %v", got, err)
+	}
+	if err := tr.UnsetConfig("user.name"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := tr.GetUserInfo(); err != nil || got != "Env Name <env@example.com>" {
+		t.Errorf("GetUserInfo from the environment: %q, %v", got, err)
+	}
+}
+
+func TestGetUserInfoRejectsBrokenIdentities(t *testing.T) {
+	tr := newTestRepo(t)
+	for _, c := range []struct{ key, value string }{
+		{"user.name", "Ann <ann@example.com>"},
+		{"user.name", "Two\nLines"},
+		{"user.email", "no-at-sign"},
+		{"user.email", "<ann@example.com>"},
+		{"user.email", "ann @example.com"},
+	} {
+		if err := tr.SetConfig(c.key, c.value); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := tr.GetUserInfo(); err == nil {
+			t.Errorf("GetUserInfo with %s %q: %q, want an error", c.key, c.value, got)
+		}
+		// Put the good identity back for the next case
+		if err := tr.SetConfig("user.name", "Test"); err != nil {
+			t.Fatal(err)
+		}
+		if err := tr.SetConfig("user.email", "test@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without any identity configured the login and host name are used
+	for _, key := range []string{"user.name", "user.email"} {
+		if err := tr.UnsetConfig(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "")
+	t.Setenv("GIT_AUTHOR_EMAIL", "")
+	t.Setenv("USER", "login")
+	hostname, _ := os.Hostname()
+	if got, err := tr.GetUserInfo(); err != nil || got != "login <login@"+hostname+">" {
+		t.Errorf("GetUserInfo with no identity: %q, %v", got, err)
+	}
+}
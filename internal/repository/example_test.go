@@ -0,0 +1,88 @@
+package repository_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/repository"
+)
+
+// exampleRepo makes an empty repository in a temporary directory
+func exampleRepo() (*repository.Repository, func()) {
+	root, err := os.MkdirTemp("", "gogit-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	gitDir := filepath.Join(root, ".gogit")
+	for _, dir := range []string{"objects", "refs/heads", "refs/tags"} {
+		if err := os.MkdirAll(filepath.Join(gitDir, dir), 0755); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+		log.Fatal(err)
+	}
+	repo, err := repository.Open(root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return repo, func() { os.RemoveAll(root) }
+}
+
+// exampleCommit stores an empty commit made at the given minute past noon
+func exampleCommit(repo *repository.Repository, message string, minute int, parents ...string) string {
+	tree, err := object.WriteObject(repo.Path, object.NewTree())
+	if err != nil {
+		log.Fatal(err)
+	}
+	commit := object.NewCommit(tree, parents, "Example <example@example.com>", message+"\n")
+	commit.Author.When = time.Date(2024, 1, 1, 12, minute, 0, 0, time.UTC)
+	commit.Committer.When = commit.Author.When
+	hash, err := object.WriteObject(repo.Path, commit)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return hash
+}
+
+func ExampleRepository_Log() {
+	repo, cleanup := exampleRepo()
+	defer cleanup()
+
+	// main: initial - readme - merge
+	//          \              /
+	// topic:    `-- feature -'
+	initial := exampleCommit(repo, "Initial commit", 0)
+	feature := exampleCommit(repo, "Add feature", 1, initial)
+	readme := exampleCommit(repo, "Update readme", 2, initial)
+	merge := exampleCommit(repo, "Merge topic", 3, readme, feature)
+
+	show := func(entry repository.LogEntry) error {
+		fmt.Println(strings.TrimSuffix(entry.Commit.Message, "\n"))
+		return nil
+	}
+
+	fmt.Println("all:")
+	if err := repo.Log([]string{merge}, repository.LogOptions{}, show); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("first parent, at most 2:")
+	if err := repo.Log([]string{merge}, repository.LogOptions{FirstParent: true, MaxCount: 2}, show); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// all:
+	// Merge topic
+	// Update readme
+	// Add feature
+	// Initial commit
+	// first parent, at most 2:
+	// Merge topic
+	// Update readme
+}
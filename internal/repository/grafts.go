@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// Grafts returns the commits whose parents history walks take from
+// somewhere other than the commit itself, mapped to the parents to use
+// instead. Those come from info/grafts, where each line lists a commit and
+// then its parents, and from the shallow file of a shallow clone, whose
+// commits have their history cut off and so are given none. A shallow
+// commit overrides a graft of the same commit, and malformed lines are
+// skipped.
+func (r *Repository) Grafts() map[string][]string {
+	r.graftsOnce.Do(func() {
+		grafts := make(map[string][]string)
+		readGraftFile(filepath.Join(r.GitDir, "info", "grafts"), func(fields []string) {
+			grafts[fields[0]] = fields[1:]
+		})
+		readGraftFile(filepath.Join(r.GitDir, "shallow"), func(fields []string) {
+			if len(fields) == 1 {
+				grafts[fields[0]] = nil
+			}
+		})
+		r.grafts = grafts
+	})
+	return r.grafts
+}
+
+// readGraftFile calls fn with the hashes on each well-formed line of a
+// grafts or shallow file. A missing file has no lines.
+func readGraftFile(path string, fn func(fields []string)) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		valid := true
+		for _, field := range fields {
+			if !object.IsHash(field) {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			fn(fields)
+		}
+	}
+}
+
+// grafted returns a commit as history walks see it: with the parents its
+// graft gives it, if it has one. The commit itself is left as read.
+func (r *Repository) grafted(hash string, commit *object.Commit) *object.Commit {
+	parents, ok := r.Grafts()[hash]
+	if !ok {
+		return commit
+	}
+	c := *commit
+	c.Parents = parents
+	return &c
+}
+
+// ReadHistoryCommit reads a commit for walking history, with grafted
+// parents in place of its own
+func (r *Repository) ReadHistoryCommit(hash string) (*object.Commit, error) {
+	commit, err := r.Objects.ReadCommit(hash)
+	if err != nil {
+		return nil, err
+	}
+	return r.grafted(hash, commit), nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGrafts(t *testing.T) {
+	tr := newTestRepo(t)
+	unrelated := tr.commit("unrelated", map[string]string{"u": "u\n"})
+	first := tr.commit("first", map[string]string{"f": "1\n"})
+	second := tr.commit("second", map[string]string{"f": "2\n"}, first)
+	names := map[string]string{unrelated: "unrelated", first: "first", second: "second"}
+
+	// Grafts are read once per Open, so each case opens the repository anew
+	open := func(grafts, shallow string) *Repository {
+		t.Helper()
+		for file, content := range map[string]string{"info/grafts": grafts, "shallow": shallow} {
+			path := filepath.Join(tr.GitDir, file)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		repo, err := Open(tr.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	}
+
+	check := func(desc, grafts, shallow, wantLog, wantBase string) {
+		t.Helper()
+		repo := open(grafts, shallow)
+		var walked []string
+		err := repo.Log([]string{second}, LogOptions{}, func(entry LogEntry) error {
+			walked = append(walked, names[entry.Hash])
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(walked, ","); got != wantLog {
+			t.Errorf("%s: log walked %s, want %s", desc, got, wantLog)
+		}
+
+		base, err := repo.MergeBase(second, unrelated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if names[base] != wantBase {
+			t.Errorf("%s: merge base of second and unrelated is %q, want %q", desc, names[base], wantBase)
+		}
+	}
+
+	check("no grafts", "", "", "second,first", "")
+	check("graft", "# comment\n"+first+" "+unrelated+"\nnot a graft\n", "", "second,first,unrelated", "unrelated")
+	check("graft onto a root", second+" "+unrelated+"\n", "", "second,unrelated", "unrelated")
+	check("shallow", first+" "+unrelated+"\n", first+"\n", "second,first", "")
+
+	repo := open(first+" "+unrelated+"\n", "")
+	if hash, err := repo.ResolveRevision(second + "~2"); err != nil || hash != unrelated {
+		t.Errorf("second~2 through the graft: %s, %v, want unrelated", names[hash], err)
+	}
+}
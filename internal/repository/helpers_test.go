@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// testEpoch is when the first test commit is made; each later one is a
+// minute newer, so history order never depends on the clock
+var testEpoch = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// testRepo is an empty repository in a temporary directory, with helpers
+// to build history in it without a working tree or index
+type testRepo struct {
+	*Repository
+	t       testing.TB
+	commits int
+}
+
+func newTestRepo(t testing.TB) *testRepo {
+	t.Helper()
+	root := t.TempDir()
+	gitDir := filepath.Join(root, ".gogit")
+	for _, dir := range []string{"objects", "refs/heads", "refs/tags"} {
+		if err := os.MkdirAll(filepath.Join(gitDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := "[user]\n\tname = Test\n\temail = test@example.com\n"
+	if err := os.WriteFile(filepath.Join(gitDir, "config"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRepo{Repository: repo, t: t}
+}
+
+// writeTree stores files, keyed by slash-separated path, as a tree and
+// returns its hash
+func (tr *testRepo) writeTree(files map[string]string) string {
+	tr.t.Helper()
+	tree := object.NewTree()
+	subdirs := make(map[string]map[string]string)
+	for path, content := range files {
+		if dir, rest, ok := strings.Cut(path, "/"); ok {
+			if subdirs[dir] == nil {
+				subdirs[dir] = make(map[string]string)
+			}
+			subdirs[dir][rest] = content
+			continue
+		}
+		hash, err := object.WriteObject(tr.Path, object.NewBlob([]byte(content)))
+		if err != nil {
+			tr.t.Fatal(err)
+		}
+		tree.AddEntry(object.ModeFile, path, hash)
+	}
+
+	names := make([]string, 0, len(subdirs))
+	for dir := range subdirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+	for _, dir := range names {
+		tree.AddEntry(object.ModeTree, dir, tr.writeTree(subdirs[dir]))
+	}
+
+	hash, err := object.WriteObject(tr.Path, tree)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return hash
+}
+
+// commit stores files as a commit with the given parents, points the
+// current branch at it and returns its hash
+func (tr *testRepo) commit(message string, files map[string]string, parents ...string) string {
+	tr.t.Helper()
+	commit := object.NewCommit(tr.writeTree(files), parents, "Test <test@example.com>", message+"\n")
+	commit.Author.When = testEpoch.Add(time.Duration(tr.commits) * time.Minute)
+	commit.Committer.When = commit.Author.When
+	tr.commits++
+
+	hash, err := object.WriteObject(tr.Path, commit)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	if err := tr.Refs.UpdateHead(hash); err != nil {
+		tr.t.Fatal(err)
+	}
+	return hash
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yourusername/gogit/internal/diff"
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// ErrStopLog can be returned by a Log callback to end the walk early
+// without Log returning an error
+var ErrStopLog = errors.New("stop log")
+
+// LogOptions controls which commits Log visits
+type LogOptions struct {
+	MaxCount    int      // Stop after this many commits; 0 for no limit
+	FirstParent bool     // Follow only the first parent of merges
+	Paths       []string // Only visit commits that changed these paths, relative to the top
+	Follow      bool     // Paths holds one file, followed across renames
+}
+
+// LogEntry is a commit visited by Log
+type LogEntry struct {
+	Hash   string
+	Commit *object.Commit
+	Paths  []string         // The paths as this commit names them, which differ from the given ones past a followed rename
+	Rename *diff.FileChange // With Follow, the rename that gave the file its later name in this commit
+}
+
+// Log walks the history reachable from the given commits newest first,
+// calling fn with each commit it visits. With paths, only the commits that
+// changed them are visited, simplifying history the way git does: a merge
+// that left the paths as one of its parents had them only has that
+// parent's history walked.
+func (r *Repository) Log(starts []string, opts LogOptions, fn func(LogEntry) error) error {
+	if opts.Follow && len(opts.Paths) != 1 {
+		return fmt.Errorf("--follow requires exactly one pathspec")
+	}
+
+	walker, err := r.WalkCommits(starts)
+	if err != nil {
+		return err
+	}
+	if opts.FirstParent {
+		walker.FirstParent()
+	}
+
+	var limiter *pathLimiter
+	if len(opts.Paths) > 0 {
+		limiter = &pathLimiter{repo: r, paths: opts.Paths, follow: opts.Follow, firstParent: opts.FirstParent}
+	}
+
+	count := 0
+	for opts.MaxCount <= 0 || count < opts.MaxCount {
+		hash, commit, err := walker.Next()
+		if err != nil {
+			return err
+		}
+		if commit == nil {
+			return nil
+		}
+
+		entry := LogEntry{Hash: hash, Commit: commit}
+		if limiter != nil {
+			entry.Paths = limiter.paths
+			changed, parents, rename, err := limiter.visit(commit)
+			if err != nil {
+				return err
+			}
+			walker.Follow(parents)
+			if !changed {
+				continue
+			}
+			entry.Rename = rename
+		}
+
+		if err := fn(entry); err != nil {
+			if errors.Is(err, ErrStopLog) {
+				return nil
+			}
+			return err
+		}
+		count++
+	}
+	return nil
+}
+
+// pathLimiter picks out the commits that changed some paths, simplifying
+// history the way git does: a commit that left the paths as one of its
+// parents had them is skipped, and only that parent's history is walked
+type pathLimiter struct {
+	repo        *Repository
+	paths       []string
+	follow      bool // paths holds one file, followed to its old name when renamed
+	firstParent bool // Merges are compared with their first parent only
+}
+
+// visit reports whether a commit changed the paths, and which of its
+// parents the walk should go on to. With follow, a commit that created the
+// file by renaming another is also returned as a rename, and older commits
+// are searched for the old name.
+func (l *pathLimiter) visit(commit *object.Commit) (bool, []string, *diff.FileChange, error) {
+	parents := commit.Parents
+	if l.firstParent && len(parents) > 1 {
+		parents = parents[:1]
+	}
+	if len(parents) == 0 {
+		same, err := l.sameIn(commit.TreeHash, "")
+		return !same, nil, nil, err
+	}
+
+	parentTrees := make([]string, len(parents))
+	for i, parent := range parents {
+		parentCommit, err := l.repo.Objects.ReadCommit(parent)
+		if err != nil {
+			return false, nil, nil, fmt.Errorf("failed to read commit %s: %w", parent, err)
+		}
+		parentTrees[i] = parentCommit.TreeHash
+
+		same, err := l.sameIn(commit.TreeHash, parentCommit.TreeHash)
+		if err != nil {
+			return false, nil, nil, err
+		}
+		if same {
+			return false, []string{parent}, nil, nil
+		}
+	}
+
+	if !l.follow {
+		return true, parents, nil, nil
+	}
+	if _, existed, err := l.repo.EntryAt(parentTrees[0], l.paths[0]); err != nil || existed {
+		return true, parents, nil, err
+	}
+	changes, err := diff.DiffTrees(l.repo.Objects, parentTrees[0], commit.TreeHash)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	renames, _, err := diff.DetectRenames(l.repo.Objects, changes, diff.RenameOptions{MinScore: diff.DefaultRenameScore})
+	if err != nil {
+		return false, nil, nil, err
+	}
+	for _, change := range renames {
+		if change.Status == diff.StatusRenamed && change.NewPath == l.paths[0] {
+			l.paths = []string{change.OldPath}
+			return true, parents, &change, nil
+		}
+	}
+	return true, parents, nil, nil
+}
+
+// sameIn reports whether every path is the same in both trees, or missing
+// from both. An empty tree hash stands for a tree with nothing in it.
+func (l *pathLimiter) sameIn(treeA, treeB string) (bool, error) {
+	for _, path := range l.paths {
+		a, okA, err := l.entryAt(treeA, path)
+		if err != nil {
+			return false, err
+		}
+		b, okB, err := l.entryAt(treeB, path)
+		if err != nil {
+			return false, err
+		}
+		if okA != okB || a.Hash != b.Hash || a.Mode != b.Mode {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (l *pathLimiter) entryAt(tree, path string) (object.TreeEntry, bool, error) {
+	if tree == "" {
+		return object.TreeEntry{}, false, nil
+	}
+	return l.repo.EntryAt(tree, path)
+}
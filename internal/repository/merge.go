@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ancestors returns every commit reachable from hash, including hash itself
+func (r *Repository) ancestors(hash string) (map[string]bool, error) {
+	seen := map[string]bool{hash: true}
+	queue := []string{hash}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		node, err := r.lookupCommit(current)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range node.parents {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	return seen, nil
+}
+
+// IsAncestor reports whether ancestor is reachable from descendant.
+// A commit is considered its own ancestor.
+func (r *Repository) IsAncestor(ancestor, descendant string) (bool, error) {
+	target, err := r.lookupCommit(ancestor)
+	if err != nil {
+		return false, err
+	}
+
+	seen := map[string]bool{descendant: true}
+	queue := []string{descendant}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == ancestor {
+			return true, nil
+		}
+
+		node, err := r.lookupCommit(current)
+		if err != nil {
+			return false, err
+		}
+		// A commit can only reach commits of lower generation, so with
+		// generation numbers from the commit-graph whole subtrees are skipped
+		if target.generation != 0 && node.generation != 0 && node.generation <= target.generation {
+			continue
+		}
+		for _, parent := range node.parents {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// MergeBase returns a best common ancestor of two commits, or "" if their
+// histories are unrelated. The history of b is walked newest first, so the
+// first commit also reachable from a is the most recent common ancestor.
+func (r *Repository) MergeBase(a, b string) (string, error) {
+	fromA, err := r.ancestors(a)
+	if err != nil {
+		return "", err
+	}
+
+	first, err := r.lookupCommit(b)
+	if err != nil {
+		return "", err
+	}
+	seen := map[string]bool{b: true}
+	pending := map[string]commitNode{b: first}
+	for len(pending) > 0 {
+		// Pick the newest pending commit
+		var current string
+		var newest commitNode
+		for hash, node := range pending {
+			if current == "" || node.time > newest.time || (node.time == newest.time && hash < current) {
+				current, newest = hash, node
+			}
+		}
+		delete(pending, current)
+
+		if fromA[current] {
+			return current, nil
+		}
+
+		for _, parent := range newest.parents {
+			if seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			node, err := r.lookupCommit(parent)
+			if err != nil {
+				return "", err
+			}
+			pending[parent] = node
+		}
+	}
+
+	return "", nil
+}
+
+// MergeHead returns the commit being merged in, or "" when no merge is in progress
+func (r *Repository) MergeHead() (string, error) {
+	content, err := os.ReadFile(filepath.Join(r.GitDir, "MERGE_HEAD"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read MERGE_HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// MergeMessage returns the prepared merge commit message
+func (r *Repository) MergeMessage() (string, error) {
+	content, err := os.ReadFile(filepath.Join(r.GitDir, "MERGE_MSG"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read MERGE_MSG: %w", err)
+	}
+	return string(content), nil
+}
+
+// WriteMergeState records an in-progress merge of mergeHead
+func (r *Repository) WriteMergeState(mergeHead, message string) error {
+	gogitDir := r.GitDir
+	if err := os.WriteFile(filepath.Join(gogitDir, "MERGE_HEAD"), []byte(mergeHead+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write MERGE_HEAD: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(gogitDir, "MERGE_MSG"), []byte(message), 0644); err != nil {
+		return fmt.Errorf("failed to write MERGE_MSG: %w", err)
+	}
+	return nil
+}
+
+// SquashMessage returns the message prepared by a squash merge for the
+// commit that concludes it, or "" if there is none
+func (r *Repository) SquashMessage() (string, error) {
+	content, err := os.ReadFile(filepath.Join(r.GitDir, "SQUASH_MSG"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read SQUASH_MSG: %w", err)
+	}
+	return string(content), nil
+}
+
+// WriteSquashMessage records the message for committing a squash merge
+func (r *Repository) WriteSquashMessage(message string) error {
+	if err := os.WriteFile(filepath.Join(r.GitDir, "SQUASH_MSG"), []byte(message), 0644); err != nil {
+		return fmt.Errorf("failed to write SQUASH_MSG: %w", err)
+	}
+	return nil
+}
+
+// ClearMergeState removes the files recording an in-progress merge or
+// squash merge
+func (r *Repository) ClearMergeState() error {
+	for _, name := range []string{"MERGE_HEAD", "MERGE_MSG", "SQUASH_MSG"} {
+		if err := os.Remove(filepath.Join(r.GitDir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// CommitRange returns the commits reachable from to but not from from, like
+// "from..to", oldest first
+func (r *Repository) CommitRange(from, to string) ([]string, error) {
+	excluded := map[string]bool{}
+	if from != "" {
+		var err error
+		if excluded, err = r.ancestors(from); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []string
+	times := make(map[string]int64)
+	seen := map[string]bool{to: true}
+	queue := []string{to}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if excluded[current] {
+			continue
+		}
+
+		node, err := r.lookupCommit(current)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, current)
+		times[current] = node.time
+		for _, parent := range node.parents {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	// The walk visits children before parents; reversed, that order breaks
+	// ties between commits made in the same second
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	sort.SliceStable(result, func(i, j int) bool { return times[result[i]] < times[result[j]] })
+	return result, nil
+}
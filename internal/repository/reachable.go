@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/yourusername/gogit/internal/index"
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// ReachableObjects returns every object reachable from the refs, HEAD, the
+// ORIG_HEAD and MERGE_HEAD pseudo-refs, the index and the reflogs, each
+// exactly once.
+// In a partial clone, objects left to the promisor remote are skipped
+// rather than fetched. Grafted commits lead to their grafted parents, so the
+// history a shallow clone lacks isn't looked for.
+func (r *Repository) ReachableObjects() ([]string, error) {
+	roots, err := r.reachabilityRoots()
+	if err != nil {
+		return nil, err
+	}
+	promisor, err := r.PromisorRemote()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	stack := roots
+
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		if promisor != nil && !r.Objects.Exists(hash) {
+			continue
+		}
+		result = append(result, hash)
+
+		objType, _, err := r.Objects.Info(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		switch objType {
+		case object.TypeCommit:
+			commit, err := r.ReadHistoryCommit(hash)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, commit.TreeHash)
+			stack = append(stack, commit.Parents...)
+		case object.TypeTree:
+			tree, err := r.Objects.ReadTree(hash)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range tree.Entries {
+				// Submodule commits live in another repository
+				if entry.Mode != object.ModeGitlink {
+					stack = append(stack, entry.Hash)
+				}
+			}
+		case object.TypeTag:
+			obj, err := r.Objects.Read(hash)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, obj.(*object.Tag).Object)
+		}
+	}
+
+	return result, nil
+}
+
+// ReachableCommits returns every commit reachable from the refs, HEAD and
+// the ORIG_HEAD and MERGE_HEAD pseudo-refs, peeling annotated tags
+func (r *Repository) ReachableCommits() ([]string, error) {
+	stack, err := r.refRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		obj, err := r.Objects.Read(hash)
+		if err != nil {
+			return nil, err
+		}
+		switch o := obj.(type) {
+		case *object.Commit:
+			result = append(result, hash)
+			stack = append(stack, r.grafted(hash, o).Parents...)
+		case *object.Tag:
+			stack = append(stack, o.Object)
+		}
+	}
+
+	return result, nil
+}
+
+// reachabilityRoots returns the objects that keep history alive
+func (r *Repository) reachabilityRoots() ([]string, error) {
+	roots, err := r.refRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := index.ReadIndex(r.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	for _, entry := range idx.Entries {
+		roots = append(roots, entry.HashString())
+	}
+
+	reflogs, err := r.reflogRoots()
+	if err != nil {
+		return nil, err
+	}
+	return append(roots, reflogs...), nil
+}
+
+// refRoots returns the objects named by refs, HEAD and pseudo-refs
+func (r *Repository) refRoots() ([]string, error) {
+	var roots []string
+
+	names, err := r.Refs.listRefs("refs/")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		hash, err := r.Refs.ResolveRef("refs/" + name)
+		if err != nil {
+			return nil, err
+		}
+		if hash != "" {
+			roots = append(roots, hash)
+		}
+	}
+
+	if head, err := r.Refs.ResolveHead(); err == nil && head != "" {
+		roots = append(roots, head)
+	}
+	for _, pseudo := range []string{"ORIG_HEAD", "MERGE_HEAD"} {
+		if hash, err := r.Refs.ResolveRef(pseudo); err == nil && hash != "" {
+			roots = append(roots, hash)
+		}
+	}
+
+	return roots, nil
+}
@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// zeroHash stands in for a missing old or new value in a reflog entry
+const zeroHash = "0000000000000000000000000000000000000000"
+
+// checkoutPrefix starts the message of every reflog entry for a HEAD switch
+const checkoutPrefix = "checkout: moving from "
+
+// ReflogEntry is one update of a ref, as recorded in its reflog
+type ReflogEntry struct {
+	OldHash   string
+	NewHash   string
+	Committer object.Signature
+	Message   string
+}
+
+// AppendReflog records an update of ref (e.g. "HEAD" or "refs/heads/main")
+func (r *Refs) AppendReflog(ref string, entry ReflogEntry) error {
+	logPath := filepath.Join(r.gitDir, "logs", ref)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("failed to create reflog directory: %w", err)
+	}
+
+	oldHash, newHash := entry.OldHash, entry.NewHash
+	if oldHash == "" {
+		oldHash = zeroHash
+	}
+	if newHash == "" {
+		newHash = zeroHash
+	}
+	// The message must stay on one line
+	message := strings.ReplaceAll(entry.Message, "\n", " ")
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open reflog: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s %s %s\t%s\n", oldHash, newHash, entry.Committer.Header(), message); err != nil {
+		return fmt.Errorf("failed to write reflog: %w", err)
+	}
+	return nil
+}
+
+// ReadReflog returns the entries of a ref's reflog, oldest first. A ref
+// without a reflog has no entries.
+func (r *Refs) ReadReflog(ref string) ([]ReflogEntry, error) {
+	f, err := os.Open(filepath.Join(r.gitDir, "logs", ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read reflog: %w", err)
+	}
+	defer f.Close()
+
+	var entries []ReflogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		header, message, _ := strings.Cut(line, "\t")
+		if len(header) < 82 || header[40] != ' ' || header[81] != ' ' {
+			continue // Malformed; git skips these too
+		}
+		entries = append(entries, ReflogEntry{
+			OldHash:   header[:40],
+			NewHash:   header[41:81],
+			Committer: object.ParseSignature(header[82:]),
+			Message:   message,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reflog: %w", err)
+	}
+	return entries, nil
+}
+
+// PreviousCheckout returns the branch (or commit, if HEAD was detached)
+// that HEAD was on before the most recent switch
+func (r *Refs) PreviousCheckout() (string, error) {
+	entries, err := r.ReadReflog("HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		rest, ok := strings.CutPrefix(entries[i].Message, checkoutPrefix)
+		if !ok {
+			continue
+		}
+		if from, _, ok := strings.Cut(rest, " to "); ok && from != "" {
+			return from, nil
+		}
+	}
+
+	return "", fmt.Errorf("no previous branch to switch to")
+}
+
+// LogCheckout records in the HEAD reflog that HEAD moved from one branch or
+// commit to another
+func (r *Repository) LogCheckout(oldHash, newHash, from, to string) error {
+	return r.LogRefUpdate("HEAD", oldHash, newHash, checkoutPrefix+from+" to "+to)
+}
+
+// LogRefUpdate records in a ref's reflog that it moved from oldHash to
+// newHash. As in git, only HEAD, branches, remote-tracking branches and
+// notes keep a reflog; updates of other refs aren't recorded.
+func (r *Repository) LogRefUpdate(ref, oldHash, newHash, message string) error {
+	if !keepsReflog(ref) {
+		return nil
+	}
+	committer, err := r.reflogCommitter()
+	if err != nil {
+		return err
+	}
+	return r.Refs.AppendReflog(ref, ReflogEntry{
+		OldHash:   oldHash,
+		NewHash:   newHash,
+		Committer: committer,
+		Message:   message,
+	})
+}
+
+// UpdateHead moves HEAD, or the branch it is on, to a commit, recording
+// the move in the reflogs of both
+func (r *Repository) UpdateHead(hash, message string) error {
+	old, _ := r.Refs.ResolveHead()
+	if err := r.Refs.UpdateHead(hash); err != nil {
+		return err
+	}
+	if branch, err := r.Refs.CurrentBranch(); err == nil {
+		if err := r.LogRefUpdate("refs/heads/"+branch, old, hash, message); err != nil {
+			return err
+		}
+	}
+	return r.LogRefUpdate("HEAD", old, hash, message)
+}
+
+// UpdateRef points a ref at an object, recording the move in its reflog,
+// and in HEAD's if HEAD is on the ref
+func (r *Repository) UpdateRef(ref, hash, message string) error {
+	old, _ := r.Refs.ResolveRef(ref)
+	if err := r.Refs.UpdateRef(ref, hash); err != nil {
+		return err
+	}
+	if err := r.LogRefUpdate(ref, old, hash, message); err != nil {
+		return err
+	}
+	if branch, err := r.Refs.CurrentBranch(); err == nil && ref == "refs/heads/"+branch {
+		return r.LogRefUpdate("HEAD", old, hash, message)
+	}
+	return nil
+}
+
+// reflogCommitter returns who to record as making a ref update, and when
+func (r *Repository) reflogCommitter() (object.Signature, error) {
+	ident, err := r.GetUserInfo()
+	if err != nil {
+		return object.Signature{}, err
+	}
+	committer := object.ParseSignature(ident)
+	committer.When = time.Now()
+	return committer, nil
+}
+
+// keepsReflog reports whether updates of a ref are recorded in a reflog
+func keepsReflog(ref string) bool {
+	return ref == "HEAD" ||
+		strings.HasPrefix(ref, "refs/heads/") ||
+		strings.HasPrefix(ref, "refs/remotes/") ||
+		strings.HasPrefix(ref, "refs/notes/")
+}
+
+// removeReflog deletes a ref's reflog, as when the ref itself is deleted
+func (r *Refs) removeReflog(ref string) error {
+	err := os.Remove(filepath.Join(r.gitDir, "logs", filepath.FromSlash(ref)))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove reflog of %s: %w", ref, err)
+	}
+	return nil
+}
+
+// ReflogRef returns the full name of the ref whose reflog a name means,
+// trying it as given and then the places a short branch name lives
+func (r *Repository) ReflogRef(name string) (string, error) {
+	logs, err := r.Refs.Reflogs()
+	if err != nil {
+		return "", err
+	}
+	has := make(map[string]bool, len(logs))
+	for _, ref := range logs {
+		has[ref] = true
+	}
+
+	for _, prefix := range []string{"", "refs/", "refs/heads/", "refs/remotes/"} {
+		if has[prefix+name] {
+			return prefix + name, nil
+		}
+	}
+	if name == "HEAD" || strings.HasPrefix(name, "refs/") {
+		return name, nil // No updates recorded yet
+	}
+	if hash, _ := r.Refs.GetBranchCommit(name); hash != "" {
+		return "refs/heads/" + name, nil
+	}
+	return "", fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree", name)
+}
+
+// Reflogs returns the names of the refs that have a reflog
+func (r *Refs) Reflogs() ([]string, error) {
+	logsDir := filepath.Join(r.gitDir, "logs")
+	var names []string
+	err := filepath.WalkDir(logsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(logsDir, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reflogs: %w", err)
+	}
+	return names, nil
+}
+
+// writeReflog replaces a ref's reflog with entries
+func (r *Refs) writeReflog(ref string, entries []ReflogEntry) error {
+	var sb strings.Builder
+	for _, entry := range entries {
+		oldHash, newHash := entry.OldHash, entry.NewHash
+		if oldHash == "" {
+			oldHash = zeroHash
+		}
+		if newHash == "" {
+			newHash = zeroHash
+		}
+		fmt.Fprintf(&sb, "%s %s %s\t%s\n", oldHash, newHash, entry.Committer.Header(), entry.Message)
+	}
+
+	logPath := filepath.Join(r.gitDir, "logs", ref)
+	tmp := logPath + ".lock"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write reflog: %w", err)
+	}
+	if err := os.Rename(tmp, logPath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write reflog: %w", err)
+	}
+	return nil
+}
+
+// ExpireReflogs drops the reflog entries dated at or before expire, and
+// those dated at or before expireUnreachable whose commit is no longer in
+// the ref's history, returning how many were dropped
+func (r *Repository) ExpireReflogs(expire, expireUnreachable time.Time) (int, error) {
+	refs, err := r.Refs.Reflogs()
+	if err != nil {
+		return 0, err
+	}
+
+	dropped := 0
+	for _, ref := range refs {
+		entries, err := r.Refs.ReadReflog(ref)
+		if err != nil {
+			return 0, err
+		}
+
+		tip := ""
+		if ref == "HEAD" {
+			tip, _ = r.Refs.ResolveHead()
+		} else {
+			tip, _ = r.Refs.ResolveRef(ref)
+		}
+
+		kept := entries[:0]
+		for _, entry := range entries {
+			when := entry.Committer.When
+			if !when.After(expire) {
+				continue
+			}
+			if !when.After(expireUnreachable) && !r.inHistory(entry.NewHash, tip) {
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		if len(kept) == len(entries) {
+			continue
+		}
+		dropped += len(entries) - len(kept)
+		if err := r.Refs.writeReflog(ref, kept); err != nil {
+			return 0, err
+		}
+	}
+	return dropped, nil
+}
+
+// inHistory reports whether hash is tip or one of its ancestors. Anything
+// that can't be read is treated as gone from history.
+func (r *Repository) inHistory(hash, tip string) bool {
+	if hash == zeroHash || tip == "" {
+		return false
+	}
+	ok, err := r.IsAncestor(hash, tip)
+	return err == nil && ok
+}
+
+// reflogRoots returns the objects the reflogs still refer to, leaving out
+// any that no longer exist
+func (r *Repository) reflogRoots() ([]string, error) {
+	refs, err := r.Refs.Reflogs()
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	for _, ref := range refs {
+		entries, err := r.Refs.ReadReflog(ref)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			for _, hash := range []string{entry.OldHash, entry.NewHash} {
+				if hash != zeroHash && object.Exists(r.Path, hash) {
+					roots = append(roots, hash)
+				}
+			}
+		}
+	}
+	return roots, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateRefName checks a full ref name such as "refs/heads/feature/x"
+// against Git's rules (see git check-ref-format), so that no ref is created
+// that revision parsing or the filesystem would later trip over
+func ValidateRefName(name string) error {
+	if problem := refNameProblem(name); problem != "" {
+		return fmt.Errorf("invalid ref name '%s': %s", name, problem)
+	}
+	return nil
+}
+
+// refNameProblem describes what is wrong with a ref name, or returns ""
+func refNameProblem(name string) string {
+	switch {
+	case name == "":
+		return "empty name"
+	case name == "@":
+		return "\"@\" is not allowed"
+	case strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/"):
+		return "begins or ends with \"/\""
+	case strings.HasSuffix(name, "."):
+		return "ends with \".\""
+	case strings.Contains(name, ".."):
+		return "contains \"..\""
+	case strings.Contains(name, "@{"):
+		return "contains \"@{\""
+	}
+
+	for _, c := range name {
+		switch {
+		case c < 0x20 || c == 0x7f:
+			return "contains a control character"
+		case strings.ContainsRune(" ~^:?*[\\", c):
+			return fmt.Sprintf("contains %q", c)
+		}
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		switch {
+		case component == "":
+			return "contains an empty path component"
+		case strings.HasPrefix(component, "."):
+			return "has a path component beginning with \".\""
+		case strings.HasSuffix(component, ".lock"):
+			return "has a path component ending with \".lock\""
+		}
+	}
+	return ""
+}
+
+// checkBranchName reports why name can't be a branch. Besides the ref name
+// rules, a branch may not be called HEAD or look like an option.
+func checkBranchName(name string) error {
+	problem := refNameProblem("refs/heads/" + name)
+	switch {
+	case name == "HEAD":
+		problem = "\"HEAD\" is reserved"
+	case strings.HasPrefix(name, "-"):
+		problem = "begins with \"-\""
+	}
+	if problem != "" {
+		return fmt.Errorf("'%s' is not a valid branch name: %s", name, problem)
+	}
+	return nil
+}
+
+// checkTagName reports why name can't be a tag
+func checkTagName(name string) error {
+	problem := refNameProblem("refs/tags/" + name)
+	if strings.HasPrefix(name, "-") {
+		problem = "begins with \"-\""
+	}
+	if problem != "" {
+		return fmt.Errorf("'%s' is not a valid tag name: %s", name, problem)
+	}
+	return nil
+}
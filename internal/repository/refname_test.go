@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// refNames are checked against git check-ref-format when git is installed
+var refNames = []struct {
+	name  string
+	valid bool
+}{
+	{"refs/heads/main", true},
+	{"refs/heads/feature/x", true},
+	{"refs/tags/v1.0", true},
+	{"refs/heads/a-b_c+d", true},
+	{"refs/heads/@x", true},
+	{"refs/heads/üñí", true},
+	{"", false},
+	{"@", false},
+	{"refs/heads/has space", false},
+	{"refs/heads/a..b", false},
+	{"refs/heads/a~1", false},
+	{"refs/heads/a^", false},
+	{"refs/heads/a:b", false},
+	{"refs/heads/a?", false},
+	{"refs/heads/a*", false},
+	{"refs/heads/a[b", false},
+	{"refs/heads/a\\b", false},
+	{"refs/heads/a\tb", false},
+	{"refs/heads/a\x7f", false},
+	{"refs/heads/a@{1}", false},
+	{"refs/heads/x.lock", false},
+	{"refs/heads/x.lock/y", false},
+	{"refs/heads/.hidden", false},
+	{"refs/heads/a/.b", false},
+	{"refs/heads/ends.", false},
+	{"refs/heads//double", false},
+	{"/refs/heads/x", false},
+	{"refs/heads/x/", false},
+}
+
+func TestValidateRefName(t *testing.T) {
+	_, lookErr := exec.LookPath("git")
+	for _, c := range refNames {
+		err := ValidateRefName(c.name)
+		if (err == nil) != c.valid {
+			t.Errorf("ValidateRefName(%q) = %v, want valid %v", c.name, err, c.valid)
+		}
+		if lookErr == nil {
+			gitValid := exec.Command("git", "check-ref-format", "--allow-onelevel", c.name).Run() == nil
+			if gitValid != c.valid {
+				t.Errorf("git check-ref-format says %q is valid: %v", c.name, gitValid)
+			}
+		}
+	}
+}
+
+func TestCreateBranchAndTagCheckNames(t *testing.T) {
+	tr := newTestRepo(t)
+	head := tr.commit("one", map[string]string{"f": "1\n"})
+
+	for _, name := range []string{"feature/x", "fix-1", "v1.0"} {
+		if err := tr.Refs.CreateBranch(name, head); err != nil {
+			t.Errorf("CreateBranch(%q): %v", name, err)
+		}
+		if err := tr.Refs.CreateTag(name, head); err != nil {
+			t.Errorf("CreateTag(%q): %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"has space", "a..b", "x.lock", "-opt", "feature/", "a:b"} {
+		if err := tr.Refs.CreateBranch(name, head); err == nil {
+			t.Errorf("CreateBranch(%q) succeeded", name)
+		}
+		if err := tr.Refs.CreateTag(name, head); err == nil {
+			t.Errorf("CreateTag(%q) succeeded", name)
+		}
+		if hash, _ := tr.Refs.ResolveRef("refs/heads/" + name); hash != "" {
+			t.Errorf("a branch %q was made", name)
+		}
+	}
+	if err := tr.Refs.CreateBranch("HEAD", head); err == nil {
+		t.Error("CreateBranch(\"HEAD\") succeeded")
+	}
+}
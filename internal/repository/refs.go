@@ -4,22 +4,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/yourusername/gogit/internal/utils"
 )
 
 // Refs manages Git references (branches, tags, HEAD)
 type Refs struct {
 	repoPath string
+	gitDir   string
 }
 
 // NewRefs creates a new Refs manager
 func NewRefs(repoPath string) *Refs {
-	return &Refs{repoPath: repoPath}
+	return &Refs{repoPath: repoPath, gitDir: utils.GitDir(repoPath)}
 }
 
 // ResolveHead resolves HEAD to a commit hash
 func (r *Refs) ResolveHead() (string, error) {
-	headPath := filepath.Join(r.repoPath, ".gogit", "HEAD")
+	headPath := filepath.Join(r.gitDir, "HEAD")
 	content, err := os.ReadFile(headPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read HEAD: %w", err)
@@ -39,21 +43,83 @@ func (r *Refs) ResolveHead() (string, error) {
 
 // ResolveRef resolves a reference to a commit hash
 func (r *Refs) ResolveRef(refPath string) (string, error) {
-	fullPath := filepath.Join(r.repoPath, ".gogit", refPath)
+	fullPath := filepath.Join(r.gitDir, refPath)
 	content, err := os.ReadFile(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", nil // Ref doesn't exist (e.g., new repo)
+			// Fall back to refs packed by "git pack-refs"
+			packed, err := r.readPackedRefs()
+			if err != nil {
+				return "", err
+			}
+			return packed[refPath], nil // Empty if the ref doesn't exist (e.g., new repo)
 		}
 		return "", fmt.Errorf("failed to read ref %s: %w", refPath, err)
 	}
 
-	return strings.TrimSpace(string(content)), nil
+	value := strings.TrimSpace(string(content))
+	if strings.HasPrefix(value, "ref: ") {
+		return r.ResolveRef(strings.TrimPrefix(value, "ref: "))
+	}
+	return value, nil
+}
+
+// readPackedRefs parses the packed-refs file into a map of ref path to hash
+func (r *Refs) readPackedRefs() (map[string]string, error) {
+	refs := make(map[string]string)
+
+	content, err := os.ReadFile(filepath.Join(r.gitDir, "packed-refs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return refs, nil
+		}
+		return nil, fmt.Errorf("failed to read packed-refs: %w", err)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		// Skip the header and peeled-tag lines
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) == 2 {
+			refs[parts[1]] = parts[0]
+		}
+	}
+
+	return refs, nil
+}
+
+// removePackedRef rewrites packed-refs without the given ref
+func (r *Refs) removePackedRef(refPath string) error {
+	packedPath := filepath.Join(r.gitDir, "packed-refs")
+	content, err := os.ReadFile(packedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read packed-refs: %w", err)
+	}
+
+	var kept []string
+	skipPeeled := false
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		if strings.HasPrefix(line, "^") && skipPeeled {
+			continue
+		}
+		skipPeeled = strings.HasSuffix(line, " "+refPath)
+		if skipPeeled {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return os.WriteFile(packedPath, []byte(strings.Join(kept, "\n")+"\n"), 0644)
 }
 
 // UpdateHead updates HEAD to point to a new commit or ref
 func (r *Refs) UpdateHead(target string) error {
-	headPath := filepath.Join(r.repoPath, ".gogit", "HEAD")
+	headPath := filepath.Join(r.gitDir, "HEAD")
 	content, err := os.ReadFile(headPath)
 	if err != nil {
 		return fmt.Errorf("failed to read HEAD: %w", err)
@@ -73,7 +139,7 @@ func (r *Refs) UpdateHead(target string) error {
 
 // UpdateRef updates a reference to point to a commit
 func (r *Refs) UpdateRef(refPath, commitHash string) error {
-	fullPath := filepath.Join(r.repoPath, ".gogit", refPath)
+	fullPath := filepath.Join(r.gitDir, refPath)
 
 	// Ensure directory exists
 	dir := filepath.Dir(fullPath)
@@ -81,12 +147,18 @@ func (r *Refs) UpdateRef(refPath, commitHash string) error {
 		return fmt.Errorf("failed to create ref directory: %w", err)
 	}
 
-	return os.WriteFile(fullPath, []byte(commitHash+"\n"), 0644)
+	if err := os.WriteFile(fullPath, []byte(commitHash+"\n"), 0644); err != nil {
+		return err
+	}
+	if strings.HasPrefix(refPath, "refs/replace/") {
+		forgetReplaceRefs()
+	}
+	return nil
 }
 
 // CurrentBranch returns the name of the current branch
 func (r *Refs) CurrentBranch() (string, error) {
-	headPath := filepath.Join(r.repoPath, ".gogit", "HEAD")
+	headPath := filepath.Join(r.gitDir, "HEAD")
 	content, err := os.ReadFile(headPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read HEAD: %w", err)
@@ -103,41 +175,101 @@ func (r *Refs) CurrentBranch() (string, error) {
 
 // ListBranches returns all local branches
 func (r *Refs) ListBranches() ([]string, error) {
-	headsPath := filepath.Join(r.repoPath, ".gogit", "refs", "heads")
-	entries, err := os.ReadDir(headsPath)
+	return r.listRefs("refs/heads/")
+}
+
+// ListRemoteBranches returns all remote-tracking branches, such as
+// "origin/main"
+func (r *Refs) ListRemoteBranches() ([]string, error) {
+	return r.listRefs("refs/remotes/")
+}
+
+// ListTags returns all tags
+func (r *Refs) ListTags() ([]string, error) {
+	return r.listRefs("refs/tags/")
+}
+
+// ListRefs returns the full names of all refs, such as "refs/heads/main"
+func (r *Refs) ListRefs() ([]string, error) {
+	names, err := r.listRefs("refs/")
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []string{}, nil
+		return nil, err
+	}
+	for i, name := range names {
+		names[i] = "refs/" + name
+	}
+	return names, nil
+}
+
+// listRefs returns the names under a ref prefix (e.g. "refs/heads/"),
+// combining loose refs (including nested names like "feature/x") and packed refs
+func (r *Refs) listRefs(prefix string) ([]string, error) {
+	seen := make(map[string]bool)
+
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		return nil, err
+	}
+	for refPath := range packed {
+		if strings.HasPrefix(refPath, prefix) {
+			seen[strings.TrimPrefix(refPath, prefix)] = true
 		}
-		return nil, fmt.Errorf("failed to read branches: %w", err)
 	}
 
-	var branches []string
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			branches = append(branches, entry.Name())
+	root := filepath.Join(r.gitDir, filepath.FromSlash(prefix))
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
 		}
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		seen[filepath.ToSlash(name)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read refs: %w", err)
 	}
 
-	return branches, nil
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
 }
 
 // CreateBranch creates a new branch pointing to a commit
 func (r *Refs) CreateBranch(name, commitHash string) error {
+	if err := checkBranchName(name); err != nil {
+		return err
+	}
+
 	refPath := filepath.Join("refs", "heads", name)
-	fullPath := filepath.Join(r.repoPath, ".gogit", refPath)
+	fullPath := filepath.Join(r.gitDir, refPath)
 
 	// Check if branch already exists
 	if _, err := os.Stat(fullPath); err == nil {
 		return fmt.Errorf("branch '%s' already exists", name)
 	}
+	if existing, _ := r.ResolveRef(refPath); existing != "" {
+		return fmt.Errorf("branch '%s' already exists", name)
+	}
 
 	return r.UpdateRef(refPath, commitHash)
 }
 
 // DeleteBranch deletes a branch
 func (r *Refs) DeleteBranch(name string) error {
-	fullPath := filepath.Join(r.repoPath, ".gogit", "refs", "heads", name)
+	fullPath := filepath.Join(r.gitDir, "refs", "heads", name)
 
 	// Check if it's the current branch
 	currentBranch, _ := r.CurrentBranch()
@@ -145,16 +277,65 @@ func (r *Refs) DeleteBranch(name string) error {
 		return fmt.Errorf("cannot delete the current branch '%s'", name)
 	}
 
-	if err := os.Remove(fullPath); err != nil {
+	refPath := "refs/heads/" + name
+	if existing, _ := r.ResolveRef(refPath); existing == "" {
+		return fmt.Errorf("branch '%s' not found", name)
+	}
+
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete branch '%s': %w", name, err)
 	}
+	if err := r.removeReflog(refPath); err != nil {
+		return err
+	}
 
-	return nil
+	return r.removePackedRef(refPath)
+}
+
+// CreateTag creates a lightweight tag pointing to an object
+func (r *Refs) CreateTag(name, hash string) error {
+	if err := checkTagName(name); err != nil {
+		return err
+	}
+
+	refPath := "refs/tags/" + name
+	if existing, _ := r.ResolveRef(refPath); existing != "" {
+		return fmt.Errorf("tag '%s' already exists", name)
+	}
+
+	return r.UpdateRef(refPath, hash)
+}
+
+// DeleteTag deletes a tag and returns the object it pointed to
+func (r *Refs) DeleteTag(name string) (string, error) {
+	refPath := "refs/tags/" + name
+	existing, _ := r.ResolveRef(refPath)
+	if existing == "" {
+		return "", fmt.Errorf("tag '%s' not found", name)
+	}
+
+	return existing, r.DeleteRef(refPath)
+}
+
+// DeleteRef removes a ref, loose or packed, along with its reflog
+func (r *Refs) DeleteRef(refPath string) error {
+	fullPath := filepath.Join(r.gitDir, filepath.FromSlash(refPath))
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", refPath, err)
+	}
+	if strings.HasPrefix(refPath, "refs/replace/") {
+		forgetReplaceRefs()
+	}
+	if err := r.removeReflog(refPath); err != nil {
+		return err
+	}
+
+	return r.removePackedRef(refPath)
 }
 
 // SetHead sets HEAD to point to a branch or commit
 func (r *Refs) SetHead(target string, symbolic bool) error {
-	headPath := filepath.Join(r.repoPath, ".gogit", "HEAD")
+	headPath := filepath.Join(r.gitDir, "HEAD")
 
 	var content string
 	if symbolic {
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// CheckSortKey reports whether key is a ref sort key SortRefs accepts
+func CheckSortKey(key string) error {
+	switch strings.TrimPrefix(key, "-") {
+	case "refname", "creatordate", "version:refname", "v:refname":
+		return nil
+	}
+	return fmt.Errorf("unknown sort key '%s' (supported: refname, creatordate, version:refname)", key)
+}
+
+// SortRefs sorts full ref names by a --sort key: refname, creatordate (the
+// tagger date of an annotated tag, the committer date of a commit) or
+// version:refname, which orders embedded numbers by value so v1.10 comes
+// after v1.9. A leading "-" reverses the order. Ties fall back to refname.
+func (r *Repository) SortRefs(refs []string, key string) error {
+	if err := CheckSortKey(key); err != nil {
+		return err
+	}
+	reverse := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+
+	var less func(a, b string) bool
+	switch key {
+	case "refname":
+		less = func(a, b string) bool { return a < b }
+	case "version:refname", "v:refname":
+		less = func(a, b string) bool {
+			if c := compareVersions(a, b); c != 0 {
+				return c < 0
+			}
+			return a < b
+		}
+	case "creatordate":
+		dates := make(map[string]time.Time, len(refs))
+		for _, ref := range refs {
+			date, err := r.creatorDate(ref)
+			if err != nil {
+				return err
+			}
+			dates[ref] = date
+		}
+		less = func(a, b string) bool {
+			if !dates[a].Equal(dates[b]) {
+				return dates[a].Before(dates[b])
+			}
+			return a < b
+		}
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		if reverse {
+			return less(refs[j], refs[i])
+		}
+		return less(refs[i], refs[j])
+	})
+	return nil
+}
+
+// creatorDate returns when the object a ref points to was made: the tagger
+// date of an annotated tag or the committer date of a commit. Trees and
+// blobs have no date and sort first.
+func (r *Repository) creatorDate(ref string) (time.Time, error) {
+	hash, err := r.Refs.ResolveRef(ref)
+	if err != nil || hash == "" {
+		return time.Time{}, err
+	}
+	obj, err := r.Objects.Read(hash)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+
+	switch o := obj.(type) {
+	case *object.Tag:
+		return o.Tagger.When, nil
+	case *object.Commit:
+		return o.Committer.When, nil
+	}
+	return time.Time{}, nil
+}
+
+// compareVersions compares two names with runs of digits compared by
+// numeric value, returning -1, 0 or +1
+func compareVersions(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			numA, restA := splitDigits(a)
+			numB, restB := splitDigits(b)
+			if c := compareNumbers(numA, numB); c != 0 {
+				return c
+			}
+			a, b = restA, restB
+			continue
+		}
+		if a[0] != b[0] {
+			if a[0] < b[0] {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[1:], b[1:]
+	}
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+// compareNumbers compares two strings of digits by value without parsing
+// them, so arbitrarily long numbers work
+func compareNumbers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func splitDigits(s string) (digits, rest string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gogit/internal/config"
+	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/pack"
+	"github.com/yourusername/gogit/internal/transport"
+)
+
+// Remote is a remote repository configured in a [remote "<name>"] section
+type Remote struct {
+	Name     string
+	URL      string
+	Promisor bool   // Objects may be left out locally and fetched on demand
+	Filter   string // Object filter used when fetching from a promisor
+}
+
+// RefUpdate is a local ref changed by a fetch
+type RefUpdate struct {
+	Ref     string // Local ref, e.g. "refs/remotes/origin/main"
+	Source  string // Ref on the remote; empty for a pruned ref
+	OldHash string // Empty for a new ref
+	NewHash string // Empty for a pruned ref
+}
+
+// FetchOptions controls what Fetch downloads and updates
+type FetchOptions struct {
+	Filter string // Object filter; defaults to the promisor remote's filter
+	Prune  bool   // Delete remote-tracking refs the remote no longer has
+}
+
+// Remote returns the configuration of a remote
+func (r *Repository) Remote(name string) (*Remote, error) {
+	cfg, err := config.Load(filepath.Join(r.GitDir, "config"))
+	if err != nil {
+		return nil, err
+	}
+
+	url, ok := cfg.Get("remote." + name + ".url")
+	if !ok || url == "" {
+		return nil, fmt.Errorf("'%s' does not appear to be a remote repository", name)
+	}
+	remote := &Remote{Name: name, URL: url}
+
+	partial, _ := cfg.Get("extensions.partialclone")
+	promisor, _ := cfg.Get("remote." + name + ".promisor")
+	if b, err := config.ParseBool(promisor); (err == nil && b) || partial == name {
+		remote.Promisor = true
+		remote.Filter, _ = cfg.Get("remote." + name + ".partialclonefilter")
+	}
+
+	return remote, nil
+}
+
+// HTTPClient returns the client to reach remotes with, which gives up on
+// transfers slower than http.lowSpeedLimit bytes per second for
+// http.lowSpeedTime seconds
+func (r *Repository) HTTPClient() *http.Client {
+	opts := transport.DefaultClientOptions()
+	if value, _ := r.GetConfig("http.lowSpeedLimit"); value != "" {
+		if limit, err := config.ParseInt(value); err == nil {
+			opts.LowSpeedLimit = int(limit)
+		}
+	}
+	if value, _ := r.GetConfig("http.lowSpeedTime"); value != "" {
+		if seconds, err := config.ParseInt(value); err == nil {
+			opts.LowSpeedTime = time.Duration(seconds) * time.Second
+		}
+	}
+	return transport.NewClient(opts)
+}
+
+// PromisorRemote returns the remote that a partial clone fetches missing
+// objects from, or nil if the repository isn't a partial clone
+func (r *Repository) PromisorRemote() (*Remote, error) {
+	// Without a config file there is no partial clone either
+	name, _ := r.GetConfig("extensions.partialclone")
+	if name == "" {
+		return nil, nil
+	}
+	return r.Remote(name)
+}
+
+// Fetch downloads the branches and tags of a remote that aren't present
+// locally. Branches are stored as refs/remotes/<remote>/<branch>; tags are
+// only created, never moved. A filter, given or configured for a promisor
+// remote, leaves the objects it excludes to be fetched on demand. With
+// Prune, remote-tracking refs for branches the remote no longer has are
+// deleted and reported as updates without a new hash.
+func (r *Repository) Fetch(remote *Remote, opts FetchOptions) ([]RefUpdate, error) {
+	filter := opts.Filter
+	if filter == "" {
+		filter = remote.Filter
+	}
+	if filter != "" && !remote.Promisor {
+		return nil, fmt.Errorf("--filter can only be used with the remote configured in extensions.partialclone")
+	}
+
+	client := r.HTTPClient()
+	adv, err := transport.GetAdvertisement(client, remote.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []RefUpdate
+	var wants []string
+	wanted := make(map[string]bool)
+	advertised := make(map[string]bool)
+	for _, ref := range adv.Refs {
+		var local string
+		switch {
+		case strings.HasSuffix(ref.Name, "^{}"):
+			continue
+		case strings.HasPrefix(ref.Name, "refs/heads/"):
+			local = "refs/remotes/" + remote.Name + "/" + strings.TrimPrefix(ref.Name, "refs/heads/")
+		case strings.HasPrefix(ref.Name, "refs/tags/"):
+			local = ref.Name
+		default:
+			continue
+		}
+		advertised[local] = true
+
+		old, _ := r.Refs.ResolveRef(local)
+		if old == ref.Hash || (old != "" && strings.HasPrefix(local, "refs/tags/")) {
+			continue
+		}
+		updates = append(updates, RefUpdate{Ref: local, Source: ref.Name, OldHash: old, NewHash: ref.Hash})
+
+		if !wanted[ref.Hash] && !object.Exists(r.Path, ref.Hash) {
+			wanted[ref.Hash] = true
+			wants = append(wants, ref.Hash)
+		}
+	}
+
+	if len(wants) > 0 {
+		haves, err := r.refRoots()
+		if err != nil {
+			return nil, err
+		}
+		data, err := transport.FetchPack(client, remote.URL, adv, transport.FetchRequest{
+			Wants:  wants,
+			Haves:  haves,
+			Filter: filter,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := storePack(r.Path, data, remote.Promisor); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, update := range updates {
+		message := "fetch " + remote.Name + ": " + r.fetchUpdateKind(update)
+		if err := r.UpdateRef(update.Ref, update.NewHash, message); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Prune {
+		pruned, err := r.pruneTracking(remote, advertised)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, pruned...)
+	}
+	return updates, nil
+}
+
+// fetchUpdateKind describes a ref update made by a fetch for its reflog
+func (r *Repository) fetchUpdateKind(update RefUpdate) string {
+	if update.OldHash == "" {
+		return "storing head"
+	}
+	if ok, err := r.IsAncestor(update.OldHash, update.NewHash); err == nil && ok {
+		return "fast-forward"
+	}
+	return "forced-update"
+}
+
+// pruneTracking deletes the remote-tracking refs of a remote that aren't
+// among the refs it advertised. The symbolic refs/remotes/<remote>/HEAD is
+// kept.
+func (r *Repository) pruneTracking(remote *Remote, advertised map[string]bool) ([]RefUpdate, error) {
+	prefix := "refs/remotes/" + remote.Name + "/"
+	names, err := r.Refs.listRefs(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []RefUpdate
+	for _, name := range names {
+		ref := prefix + name
+		if name == "HEAD" || advertised[ref] {
+			continue
+		}
+		old, _ := r.Refs.ResolveRef(ref)
+		if err := r.Refs.DeleteRef(ref); err != nil {
+			return nil, err
+		}
+		pruned = append(pruned, RefUpdate{Ref: ref, OldHash: old})
+	}
+	return pruned, nil
+}
+
+// FetchPromised fetches objects missing from a partial clone from its
+// promisor remote. It is installed as the object reader's PromisorFetcher.
+func FetchPromised(repoPath string, hashes []string) error {
+	r, err := Open(repoPath)
+	if err != nil {
+		return err
+	}
+	remote, err := r.PromisorRemote()
+	if err != nil {
+		return err
+	}
+	if remote == nil {
+		return object.ErrNotPromised
+	}
+
+	client := r.HTTPClient()
+	adv, err := transport.GetAdvertisement(client, remote.URL)
+	if err != nil {
+		return err
+	}
+	// Protocol v0 only serves objects named by refs unless the server opts in
+	if !adv.HasCapability("allow-reachable-sha1-in-want") {
+		return fmt.Errorf("%s does not serve objects by name; cannot fetch missing object %s (the server needs uploadpack.allowReachableSHA1InWant)", remote.URL, hashes[0])
+	}
+
+	data, err := transport.FetchPack(client, remote.URL, adv, transport.FetchRequest{
+		Wants:  hashes,
+		Filter: remote.Filter,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch missing objects from %s: %w", remote.Name, err)
+	}
+	return storePack(repoPath, data, true)
+}
+
+// storePack adds a pack received from a remote to the object store. Packs
+// from a promisor remote get a ".promisor" marker, which tells Git that
+// objects they refer to may legitimately be missing.
+func storePack(repoPath string, data []byte, promisor bool) error {
+	dir := object.PackDir(repoPath)
+	name, err := pack.Index(dir, data)
+	if err != nil {
+		return fmt.Errorf("failed to store fetched pack: %w", err)
+	}
+	if promisor {
+		if err := os.WriteFile(filepath.Join(dir, name+".promisor"), nil, 0644); err != nil {
+			return fmt.Errorf("failed to store fetched pack: %w", err)
+		}
+	}
+	object.ClosePacks(repoPath)
+	return nil
+}
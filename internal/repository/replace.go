@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// replaceMaps caches each repository's replacements until a replace ref
+// changes
+var replaceMaps sync.Map
+
+// ReplaceRefs returns the replacements recorded under refs/replace/: each
+// ref is named after the object it replaces and points at its replacement.
+// It is installed as the object reader's ReplaceMap.
+func ReplaceRefs(repoPath string) map[string]string {
+	if cached, ok := replaceMaps.Load(repoPath); ok {
+		return cached.(map[string]string)
+	}
+
+	replacements := make(map[string]string)
+	refs := NewRefs(repoPath)
+	names, _ := refs.listRefs("refs/replace/")
+	for _, name := range names {
+		if !object.IsHash(name) {
+			continue
+		}
+		if hash, err := refs.ResolveRef("refs/replace/" + name); err == nil && hash != "" {
+			replacements[name] = hash
+		}
+	}
+
+	replaceMaps.Store(repoPath, replacements)
+	return replacements
+}
+
+// forgetReplaceRefs drops the cached replacements after a replace ref is
+// written or deleted. Repositories may be cached under several paths, so
+// all of them go.
+func forgetReplaceRefs() {
+	replaceMaps.Range(func(key, _ any) bool {
+		replaceMaps.Delete(key)
+		return true
+	})
+}
@@ -1,18 +1,35 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
+	"github.com/yourusername/gogit/internal/commitgraph"
+	"github.com/yourusername/gogit/internal/config"
 	"github.com/yourusername/gogit/internal/index"
 	"github.com/yourusername/gogit/internal/object"
+	"github.com/yourusername/gogit/internal/utils"
 )
 
+// ErrConfigNotFound is returned by GetConfig for a key that isn't set
+var ErrConfigNotFound = errors.New("config key not found")
+
 // Repository represents a GoGit repository
 type Repository struct {
-	Path string
-	Refs *Refs
+	Path    string // Working tree root
+	GitDir  string // Metadata directory (.gogit, or .git as a fallback)
+	Refs    *Refs
+	Objects *object.Store
+
+	graph     *commitgraph.Graph // Loaded lazily by commitGraph
+	graphOnce sync.Once
+
+	grafts     map[string][]string // Loaded lazily by Grafts
+	graftsOnce sync.Once
 }
 
 // dirEntry represents a directory entry for tree building
@@ -24,19 +41,88 @@ type dirEntry struct {
 	entries map[string]*dirEntry
 }
 
-// Open opens an existing repository
+// Open opens an existing repository. The metadata directory must have a
+// well-formed HEAD and objects and refs directories, so that a damaged
+// repository is reported up front rather than by whatever reads it first.
 func Open(path string) (*Repository, error) {
-	gogitPath := filepath.Join(path, ".gogit")
-	if _, err := os.Stat(gogitPath); os.IsNotExist(err) {
+	gitDir := utils.GitDir(path)
+	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
 		return nil, fmt.Errorf("not a gogit repository: %s", path)
 	}
+	if err := checkLayout(gitDir); err != nil {
+		return nil, fmt.Errorf("invalid gogit repository %s: %w", gitDir, err)
+	}
 
 	return &Repository{
-		Path: path,
-		Refs: NewRefs(path),
+		Path:    path,
+		GitDir:  gitDir,
+		Refs:    NewRefs(path),
+		Objects: object.NewStore(path),
 	}, nil
 }
 
+// ReadAsStored makes the repository read objects as stored, ignoring
+// replacements, for commands that work with history as it was written
+func (r *Repository) ReadAsStored() {
+	r.Objects = r.Objects.AsStored()
+	r.graphOnce = sync.Once{}
+	r.graph = nil
+}
+
+// checkLayout checks that a metadata directory has what every repository
+// needs: a HEAD holding a ref or an object name, and the objects and refs
+// directories
+func checkLayout(gitDir string) error {
+	content, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("HEAD is missing")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if !validHead(strings.TrimRight(string(content), "\n")) {
+		return fmt.Errorf("HEAD is malformed: %q", strings.TrimSpace(string(content)))
+	}
+
+	for _, dir := range []string{"objects", "refs"} {
+		info, err := os.Stat(filepath.Join(gitDir, dir))
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("the %s directory is missing", dir)
+		}
+	}
+	return nil
+}
+
+// validHead reports whether HEAD's content is "ref: refs/..." or a full
+// object name
+func validHead(head string) bool {
+	if target, ok := strings.CutPrefix(head, "ref: "); ok {
+		return strings.HasPrefix(strings.TrimSpace(target), "refs/")
+	}
+	return object.IsHash(head)
+}
+
+// Check runs a quick consistency check: every ref must point to an object
+// that can be read, and the commits among them to a readable tree. It is
+// far from a full fsck, but catches a repository whose refs have outlived
+// its objects.
+func (r *Repository) Check() error {
+	tips, err := r.RefTips()
+	if err != nil {
+		return err
+	}
+	for _, tip := range tips {
+		commit, err := r.Objects.ReadCommit(tip)
+		if err != nil {
+			return fmt.Errorf("failed to read commit %s: %w", tip, err)
+		}
+		if _, err := r.Objects.ReadTree(commit.TreeHash); err != nil {
+			return fmt.Errorf("failed to read tree %s of commit %s: %w", commit.TreeHash, tip, err)
+		}
+	}
+	return nil
+}
+
 // BuildTree creates a tree object from the current index
 func (r *Repository) BuildTree(idx *index.Index) (*object.Tree, error) {
 	tree := object.NewTree()
@@ -51,15 +137,19 @@ func (r *Repository) BuildTree(idx *index.Index) (*object.Tree, error) {
 	return tree, nil
 }
 
-// BuildTreeRecursive creates tree objects for nested directory structure
-func (r *Repository) BuildTreeRecursive(idx *index.Index) (string, error) {
+// BuildTreeRecursive creates tree objects for nested directory structure,
+// queueing them on batch rather than writing them
+func (r *Repository) BuildTreeRecursive(idx *index.Index, batch *object.Batch) (string, error) {
 	root := &dirEntry{
 		isDir:   true,
 		entries: make(map[string]*dirEntry),
 	}
 
-	// Build directory structure
+	// Build directory structure from resolved entries
 	for _, entry := range idx.Entries {
+		if entry.Stage() != 0 {
+			continue
+		}
 		parts := splitPath(entry.Path)
 		current := root
 
@@ -87,32 +177,22 @@ func (r *Repository) BuildTreeRecursive(idx *index.Index) (string, error) {
 	}
 
 	// Build trees bottom-up
-	return r.buildTreeFromDir(root)
+	return r.buildTreeFromDir(root, batch), nil
 }
 
-func (r *Repository) buildTreeFromDir(dir *dirEntry) (string, error) {
+func (r *Repository) buildTreeFromDir(dir *dirEntry, batch *object.Batch) string {
 	tree := object.NewTree()
 
 	for name, entry := range dir.entries {
 		if entry.isDir {
 			// Recursively build subtree
-			hash, err := r.buildTreeFromDir(entry)
-			if err != nil {
-				return "", err
-			}
-			tree.AddEntry("40000", name, hash)
+			tree.AddEntry(object.ModeTree, name, r.buildTreeFromDir(entry, batch))
 		} else {
 			tree.AddEntry(entry.mode, name, entry.hash)
 		}
 	}
 
-	// Write tree and return hash
-	hash, err := object.WriteObject(r.Path, tree)
-	if err != nil {
-		return "", fmt.Errorf("failed to write tree: %w", err)
-	}
-
-	return hash, nil
+	return batch.Add(tree)
 }
 
 func splitPath(path string) []string {
@@ -133,22 +213,74 @@ func splitPath(path string) []string {
 	return parts
 }
 
-// GetConfig returns the repository configuration
+// GetConfig returns the value of a dotted config key such as
+// "core.filemode". A key that isn't set, including when there is no config
+// file at all, gives ErrConfigNotFound; other errors mean the file couldn't
+// be read or parsed.
 func (r *Repository) GetConfig(key string) (string, error) {
-	// Simple implementation - in reality would parse config file
-	configPath := filepath.Join(r.Path, ".gogit", "config")
-	_, err := os.Stat(configPath)
+	cfg, err := config.Load(filepath.Join(r.GitDir, "config"))
+	if os.IsNotExist(err) {
+		return "", ErrConfigNotFound
+	}
 	if err != nil {
 		return "", err
 	}
-	// For now, return empty - full implementation would parse INI
-	return "", nil
+	value, ok := cfg.Get(key)
+	if !ok {
+		return "", ErrConfigNotFound
+	}
+	return value, nil
+}
+
+// SetConfig sets a dotted config key, keeping the rest of the config file
+// as it is
+func (r *Repository) SetConfig(key, value string) error {
+	return config.Set(filepath.Join(r.GitDir, "config"), key, value)
+}
+
+// UnsetConfig removes a dotted config key. A key that isn't set gives
+// ErrConfigNotFound.
+func (r *Repository) UnsetConfig(key string) error {
+	err := config.Unset(filepath.Join(r.GitDir, "config"), key)
+	if errors.Is(err, config.ErrNotSet) {
+		return ErrConfigNotFound
+	}
+	return err
+}
+
+// ListConfig returns every value in the config file, in file order
+func (r *Repository) ListConfig() ([]config.Entry, error) {
+	cfg, err := config.Load(filepath.Join(r.GitDir, "config"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Entries(), nil
+}
+
+// ConfigBool returns a boolean config value, or def if it is unset or invalid
+func (r *Repository) ConfigBool(key string, def bool) bool {
+	value, err := r.GetConfig(key)
+	if err != nil || value == "" {
+		return def
+	}
+	b, err := config.ParseBool(value)
+	if err != nil {
+		return def
+	}
+	return b
 }
 
-// GetUserInfo returns author/committer info
+// GetUserInfo returns author/committer info, from user.name and user.email
+// in the config, else the environment, else the login name and host. An
+// identity that couldn't be written into a commit header is an error.
 func (r *Repository) GetUserInfo() (string, error) {
-	// Try to get from environment or config
-	name := os.Getenv("GIT_AUTHOR_NAME")
+	name, _ := r.GetConfig("user.name")
+	if name == "" {
+		name = os.Getenv("GIT_AUTHOR_NAME")
+	}
 	if name == "" {
 		name = os.Getenv("USER")
 	}
@@ -156,11 +288,20 @@ func (r *Repository) GetUserInfo() (string, error) {
 		name = "Unknown"
 	}
 
-	email := os.Getenv("GIT_AUTHOR_EMAIL")
+	email, _ := r.GetConfig("user.email")
+	if email == "" {
+		email = os.Getenv("GIT_AUTHOR_EMAIL")
+	}
 	if email == "" {
 		hostname, _ := os.Hostname()
 		email = name + "@" + hostname
 	}
 
+	if strings.ContainsAny(name, "<>\n") {
+		return "", fmt.Errorf("invalid user name '%s': it may not contain '<', '>' or a newline", name)
+	}
+	if strings.ContainsAny(email, "<> \t\n") || !strings.Contains(email, "@") {
+		return "", fmt.Errorf("invalid user email '%s': expected an address like 'name@example.com'", email)
+	}
 	return fmt.Sprintf("%s <%s>", name, email), nil
 }
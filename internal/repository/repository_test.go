@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+func TestOpenChecksLayout(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		damage  func(gitDir string) error
+		wantErr string // "" if Open should succeed
+	}{
+		{"intact", func(string) error { return nil }, ""},
+		{"detached HEAD", func(gitDir string) error {
+			return os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte(strings.Repeat("ab", 20)+"\n"), 0644)
+		}, ""},
+		{"missing HEAD", func(gitDir string) error {
+			return os.Remove(filepath.Join(gitDir, "HEAD"))
+		}, "HEAD is missing"},
+		{"empty HEAD", func(gitDir string) error {
+			return os.WriteFile(filepath.Join(gitDir, "HEAD"), nil, 0644)
+		}, "HEAD is malformed"},
+		{"HEAD outside refs", func(gitDir string) error {
+			return os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: heads/main\n"), 0644)
+		}, "HEAD is malformed"},
+		{"short hash in HEAD", func(gitDir string) error {
+			return os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("abcdef\n"), 0644)
+		}, "HEAD is malformed"},
+		{"missing objects", func(gitDir string) error {
+			return os.RemoveAll(filepath.Join(gitDir, "objects"))
+		}, "the objects directory is missing"},
+		{"objects is a file", func(gitDir string) error {
+			if err := os.RemoveAll(filepath.Join(gitDir, "objects")); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(gitDir, "objects"), nil, 0644)
+		}, "the objects directory is missing"},
+		{"missing refs", func(gitDir string) error {
+			return os.RemoveAll(filepath.Join(gitDir, "refs"))
+		}, "the refs directory is missing"},
+	} {
+		tr := newTestRepo(t)
+		if err := c.damage(tr.GitDir); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Open(tr.Path)
+		switch {
+		case c.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", c.name, err)
+		case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+			t.Errorf("%s: Open returned %v, want an error saying %q", c.name, err, c.wantErr)
+		}
+	}
+
+	if _, err := Open(t.TempDir()); err == nil || !strings.Contains(err.Error(), "not a gogit repository") {
+		t.Errorf("Open of a plain directory: %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tr := newTestRepo(t)
+	head := tr.commit("one", map[string]string{"f": "1\n"})
+	if err := tr.Check(); err != nil {
+		t.Fatalf("Check of a sound repository: %v", err)
+	}
+
+	commit, err := tr.Objects.ReadCommit(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := object.RemoveLoose(tr.Path, commit.TreeHash); err != nil {
+		t.Fatal(err)
+	}
+	// A fresh Repository, since the store caches what it has read
+	check := func() error {
+		repo, err := Open(tr.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo.Check()
+	}
+	if err := check(); err == nil || !strings.Contains(err.Error(), commit.TreeHash) {
+		t.Errorf("Check with the tree gone: %v", err)
+	}
+
+	if err := object.RemoveLoose(tr.Path, head); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(); err == nil || !strings.Contains(err.Error(), head) {
+		t.Errorf("Check with the commit gone: %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// ResolveRevision resolves a revision name (HEAD, a ref, a branch or tag
+// name, a reflog entry such as "HEAD@{1}", or a full or abbreviated hash)
+// to an object hash. The name may be followed by ancestry suffixes such as
+// "~2" or "^", and by peeling suffixes such as "^{tree}".
+func (r *Repository) ResolveRevision(rev string) (string, error) {
+	if rev == "" {
+		return "", fmt.Errorf("empty revision")
+	}
+
+	base, suffix := rev, ""
+	if idx := strings.IndexAny(rev, "~^"); idx > 0 {
+		base, suffix = rev[:idx], rev[idx:]
+	}
+
+	hash, err := r.resolveName(base)
+	if err != nil {
+		return "", err
+	}
+
+	return r.applySuffix(hash, suffix, rev)
+}
+
+// applySuffix walks ancestry operators ("~<n>", "^<n>") and peeling
+// operators ("^{<type>}", "^{}") from a resolved hash
+func (r *Repository) applySuffix(hash, suffix, rev string) (string, error) {
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+
+		if op == '^' && strings.HasPrefix(suffix, "{") {
+			end := strings.IndexByte(suffix, '}')
+			if end < 0 {
+				return "", fmt.Errorf("unknown revision: %s", rev)
+			}
+			peeled, err := r.peel(hash, suffix[1:end], rev)
+			if err != nil {
+				return "", err
+			}
+			hash, suffix = peeled, suffix[end+1:]
+			continue
+		}
+
+		digits := 0
+		for digits < len(suffix) && suffix[digits] >= '0' && suffix[digits] <= '9' {
+			digits++
+		}
+		n := 1
+		if digits > 0 {
+			n, _ = strconv.Atoi(suffix[:digits])
+			suffix = suffix[digits:]
+		}
+
+		switch op {
+		case '~':
+			for i := 0; i < n; i++ {
+				parent, err := r.nthParent(hash, 1, rev)
+				if err != nil {
+					return "", err
+				}
+				hash = parent
+			}
+		case '^':
+			if n == 0 {
+				continue
+			}
+			parent, err := r.nthParent(hash, n, rev)
+			if err != nil {
+				return "", err
+			}
+			hash = parent
+		default:
+			return "", fmt.Errorf("unknown revision: %s", rev)
+		}
+	}
+
+	return hash, nil
+}
+
+// nthParent returns the n-th parent (1-based) of a commit
+func (r *Repository) nthParent(hash string, n int, rev string) (string, error) {
+	commitHash, err := r.peelToCommit(hash, rev)
+	if err != nil {
+		return "", err
+	}
+	commit, err := r.ReadHistoryCommit(commitHash)
+	if err != nil {
+		return "", err
+	}
+
+	parents := commit.Parents
+	if n > len(parents) {
+		return "", fmt.Errorf("unknown revision: %s (commit %s has no parent %d)", rev, commitHash[:7], n)
+	}
+	return parents[n-1], nil
+}
+
+// resolveName resolves a bare revision name without ancestry suffixes
+func (r *Repository) resolveName(rev string) (string, error) {
+	if at := strings.LastIndex(rev, "@{"); at >= 0 && strings.HasSuffix(rev, "}") {
+		return r.resolveReflogEntry(rev[:at], rev[at+2:len(rev)-1], rev)
+	}
+	if rev == "HEAD" || rev == "@" {
+		hash, err := r.Refs.ResolveHead()
+		if err != nil {
+			return "", err
+		}
+		if hash == "" {
+			return "", fmt.Errorf("ambiguous argument 'HEAD': unknown revision (no commits yet)")
+		}
+		return hash, nil
+	}
+
+	// Try refs in Git's lookup order
+	candidates := []string{rev}
+	if !strings.HasPrefix(rev, "refs/") {
+		candidates = append(candidates,
+			"refs/"+rev,
+			"refs/tags/"+rev,
+			"refs/heads/"+rev,
+			"refs/remotes/"+rev,
+		)
+	}
+	for _, refPath := range candidates {
+		if !strings.HasPrefix(refPath, "refs/") && !isPseudoRef(refPath) {
+			continue
+		}
+		hash, err := r.Refs.ResolveRef(refPath)
+		if err != nil {
+			return "", err
+		}
+		if hash != "" {
+			return hash, nil
+		}
+	}
+
+	// Fall back to an object hash
+	if hash, err := object.ExpandHash(r.Path, rev); err == nil {
+		return hash, nil
+	} else if errors.Is(err, object.ErrAmbiguousHash) {
+		return "", err
+	}
+
+	return "", fmt.Errorf("unknown revision: %s", rev)
+}
+
+// resolveReflogEntry resolves "<name>@{<n>}" to where the ref was n updates
+// ago, as its reflog records. Without a name, the current branch is meant,
+// or HEAD when it is detached.
+func (r *Repository) resolveReflogEntry(name, selector, rev string) (string, error) {
+	n, err := strconv.Atoi(selector)
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("unknown revision: %s", rev)
+	}
+	if name == "" {
+		if name, err = r.Refs.CurrentBranch(); err != nil {
+			name = "HEAD"
+		}
+	}
+
+	ref, err := r.ReflogRef(name)
+	if err != nil {
+		return "", err
+	}
+	entries, err := r.Refs.ReadReflog(ref)
+	if err != nil {
+		return "", err
+	}
+	if n >= len(entries) {
+		return "", fmt.Errorf("log for '%s' only has %d entries", name, len(entries))
+	}
+	hash := entries[len(entries)-1-n].NewHash
+	if hash == zeroHash {
+		return "", fmt.Errorf("unknown revision: %s (the ref was deleted then)", rev)
+	}
+	return hash, nil
+}
+
+// ResolveCommit resolves a revision and peels annotated tags down to a commit
+func (r *Repository) ResolveCommit(rev string) (string, error) {
+	hash, err := r.ResolveRevision(rev)
+	if err != nil {
+		return "", err
+	}
+
+	return r.peelToCommit(hash, rev)
+}
+
+// peelToCommit follows annotated tags until it reaches a commit
+func (r *Repository) peelToCommit(hash, rev string) (string, error) {
+	for {
+		obj, err := r.Objects.Read(hash)
+		if err != nil {
+			return "", err
+		}
+
+		switch o := obj.(type) {
+		case *object.Commit:
+			return hash, nil
+		case *object.Tag:
+			hash = o.Object
+		default:
+			return "", fmt.Errorf("%s is a %s, not a commit", rev, obj.Type())
+		}
+	}
+}
+
+// peel follows tags, and commits to their trees, until it reaches an object
+// of the wanted type: "commit", "tree", "blob" or "tag". "object" accepts
+// any object and "" peels tags until it reaches something else.
+func (r *Repository) peel(hash, want, rev string) (string, error) {
+	switch want {
+	case "", "object", string(object.TypeCommit), string(object.TypeTree), string(object.TypeBlob), string(object.TypeTag):
+	default:
+		return "", fmt.Errorf("unknown revision: %s (unknown object type %q)", rev, want)
+	}
+
+	for {
+		obj, err := r.Objects.Read(hash)
+		if err != nil {
+			return "", err
+		}
+		if want == "object" || string(obj.Type()) == want {
+			return hash, nil
+		}
+
+		switch o := obj.(type) {
+		case *object.Tag:
+			hash = o.Object
+			continue
+		case *object.Commit:
+			// Like git, look for anything but a commit in its tree
+			if want != "" {
+				hash = o.TreeHash
+				continue
+			}
+		}
+		if want == "" {
+			return hash, nil
+		}
+		return "", fmt.Errorf("%s: expected %s type, but the object dereferences to %s type", rev, want, obj.Type())
+	}
+}
+
+// ResolveTree resolves a revision to a tree, peeling tags and commits
+func (r *Repository) ResolveTree(rev string) (string, error) {
+	hash, err := r.ResolveRevision(rev)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		obj, err := r.Objects.Read(hash)
+		if err != nil {
+			return "", err
+		}
+
+		switch o := obj.(type) {
+		case *object.Tree:
+			return hash, nil
+		case *object.Commit:
+			return o.TreeHash, nil
+		case *object.Tag:
+			hash = o.Object
+		default:
+			return "", fmt.Errorf("%s is a %s, not a tree-ish", rev, obj.Type())
+		}
+	}
+}
+
+// isPseudoRef reports whether name looks like a top-level ref such as ORIG_HEAD
+func isPseudoRef(name string) bool {
+	if !strings.HasSuffix(name, "HEAD") {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'A' || c > 'Z') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
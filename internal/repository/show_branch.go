@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// MaxShowBranchTips is how many tips ShowBranch can compare at once
+const MaxShowBranchTips = 26
+
+// ShowBranchCommit is a commit in the history of the tips compared by
+// ShowBranch
+type ShowBranchCommit struct {
+	Hash    string
+	Parents []string
+	Reached []bool // Which of the tips the commit is reachable from
+	Name    string // Such as "main~2" or "topic^2", or "" if none was found
+}
+
+// IsMerge reports whether the commit has more than one parent
+func (c ShowBranchCommit) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+// ReachedByAll reports whether every tip reaches the commit
+func (c ShowBranchCommit) ReachedByAll() bool {
+	for _, reached := range c.Reached {
+		if !reached {
+			return false
+		}
+	}
+	return true
+}
+
+// showBranchNode is a commit visited by ShowBranch
+type showBranchNode struct {
+	hash          string
+	parents       []string
+	time          int64
+	tips          uint64 // Bit i is set if tip i reaches the commit
+	uninteresting bool   // Every tip reaches it or a descendant of it that every tip reaches
+	seen          bool
+}
+
+// showBranchName names a commit as a generation of a named commit
+type showBranchName struct {
+	head       string
+	generation int
+}
+
+// showBranch holds the state of one ShowBranch run
+type showBranch struct {
+	r     *Repository
+	nodes map[string]*showBranchNode
+	queue commitQueue
+	seq   int
+	seen  []*showBranchNode // In the order they were first reached
+}
+
+// ShowBranch walks the history of the given tips newest first, far enough
+// to reach the commits common to all of them and then more commits
+// further, and returns the commits it saw in graph order: tips first, and
+// no commit before its children. names are used to name the tips, and the
+// other commits are named after the tips as "name~n" and "name^2" along
+// the shortest path found.
+func (r *Repository) ShowBranch(tips, names []string, more int) ([]ShowBranchCommit, error) {
+	if len(tips) > MaxShowBranchTips {
+		return nil, fmt.Errorf("cannot handle more than %d revs.", MaxShowBranchTips)
+	}
+	s := &showBranch{r: r, nodes: make(map[string]*showBranchNode)}
+
+	all := uint64(1)<<len(tips) - 1
+	for i, tip := range tips {
+		node, err := s.node(tip)
+		if err != nil {
+			return nil, err
+		}
+		first := node.tips == 0
+		node.tips |= 1 << i
+		if first {
+			s.push(node)
+		}
+	}
+
+	if err := s.walk(all, more); err != nil {
+		return nil, err
+	}
+	sorted := s.sortGraphOrder()
+	named := s.nameCommits(sorted, tips, names)
+
+	commits := make([]ShowBranchCommit, len(sorted))
+	for i, node := range sorted {
+		c := ShowBranchCommit{Hash: node.hash, Parents: node.parents, Reached: make([]bool, len(tips))}
+		for j := range tips {
+			c.Reached[j] = node.tips&(1<<j) != 0
+		}
+		if name, ok := named[node.hash]; ok {
+			c.Name = name.head
+			switch {
+			case name.generation == 1:
+				c.Name += "^"
+			case name.generation > 1:
+				c.Name += fmt.Sprintf("~%d", name.generation)
+			}
+		}
+		commits[i] = c
+	}
+	return commits, nil
+}
+
+// node returns the visited commit for a hash, reading it the first time
+func (s *showBranch) node(hash string) (*showBranchNode, error) {
+	if node, ok := s.nodes[hash]; ok {
+		return node, nil
+	}
+	c, err := s.r.lookupCommit(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	node := &showBranchNode{hash: hash, parents: c.parents, time: c.time}
+	s.nodes[hash] = node
+	return node, nil
+}
+
+// push queues a commit by date. A commit may be queued more than once,
+// when it is reached again by more tips.
+func (s *showBranch) push(node *showBranchNode) {
+	heap.Push(&s.queue, queuedCommit{hash: node.hash, time: node.time, seq: s.seq})
+	s.seq++
+}
+
+// markSeen records a commit as seen, reporting false if it already was
+func (s *showBranch) markSeen(node *showBranchNode) bool {
+	if node.seen {
+		return false
+	}
+	node.seen = true
+	s.seen = append(s.seen, node)
+	return true
+}
+
+// interesting reports whether anything queued isn't yet common to all tips
+func (s *showBranch) interesting() bool {
+	for _, item := range s.queue {
+		if !s.nodes[item.hash].uninteresting {
+			return true
+		}
+	}
+	return false
+}
+
+// walk spreads the tips' bits through history until only commits every
+// tip reaches are queued, and then until more further commits are seen
+func (s *showBranch) walk(all uint64, more int) error {
+	for s.queue.Len() > 0 {
+		stillInteresting := s.interesting()
+		node := s.nodes[heap.Pop(&s.queue).(queuedCommit).hash]
+		tips, uninteresting := node.tips, node.uninteresting
+
+		if !stillInteresting && more <= 0 {
+			break
+		}
+		s.markSeen(node)
+		if tips&all == all {
+			uninteresting = true
+		}
+
+		for _, hash := range node.parents {
+			parent, err := s.node(hash)
+			if err != nil {
+				return err
+			}
+			if parent.tips&tips == tips && (parent.uninteresting || !uninteresting) {
+				continue
+			}
+			if s.markSeen(parent) && !stillInteresting {
+				more--
+			}
+			parent.tips |= tips
+			parent.uninteresting = parent.uninteresting || uninteresting
+			s.push(parent)
+		}
+	}
+	return nil
+}
+
+// sortGraphOrder sorts the seen commits so that each comes after all of
+// its children, keeping each line of history together
+func (s *showBranch) sortGraphOrder() []*showBranchNode {
+	seen := s.seen
+	indegree := make(map[string]int, len(seen))
+	for _, node := range seen {
+		indegree[node.hash] = 1
+	}
+	for _, node := range seen {
+		for _, parent := range node.parents {
+			if indegree[parent] > 0 {
+				indegree[parent]++
+			}
+		}
+	}
+
+	// A stack, so that a commit's parents are taken next; the tips start
+	// in the order they were seen
+	var stack []*showBranchNode
+	for i := len(seen) - 1; i >= 0; i-- {
+		if indegree[seen[i].hash] == 1 {
+			stack = append(stack, seen[i])
+		}
+	}
+
+	sorted := make([]*showBranchNode, 0, len(seen))
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, parent := range node.parents {
+			if indegree[parent] == 0 {
+				continue
+			}
+			indegree[parent]--
+			if indegree[parent] == 1 {
+				stack = append(stack, s.nodes[parent])
+			}
+		}
+		sorted = append(sorted, node)
+	}
+	return sorted
+}
+
+// nameCommits names the tips, then the commits along their first-parent
+// chains, then the other parents of named commits
+func (s *showBranch) nameCommits(sorted []*showBranchNode, tips, names []string) map[string]showBranchName {
+	named := make(map[string]showBranchName)
+
+	for _, node := range sorted {
+		if _, ok := named[node.hash]; ok {
+			continue
+		}
+		for i, tip := range tips {
+			if tip == node.hash {
+				named[node.hash] = showBranchName{head: names[i]}
+				break
+			}
+		}
+	}
+
+	// nameChain names the first parents of a named commit, stopping at one
+	// that already has a name
+	nameChain := func(hash string) int {
+		n := 0
+		for {
+			name, ok := named[hash]
+			if !ok {
+				return n
+			}
+			node, ok := s.nodes[hash]
+			if !ok || len(node.parents) == 0 {
+				return n
+			}
+			parent := node.parents[0]
+			if _, ok := named[parent]; ok {
+				return n
+			}
+			named[parent] = showBranchName{head: name.head, generation: name.generation + 1}
+			n++
+			hash = parent
+		}
+	}
+
+	for {
+		n := 0
+		for _, node := range sorted {
+			n += nameChain(node.hash)
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	for {
+		n := 0
+		for _, node := range sorted {
+			name, ok := named[node.hash]
+			if !ok {
+				continue
+			}
+			for i, parent := range node.parents {
+				if _, ok := named[parent]; ok {
+					continue
+				}
+				head := name.head
+				switch {
+				case name.generation == 1:
+					head += "^"
+				case name.generation > 1:
+					head += fmt.Sprintf("~%d", name.generation)
+				}
+				if i == 0 {
+					head += "^"
+				} else {
+					head += fmt.Sprintf("^%d", i+1)
+				}
+				named[parent] = showBranchName{head: head}
+				n++
+				nameChain(parent)
+			}
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return named
+}
@@ -0,0 +1,69 @@
+package repository
+
+import "fmt"
+
+// StashRef holds the newest stash entry; older ones live in its reflog
+const StashRef = "refs/stash"
+
+// Stashes returns the stash entries, newest first, so that entry n is
+// stash@{n}. Each entry's NewHash is the stash commit and its Message the
+// commit's subject.
+func (r *Repository) Stashes() ([]ReflogEntry, error) {
+	entries, err := r.Refs.ReadReflog(StashRef)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// PushStash makes a stash commit the newest stash entry
+func (r *Repository) PushStash(hash, message string) error {
+	committer, err := r.reflogCommitter()
+	if err != nil {
+		return err
+	}
+
+	old, _ := r.Refs.ResolveRef(StashRef)
+	if err := r.Refs.UpdateRef(StashRef, hash); err != nil {
+		return fmt.Errorf("failed to update %s: %w", StashRef, err)
+	}
+	return r.Refs.AppendReflog(StashRef, ReflogEntry{
+		OldHash:   old,
+		NewHash:   hash,
+		Committer: committer,
+		Message:   message,
+	})
+}
+
+// DropStash removes stash@{n} and returns its commit. Dropping the last
+// entry removes the stash ref altogether.
+func (r *Repository) DropStash(n int) (string, error) {
+	stashes, err := r.Stashes()
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || n >= len(stashes) {
+		return "", fmt.Errorf("stash@{%d} is not a valid stash entry", n)
+	}
+	dropped := stashes[n].NewHash
+
+	if len(stashes) == 1 {
+		return dropped, r.Refs.DeleteRef(StashRef)
+	}
+
+	// Back to oldest first, as the reflog is written
+	kept := append(stashes[:n:n], stashes[n+1:]...)
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	if err := r.Refs.writeReflog(StashRef, kept); err != nil {
+		return "", err
+	}
+	if err := r.Refs.UpdateRef(StashRef, kept[len(kept)-1].NewHash); err != nil {
+		return "", fmt.Errorf("failed to update %s: %w", StashRef, err)
+	}
+	return dropped, nil
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"container/heap"
+	"fmt"
+
+	"github.com/yourusername/gogit/internal/object"
+)
+
+// CommitWalker walks history newest first, following every parent. Only
+// the frontier of the walk is queued, and a commit's parents are not read
+// until the commit after it is asked for, so a caller that stops early
+// never reads more history than it printed.
+type CommitWalker struct {
+	r       *Repository
+	queue   commitQueue
+	seen    map[string]bool
+	parents []string // Parents of the commit returned last, queued on the next call
+	seq     int
+
+	firstParent bool
+}
+
+// queuedCommit is a commit waiting in the walk, ordered by committer date
+// and then by the order it was reached in
+type queuedCommit struct {
+	hash string
+	time int64
+	seq  int
+}
+
+type commitQueue []queuedCommit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	if q[i].time != q[j].time {
+		return q[i].time > q[j].time
+	}
+	return q[i].seq < q[j].seq
+}
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)   { *q = append(*q, x.(queuedCommit)) }
+func (q *commitQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// WalkCommits starts a walk of the history reachable from the given commits
+func (r *Repository) WalkCommits(starts []string) (*CommitWalker, error) {
+	w := &CommitWalker{r: r, seen: make(map[string]bool)}
+	if err := w.push(starts); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Next returns the newest commit not yet returned, or "" and a nil commit
+// once the history is exhausted
+func (w *CommitWalker) Next() (string, *object.Commit, error) {
+	if err := w.push(w.parents); err != nil {
+		return "", nil, err
+	}
+	w.parents = nil
+
+	if w.queue.Len() == 0 {
+		return "", nil, nil
+	}
+	item := heap.Pop(&w.queue).(queuedCommit)
+	commit, err := w.r.ReadHistoryCommit(item.hash)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read commit %s: %w", item.hash, err)
+	}
+	w.parents = commit.Parents
+	if w.firstParent && len(w.parents) > 1 {
+		w.parents = w.parents[:1]
+	}
+	return item.hash, commit, nil
+}
+
+// FirstParent makes the walk follow only the first parent of each merge,
+// so that only the mainline of history is walked
+func (w *CommitWalker) FirstParent() {
+	w.firstParent = true
+}
+
+// Follow replaces the parents the walk goes on to from the commit returned
+// last, so that history a caller has no interest in isn't walked
+func (w *CommitWalker) Follow(parents []string) {
+	w.parents = parents
+}
+
+// push queues the commits not seen before. Only their dates are needed,
+// which the commit-graph provides without reading the objects.
+func (w *CommitWalker) push(hashes []string) error {
+	for _, hash := range hashes {
+		if w.seen[hash] {
+			continue
+		}
+		w.seen[hash] = true
+
+		node, err := w.r.lookupCommit(hash)
+		if err != nil {
+			return fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		heap.Push(&w.queue, queuedCommit{hash: hash, time: node.time, seq: w.seq})
+		w.seq++
+	}
+	return nil
+}
+
+// RefTips returns the commits that HEAD and every ref point to, with
+// annotated tags peeled. Refs to other kinds of object are left out.
+func (r *Repository) RefTips() ([]string, error) {
+	names, err := r.Refs.listRefs("refs/")
+	if err != nil {
+		return nil, err
+	}
+
+	var tips []string
+	add := func(hash, name string) error {
+		if hash == "" {
+			return nil
+		}
+		if _, err := r.Objects.Read(hash); err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		commit, err := r.peelToCommit(hash, name)
+		if err != nil {
+			// A ref to a tree or blob has no history to show
+			return nil
+		}
+		tips = append(tips, commit)
+		return nil
+	}
+
+	head, err := r.Refs.ResolveHead()
+	if err != nil {
+		return nil, err
+	}
+	if err := add(head, "HEAD"); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		hash, err := r.Refs.ResolveRef("refs/" + name)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(hash, "refs/"+name); err != nil {
+			return nil, err
+		}
+	}
+	return tips, nil
+}
+
+// LostCommits returns the commits reachable from hash but not from any ref
+// or from the commits in keep, newest first. They are what moving a
+// detached HEAD away from hash would leave behind. Both sides are walked
+// together by date, so the walk stops where the histories meet instead of
+// reading everything the refs reach.
+func (r *Repository) LostCommits(hash string, keep []string) ([]string, error) {
+	names, err := r.Refs.ListRefs()
+	if err != nil {
+		return nil, err
+	}
+	excluded := append([]string(nil), keep...)
+	for _, name := range names {
+		tip, err := r.Refs.ResolveRef(name)
+		if err != nil {
+			return nil, err
+		}
+		if tip == "" {
+			continue
+		}
+		if commit, err := r.peelToCommit(tip, name); err == nil {
+			excluded = append(excluded, commit)
+		}
+	}
+
+	var queue commitQueue
+	uninteresting := make(map[string]bool)
+	queued := make(map[string]bool)
+	seq := 0
+	push := func(hash string, hidden bool) error {
+		if hidden && !uninteresting[hash] {
+			uninteresting[hash] = true
+			// Already walked as interesting: walk it again to hide its parents
+			queued[hash] = false
+		}
+		if queued[hash] {
+			return nil
+		}
+		queued[hash] = true
+		node, err := r.lookupCommit(hash)
+		if err != nil {
+			return fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		heap.Push(&queue, queuedCommit{hash: hash, time: node.time, seq: seq})
+		seq++
+		return nil
+	}
+
+	for _, tip := range excluded {
+		if err := push(tip, true); err != nil {
+			return nil, err
+		}
+	}
+	if err := push(hash, false); err != nil {
+		return nil, err
+	}
+
+	var walked []string
+	for queue.Len() > 0 && !allUninteresting(queue, uninteresting) {
+		item := heap.Pop(&queue).(queuedCommit)
+		hidden := uninteresting[item.hash]
+		if !hidden {
+			walked = append(walked, item.hash)
+		}
+		node, err := r.lookupCommit(item.hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range node.parents {
+			if err := push(parent, hidden); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var lost []string
+	for _, commit := range walked {
+		if !uninteresting[commit] {
+			lost = append(lost, commit)
+		}
+	}
+	return lost, nil
+}
+
+// allUninteresting reports whether nothing left in the queue could still
+// turn out to be lost
+func allUninteresting(queue commitQueue, uninteresting map[string]bool) bool {
+	for _, item := range queue {
+		if !uninteresting[item.hash] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// zeroHash is advertised in place of a ref by an empty repository
+const zeroHash = "0000000000000000000000000000000000000000"
+
+// Ref is one ref advertised by a remote
+type Ref struct {
+	Name string
+	Hash string
+}
+
+// Advertisement is the ref advertisement sent by git-upload-pack
+type Advertisement struct {
+	Refs         []Ref // In the order the remote sent them, peeled tags included
+	Capabilities []string
+}
+
+// HasCapability reports whether the remote advertised a capability, either
+// bare ("shallow") or with a value ("agent=git/2.43.0")
+func (a *Advertisement) HasCapability(name string) bool {
+	for _, capability := range a.Capabilities {
+		if capability == name || strings.HasPrefix(capability, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAdvertisement reads a ref advertisement. The "# service=" header
+// that smart HTTP puts in front of it is skipped if present.
+func ParseAdvertisement(r io.Reader) (*Advertisement, error) {
+	pkt := newPktReader(r)
+	adv := &Advertisement{}
+	first := true
+	inHeader := false
+
+	for {
+		line, flush, err := pkt.ReadLine()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ref advertisement: %w", err)
+		}
+		if flush {
+			if inHeader {
+				// End of the service header section
+				inHeader = false
+				continue
+			}
+			return adv, nil
+		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+
+		if first && bytes.HasPrefix(line, []byte("# service=")) {
+			inHeader = true
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("ERR ")) {
+			return nil, fmt.Errorf("remote error: %s", line[4:])
+		}
+
+		// The first ref carries the capability list after a NUL
+		if first {
+			if nul := bytes.IndexByte(line, 0); nul >= 0 {
+				adv.Capabilities = strings.Fields(string(line[nul+1:]))
+				line = line[:nul]
+			}
+		}
+		first = false
+
+		hash, name, ok := strings.Cut(string(line), " ")
+		if !ok || len(hash) != 40 {
+			return nil, fmt.Errorf("malformed ref advertisement line %q", line)
+		}
+		// An empty repository advertises only its capabilities
+		if hash == zeroHash && name == "capabilities^{}" {
+			continue
+		}
+		adv.Refs = append(adv.Refs, Ref{Name: name, Hash: hash})
+	}
+}
+
+// SymrefTarget returns the ref a symbolic ref such as HEAD points to on the
+// remote, if the remote advertised it with the symref capability
+func (a *Advertisement) SymrefTarget(name string) (string, bool) {
+	for _, capability := range a.Capabilities {
+		value, ok := strings.CutPrefix(capability, "symref=")
+		if !ok {
+			continue
+		}
+		if source, target, ok := strings.Cut(value, ":"); ok && source == name {
+			return target, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,224 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// retryDelay is how long the first retry waits; each later one waits twice
+// as long as the one before
+const retryDelay = time.Second
+
+// ClientOptions decide how long the HTTP client waits on a slow remote and
+// how often it retries one that fails
+type ClientOptions struct {
+	// A request is abandoned once it has moved less than LowSpeedLimit
+	// bytes per second for LowSpeedTime, counting the wait for the
+	// response as well as the transfer. Zero for either disables this.
+	LowSpeedLimit int
+	LowSpeedTime  time.Duration
+
+	// Retries is how many more times a request is attempted if it fails
+	// before the server responds, or gets a 502, 503 or 504 response
+	Retries int
+}
+
+// DefaultClientOptions gives up on a remote that sends nothing for a
+// minute, and retries a failed request twice
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{LowSpeedLimit: 1, LowSpeedTime: time.Minute, Retries: 2}
+}
+
+// StallError reports a transfer abandoned for being too slow
+type StallError struct {
+	Limit int
+	Time  time.Duration
+}
+
+func (e *StallError) Error() string {
+	return fmt.Sprintf("operation too slow: less than %d bytes/sec transferred the last %d seconds", e.Limit, int(e.Time.Seconds()))
+}
+
+// Timeout reports true, so that a StallError counts as a timeout
+func (e *StallError) Timeout() bool { return true }
+
+// NewClient returns an HTTP client for talking to remotes. As in git, the
+// GIT_HTTP_LOW_SPEED_LIMIT and GIT_HTTP_LOW_SPEED_TIME environment
+// variables, if set, override the low speed options.
+func NewClient(opts ClientOptions) *http.Client {
+	if limit, err := strconv.Atoi(os.Getenv("GIT_HTTP_LOW_SPEED_LIMIT")); err == nil {
+		opts.LowSpeedLimit = limit
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("GIT_HTTP_LOW_SPEED_TIME")); err == nil {
+		opts.LowSpeedTime = time.Duration(seconds) * time.Second
+	}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	return &http.Client{Transport: &patientTransport{base: base, opts: opts}}
+}
+
+// patientTransport retries requests that fail for reasons that may pass,
+// and abandons ones that stall
+type patientTransport struct {
+	base http.RoundTripper
+	opts ClientOptions
+}
+
+func (t *patientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.roundTrip(req)
+		if attempt >= t.opts.Retries || !transient(resp, err) || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(retryDelay << attempt):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		// The body was consumed by the failed attempt
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		req = retry
+	}
+}
+
+// roundTrip makes one attempt at a request, watched for stalls until its
+// response body is closed
+func (t *patientTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.opts.LowSpeedLimit <= 0 || t.opts.LowSpeedTime <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	w := newWatchdog(t.opts, cancel)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		w.stop()
+		if w.stalled.Load() {
+			return nil, w.err()
+		}
+		return nil, err
+	}
+	resp.Body = &watchedBody{ReadCloser: resp.Body, w: w}
+	return resp, nil
+}
+
+// transient reports whether a failed attempt is worth retrying: the
+// connection failed or stalled, or a gateway or overloaded server answered
+func transient(resp *http.Response, err error) bool {
+	if err == nil {
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var stall *StallError
+	if errors.As(err, &stall) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// watchdog cancels a request that moves too few bytes for too long. Speed
+// is sampled every second, or every LowSpeedTime if that is shorter.
+type watchdog struct {
+	opts     ClientOptions
+	cancel   context.CancelFunc
+	bytes    atomic.Int64
+	stalled  atomic.Bool
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newWatchdog(opts ClientOptions, cancel context.CancelFunc) *watchdog {
+	w := &watchdog{opts: opts, cancel: cancel, done: make(chan struct{})}
+	go w.watch()
+	return w
+}
+
+func (w *watchdog) watch() {
+	interval := min(time.Second, w.opts.LowSpeedTime)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var slowSince time.Time
+	last := time.Now()
+	for {
+		select {
+		case <-w.done:
+			return
+		case now := <-ticker.C:
+			moved := w.bytes.Swap(0)
+			if float64(moved) >= float64(w.opts.LowSpeedLimit)*now.Sub(last).Seconds() {
+				slowSince = time.Time{}
+			} else if slowSince.IsZero() {
+				slowSince = last
+			}
+			last = now
+			if !slowSince.IsZero() && now.Sub(slowSince) >= w.opts.LowSpeedTime {
+				w.stalled.Store(true)
+				w.cancel()
+				return
+			}
+		}
+	}
+}
+
+// stop ends the watch and releases the request's context
+func (w *watchdog) stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+		w.cancel()
+	})
+}
+
+func (w *watchdog) err() error {
+	return &StallError{Limit: w.opts.LowSpeedLimit, Time: w.opts.LowSpeedTime}
+}
+
+// watchedBody counts the bytes read from a response for its watchdog
+type watchedBody struct {
+	io.ReadCloser
+	w *watchdog
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.w.bytes.Add(int64(n))
+	if err != nil && err != io.EOF && b.w.stalled.Load() {
+		return n, b.w.err()
+	}
+	return n, err
+}
+
+func (b *watchedBody) Close() error {
+	b.w.stop()
+	return b.ReadCloser.Close()
+}
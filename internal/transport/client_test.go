@@ -0,0 +1,182 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stallingServer answers with handler, which may block until the client
+// gives up or the test ends
+func stallingServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, release <-chan struct{})) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, release)
+	}))
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+	return srv
+}
+
+func wait(r *http.Request, release <-chan struct{}) {
+	select {
+	case <-r.Context().Done():
+	case <-release:
+	}
+}
+
+func stallOptions() ClientOptions {
+	return ClientOptions{LowSpeedLimit: 1, LowSpeedTime: 300 * time.Millisecond}
+}
+
+func TestClientAbandonsServerThatNeverResponds(t *testing.T) {
+	srv := stallingServer(t, func(w http.ResponseWriter, r *http.Request, release <-chan struct{}) {
+		wait(r, release)
+	})
+
+	start := time.Now()
+	_, err := NewClient(stallOptions()).Get(srv.URL)
+	var stall *StallError
+	if !errors.As(err, &stall) {
+		t.Fatalf("Get returned %v, want a StallError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("gave up after %v, want about %v", elapsed, stallOptions().LowSpeedTime)
+	}
+}
+
+func TestClientAbandonsStalledBody(t *testing.T) {
+	srv := stallingServer(t, func(w http.ResponseWriter, r *http.Request, release <-chan struct{}) {
+		w.Write([]byte("some of the body"))
+		w.(http.Flusher).Flush()
+		wait(r, release)
+	})
+
+	resp, err := NewClient(stallOptions()).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	var stall *StallError
+	if !errors.As(err, &stall) {
+		t.Fatalf("reading the body returned %v, want a StallError", err)
+	}
+	if string(body) != "some of the body" {
+		t.Errorf("read %q before the stall, want what was sent", body)
+	}
+}
+
+func TestClientKeepsSlowButSteadyTransfer(t *testing.T) {
+	srv := stallingServer(t, func(w http.ResponseWriter, r *http.Request, release <-chan struct{}) {
+		for i := 0; i < 8; i++ {
+			w.Write([]byte("chunk\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	})
+
+	resp, err := NewClient(stallOptions()).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("a transfer that kept moving was abandoned: %v", err)
+	}
+}
+
+// flakyServer fails the first failures requests with status, then
+// answers "ok", and counts the requests it gets
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestClientRetriesTransientFailure(t *testing.T) {
+	srv, hits := flakyServer(t, 1, http.StatusServiceUnavailable)
+
+	resp, err := NewClient(ClientOptions{Retries: 2}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("got %d %q, want the retry's 200 ok", resp.StatusCode, body)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("server got %d requests, want 2", hits.Load())
+	}
+}
+
+func TestClientStopsRetrying(t *testing.T) {
+	srv, hits := flakyServer(t, 100, http.StatusBadGateway)
+
+	resp, err := NewClient(ClientOptions{Retries: 1}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("got %d, want the last attempt's %d", resp.StatusCode, http.StatusBadGateway)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("server got %d requests, want 2", hits.Load())
+	}
+}
+
+func TestClientDoesNotRetryPermanentFailure(t *testing.T) {
+	srv, hits := flakyServer(t, 100, http.StatusNotFound)
+
+	resp, err := NewClient(ClientOptions{Retries: 2}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if hits.Load() != 1 {
+		t.Errorf("server got %d requests, want 1", hits.Load())
+	}
+}
+
+func TestClientRetriesAfterStall(t *testing.T) {
+	var hits atomic.Int32
+	srv := stallingServer(t, func(w http.ResponseWriter, r *http.Request, release <-chan struct{}) {
+		if hits.Add(1) == 1 {
+			wait(r, release)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	opts := stallOptions()
+	opts.Retries = 1
+	resp, err := NewClient(opts).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get returned %v, want the retry to succeed", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+		t.Errorf("got %q, want the retry's ok", body)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("server got %d requests, want 2", hits.Load())
+	}
+}
@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FetchRequest describes the objects to ask a remote for
+type FetchRequest struct {
+	Wants  []string // Objects to fetch, along with everything they reach
+	Haves  []string // Objects the client already has
+	Filter string   // Object filter such as "blob:none"; empty for everything
+}
+
+// ValidateFilter checks that spec is an object filter gogit can request:
+// blob:none, blob:limit=<n>[kmg] or tree:<depth>
+func ValidateFilter(spec string) error {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch {
+	case kind == "blob" && arg == "none":
+		return nil
+	case kind == "blob" && strings.HasPrefix(arg, "limit="):
+		limit := strings.TrimRight(strings.ToLower(strings.TrimPrefix(arg, "limit=")), "kmg")
+		if _, err := strconv.ParseUint(limit, 10, 64); err == nil {
+			return nil
+		}
+	case kind == "tree":
+		if _, err := strconv.ParseUint(arg, 10, 64); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid filter-spec '%s'", spec)
+}
+
+// FetchPack negotiates with git-upload-pack over smart HTTP and returns the
+// pack the remote sends for req. The advertisement must be the remote's
+// current one, as its capabilities decide what can be requested.
+func FetchPack(client *http.Client, rawURL string, adv *Advertisement, req FetchRequest) ([]byte, error) {
+	if len(req.Wants) == 0 {
+		return nil, fmt.Errorf("nothing to fetch")
+	}
+	if req.Filter != "" && !adv.HasCapability("filter") {
+		return nil, fmt.Errorf("%s does not support filtering objects", rawURL)
+	}
+
+	var capabilities []string
+	sideBand := ""
+	for _, capability := range []string{"side-band-64k", "side-band"} {
+		if adv.HasCapability(capability) {
+			sideBand = capability
+			capabilities = append(capabilities, capability)
+			break
+		}
+	}
+	for _, capability := range []string{"ofs-delta", "no-progress"} {
+		if adv.HasCapability(capability) {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	if req.Filter != "" {
+		capabilities = append(capabilities, "filter")
+	}
+
+	var body strings.Builder
+	for i, want := range req.Wants {
+		if i == 0 {
+			body.WriteString(pktLine(fmt.Sprintf("want %s %s\n", want, strings.Join(capabilities, " "))))
+			continue
+		}
+		body.WriteString(pktLine(fmt.Sprintf("want %s\n", want)))
+	}
+	if req.Filter != "" {
+		body.WriteString(pktLine(fmt.Sprintf("filter %s\n", req.Filter)))
+	}
+	body.WriteString(flushPkt)
+	for _, have := range req.Haves {
+		body.WriteString(pktLine(fmt.Sprintf("have %s\n", have)))
+	}
+	body.WriteString(pktLine("done\n"))
+
+	endpoint := strings.TrimSuffix(rawURL, "/") + "/" + uploadPackService
+	httpReq, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(body.String()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("User-Agent", userAgent)
+	httpReq.Header.Set("Content-Type", "application/x-"+uploadPackService+"-request")
+	httpReq.Header.Set("Accept", "application/x-"+uploadPackService+"-result")
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch from %s: server returned %s", rawURL, resp.Status)
+	}
+
+	return readPackResponse(resp.Body, sideBand != "")
+}
+
+// readPackResponse skips the acknowledgements at the start of an
+// upload-pack response and returns the pack that follows
+func readPackResponse(r io.Reader, sideBand bool) ([]byte, error) {
+	pkt := newPktReader(r)
+	var data bytes.Buffer
+	inPack := false
+
+	for {
+		// Without side-band the pack follows the last ACK/NAK unframed
+		if !sideBand {
+			if head, err := pkt.r.Peek(4); err == nil && string(head) == "PACK" {
+				if _, err := io.Copy(&data, pkt.r); err != nil {
+					return nil, fmt.Errorf("failed to read pack: %w", err)
+				}
+				return data.Bytes(), nil
+			}
+		}
+
+		line, flush, err := pkt.ReadLine()
+		if inPack && (err == io.EOF || flush) {
+			return data.Bytes(), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fetch response: %w", err)
+		}
+		if flush || len(line) == 0 {
+			continue
+		}
+
+		// Band 1 carries the pack, 2 progress messages and 3 a fatal error
+		if sideBand && line[0] <= 3 {
+			inPack = true
+			switch line[0] {
+			case 1:
+				data.Write(line[1:])
+			case 3:
+				return nil, fmt.Errorf("remote error: %s", strings.TrimSpace(string(line[1:])))
+			}
+			continue
+		}
+
+		text := strings.TrimSuffix(string(line), "\n")
+		switch {
+		case strings.HasPrefix(text, "ERR "):
+			return nil, fmt.Errorf("remote error: %s", text[4:])
+		case text == "NAK" || strings.HasPrefix(text, "ACK "):
+		default:
+			return nil, fmt.Errorf("unexpected fetch response line %q", text)
+		}
+	}
+}
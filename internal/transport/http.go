@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// uploadPackService is the service used to fetch from a remote
+const uploadPackService = "git-upload-pack"
+
+// userAgent identifies gogit to servers. Some servers only speak the smart
+// protocol to clients whose agent starts with "git/".
+const userAgent = "git/2.0 (gogit)"
+
+// ValidateURL checks that rawURL is a remote gogit can talk to
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL %s: only http and https remotes are supported", rawURL)
+	}
+	return nil
+}
+
+// GetAdvertisement fetches the refs of a remote repository over smart
+// HTTP, without downloading any objects
+func GetAdvertisement(client *http.Client, rawURL string) (*Advertisement, error) {
+	if err := ValidateURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(rawURL, "/") + "/info/refs?service=" + uploadPackService
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to reach %s: server returned %s", rawURL, resp.Status)
+	}
+	contentType := "application/x-" + uploadPackService + "-advertisement"
+	if resp.Header.Get("Content-Type") != contentType {
+		return nil, fmt.Errorf("%s does not support the smart HTTP protocol", rawURL)
+	}
+
+	return ParseAdvertisement(resp.Body)
+}
@@ -0,0 +1,55 @@
+// Package transport implements the client side of Git's smart HTTP protocol
+package transport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxPktLen is the largest pkt-line, including its four byte length prefix
+const maxPktLen = 65520
+
+// pktReader reads pkt-line framed data
+type pktReader struct {
+	r *bufio.Reader
+}
+
+func newPktReader(r io.Reader) *pktReader {
+	return &pktReader{r: bufio.NewReader(r)}
+}
+
+// ReadLine returns the payload of the next pkt-line. A flush packet
+// ("0000") is reported as flush=true with no payload.
+func (p *pktReader) ReadLine() (payload []byte, flush bool, err error) {
+	var header [4]byte
+	if _, err := io.ReadFull(p.r, header[:]); err != nil {
+		return nil, false, err
+	}
+
+	length, err := strconv.ParseUint(string(header[:]), 16, 16)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid pkt-line length %q", header[:])
+	}
+	if length == 0 {
+		return nil, true, nil
+	}
+	if length < 4 || length > maxPktLen {
+		return nil, false, fmt.Errorf("invalid pkt-line length %d", length)
+	}
+
+	payload = make([]byte, length-4)
+	if _, err := io.ReadFull(p.r, payload); err != nil {
+		return nil, false, fmt.Errorf("truncated pkt-line: %w", err)
+	}
+	return payload, false, nil
+}
+
+// flushPkt ends a section of pkt-lines
+const flushPkt = "0000"
+
+// pktLine frames payload as a pkt-line
+func pktLine(payload string) string {
+	return fmt.Sprintf("%04x%s", len(payload)+4, payload)
+}
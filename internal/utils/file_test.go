@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readOnlyFile makes a read-only file holding content, as objects and
+// packs are
+func readOnlyFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0444); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReplaceFileOverReadOnlyTarget(t *testing.T) {
+	dir := t.TempDir()
+	target := readOnlyFile(t, dir, "target", "old")
+	source := filepath.Join(dir, "source")
+	if err := os.WriteFile(source, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReplaceFile(source, target); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(target); err != nil || string(content) != "new" {
+		t.Errorf("target holds %q (%v), want the source's content", content, err)
+	}
+	if _, err := os.Lstat(source); !os.IsNotExist(err) {
+		t.Errorf("source still exists after the rename: %v", err)
+	}
+}
+
+func TestWriteFileAtomicOverReadOnlyTarget(t *testing.T) {
+	dir := t.TempDir()
+	path := readOnlyFile(t, dir, "object", "old")
+
+	if err := WriteFileAtomic(path, []byte("new"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "new" {
+		t.Errorf("file holds %q (%v), want the new content", content, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0222 != 0 {
+		t.Errorf("file mode is %v, want it read-only again", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want no temp file left beside the object", len(entries))
+	}
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultGitDirName is the metadata directory created by "gogit init"
+const DefaultGitDirName = ".gogit"
+
+// GitDirNameEnv overrides the metadata directory name when set
+const GitDirNameEnv = "GOGIT_DIR_NAME"
+
+// gitDirNames lists the metadata directory names recognized, in lookup order
+var gitDirNames = []string{DefaultGitDirName, ".git"}
+
+// gitDirs caches the metadata directory resolved for each working tree root
+var gitDirs sync.Map
+
+// GitDirName returns the name of the metadata directory of the repository
+// at root: the GOGIT_DIR_NAME override if set, otherwise .gogit, falling
+// back to .git. If neither exists yet, the default name is returned.
+func GitDirName(root string) string {
+	if name := os.Getenv(GitDirNameEnv); name != "" {
+		return name
+	}
+	if name, ok := gitDirs.Load(root); ok {
+		return name.(string)
+	}
+
+	for _, name := range gitDirNames {
+		if info, err := os.Stat(filepath.Join(root, name)); err == nil && info.IsDir() {
+			// Only cache hits, so a repository created later is still found
+			gitDirs.Store(root, name)
+			return name
+		}
+	}
+	return DefaultGitDirName
+}
+
+// GitDir returns the path of the metadata directory of the repository at root
+func GitDir(root string) string {
+	return filepath.Join(root, GitDirName(root))
+}
+
+// IsGitDirName reports whether name is a recognized metadata directory name
+func IsGitDirName(name string) bool {
+	if override := os.Getenv(GitDirNameEnv); override != "" && name == override {
+		return true
+	}
+	for _, known := range gitDirNames {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package utils
+
+import "os"
+
+// ReplaceFile renames oldPath to newPath, atomically replacing any file
+// already there
+func ReplaceFile(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
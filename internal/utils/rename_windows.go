@@ -0,0 +1,26 @@
+//go:build windows
+
+package utils
+
+import "os"
+
+// ReplaceFile renames oldPath to newPath, replacing any file already there.
+// Windows refuses to rename over a read-only file, which objects and packs
+// are, so when the plain rename fails the target is made writable and
+// removed first. That leaves a moment without the file, which is harmless
+// for content-addressed files: anything replacing one has the same content.
+func ReplaceFile(oldPath, newPath string) error {
+	err := os.Rename(oldPath, newPath)
+	if err == nil {
+		return nil
+	}
+	if _, statErr := os.Lstat(newPath); statErr != nil {
+		return err
+	}
+
+	os.Chmod(newPath, 0644)
+	if removeErr := os.Remove(newPath); removeErr != nil && !os.IsNotExist(removeErr) {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}